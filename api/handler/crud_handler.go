@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"context"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/utils"
 	"github.com/sky-xhsoft/sky-server/internal/service/crud"
 )
@@ -28,6 +31,7 @@ func NewCrudHandler(crudService crud.Service) *CrudHandler {
 // @Produce json
 // @Param tableName path string true "表名"
 // @Param id path int true "记录ID"
+// @Param include query string false "关联表/外键字段，多个用逗号分隔"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/data/{tableName}/{id} [get]
 func (h *CrudHandler) GetOne(c *gin.Context) {
@@ -47,8 +51,18 @@ func (h *CrudHandler) GetOne(c *gin.Context) {
 		return
 	}
 
-	result, err := h.crudService.GetOne(c.Request.Context(), tableName, uint(id), userID.(uint))
+	// 解析关联参数
+	var include []string
+	if includeStr := c.Query("include"); includeStr != "" {
+		include = strings.Split(includeStr, ",")
+	}
+
+	result, err := h.crudService.GetOne(c.Request.Context(), tableName, uint(id), include, userID.(uint))
 	if err != nil {
+		if errors.GetCode(err) == errors.ErrInvalidParam {
+			utils.Error(c, 400, err)
+			return
+		}
 		utils.InternalError(c, "查询失败: "+err.Error())
 		return
 	}
@@ -58,7 +72,7 @@ func (h *CrudHandler) GetOne(c *gin.Context) {
 
 // GetList 查询列表
 // @Summary 查询列表
-// @Description 查询记录列表，支持分页、排序、过滤
+// @Description 查询记录列表，支持分页、排序、过滤（filters 支持 eq/ne/gt/gte/lt/lte/between/in/notIn/isNull/notNull/like/startsWith/endsWith 操作符及 $and/$or 条件组）
 // @Tags CRUD
 // @Accept json
 // @Produce json
@@ -94,6 +108,13 @@ func (h *CrudHandler) GetList(c *gin.Context) {
 	if order, ok := rawData["order"].(string); ok {
 		req.Order = order
 	}
+	if include, ok := rawData["include"].([]interface{}); ok {
+		for _, item := range include {
+			if name, ok := item.(string); ok {
+				req.Include = append(req.Include, name)
+			}
+		}
+	}
 
 	// 提取 filters 字段（如果存在）
 	if filters, ok := rawData["filters"].(map[string]interface{}); ok {
@@ -126,6 +147,11 @@ func (h *CrudHandler) GetList(c *gin.Context) {
 
 	result, err := h.crudService.GetList(c.Request.Context(), &req, userID.(uint))
 	if err != nil {
+		// 过滤条件或关联参数校验失败返回400
+		if errors.GetCode(err) == errors.ErrInvalidParam {
+			utils.Error(c, 400, err)
+			return
+		}
 		utils.InternalError(c, "查询失败: "+err.Error())
 		return
 	}
@@ -161,7 +187,7 @@ func (h *CrudHandler) Create(c *gin.Context) {
 
 	result, err := h.crudService.Create(c.Request.Context(), tableName, data, userID.(uint))
 	if err != nil {
-		utils.InternalError(c, "创建失败: "+err.Error())
+		respondCrudError(c, "创建失败: ", err)
 		return
 	}
 
@@ -203,7 +229,7 @@ func (h *CrudHandler) Update(c *gin.Context) {
 	}
 
 	if err := h.crudService.Update(c.Request.Context(), tableName, uint(id), data, userID.(uint)); err != nil {
-		utils.InternalError(c, "更新失败: "+err.Error())
+		respondCrudError(c, "更新失败: ", err)
 		return
 	}
 
@@ -238,7 +264,7 @@ func (h *CrudHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.crudService.Delete(c.Request.Context(), tableName, uint(id), userID.(uint)); err != nil {
-		utils.InternalError(c, "删除失败: "+err.Error())
+		respondCrudError(c, "删除失败: ", err)
 		return
 	}
 
@@ -272,14 +298,276 @@ func (h *CrudHandler) BatchDelete(c *gin.Context) {
 	}
 
 	if err := h.crudService.BatchDelete(c.Request.Context(), tableName, req.IDs, userID.(uint)); err != nil {
-		utils.InternalError(c, "批量删除失败: "+err.Error())
+		respondCrudError(c, "批量删除失败: ", err)
 		return
 	}
 
 	utils.Success(c, gin.H{"message": "批量删除成功"})
 }
 
+// Submit 提交单据
+// @Summary 提交单据
+// @Description 将未提交的单据改为已提交（表MASK需包含S），提交后不能修改和删除
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/{id}/submit [post]
+func (h *CrudHandler) Submit(c *gin.Context) {
+	h.handleLifecycle(c, h.crudService.Submit, "提交")
+}
+
+// Unsubmit 反提交单据
+// @Summary 反提交单据
+// @Description 将已提交的单据退回未提交（表MASK需包含U）
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/{id}/unsubmit [post]
+func (h *CrudHandler) Unsubmit(c *gin.Context) {
+	h.handleLifecycle(c, h.crudService.Unsubmit, "反提交")
+}
+
+// Void 作废单据
+// @Summary 作废单据
+// @Description 作废未提交的单据（表MASK需包含V）
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/{id}/void [post]
+func (h *CrudHandler) Void(c *gin.Context) {
+	h.handleLifecycle(c, h.crudService.Void, "作废")
+}
+
+// handleLifecycle 处理单据状态操作
+func (h *CrudHandler) handleLifecycle(c *gin.Context, fn func(ctx context.Context, tableName string, id uint, userID uint) error, name string) {
+	tableName := c.Param("tableName")
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := fn(c.Request.Context(), tableName, uint(id), userID.(uint)); err != nil {
+		respondCrudError(c, name+"失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": name + "成功"})
+}
+
+// ListChanges 查询记录的变更历史
+// @Summary 查询记录的变更历史
+// @Description 按时间倒序返回记录的修改、删除、恢复历史，每条包含字段变更（字段名、显示名、原值、新值）
+// @Tags CRUD
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/{id}/history [get]
+func (h *CrudHandler) ListChanges(c *gin.Context) {
+	tableName := c.Param("tableName")
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	changes, err := h.crudService.ListChanges(c.Request.Context(), tableName, uint(id), userID.(uint))
+	if err != nil {
+		respondCrudError(c, "查询变更历史失败: ", err)
+		return
+	}
+
+	utils.Success(c, changes)
+}
+
+// RestoreRecord 恢复记录到某次变更之前的状态
+// @Summary 恢复记录到历史版本
+// @Description 将记录恢复到指定变更之前的状态；记录已删除时重新创建
+// @Tags CRUD
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Param changeId path int true "变更历史ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/{id}/history/{changeId}/restore [post]
+func (h *CrudHandler) RestoreRecord(c *gin.Context) {
+	tableName := c.Param("tableName")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+	changeID, err := strconv.ParseUint(c.Param("changeId"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "变更历史ID格式错误")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.crudService.RestoreRecord(c.Request.Context(), tableName, uint(id), uint(changeID), userID.(uint)); err != nil {
+		respondCrudError(c, "恢复失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "恢复成功"})
+}
+
+// ListDeleted 查询回收站
+// @Summary 查询回收站
+// @Description 分页查询已删除的记录（表 PROPS 需启用 softDelete），按删除时间倒序
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param request body CRUDRecycleQueryRequest false "查询请求"
+// @Success 200 {object} crud.QueryResponse
+// @Router /api/v1/data/{tableName}/recycle/query [post]
+func (h *CrudHandler) ListDeleted(c *gin.Context) {
+	var body CRUDRecycleQueryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			utils.BadRequest(c, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	req := crud.QueryRequest{
+		TableName: c.Param("tableName"),
+		Page:      body.Page,
+		PageSize:  body.PageSize,
+		Filters:   body.Filters,
+	}
+	result, err := h.crudService.ListDeleted(c.Request.Context(), &req, userID.(uint))
+	if err != nil {
+		respondCrudError(c, "查询回收站失败: ", err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// RestoreDeleted 从回收站恢复
+// @Summary 从回收站恢复记录
+// @Description 恢复已删除的记录，级联删除的子记录一并恢复
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param request body CRUDRecycleRequest true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/recycle/restore [post]
+func (h *CrudHandler) RestoreDeleted(c *gin.Context) {
+	h.handleRecycle(c, h.crudService.RestoreDeleted, "恢复")
+}
+
+// PurgeDeleted 从回收站彻底删除
+// @Summary 从回收站彻底删除记录
+// @Description 物理删除回收站中的记录，级联删除的子记录一并彻底删除
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param request body CRUDRecycleRequest true "记录ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/data/{tableName}/recycle/purge [post]
+func (h *CrudHandler) PurgeDeleted(c *gin.Context) {
+	h.handleRecycle(c, h.crudService.PurgeDeleted, "彻底删除")
+}
+
+// handleRecycle 处理回收站记录操作
+func (h *CrudHandler) handleRecycle(c *gin.Context, fn func(ctx context.Context, tableName string, ids []uint, userID uint) error, name string) {
+	var req CRUDRecycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := fn(c.Request.Context(), c.Param("tableName"), req.IDs, userID.(uint)); err != nil {
+		respondCrudError(c, name+"失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": name + "成功"})
+}
+
+// respondCrudError 根据错误码返回对应的HTTP状态
+func respondCrudError(c *gin.Context, prefix string, err error) {
+	switch errors.GetCode(err) {
+	case errors.ErrInvalidParam, errors.ErrValidation:
+		utils.Error(c, 400, err)
+	case errors.ErrPermissionDenied:
+		utils.Error(c, 403, err)
+	case errors.ErrResourceNotFound:
+		utils.Error(c, 404, err)
+	case errors.ErrResourceConflict, errors.ErrVersionConflict:
+		utils.Error(c, 409, err)
+	default:
+		utils.InternalError(c, prefix+err.Error())
+	}
+}
+
 // CRUDBatchDeleteRequest 批量删除请求
 type CRUDBatchDeleteRequest struct {
 	IDs []uint `json:"ids" binding:"required"`
 }
+
+// CRUDRecycleQueryRequest 回收站查询请求
+type CRUDRecycleQueryRequest struct {
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"pageSize"`
+	Filters  map[string]interface{} `json:"filters"`
+}
+
+// CRUDRecycleRequest 回收站记录操作请求
+type CRUDRecycleRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/utils"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/sky-xhsoft/sky-server/internal/service/imex"
+)
+
+// ImexHandler Excel导入导出处理器
+type ImexHandler struct {
+	imexService imex.Service
+}
+
+// NewImexHandler 创建Excel导入导出处理器
+func NewImexHandler(imexService imex.Service) *ImexHandler {
+	return &ImexHandler{
+		imexService: imexService,
+	}
+}
+
+// Export 导出数据
+// @Summary 导出数据
+// @Description 提交导出作业，按查询条件导出数据（过滤条件同列表查询，不分页），需要导出权限；只导出MASK导出可见的字段，字典字段导出显示名称。
+// @Description format 为导出格式：xlsx（默认）、csv（可设置 delimiter 分隔符和 encoding 编码）、ndjson（每行一个JSON对象，键为字段名）。
+// @Description 作业进度和结果通过WebSocket（JOB_PROGRESS）推送，完成后从 downloadUrl 下载
+// @Tags CRUD
+// @Accept json
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param request body CRUDExportRequest false "导出条件"
+// @Success 200 {object} job.JobView
+// @Router /api/v1/data/{tableName}/export [post]
+func (h *ImexHandler) Export(c *gin.Context) {
+	var body CRUDExportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			utils.BadRequest(c, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	req := crud.QueryRequest{
+		TableName: c.Param("tableName"),
+		OrderBy:   body.OrderBy,
+		Order:     body.Order,
+		Filters:   body.Filters,
+	}
+	file := imex.FileOptions{
+		Format:    body.Format,
+		Delimiter: body.Delimiter,
+		Encoding:  body.Encoding,
+	}
+	result, err := h.imexService.SubmitExport(c.Request.Context(), &req, file, userID.(uint))
+	if err != nil {
+		respondCrudError(c, "导出失败: ", err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// Import 导入数据
+// @Summary 导入数据
+// @Description 上传文件提交导入作业，逐行新增记录，需要导入权限；Excel、CSV第一行为表头（字段显示名称或字段名），NDJSON每行一个JSON对象（键为字段名或字段显示名称）。
+// @Description 字典字段可填显示名称或值，外键字段填写被引用表的显示键。未指定 format 时按文件扩展名判断格式。
+// @Description upsert 为 true 时按输入键匹配已有记录修改（还需要修改权限），dryRun 为 true 时只校验不写入。
+// @Description 作业完成后 result 为导入结果（新增、修改、失败行数和失败行明细），Excel文件有失败的行时可下载错误报告
+// @Tags CRUD
+// @Accept multipart/form-data
+// @Produce json
+// @Param tableName path string true "表名"
+// @Param file formData file true "导入文件（xlsx、csv、ndjson）"
+// @Param format formData string false "文件格式: xlsx, csv, ndjson"
+// @Param delimiter formData string false "CSV分隔符，默认逗号，tab 表示制表符"
+// @Param encoding formData string false "CSV编码: utf-8（默认）, gbk, gb18030"
+// @Param dryRun formData bool false "试运行（只校验不写入）"
+// @Param upsert formData bool false "按输入键更新已有记录"
+// @Success 200 {object} job.JobView
+// @Router /api/v1/data/{tableName}/import [post]
+func (h *ImexHandler) Import(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequest(c, "未找到上传文件")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	var opts crud.ImportOptions
+	if opts.DryRun, err = formBool(c, "dryRun"); err != nil {
+		utils.BadRequest(c, "dryRun 参数错误")
+		return
+	}
+	if opts.Upsert, err = formBool(c, "upsert"); err != nil {
+		utils.BadRequest(c, "upsert 参数错误")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.BadRequest(c, "打开文件失败: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	upload := &imex.Upload{
+		Reader: file,
+		Name:   fileHeader.Filename,
+		Size:   fileHeader.Size,
+		Options: imex.FileOptions{
+			Format:    c.PostForm("format"),
+			Delimiter: c.PostForm("delimiter"),
+			Encoding:  c.PostForm("encoding"),
+		},
+	}
+	result, err := h.imexService.SubmitImport(c.Request.Context(), c.Param("tableName"), upload, opts, userID.(uint))
+	if err != nil {
+		respondCrudError(c, "导入失败: ", err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// Template 下载导入模板
+// @Summary 下载导入模板
+// @Description 生成包含可导入字段表头的Excel模板，字典字段提供下拉选项，需要导入权限
+// @Tags CRUD
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param tableName path string true "表名"
+// @Success 200 {file} file
+// @Router /api/v1/data/{tableName}/template [get]
+func (h *ImexHandler) Template(c *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	tableName := c.Param("tableName")
+	var buf bytes.Buffer
+	if err := h.imexService.GenerateTemplate(c.Request.Context(), tableName, userID.(uint), &buf); err != nil {
+		respondCrudError(c, "生成模板失败: ", err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_template.xlsx", tableName)
+	c.Header("Content-Disposition", "attachment; filename="+url.PathEscape(filename))
+	c.Data(http.StatusOK, imex.XlsxContentType, buf.Bytes())
+}
+
+// formBool 读取布尔类型的表单参数（未提交时为 false）
+func formBool(c *gin.Context, key string) (bool, error) {
+	value := c.PostForm(key)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// CRUDExportRequest 导出请求
+type CRUDExportRequest struct {
+	OrderBy string                 `json:"orderBy"` // 排序字段
+	Order   string                 `json:"order"`   // 排序方向: asc, desc
+	Filters map[string]interface{} `json:"filters"` // 过滤条件（同列表查询）
+
+	Format    string `json:"format"`    // 导出格式: xlsx（默认）, csv, ndjson
+	Delimiter string `json:"delimiter"` // CSV分隔符，默认逗号，tab 表示制表符
+	Encoding  string `json:"encoding"`  // CSV编码: utf-8（默认）, gbk, gb18030
+}
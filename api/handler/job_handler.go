@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/utils"
+	"github.com/sky-xhsoft/sky-server/internal/service/job"
+)
+
+// JobHandler 后台作业处理器
+type JobHandler struct {
+	jobService job.Service
+}
+
+// NewJobHandler 创建后台作业处理器
+func NewJobHandler(jobService job.Service) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+	}
+}
+
+// ListJobs 查询我的作业列表
+// @Summary 查询我的作业列表
+// @Description 查询当前用户提交的后台作业（导入、导出等），按提交时间倒序
+// @Tags 后台作业
+// @Produce json
+// @Param status query string false "状态: queued, running, succeeded, failed, cancelled"
+// @Param jobType query string false "作业类型: export, import"
+// @Param page query int false "页码"
+// @Param pageSize query int false "每页大小"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	jobs, total, err := h.jobService.ListJobs(c.Request.Context(), &job.ListRequest{
+		Status:   c.Query("status"),
+		JobType:  c.Query("jobType"),
+		Page:     page,
+		PageSize: pageSize,
+		UserID:   userID.(uint),
+	})
+	if err != nil {
+		respondCrudError(c, "查询作业列表失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"list":  jobs,
+		"total": total,
+	})
+}
+
+// GetJob 查询作业
+// @Summary 查询作业
+// @Description 查询作业的状态、进度和结果
+// @Tags 后台作业
+// @Produce json
+// @Param id path int true "作业ID"
+// @Success 200 {object} job.JobView
+// @Router /api/v1/jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	result, err := h.jobService.GetJob(c.Request.Context(), uint(id), userID.(uint))
+	if err != nil {
+		respondCrudError(c, "查询作业失败: ", err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// CancelJob 取消作业
+// @Summary 取消作业
+// @Description 取消排队中或执行中的作业，执行中的作业在下次报告进度时停止
+// @Tags 后台作业
+// @Produce json
+// @Param id path int true "作业ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/jobs/{id}/cancel [post]
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.jobService.CancelJob(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		respondCrudError(c, "取消作业失败: ", err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// Download 下载作业结果文件
+// @Summary 下载作业结果文件
+// @Description 下载已完成作业的结果文件（如导出的Excel）
+// @Tags 后台作业
+// @Produce application/octet-stream
+// @Param id path int true "作业ID"
+// @Success 200 {file} file
+// @Router /api/v1/jobs/{id}/download [get]
+func (h *JobHandler) Download(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	reader, result, err := h.jobService.OpenResult(c.Request.Context(), uint(id), userID.(uint))
+	if err != nil {
+		respondCrudError(c, "下载结果文件失败: ", err)
+		return
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(result.ResultName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", "attachment; filename="+url.PathEscape(result.ResultName))
+	c.Header("Content-Type", contentType)
+
+	// 流式传输文件
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		utils.InternalError(c, "传输文件失败: "+err.Error())
+		return
+	}
+}
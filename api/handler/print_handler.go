@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/utils"
+	"github.com/sky-xhsoft/sky-server/internal/service/printing"
+)
+
+// PrintHandler 打印处理器
+type PrintHandler struct {
+	printService printing.Service
+}
+
+// NewPrintHandler 创建打印处理器
+func NewPrintHandler(printService printing.Service) *PrintHandler {
+	return &PrintHandler{
+		printService: printService,
+	}
+}
+
+// ListTemplates 查询表的打印模板
+// @Summary 查询表的打印模板
+// @Description 查询表的打印模板，按排序号排列，需要表的查询权限
+// @Tags 打印
+// @Produce json
+// @Param tableName query string true "表名"
+// @Success 200 {array} entity.SysPrintTemplate
+// @Router /api/v1/print-templates [get]
+func (h *PrintHandler) ListTemplates(c *gin.Context) {
+	tableName := c.Query("tableName")
+	if tableName == "" {
+		utils.BadRequest(c, "缺少表名")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	templates, err := h.printService.ListTemplates(c.Request.Context(), tableName, userID.(uint))
+	if err != nil {
+		respondCrudError(c, "查询打印模板失败: ", err)
+		return
+	}
+
+	utils.Success(c, templates)
+}
+
+// GetTemplate 查询打印模板
+// @Summary 查询打印模板
+// @Tags 打印
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} entity.SysPrintTemplate
+// @Router /api/v1/print-templates/{id} [get]
+func (h *PrintHandler) GetTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	tpl, err := h.printService.GetTemplate(c.Request.Context(), uint(id), userID.(uint))
+	if err != nil {
+		respondCrudError(c, "查询打印模板失败: ", err)
+		return
+	}
+
+	utils.Success(c, tpl)
+}
+
+// CreateTemplate 创建打印模板
+// @Summary 创建打印模板
+// @Description 创建表的打印模板，需要表的修改权限。headerColumns 为逗号分隔的表头字段名；
+// @Description details 为明细表JSON数组，如 [{"table":"order_item","title":"商品明细","columns":["SKU","QTY"]}]，为空时打印全部一对多子表
+// @Tags 打印
+// @Accept json
+// @Produce json
+// @Param request body entity.SysPrintTemplate true "打印模板"
+// @Success 200 {object} entity.SysPrintTemplate
+// @Router /api/v1/print-templates [post]
+func (h *PrintHandler) CreateTemplate(c *gin.Context) {
+	var tpl entity.SysPrintTemplate
+	if err := c.ShouldBindJSON(&tpl); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.printService.CreateTemplate(c.Request.Context(), &tpl, userID.(uint)); err != nil {
+		respondCrudError(c, "创建打印模板失败: ", err)
+		return
+	}
+
+	utils.Success(c, tpl)
+}
+
+// UpdateTemplate 修改打印模板
+// @Summary 修改打印模板
+// @Description 修改打印模板，需要所属表的修改权限，所属表不可修改
+// @Tags 打印
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body entity.SysPrintTemplate true "打印模板"
+// @Success 200 {object} entity.SysPrintTemplate
+// @Router /api/v1/print-templates/{id} [put]
+func (h *PrintHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	var tpl entity.SysPrintTemplate
+	if err := c.ShouldBindJSON(&tpl); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	tpl.ID = uint(id)
+	if err := h.printService.UpdateTemplate(c.Request.Context(), &tpl, userID.(uint)); err != nil {
+		respondCrudError(c, "更新打印模板失败: ", err)
+		return
+	}
+
+	utils.Success(c, tpl)
+}
+
+// DeleteTemplate 删除打印模板
+// @Summary 删除打印模板
+// @Tags 打印
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v1/print-templates/{id} [delete]
+func (h *PrintHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.printService.DeleteTemplate(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		respondCrudError(c, "删除打印模板失败: ", err)
+		return
+	}
+
+	utils.Success(c, nil)
+}
+
+// Print 打印记录
+// @Summary 打印记录
+// @Description 按打印模板输出记录的HTML或PDF，只打印MASK打印可见的字段，字典字段打印显示名称，明细取自一对多关联的子表；需要查询权限
+// @Tags 打印
+// @Produce html
+// @Produce application/pdf
+// @Param tableName path string true "表名"
+// @Param id path int true "记录ID"
+// @Param templateId query int false "打印模板ID（默认使用表的默认模板）"
+// @Param format query string false "输出格式: html（默认）, pdf"
+// @Success 200 {file} file
+// @Router /api/v1/data/{tableName}/{id}/print [get]
+func (h *PrintHandler) Print(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	var templateID uint64
+	if value := c.Query("templateId"); value != "" {
+		if templateID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			utils.BadRequest(c, "模板ID格式错误")
+			return
+		}
+	}
+
+	h.render(c, &printing.RenderRequest{
+		TableName:  c.Param("tableName"),
+		IDs:        []uint{uint(id)},
+		TemplateID: uint(templateID),
+		Format:     c.Query("format"),
+	}, fmt.Sprintf("%s_%d", c.Param("tableName"), id))
+}
+
+// BatchPrint 批量打印记录
+// @Summary 批量打印记录
+// @Description 按打印模板输出多条记录的HTML或PDF（单次最多100条），每条记录从新的一页开始
+// @Tags 打印
+// @Accept json
+// @Produce html
+// @Produce application/pdf
+// @Param tableName path string true "表名"
+// @Param request body printing.RenderRequest true "打印请求"
+// @Success 200 {file} file
+// @Router /api/v1/data/{tableName}/print [post]
+func (h *PrintHandler) BatchPrint(c *gin.Context) {
+	var req printing.RenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	req.TableName = c.Param("tableName")
+	h.render(c, &req, req.TableName)
+}
+
+// render 输出打印结果（HTML、PDF均在浏览器中直接打开）
+func (h *PrintHandler) render(c *gin.Context, req *printing.RenderRequest, name string) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.printService.Render(c.Request.Context(), req, userID.(uint), &buf); err != nil {
+		respondCrudError(c, "打印失败: ", err)
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = printing.FormatHTML
+	}
+	filename := fmt.Sprintf("%s.%s", name, format)
+	c.Header("Content-Disposition", "inline; filename="+url.PathEscape(filename))
+	c.Data(http.StatusOK, printing.ContentType(format), buf.Bytes())
+}
@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/utils"
 	"github.com/sky-xhsoft/sky-server/internal/service/workflow"
 )
@@ -14,6 +19,9 @@ type WorkflowHandler struct {
 	workflowService workflow.Service
 }
 
+// maxBPMNSize BPMN文件大小上限
+const maxBPMNSize = 5 << 20
+
 // NewWorkflowHandler 创建工作流处理器
 func NewWorkflowHandler(workflowService workflow.Service) *WorkflowHandler {
 	return &WorkflowHandler{
@@ -38,7 +46,7 @@ func (h *WorkflowHandler) CreateDefinition(c *gin.Context) {
 
 	def.IsActive = "Y"
 	if err := h.workflowService.CreateDefinition(c.Request.Context(), &def); err != nil {
-		utils.InternalError(c, "创建流程定义失败: "+err.Error())
+		respondWorkflowError(c, "创建流程定义失败: ", err)
 		return
 	}
 
@@ -94,7 +102,7 @@ func (h *WorkflowHandler) UpdateDefinition(c *gin.Context) {
 
 	def.ID = uint(id)
 	if err := h.workflowService.UpdateDefinition(c.Request.Context(), &def); err != nil {
-		utils.InternalError(c, "更新流程定义失败: "+err.Error())
+		respondWorkflowError(c, "更新流程定义失败: ", err)
 		return
 	}
 
@@ -117,13 +125,136 @@ func (h *WorkflowHandler) PublishDefinition(c *gin.Context) {
 	}
 
 	if err := h.workflowService.PublishDefinition(c.Request.Context(), uint(id)); err != nil {
-		utils.InternalError(c, "发布流程定义失败: "+err.Error())
+		respondWorkflowError(c, "发布流程定义失败: ", err)
 		return
 	}
 
 	utils.Success(c, gin.H{"message": "发布成功"})
 }
 
+// CreateVersion 创建流程新版本
+// @Summary 创建流程新版本
+// @Description 以指定版本为基础复制节点和流转，创建新的草稿版本
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程定义ID"
+// @Success 200 {object} entity.WfDefinition
+// @Router /api/v1/workflow/definitions/{id}/versions [post]
+func (h *WorkflowHandler) CreateVersion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	def, err := h.workflowService.CreateVersion(c.Request.Context(), uint(id))
+	if err != nil {
+		respondWorkflowError(c, "创建流程版本失败: ", err)
+		return
+	}
+
+	utils.Success(c, def)
+}
+
+// ListVersions 查询流程版本
+// @Summary 查询流程版本
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程定义ID"
+// @Success 200 {array} entity.WfDefinition
+// @Router /api/v1/workflow/definitions/{id}/versions [get]
+func (h *WorkflowHandler) ListVersions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	versions, err := h.workflowService.ListVersions(c.Request.Context(), uint(id))
+	if err != nil {
+		respondWorkflowError(c, "查询流程版本失败: ", err)
+		return
+	}
+
+	utils.Success(c, versions)
+}
+
+// ImportBPMN 导入BPMN流程定义
+// @Summary 导入BPMN流程定义
+// @Description 导入BPMN 2.0 XML创建草稿；同名流程已存在时作为新版本导入
+// @Tags 工作流
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "BPMN文件（也可直接以请求体上传XML）"
+// @Success 200 {object} entity.WfDefinition
+// @Router /api/v1/workflow/definitions/import [post]
+func (h *WorkflowHandler) ImportBPMN(c *gin.Context) {
+	var data []byte
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.BadRequest(c, "读取文件失败")
+			return
+		}
+		defer file.Close()
+		data, err = io.ReadAll(io.LimitReader(file, maxBPMNSize+1))
+		if err != nil {
+			utils.BadRequest(c, "读取文件失败")
+			return
+		}
+	} else {
+		data, err = io.ReadAll(io.LimitReader(c.Request.Body, maxBPMNSize+1))
+		if err != nil {
+			utils.BadRequest(c, "读取请求体失败")
+			return
+		}
+	}
+	if len(data) == 0 {
+		utils.BadRequest(c, "BPMN文件不能为空")
+		return
+	}
+	if len(data) > maxBPMNSize {
+		utils.BadRequest(c, "BPMN文件过大")
+		return
+	}
+
+	def, err := h.workflowService.ImportBPMN(c.Request.Context(), data)
+	if err != nil {
+		respondWorkflowError(c, "导入BPMN失败: ", err)
+		return
+	}
+
+	utils.Success(c, def)
+}
+
+// ExportBPMN 导出BPMN流程定义
+// @Summary 导出BPMN流程定义
+// @Tags 工作流
+// @Produce application/xml
+// @Param id path int true "流程定义ID"
+// @Success 200 {file} file
+// @Router /api/v1/workflow/definitions/{id}/bpmn [get]
+func (h *WorkflowHandler) ExportBPMN(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	def, data, err := h.workflowService.ExportBPMN(c.Request.Context(), uint(id))
+	if err != nil {
+		respondWorkflowError(c, "导出BPMN失败: ", err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_v%d.bpmn", def.Name, def.Version)
+	c.Header("Content-Disposition", "attachment; filename="+url.PathEscape(filename))
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
 // ListDefinitions 查询流程定义列表
 // @Summary 查询流程定义列表
 // @Tags 工作流
@@ -169,7 +300,7 @@ func (h *WorkflowHandler) CreateNode(c *gin.Context) {
 
 	node.IsActive = "Y"
 	if err := h.workflowService.CreateNode(c.Request.Context(), &node); err != nil {
-		utils.InternalError(c, "创建流程节点失败: "+err.Error())
+		respondWorkflowError(c, "创建流程节点失败: ", err)
 		return
 	}
 
@@ -225,7 +356,7 @@ func (h *WorkflowHandler) UpdateNode(c *gin.Context) {
 
 	node.ID = uint(id)
 	if err := h.workflowService.UpdateNode(c.Request.Context(), &node); err != nil {
-		utils.InternalError(c, "更新流程节点失败: "+err.Error())
+		respondWorkflowError(c, "更新流程节点失败: ", err)
 		return
 	}
 
@@ -248,7 +379,7 @@ func (h *WorkflowHandler) DeleteNode(c *gin.Context) {
 	}
 
 	if err := h.workflowService.DeleteNode(c.Request.Context(), uint(id)); err != nil {
-		utils.InternalError(c, "删除流程节点失败: "+err.Error())
+		respondWorkflowError(c, "删除流程节点失败: ", err)
 		return
 	}
 
@@ -272,7 +403,7 @@ func (h *WorkflowHandler) CreateTransition(c *gin.Context) {
 
 	transition.IsActive = "Y"
 	if err := h.workflowService.CreateTransition(c.Request.Context(), &transition); err != nil {
-		utils.InternalError(c, "创建流程流转失败: "+err.Error())
+		respondWorkflowError(c, "创建流程流转失败: ", err)
 		return
 	}
 
@@ -319,7 +450,7 @@ func (h *WorkflowHandler) DeleteTransition(c *gin.Context) {
 	}
 
 	if err := h.workflowService.DeleteTransition(c.Request.Context(), uint(id)); err != nil {
-		utils.InternalError(c, "删除流程流转失败: "+err.Error())
+		respondWorkflowError(c, "删除流程流转失败: ", err)
 		return
 	}
 
@@ -351,7 +482,7 @@ func (h *WorkflowHandler) StartProcess(c *gin.Context) {
 
 	instance, err := h.workflowService.StartProcess(c.Request.Context(), &req)
 	if err != nil {
-		utils.InternalError(c, "启动流程失败: "+err.Error())
+		respondWorkflowError(c, "启动流程失败: ", err)
 		return
 	}
 
@@ -449,13 +580,196 @@ func (h *WorkflowHandler) TerminateInstance(c *gin.Context) {
 	}
 
 	if err := h.workflowService.TerminateInstance(c.Request.Context(), uint(id), userID.(uint)); err != nil {
-		utils.InternalError(c, "终止流程失败: "+err.Error())
+		respondWorkflowError(c, "终止流程失败: ", err)
 		return
 	}
 
 	utils.Success(c, gin.H{"message": "终止成功"})
 }
 
+// WithdrawInstance 撤回流程实例
+// @Summary 撤回流程实例
+// @Description 发起人在尚无审批人同意时撤回流程
+// @Tags 工作流
+// @Accept json
+// @Produce json
+// @Param id path int true "流程实例ID"
+// @Param request body WithdrawInstanceRequest false "撤回请求"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/instances/{id}/withdraw [post]
+func (h *WorkflowHandler) WithdrawInstance(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	var req WithdrawInstanceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.workflowService.WithdrawInstance(c.Request.Context(), uint(id), userID.(uint), req.Comment); err != nil {
+		switch errors.GetCode(err) {
+		case errors.ErrPermissionDenied:
+			utils.Forbidden(c, err.Error())
+		case errors.ErrResourceConflict:
+			utils.Error(c, 409, err)
+		default:
+			utils.InternalError(c, "撤回流程失败: "+err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "撤回成功"})
+}
+
+// MigrateInstances 迁移流程实例版本
+// @Summary 迁移流程实例版本
+// @Description 管理员将运行中的实例迁移到同一流程的其他已发布版本，按节点映射改挂令牌和任务
+// @Tags 工作流
+// @Accept json
+// @Produce json
+// @Param request body workflow.MigrateInstancesRequest true "迁移请求"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/instances/migrate [post]
+func (h *WorkflowHandler) MigrateInstances(c *gin.Context) {
+	var req workflow.MigrateInstancesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+	req.UserID = userID.(uint)
+
+	if err := h.workflowService.MigrateInstances(c.Request.Context(), &req); err != nil {
+		respondWorkflowError(c, "迁移流程实例失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "迁移成功"})
+}
+
+// ListTokens 查询流程实例令牌
+// @Summary 查询流程实例令牌
+// @Description 每个令牌对应一次节点访问，并行分支中同时存在多个活动令牌
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程实例ID"
+// @Param active query bool false "只返回活动和等待汇聚的令牌"
+// @Success 200 {array} entity.WfToken
+// @Router /api/v1/workflow/instances/{id}/tokens [get]
+func (h *WorkflowHandler) ListTokens(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	activeOnly := c.Query("active") == "true"
+
+	tokens, err := h.workflowService.ListTokens(c.Request.Context(), uint(id), activeOnly)
+	if err != nil {
+		utils.InternalError(c, "查询流程令牌失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, tokens)
+}
+
+// ListHistory 查询流程实例历史
+// @Summary 查询流程实例历史
+// @Description 到期提醒、超时处理、退回、撤回等流程事件
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程实例ID"
+// @Success 200 {array} entity.WfHistory
+// @Router /api/v1/workflow/instances/{id}/history [get]
+func (h *WorkflowHandler) ListHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	histories, err := h.workflowService.ListHistory(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.InternalError(c, "查询流程历史失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, histories)
+}
+
+// GetTimeline 查询流程实例时间线
+// @Summary 查询流程实例时间线
+// @Description 按时间顺序返回节点进出、任务处理、变量变更、自动任务结果等事件
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程实例ID"
+// @Success 200 {object} workflow.InstanceTimeline
+// @Router /api/v1/workflow/instances/{id}/timeline [get]
+func (h *WorkflowHandler) GetTimeline(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	timeline, err := h.workflowService.GetTimeline(c.Request.Context(), uint(id))
+	if err != nil {
+		respondWorkflowError(c, "查询流程时间线失败: ", err)
+		return
+	}
+
+	utils.Success(c, timeline)
+}
+
+// GetInstanceGraph 查询流程实例图状态
+// @Summary 查询流程实例图状态
+// @Description 返回流程图节点和流转，标记已经过、停留中和未经过的节点及经过的流转
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "流程实例ID"
+// @Success 200 {object} workflow.InstanceGraph
+// @Router /api/v1/workflow/instances/{id}/graph [get]
+func (h *WorkflowHandler) GetInstanceGraph(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	graph, err := h.workflowService.GetInstanceGraph(c.Request.Context(), uint(id))
+	if err != nil {
+		respondWorkflowError(c, "查询流程图状态失败: ", err)
+		return
+	}
+
+	utils.Success(c, graph)
+}
+
 // ListMyTasks 查询我的任务列表
 // @Summary 查询我的任务列表
 // @Tags 工作流
@@ -517,6 +831,7 @@ func (h *WorkflowHandler) GetTask(c *gin.Context) {
 
 // CompleteTask 完成任务
 // @Summary 完成任务
+// @Description action: approve 同意, reject 拒绝, back 退回（可指定 targetNodeId）, backToStarter 退回发起人
 // @Tags 工作流
 // @Accept json
 // @Produce json
@@ -539,7 +854,7 @@ func (h *WorkflowHandler) CompleteTask(c *gin.Context) {
 	req.UserID = userID.(uint)
 
 	if err := h.workflowService.CompleteTask(c.Request.Context(), &req); err != nil {
-		utils.InternalError(c, "完成任务失败: "+err.Error())
+		respondWorkflowError(c, "完成任务失败: ", err)
 		return
 	}
 
@@ -569,6 +884,11 @@ func (h *WorkflowHandler) ClaimTask(c *gin.Context) {
 	}
 
 	if err := h.workflowService.ClaimTask(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		// 候选组任务已被他人签收
+		if errors.GetCode(err) == errors.ErrResourceConflict {
+			utils.Error(c, 409, err)
+			return
+		}
 		utils.InternalError(c, "签收任务失败: "+err.Error())
 		return
 	}
@@ -576,6 +896,31 @@ func (h *WorkflowHandler) ClaimTask(c *gin.Context) {
 	utils.Success(c, gin.H{"message": "签收成功"})
 }
 
+// GetTaskCandidates 获取任务候选人
+// @Summary 获取任务候选人
+// @Description 候选组任务签收前的候选用户ID列表
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {array} uint
+// @Router /api/v1/workflow/tasks/{id}/candidates [get]
+func (h *WorkflowHandler) GetTaskCandidates(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	candidates, err := h.workflowService.GetTaskCandidates(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.InternalError(c, "查询任务候选人失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, candidates)
+}
+
 // TransferTask 转交任务
 // @Summary 转交任务
 // @Tags 工作流
@@ -607,15 +952,195 @@ func (h *WorkflowHandler) TransferTask(c *gin.Context) {
 	}
 
 	if err := h.workflowService.TransferTask(c.Request.Context(), uint(id), userID.(uint), req.ToUserID, req.Comment); err != nil {
-		utils.InternalError(c, "转交任务失败: "+err.Error())
+		respondWorkflowError(c, "转交任务失败: ", err)
 		return
 	}
 
 	utils.Success(c, gin.H{"message": "转交成功"})
 }
 
+// ListJobs 查询自动任务作业
+// @Summary 查询自动任务作业
+// @Description 管理员查看自动任务作业，可按状态（如 failed）和流程实例过滤
+// @Tags 工作流
+// @Produce json
+// @Param status query string false "状态(pending/running/completed/failed/canceled)"
+// @Param instanceId query int false "流程实例ID"
+// @Param page query int false "页码"
+// @Param pageSize query int false "每页大小"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/jobs [get]
+func (h *WorkflowHandler) ListJobs(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	req := workflow.ListJobsRequest{
+		Status: c.Query("status"),
+		UserID: userID.(uint),
+	}
+	if instanceIDStr := c.Query("instanceId"); instanceIDStr != "" {
+		id, _ := strconv.ParseUint(instanceIDStr, 10, 32)
+		req.InstanceID = uint(id)
+	}
+	req.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	req.PageSize, _ = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	jobs, total, err := h.workflowService.ListJobs(c.Request.Context(), &req)
+	if err != nil {
+		respondWorkflowError(c, "查询自动任务作业失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"total":    total,
+		"page":     req.Page,
+		"pageSize": req.PageSize,
+		"data":     jobs,
+	})
+}
+
+// RetryJob 重试失败的自动任务作业
+// @Summary 重试失败的自动任务作业
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "作业ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/jobs/{id}/retry [post]
+func (h *WorkflowHandler) RetryJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.workflowService.RetryJob(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		respondWorkflowError(c, "重试自动任务作业失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "已重新排队"})
+}
+
+// CreateDelegation 创建任务委托规则
+// @Summary 创建任务委托规则
+// @Description 委托期间新创建的任务分配给受托人，可限定流程；管理员可为他人设置
+// @Tags 工作流
+// @Accept json
+// @Produce json
+// @Param request body workflow.DelegationRequest true "委托规则"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/delegations [post]
+func (h *WorkflowHandler) CreateDelegation(c *gin.Context) {
+	var req workflow.DelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+	req.UserID = userID.(uint)
+
+	delegation, err := h.workflowService.CreateDelegation(c.Request.Context(), &req)
+	if err != nil {
+		respondWorkflowError(c, "创建委托规则失败: ", err)
+		return
+	}
+
+	utils.Success(c, delegation)
+}
+
+// ListDelegations 查询我的任务委托规则
+// @Summary 查询我的任务委托规则
+// @Description 查询自己委托出去的和委托给自己的规则
+// @Tags 工作流
+// @Produce json
+// @Param includeExpired query bool false "是否包含已过期的规则"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/delegations [get]
+func (h *WorkflowHandler) ListDelegations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	includeExpired := c.Query("includeExpired") == "true"
+	delegations, err := h.workflowService.ListDelegations(c.Request.Context(), userID.(uint), includeExpired)
+	if err != nil {
+		respondWorkflowError(c, "查询委托规则失败: ", err)
+		return
+	}
+
+	utils.Success(c, delegations)
+}
+
+// CancelDelegation 取消任务委托规则
+// @Summary 取消任务委托规则
+// @Description 已分配给受托人的任务不收回
+// @Tags 工作流
+// @Produce json
+// @Param id path int true "委托规则ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workflow/delegations/{id} [delete]
+func (h *WorkflowHandler) CancelDelegation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "ID格式错误")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.Unauthorized(c, "未授权")
+		return
+	}
+
+	if err := h.workflowService.CancelDelegation(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		respondWorkflowError(c, "取消委托规则失败: ", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": "已取消"})
+}
+
+// respondWorkflowError 按错误码返回工作流错误
+func respondWorkflowError(c *gin.Context, prefix string, err error) {
+	switch errors.GetCode(err) {
+	case errors.ErrInvalidParam, errors.ErrValidation:
+		utils.Error(c, 400, err)
+	case errors.ErrPermissionDenied:
+		utils.Forbidden(c, err.Error())
+	case errors.ErrResourceNotFound:
+		utils.Error(c, 404, err)
+	case errors.ErrResourceExists, errors.ErrResourceConflict:
+		utils.Error(c, 409, err)
+	default:
+		utils.InternalError(c, prefix+err.Error())
+	}
+}
+
 // TransferTaskRequest 转交任务请求
 type TransferTaskRequest struct {
 	ToUserID uint   `json:"toUserId" binding:"required"`
 	Comment  string `json:"comment"`
 }
+
+// WithdrawInstanceRequest 撤回流程请求
+type WithdrawInstanceRequest struct {
+	Comment string `json:"comment"`
+}
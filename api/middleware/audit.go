@@ -128,6 +128,12 @@ func parseActionAndResource(method, path string) (action, resource string) {
 			action = entity.ActionTransferTask
 		} else if strings.Contains(path, "/publish") {
 			action = entity.ActionPublishWorkflow
+		} else if strings.HasSuffix(path, "/unsubmit") {
+			action = entity.ActionUnsubmit
+		} else if strings.HasSuffix(path, "/submit") {
+			action = entity.ActionSubmit
+		} else if strings.HasSuffix(path, "/void") {
+			action = entity.ActionVoid
 		} else {
 			action = entity.ActionCreate
 		}
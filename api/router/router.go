@@ -14,9 +14,12 @@ import (
 	"github.com/sky-xhsoft/sky-server/internal/service/dict"
 	"github.com/sky-xhsoft/sky-server/internal/service/file"
 	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"github.com/sky-xhsoft/sky-server/internal/service/imex"
+	"github.com/sky-xhsoft/sky-server/internal/service/job"
 	"github.com/sky-xhsoft/sky-server/internal/service/menu"
 	"github.com/sky-xhsoft/sky-server/internal/service/message"
 	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
+	"github.com/sky-xhsoft/sky-server/internal/service/printing"
 	"github.com/sky-xhsoft/sky-server/internal/service/sequence"
 	"github.com/sky-xhsoft/sky-server/internal/service/sso"
 	"github.com/sky-xhsoft/sky-server/internal/service/workflow"
@@ -33,6 +36,9 @@ type Services struct {
 	Dict            dict.Service
 	Sequence        sequence.Service
 	CRUD            crud.Service
+	Imex            imex.Service
+	Job             job.Service
+	Print           printing.Service
 	Action          action.Service
 	Workflow        workflow.Service
 	Audit           audit.Service
@@ -86,6 +92,15 @@ func Setup(engine *gin.Engine, cfg *config.Config, jwtUtil *jwt.JWT, services *S
 		// 注册通用CRUD路由
 		registerCRUDRoutes(v1, jwtUtil, services.CRUD)
 
+		// 注册Excel导入导出路由
+		registerImexRoutes(v1, jwtUtil, services.Imex)
+
+		// 注册后台作业路由
+		registerJobRoutes(v1, jwtUtil, services.Job)
+
+		// 注册打印路由
+		registerPrintRoutes(v1, jwtUtil, services.Print)
+
 		// 注册动作路由
 		registerActionRoutes(v1, jwtUtil, services.Action)
 
@@ -210,6 +225,69 @@ func registerCRUDRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, crudService crud.
 		data.PUT("/:tableName/:id", crudHandler.Update)
 		data.DELETE("/:tableName/:id", crudHandler.Delete)
 		data.POST("/:tableName/batch-delete", crudHandler.BatchDelete)
+
+		// 变更历史
+		data.GET("/:tableName/:id/history", crudHandler.ListChanges)
+		data.POST("/:tableName/:id/history/:changeId/restore", crudHandler.RestoreRecord)
+
+		// 回收站
+		data.POST("/:tableName/recycle/query", crudHandler.ListDeleted)
+		data.POST("/:tableName/recycle/restore", crudHandler.RestoreDeleted)
+		data.POST("/:tableName/recycle/purge", crudHandler.PurgeDeleted)
+
+		// 单据生命周期
+		data.POST("/:tableName/:id/submit", crudHandler.Submit)
+		data.POST("/:tableName/:id/unsubmit", crudHandler.Unsubmit)
+		data.POST("/:tableName/:id/void", crudHandler.Void)
+	}
+}
+
+// registerImexRoutes 注册Excel导入导出路由
+func registerImexRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, imexService imex.Service) {
+	imexHandler := handler.NewImexHandler(imexService)
+
+	data := rg.Group("/data")
+	data.Use(middleware.AuthRequired(jwtUtil))
+	{
+		data.POST("/:tableName/export", imexHandler.Export)
+		data.POST("/:tableName/import", imexHandler.Import)
+		data.GET("/:tableName/template", imexHandler.Template)
+	}
+}
+
+// registerJobRoutes 注册后台作业路由
+func registerJobRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, jobService job.Service) {
+	jobHandler := handler.NewJobHandler(jobService)
+
+	jobs := rg.Group("/jobs")
+	jobs.Use(middleware.AuthRequired(jwtUtil))
+	{
+		jobs.GET("", jobHandler.ListJobs)
+		jobs.GET("/:id", jobHandler.GetJob)
+		jobs.POST("/:id/cancel", jobHandler.CancelJob)
+		jobs.GET("/:id/download", jobHandler.Download)
+	}
+}
+
+// registerPrintRoutes 注册打印路由
+func registerPrintRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, printService printing.Service) {
+	printHandler := handler.NewPrintHandler(printService)
+
+	templates := rg.Group("/print-templates")
+	templates.Use(middleware.AuthRequired(jwtUtil))
+	{
+		templates.GET("", printHandler.ListTemplates)
+		templates.POST("", printHandler.CreateTemplate)
+		templates.GET("/:id", printHandler.GetTemplate)
+		templates.PUT("/:id", printHandler.UpdateTemplate)
+		templates.DELETE("/:id", printHandler.DeleteTemplate)
+	}
+
+	data := rg.Group("/data")
+	data.Use(middleware.AuthRequired(jwtUtil))
+	{
+		data.GET("/:tableName/:id/print", printHandler.Print)
+		data.POST("/:tableName/print", printHandler.BatchPrint)
 	}
 }
 
@@ -242,6 +320,10 @@ func registerWorkflowRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, workflowServi
 			definitions.GET("/:id", workflowHandler.GetDefinition)
 			definitions.PUT("/:id", workflowHandler.UpdateDefinition)
 			definitions.POST("/:id/publish", workflowHandler.PublishDefinition)
+			definitions.POST("/:id/versions", workflowHandler.CreateVersion)
+			definitions.GET("/:id/versions", workflowHandler.ListVersions)
+			definitions.POST("/import", workflowHandler.ImportBPMN)
+			definitions.GET("/:id/bpmn", workflowHandler.ExportBPMN)
 		}
 
 		// 流程节点管理
@@ -265,9 +347,22 @@ func registerWorkflowRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, workflowServi
 		instances := workflow.Group("/instances")
 		{
 			instances.POST("/start", workflowHandler.StartProcess)
+			instances.POST("/migrate", workflowHandler.MigrateInstances)
 			instances.GET("", workflowHandler.ListInstances)
 			instances.GET("/:id", workflowHandler.GetInstance)
 			instances.POST("/:id/terminate", workflowHandler.TerminateInstance)
+			instances.POST("/:id/withdraw", workflowHandler.WithdrawInstance)
+			instances.GET("/:id/tokens", workflowHandler.ListTokens)
+			instances.GET("/:id/history", workflowHandler.ListHistory)
+			instances.GET("/:id/timeline", workflowHandler.GetTimeline)
+			instances.GET("/:id/graph", workflowHandler.GetInstanceGraph)
+		}
+
+		// 自动任务作业管理（管理员）
+		jobs := workflow.Group("/jobs")
+		{
+			jobs.GET("", workflowHandler.ListJobs)
+			jobs.POST("/:id/retry", workflowHandler.RetryJob)
 		}
 
 		// 任务管理
@@ -277,8 +372,17 @@ func registerWorkflowRoutes(rg *gin.RouterGroup, jwtUtil *jwt.JWT, workflowServi
 			tasks.GET("/:id", workflowHandler.GetTask)
 			tasks.POST("/complete", workflowHandler.CompleteTask)
 			tasks.POST("/:id/claim", workflowHandler.ClaimTask)
+			tasks.GET("/:id/candidates", workflowHandler.GetTaskCandidates)
 			tasks.POST("/:id/transfer", workflowHandler.TransferTask)
 		}
+
+		// 任务委托
+		delegations := workflow.Group("/delegations")
+		{
+			delegations.POST("", workflowHandler.CreateDelegation)
+			delegations.GET("", workflowHandler.ListDelegations)
+			delegations.DELETE("/:id", workflowHandler.CancelDelegation)
+		}
 	}
 }
 
@@ -27,13 +27,17 @@ import (
 	"github.com/sky-xhsoft/sky-server/internal/service/file"
 	"github.com/sky-xhsoft/sky-server/internal/service/groups"
 	"github.com/sky-xhsoft/sky-server/internal/service/idgen"
+	"github.com/sky-xhsoft/sky-server/internal/service/imex"
+	"github.com/sky-xhsoft/sky-server/internal/service/job"
 	"github.com/sky-xhsoft/sky-server/internal/service/menu"
 	"github.com/sky-xhsoft/sky-server/internal/service/message"
 	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
+	"github.com/sky-xhsoft/sky-server/internal/service/printing"
 	"github.com/sky-xhsoft/sky-server/internal/service/sequence"
 	"github.com/sky-xhsoft/sky-server/internal/service/sso"
 	"github.com/sky-xhsoft/sky-server/internal/service/workflow"
 	"github.com/sky-xhsoft/sky-server/plugins"
+	"github.com/sky-xhsoft/sky-server/plugins/core"
 	"go.uber.org/zap"
 )
 
@@ -138,7 +142,7 @@ func main() {
 	idgenService := idgen.NewService(db, redisClient)
 
 	// 初始化插件管理器并注册所有钩子函数
-	_ = plugins.Setup(db)
+	pluginManager := plugins.Setup(db)
 
 	crudService := crud.NewService(
 		db,
@@ -147,6 +151,9 @@ func main() {
 		metadataRepo,
 		userRepo,
 		idgenService,
+		dictService,
+		seqService,
+		pluginManager,
 	)
 
 	actionService := action.NewService(
@@ -156,11 +163,6 @@ func main() {
 		cfg.Action.ScriptTimeout,
 	)
 
-	workflowService := workflow.NewService(
-		db,
-		actionService,
-	)
-
 	auditService := audit.NewService(db)
 
 	// 初始化菜单服务
@@ -186,6 +188,53 @@ func main() {
 	// 初始化消息服务
 	messageService := message.NewService(db, wsManager)
 
+	// 初始化工作流服务（到期提醒通过消息服务发送，审批结果回写业务单据，自动任务异步执行）
+	workflowService := workflow.NewService(
+		db,
+		actionService,
+		messageService,
+		crudService,
+		&workflow.JobConfig{
+			MaxAttempts: cfg.Workflow.JobMaxAttempts,
+			Backoff:     cfg.Workflow.JobBackoff,
+			Timeout:     cfg.Workflow.JobTimeout,
+			Workers:     cfg.Workflow.JobWorkers,
+		},
+	)
+
+	// 初始化后台作业存储（导入文件和导出结果）
+	jobStorage, err := storage.NewLocalStorage(&storage.LocalStorageConfig{
+		BasePath: cfg.File.UploadDir + "/jobs", // 使用 uploads/jobs 作为作业文件目录
+		BaseURL:  fmt.Sprintf("http://localhost:%d/files/jobs", cfg.App.Port),
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize job storage", zap.Error(err))
+	}
+
+	// 初始化后台作业服务（进度和结果通过WebSocket推送给提交人）
+	jobService := job.NewService(db, jobStorage, wsManager, userRepo, &job.Config{
+		Workers: cfg.Job.Workers,
+		Timeout: cfg.Job.Timeout,
+	})
+
+	// 初始化导入导出服务（导入、导出作为后台作业执行）
+	imexService := imex.NewService(crudService, dictService, jobService)
+
+	// 初始化打印服务（按打印模板输出HTML、PDF）
+	printService := printing.NewService(db, crudService, metadataService, dictService, groupsService, &printing.Config{
+		FontPath: cfg.Print.FontPath,
+	})
+	if cfg.Print.FontPath == "" {
+		logger.Warn("未配置打印字体(print.fontPath)，PDF打印不可用，只能使用HTML打印")
+	}
+
+	// 单据提交后自动启动业务表关联的审批流程
+	if err := pluginManager.Register(workflow.ApprovalHookPoint, workflow.NewApprovalPlugin(workflowService), core.PluginMetadata{
+		Enabled: true,
+	}); err != nil {
+		logger.Error("注册审批插件失败", zap.Error(err))
+	}
+
 	// 初始化云盘存储
 	cloudStorage, err := storage.NewLocalStorage(&storage.LocalStorageConfig{
 		BasePath: cfg.File.UploadDir + "/cloud", // 使用 uploads/cloud 作为云盘存储目录
@@ -229,6 +278,9 @@ func main() {
 		Dict:            dictService,
 		Sequence:        seqService,
 		CRUD:            crudService,
+		Imex:            imexService,
+		Job:             jobService,
+		Print:           printService,
 		Action:          actionService,
 		Workflow:        workflowService,
 		Audit:           auditService,
@@ -261,6 +313,63 @@ func main() {
 		}
 	}()
 
+	// 启动工作流任务到期检查
+	go func() {
+		interval := cfg.Workflow.DeadlineCheckInterval
+		if interval <= 0 {
+			interval = 60
+		}
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		logger.Info("工作流到期检查任务已启动",
+			zap.Int("intervalSeconds", interval))
+
+		for range ticker.C {
+			if err := workflowService.ProcessDeadlines(context.Background()); err != nil {
+				logger.Error("工作流到期检查失败", zap.Error(err))
+			}
+		}
+	}()
+
+	// 启动工作流自动任务作业执行
+	go func() {
+		interval := cfg.Workflow.JobCheckInterval
+		if interval <= 0 {
+			interval = 5
+		}
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		logger.Info("工作流自动任务执行已启动",
+			zap.Int("intervalSeconds", interval))
+
+		for range ticker.C {
+			if err := workflowService.ProcessJobs(context.Background()); err != nil {
+				logger.Error("工作流自动任务执行失败", zap.Error(err))
+			}
+		}
+	}()
+
+	// 启动后台作业执行
+	go func() {
+		interval := cfg.Job.CheckInterval
+		if interval <= 0 {
+			interval = 5
+		}
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		logger.Info("后台作业执行已启动",
+			zap.Int("intervalSeconds", interval))
+
+		for range ticker.C {
+			if err := jobService.ProcessJobs(context.Background()); err != nil {
+				logger.Error("后台作业执行失败", zap.Error(err))
+			}
+		}
+	}()
+
 	// 11. 启动HTTP服务器
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.App.Port),
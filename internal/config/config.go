@@ -16,6 +16,9 @@ type Config struct {
 	CORS            CORSConfig            `mapstructure:"cors"`
 	Cache           CacheConfig           `mapstructure:"cache"`
 	Action          ActionConfig          `mapstructure:"action"`
+	Workflow        WorkflowConfig        `mapstructure:"workflow"`
+	Job             JobConfig             `mapstructure:"job"`
+	Print           PrintConfig           `mapstructure:"print"`
 	RateLimit       RateLimitConfig       `mapstructure:"rateLimit"`
 	Upload          UploadConfig          `mapstructure:"upload"`
 	File            FileConfig            `mapstructure:"file"`
@@ -106,6 +109,28 @@ type ActionConfig struct {
 	ScriptTimeout int `mapstructure:"scriptTimeout"` // 脚本执行超时时间（秒）
 }
 
+// WorkflowConfig 工作流配置
+type WorkflowConfig struct {
+	DeadlineCheckInterval int `mapstructure:"deadlineCheckInterval"` // 任务到期检查间隔（秒）
+	JobCheckInterval      int `mapstructure:"jobCheckInterval"`      // 自动任务作业扫描间隔（秒）
+	JobMaxAttempts        int `mapstructure:"jobMaxAttempts"`        // 自动任务默认最大执行次数
+	JobBackoff            int `mapstructure:"jobBackoff"`            // 自动任务默认首次重试间隔（秒），之后每次翻倍
+	JobTimeout            int `mapstructure:"jobTimeout"`            // 自动任务默认单次执行超时（秒）
+	JobWorkers            int `mapstructure:"jobWorkers"`            // 同时执行的自动任务作业数
+}
+
+// JobConfig 后台作业配置（Excel导入导出等）
+type JobConfig struct {
+	CheckInterval int `mapstructure:"checkInterval"` // 排队作业扫描间隔（秒）
+	Workers       int `mapstructure:"workers"`       // 同时执行的作业数
+	Timeout       int `mapstructure:"timeout"`       // 单个作业的执行超时（秒）
+}
+
+// PrintConfig 打印配置
+type PrintConfig struct {
+	FontPath string `mapstructure:"fontPath"` // PDF使用的TrueType字体文件（.ttf，需支持中文），未配置时不能输出PDF（HTML打印不受影响）
+}
+
 // RateLimitConfig 限流配置
 type RateLimitConfig struct {
 	Enabled           bool `mapstructure:"enabled"`
@@ -47,6 +47,11 @@ const (
 	ActionDelete = "delete" // 删除
 	ActionQuery  = "query"  // 查询
 
+	// 单据操作
+	ActionSubmit   = "submit"   // 提交
+	ActionUnsubmit = "unsubmit" // 反提交
+	ActionVoid     = "void"     // 作废
+
 	// 动作执行
 	ActionExecute      = "execute"       // 执行动作
 	ActionBatchExecute = "batch_execute" // 批量执行
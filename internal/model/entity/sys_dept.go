@@ -0,0 +1,16 @@
+package entity
+
+// SysDept 部门
+type SysDept struct {
+	BaseModel
+	Name        string `gorm:"column:NAME;size:255;not null" json:"name"`
+	ParentID    *uint  `gorm:"column:PARENT_ID;index" json:"parentId"`   // 上级部门
+	ManagerID   uint   `gorm:"column:MANAGER_ID;index" json:"managerId"` // 部门负责人
+	Orderno     int    `gorm:"column:ORDERNO" json:"orderno"`
+	Description string `gorm:"column:DESCRIPTION;size:255" json:"description"`
+}
+
+// TableName 指定表名
+func (SysDept) TableName() string {
+	return "sys_dept"
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// SysJob 后台作业
+// 耗时的导入、导出等操作提交为作业后由后台异步执行，输入文件和结果文件保存在存储中
+type SysJob struct {
+	BaseModel
+	JobType     string     `gorm:"column:JOB_TYPE;size:50;not null" json:"jobType"`                   // 作业类型（如 export、import）
+	Title       string     `gorm:"column:TITLE;size:255" json:"title"`                                // 作业说明
+	UserID      uint       `gorm:"column:USER_ID;not null;index:idx_job_user" json:"userId"`          // 提交人
+	Status      string     `gorm:"column:STATUS;size:20;not null;index:idx_job_status" json:"status"` // queued:排队中, running:执行中, succeeded:已完成, failed:失败, cancelled:已取消
+	Progress    int        `gorm:"column:PROGRESS;default:0" json:"progress"`                         // 进度百分比(0-100)
+	Params      string     `gorm:"column:PARAMS;type:text" json:"-"`                                  // 作业参数(JSON)
+	InputFile   string     `gorm:"column:INPUT_FILE;size:500" json:"-"`                               // 输入文件在存储中的路径（作业结束后删除文件）
+	ResultFile  string     `gorm:"column:RESULT_FILE;size:500" json:"-"`                              // 结果文件在存储中的路径
+	ResultName  string     `gorm:"column:RESULT_NAME;size:255" json:"resultName"`                     // 结果文件下载名称
+	Result      string     `gorm:"column:RESULT;type:text" json:"result"`                             // 执行结果(JSON)
+	Error       string     `gorm:"column:ERROR;size:2000" json:"error"`                               // 失败原因
+	LockedUntil *time.Time `gorm:"column:LOCKED_UNTIL" json:"-"`                                      // 执行租约到期时间，执行进程异常退出后作业标记为失败
+	StartTime   *time.Time `gorm:"column:START_TIME" json:"startTime"`                                // 开始执行时间
+	FinishTime  *time.Time `gorm:"column:FINISH_TIME" json:"finishTime"`                              // 结束时间（完成、失败或取消）
+}
+
+// TableName 指定表名
+func (SysJob) TableName() string {
+	return "sys_job"
+}
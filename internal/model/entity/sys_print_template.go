@@ -0,0 +1,24 @@
+package entity
+
+// SysPrintTemplate 打印模板
+// 模板属于某个表：表头字段取自该表，明细取自 sys_table_ref 中一对多关联的子表；只打印 MASK 打印可见的字段
+type SysPrintTemplate struct {
+	BaseModel
+	SysTableID    uint   `gorm:"column:SYS_TABLE_ID;not null;index:idx_print_template_table" json:"sysTableId"` // 所属表
+	Name          string `gorm:"column:NAME;size:100;not null" json:"name"`                                     // 模板名称（同一表内唯一）
+	Title         string `gorm:"column:TITLE;size:255" json:"title"`                                            // 打印标题（为空时为表的显示名称）
+	PaperSize     string `gorm:"column:PAPER_SIZE;size:20" json:"paperSize"`                                    // 纸张：A4（默认）、A5、Letter
+	Orientation   string `gorm:"column:ORIENTATION;size:1" json:"orientation"`                                  // P:纵向（默认）, L:横向
+	HeaderColumns string `gorm:"column:HEADER_COLUMNS;size:2000" json:"headerColumns"`                          // 表头字段名，逗号分隔（为空时为全部打印可见字段）
+	HeaderCols    int    `gorm:"column:HEADER_COLS" json:"headerCols"`                                          // 表头每行的字段数（默认2）
+	Details       string `gorm:"column:DETAILS;type:text" json:"details"`                                       // 明细表(JSON数组，为空时为全部一对多子表)
+	Footer        string `gorm:"column:FOOTER;size:2000" json:"footer"`                                         // 页脚文字（如签收栏）
+	IsDefault     string `gorm:"column:IS_DEFAULT;size:1;default:N" json:"isDefault"`                           // Y:表的默认模板
+	OrderNo       int    `gorm:"column:ORDERNO" json:"orderno"`                                                 // 排序
+	Description   string `gorm:"column:DESCRIPTION;size:2000" json:"description"`                               // 备注
+}
+
+// TableName 指定表名
+func (SysPrintTemplate) TableName() string {
+	return "sys_print_template"
+}
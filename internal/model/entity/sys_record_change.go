@@ -0,0 +1,19 @@
+package entity
+
+// SysRecordChange 业务记录变更历史
+// 通用CRUD修改、删除、恢复记录时在同一事务中写入，CREATE_BY/CREATE_TIME 为操作人和操作时间
+type SysRecordChange struct {
+	BaseModel
+	SysTableID uint   `gorm:"column:SYS_TABLE_ID;not null;index:idx_record_change" json:"sysTableId"`
+	RecordID   uint   `gorm:"column:RECORD_ID;not null;index:idx_record_change" json:"recordId"`
+	Action     string `gorm:"column:ACTION;size:20;not null" json:"action"`                 // update:修改, delete:删除, restore:恢复, purge:彻底删除, submit:提交, unsubmit:反提交, void:作废
+	UserID     uint   `gorm:"column:USER_ID" json:"userId"`                                 // 操作人ID
+	CauseID    uint   `gorm:"column:CAUSE_ID;index:idx_record_change_cause" json:"causeId"` // 引发本次变更的历史ID（级联删除、置空时为父记录的删除历史）
+	Changes    string `gorm:"column:CHANGES;type:mediumtext" json:"changes"`                // 字段变更(JSON数组: column, displayName, old, new)
+	Snapshot   string `gorm:"column:SNAPSHOT;type:mediumtext" json:"-"`                     // 变更前的完整记录(JSON)，恢复时使用
+}
+
+// TableName 指定表名
+func (SysRecordChange) TableName() string {
+	return "sys_record_change"
+}
@@ -11,6 +11,7 @@ type SysUser struct {
 	Language string `gorm:"column:LANGUAGE;size:255" json:"language"`
 	IsAdmin  string `gorm:"column:IS_ADMIN;size:2;default:N" json:"isAdmin"` // Y/N
 	Sgrade   int    `gorm:"column:SGRADE" json:"sgrade"`                      // 字段访问级别
+	SysDeptID *uint `gorm:"column:SYS_DEPT_ID;index" json:"sysDeptId"` // 所属部门
 }
 
 // TableName 指定表名
@@ -3,10 +3,10 @@ package entity
 // WfDefinition 工作流定义
 type WfDefinition struct {
 	BaseModel
-	Name        string `gorm:"column:NAME;size:80;not null" json:"name"`
+	Name        string `gorm:"column:NAME;size:80;not null" json:"name"` // 同名定义为同一流程的不同版本
 	DisplayName string `gorm:"column:DISPLAY_NAME;size:255" json:"displayName"`
 	Version     int    `gorm:"column:VERSION;not null;default:1" json:"version"`
-	Status      string `gorm:"column:STATUS;size:20;not null;default:'draft'" json:"status"` // draft:草稿, published:已发布, archived:已归档
+	Status      string `gorm:"column:STATUS;size:20;not null;default:'draft'" json:"status"` // draft:草稿, published:已发布(不可修改), archived:已归档
 	SysTableID  int    `gorm:"column:SYS_TABLE_ID;index" json:"sysTableId"`                   // 关联的业务表
 	Description string `gorm:"column:DESCRIPTION;size:2000" json:"description"`
 	Config      string `gorm:"column:CONFIG;type:text" json:"config"` // JSON配置
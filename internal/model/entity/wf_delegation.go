@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// WfDelegation 任务委托规则（外出代理）
+// 委托期间新创建的任务分配给受托人，任务上记录原处理人
+type WfDelegation struct {
+	BaseModel
+	FromUserID      uint      `gorm:"column:FROM_USER_ID;not null;index" json:"fromUserId"`     // 委托人
+	ToUserID        uint      `gorm:"column:TO_USER_ID;not null;index" json:"toUserId"`         // 受托人
+	StartTime       time.Time `gorm:"column:START_TIME;not null" json:"startTime"`              // 生效时间
+	EndTime         time.Time `gorm:"column:END_TIME;not null" json:"endTime"`                  // 失效时间
+	DefinitionNames string    `gorm:"column:DEFINITION_NAMES;size:2000" json:"definitionNames"` // 限定的流程名称（逗号分隔，对该流程所有版本生效），为空时对所有流程生效
+	Reason          string    `gorm:"column:REASON;size:500" json:"reason"`                     // 委托原因
+}
+
+// TableName 指定表名
+func (WfDelegation) TableName() string {
+	return "wf_delegation"
+}
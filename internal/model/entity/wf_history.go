@@ -0,0 +1,20 @@
+package entity
+
+// WfHistory 流程历史记录（到期提醒、超时处理、退回、撤回、变量变更、自动任务结果等事件）
+type WfHistory struct {
+	BaseModel
+	WfInstanceID uint   `gorm:"column:WF_INSTANCE_ID;not null;index" json:"wfInstanceId"`
+	WfTaskID     uint   `gorm:"column:WF_TASK_ID;index" json:"wfTaskId"`
+	WfNodeID     uint   `gorm:"column:WF_NODE_ID" json:"wfNodeId"`
+	EventType    string `gorm:"column:EVENT_TYPE;size:30;not null" json:"eventType"` // remind:到期提醒, escalate:超时处理, back:退回, backToStarter:退回发起人, withdraw:撤回, migrate:版本迁移, variables:变量变更, autoTask:自动任务, jobRetry:作业重试, delegate:委托
+	OperatorID   uint   `gorm:"column:OPERATOR_ID" json:"operatorId"`                // 操作人（系统自动处理为0）
+	TargetUserID uint   `gorm:"column:TARGET_USER_ID" json:"targetUserId"`           // 目标用户（提醒对象、转交对象等）
+	Action       string `gorm:"column:ACTION;size:20" json:"action"`                 // 事件对应的处理方式
+	Comment      string `gorm:"column:COMMENT;size:2000" json:"comment"`             // 说明
+	Detail       string `gorm:"column:DETAIL;type:text" json:"detail"`               // 详细信息(JSON)
+}
+
+// TableName 指定表名
+func (WfHistory) TableName() string {
+	return "wf_history"
+}
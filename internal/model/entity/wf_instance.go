@@ -8,8 +8,8 @@ type WfInstance struct {
 	WfDefinitionID uint      `gorm:"column:WF_DEFINITION_ID;not null;index" json:"wfDefinitionId"`
 	SysTableID     int       `gorm:"column:SYS_TABLE_ID;index" json:"sysTableId"`             // 关联的业务表
 	BusinessID     uint      `gorm:"column:BUSINESS_ID;index" json:"businessId"`              // 业务数据ID
-	Status         string    `gorm:"column:STATUS;size:20;not null" json:"status"`            // running:运行中, completed:已完成, terminated:已终止, suspended:已挂起
-	CurrentNodeID  uint      `gorm:"column:CURRENT_NODE_ID;index" json:"currentNodeId"`       // 当前节点ID
+	Status         string    `gorm:"column:STATUS;size:20;not null" json:"status"`            // running:运行中, completed:已完成, terminated:已终止, suspended:已挂起, withdrawn:已撤回
+	CurrentNodeID  uint      `gorm:"column:CURRENT_NODE_ID;index" json:"currentNodeId"`       // 最近进入的节点ID（并行时以令牌为准）
 	StartUserID    uint      `gorm:"column:START_USER_ID;index" json:"startUserId"`           // 发起人
 	StartTime      time.Time `gorm:"column:START_TIME" json:"startTime"`                      // 开始时间
 	EndTime        time.Time `gorm:"column:END_TIME" json:"endTime"`                          // 结束时间
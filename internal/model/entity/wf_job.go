@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// WfJob 自动任务作业
+// 自动任务节点的动作由后台异步执行，令牌停留在节点上直到作业完成；失败按退避策略重试
+type WfJob struct {
+	BaseModel
+	WfInstanceID uint       `gorm:"column:WF_INSTANCE_ID;not null;index" json:"wfInstanceId"`
+	WfNodeID     uint       `gorm:"column:WF_NODE_ID;not null" json:"wfNodeId"`
+	WfTokenID    uint       `gorm:"column:WF_TOKEN_ID;not null;index" json:"wfTokenId"`
+	ActionID     uint       `gorm:"column:ACTION_ID;not null" json:"actionId"`
+	Status       string     `gorm:"column:STATUS;size:20;not null" json:"status"`     // pending:等待执行, running:执行中, completed:已完成, failed:失败(重试次数用尽), canceled:已取消
+	Attempts     int        `gorm:"column:ATTEMPTS;default:0" json:"attempts"`        // 已执行次数
+	MaxAttempts  int        `gorm:"column:MAX_ATTEMPTS;default:3" json:"maxAttempts"` // 最大执行次数
+	Backoff      int        `gorm:"column:BACKOFF;default:30" json:"backoff"`         // 首次重试间隔（秒），之后每次翻倍
+	Timeout      int        `gorm:"column:TIMEOUT;default:60" json:"timeout"`         // 单次执行超时（秒）
+	NextRunTime  time.Time  `gorm:"column:NEXT_RUN_TIME;index" json:"nextRunTime"`    // 下次执行时间
+	LockedUntil  *time.Time `gorm:"column:LOCKED_UNTIL" json:"lockedUntil"`           // 执行租约到期时间，执行进程异常退出后可被重新领取
+	StartTime    time.Time  `gorm:"column:START_TIME" json:"startTime"`               // 最近一次开始执行时间
+	FinishTime   time.Time  `gorm:"column:FINISH_TIME" json:"finishTime"`             // 完成或最终失败时间
+	LastError    string     `gorm:"column:LAST_ERROR;size:2000" json:"lastError"`     // 最近一次失败原因
+	Result       string     `gorm:"column:RESULT;type:text" json:"result"`            // 执行结果(JSON)
+}
+
+// TableName 指定表名
+func (WfJob) TableName() string {
+	return "wf_job"
+}
@@ -6,11 +6,11 @@ type WfNode struct {
 	WfDefinitionID uint   `gorm:"column:WF_DEFINITION_ID;not null;index" json:"wfDefinitionId"`
 	Name           string `gorm:"column:NAME;size:80;not null" json:"name"`
 	DisplayName    string `gorm:"column:DISPLAY_NAME;size:255" json:"displayName"`
-	NodeType       string `gorm:"column:NODE_TYPE;size:20;not null" json:"nodeType"` // start:开始, end:结束, user:用户任务, auto:自动任务, gateway:网关
-	AssignType     string `gorm:"column:ASSIGN_TYPE;size:20" json:"assignType"`      // user:指定用户, role:角色, expression:表达式
-	AssignValue    string `gorm:"column:ASSIGN_VALUE;size:500" json:"assignValue"`   // 分配值(用户ID/角色ID/表达式)
+	NodeType       string `gorm:"column:NODE_TYPE;size:20;not null" json:"nodeType"` // start:开始, end:结束, user:用户任务, auto:自动任务, gateway:排他网关, fork:并行分叉, join:并行汇聚
+	AssignType     string `gorm:"column:ASSIGN_TYPE;size:20" json:"assignType"`      // user:指定用户, starter:发起人, role/group:权限组, directory:安全目录, deptManager:部门负责人, expression:表达式
+	AssignValue    string `gorm:"column:ASSIGN_VALUE;size:500" json:"assignValue"`   // 分配值(用户ID/权限组ID/目录ID[:权限位]/部门层级/表达式)
 	ActionID       uint   `gorm:"column:ACTION_ID;index" json:"actionId"`            // 自动任务关联的动作ID
-	Config         string `gorm:"column:CONFIG;type:text" json:"config"`             // JSON配置
+	Config         string `gorm:"column:CONFIG;type:text" json:"config"`             // JSON配置(会签规则等)
 	PosX           int    `gorm:"column:POS_X" json:"posX"`                          // 节点X坐标
 	PosY           int    `gorm:"column:POS_Y" json:"posY"`                          // 节点Y坐标
 }
@@ -5,17 +5,23 @@ import "time"
 // WfTask 工作流任务
 type WfTask struct {
 	BaseModel
-	WfInstanceID uint      `gorm:"column:WF_INSTANCE_ID;not null;index" json:"wfInstanceId"`
-	WfNodeID     uint      `gorm:"column:WF_NODE_ID;not null;index" json:"wfNodeId"`
-	AssigneeID   uint      `gorm:"column:ASSIGNEE_ID;index" json:"assigneeId"`           // 任务执行人
-	Status       string    `gorm:"column:STATUS;size:20;not null" json:"status"`         // pending:待处理, completed:已完成, rejected:已拒绝, transferred:已转交
-	Action       string    `gorm:"column:ACTION;size:20" json:"action"`                  // approve:同意, reject:拒绝, transfer:转交
-	Comment      string    `gorm:"column:COMMENT;size:2000" json:"comment"`              // 审批意见
-	ClaimTime    time.Time `gorm:"column:CLAIM_TIME" json:"claimTime"`                   // 签收时间
-	CompleteTime time.Time `gorm:"column:COMPLETE_TIME" json:"completeTime"`             // 完成时间
-	DueTime      time.Time `gorm:"column:DUE_TIME" json:"dueTime"`                       // 截止时间
-	Priority     int       `gorm:"column:PRIORITY;default:0" json:"priority"`            // 优先级
-	Variables    string    `gorm:"column:VARIABLES;type:text" json:"variables"`          // 任务变量(JSON)
+	WfInstanceID       uint       `gorm:"column:WF_INSTANCE_ID;not null;index" json:"wfInstanceId"`
+	WfNodeID           uint       `gorm:"column:WF_NODE_ID;not null;index" json:"wfNodeId"`
+	WfTokenID          uint       `gorm:"column:WF_TOKEN_ID;index" json:"wfTokenId"`                   // 所属令牌（同一次节点访问的会签任务共享）
+	AssigneeID         uint       `gorm:"column:ASSIGNEE_ID;index" json:"assigneeId"`                  // 任务执行人（候选组任务签收前为0）
+	OriginalAssigneeID uint       `gorm:"column:ORIGINAL_ASSIGNEE_ID;index" json:"originalAssigneeId"` // 原处理人（按委托规则分配给受托人时记录委托人）
+	Status             string     `gorm:"column:STATUS;size:20;not null" json:"status"`                // pending:待处理, completed:已完成, rejected:已拒绝, transferred:已转交, canceled:已取消
+	Action             string     `gorm:"column:ACTION;size:20" json:"action"`                         // approve:同意, reject:拒绝, back:退回, backToStarter:退回发起人, transfer:转交
+	Comment            string     `gorm:"column:COMMENT;size:2000" json:"comment"`                     // 审批意见
+	ClaimTime          time.Time  `gorm:"column:CLAIM_TIME" json:"claimTime"`                          // 签收时间
+	CompleteTime       time.Time  `gorm:"column:COMPLETE_TIME" json:"completeTime"`                    // 完成时间
+	DueTime            *time.Time `gorm:"column:DUE_TIME" json:"dueTime"`                              // 截止时间（节点未配置时限时为空）
+	RemindTime         *time.Time `gorm:"column:REMIND_TIME" json:"remindTime"`                        // 计划提醒时间（提醒发送后清空）
+	RemindAttempts     int        `gorm:"column:REMIND_ATTEMPTS;default:0" json:"remindAttempts"`      // 到期提醒发送次数（含失败）
+	EscalateTime       *time.Time `gorm:"column:ESCALATE_TIME" json:"escalateTime"`                    // 超时处理时间
+	EscalateAttempts   int        `gorm:"column:ESCALATE_ATTEMPTS;default:0" json:"escalateAttempts"`  // 超时处理失败次数
+	Priority           int        `gorm:"column:PRIORITY;default:0" json:"priority"`                   // 优先级
+	Variables          string     `gorm:"column:VARIABLES;type:text" json:"variables"`                 // 任务变量(JSON)
 }
 
 // TableName 指定表名
@@ -0,0 +1,14 @@
+package entity
+
+// WfTaskCandidate 任务候选人（候选组任务签收前可见）
+type WfTaskCandidate struct {
+	BaseModel
+	WfTaskID       uint `gorm:"column:WF_TASK_ID;not null;index" json:"wfTaskId"`
+	UserID         uint `gorm:"column:USER_ID;not null;index" json:"userId"`
+	OriginalUserID uint `gorm:"column:ORIGINAL_USER_ID;index" json:"originalUserId"` // 原候选人（按委托规则由受托人代替时记录委托人）
+}
+
+// TableName 指定表名
+func (WfTaskCandidate) TableName() string {
+	return "wf_task_candidate"
+}
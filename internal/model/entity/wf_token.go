@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// WfToken 流程令牌（一次节点访问）
+// 每进入一个节点生成一个令牌，并行分支中同时存在多个活动令牌
+type WfToken struct {
+	BaseModel
+	WfInstanceID   uint      `gorm:"column:WF_INSTANCE_ID;not null;index" json:"wfInstanceId"`
+	WfNodeID       uint      `gorm:"column:WF_NODE_ID;not null;index" json:"wfNodeId"`
+	ForkID         uint      `gorm:"column:FORK_ID;index" json:"forkId"`            // 所属分支的分叉令牌ID（主干为0）
+	Branches       int       `gorm:"column:BRANCHES;default:0" json:"branches"`     // 分叉节点激活的分支数
+	WfTransitionID uint      `gorm:"column:WF_TRANSITION_ID" json:"wfTransitionId"` // 进入节点经过的流转（开始节点和退回为0）
+	Status         string    `gorm:"column:STATUS;size:20;not null" json:"status"`  // active:活动, waiting:等待汇聚, completed:已完成, canceled:已取消
+	EnterTime      time.Time `gorm:"column:ENTER_TIME" json:"enterTime"`            // 进入节点时间
+	LeaveTime      time.Time `gorm:"column:LEAVE_TIME" json:"leaveTime"`            // 离开节点时间
+}
+
+// TableName 指定表名
+func (WfToken) TableName() string {
+	return "wf_token"
+}
@@ -4,9 +4,10 @@ import "fmt"
 
 // AppError 应用错误
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Err     error  `json:"-"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Err     error       `json:"-"`
+	Data    interface{} `json:"data,omitempty"` // 随错误返回给客户端的数据（如版本冲突时的当前值）
 }
 
 func (e *AppError) Error() string {
@@ -52,6 +53,12 @@ func Wrap(code int, message string, err error) *AppError {
 	}
 }
 
+// WithData 附带返回给客户端的数据
+func (e *AppError) WithData(data interface{}) *AppError {
+	e.Data = data
+	return e
+}
+
 // GetCode 获取错误码
 func GetCode(err error) int {
 	if err == nil {
@@ -84,6 +91,7 @@ const (
 	ErrResourceNotFound = 30001
 	ErrResourceExists   = 30002
 	ErrResourceConflict = 30003
+	ErrVersionConflict  = 30004 // 记录已被他人修改（乐观锁校验失败）
 
 	// 数据库错误 40xxx
 	ErrDatabase = 40001
@@ -116,6 +124,7 @@ var (
 	ResourceNotFound = New(ErrResourceNotFound, "资源不存在")
 	ResourceExists   = New(ErrResourceExists, "资源已存在")
 	ResourceConflict = New(ErrResourceConflict, "资源冲突")
+	VersionConflict  = New(ErrVersionConflict, "数据已被修改")
 
 	// 数据库错误
 	DatabaseError = New(ErrDatabase, "数据库错误")
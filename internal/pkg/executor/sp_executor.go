@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
 	"gorm.io/gorm"
 )
 
@@ -50,19 +51,24 @@ func (e *SPExecutor) Execute(ctx context.Context, req *SPRequest) (*SPResponse,
 		ResultSets: [][]map[string]interface{}{},
 	}
 
-	// 获取原始数据库连接
-	sqlDB, err := e.db.DB()
-	if err != nil {
-		response.Error = fmt.Sprintf("获取数据库连接失败: %v", err)
-		response.Duration = time.Since(start)
-		return response, nil
-	}
-
 	// 构建CALL语句
 	callStmt, args := e.buildCallStatement(req)
 
-	// 执行存储过程
-	rows, err := sqlDB.QueryContext(ctx, callStmt, args...)
+	// 执行存储过程：context 中有调用方事务时在事务中执行，随调用方一起提交或回滚
+	var rows *sql.Rows
+	var err error
+	if tx := transaction.GetTxFromContext(ctx); tx != nil {
+		rows, err = tx.WithContext(ctx).Raw(callStmt, args...).Rows()
+	} else {
+		// 获取原始数据库连接
+		sqlDB, dbErr := e.db.DB()
+		if dbErr != nil {
+			response.Error = fmt.Sprintf("获取数据库连接失败: %v", dbErr)
+			response.Duration = time.Since(start)
+			return response, nil
+		}
+		rows, err = sqlDB.QueryContext(ctx, callStmt, args...)
+	}
 	if err != nil {
 		response.Error = fmt.Sprintf("执行存储过程失败: %v", err)
 		response.Duration = time.Since(start)
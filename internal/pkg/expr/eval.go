@@ -0,0 +1,428 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eval 对语法树求值
+func eval(n node, env map[string]interface{}) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return lookup(env, n.name), nil
+
+	case *memberNode:
+		object, err := eval(n.object, env)
+		if err != nil {
+			return nil, err
+		}
+		return member(object, n.name), nil
+
+	case *indexNode:
+		object, err := eval(n.object, env)
+		if err != nil {
+			return nil, err
+		}
+		index, err := eval(n.index, env)
+		if err != nil {
+			return nil, err
+		}
+		return indexValue(object, index), nil
+
+	case *listNode:
+		items := make([]interface{}, 0, len(n.items))
+		for _, item := range n.items {
+			value, err := eval(item, env)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+
+	case *unaryNode:
+		operand, err := eval(n.operand, env)
+		if err != nil {
+			return nil, err
+		}
+		return evalUnary(n.op, operand)
+
+	case *binaryNode:
+		return evalBinary(n, env)
+
+	case *callNode:
+		args := make([]interface{}, 0, len(n.args))
+		for _, arg := range n.args {
+			value, err := eval(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+		}
+		return functions[n.name](args)
+	}
+
+	return nil, fmt.Errorf("不支持的表达式节点 %T", n)
+}
+
+// evalUnary 一元运算
+func evalUnary(op string, operand interface{}) (interface{}, error) {
+	switch op {
+	case "!":
+		return !truthy(operand), nil
+	case "-":
+		if operand == nil {
+			return nil, nil
+		}
+		num, ok := toNumber(operand)
+		if !ok {
+			return nil, fmt.Errorf("不能对 %v 取负", operand)
+		}
+		return -num, nil
+	}
+	return nil, fmt.Errorf("不支持的运算符 %s", op)
+}
+
+// evalBinary 二元运算（&& 和 || 短路求值）
+func evalBinary(n *binaryNode, env map[string]interface{}) (interface{}, error) {
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		if left == nil || right == nil {
+			return false, nil
+		}
+		cmp, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "in":
+		return contains(right, left), nil
+	case "+", "-", "*", "/", "%":
+		return arithmetic(n.op, left, right)
+	}
+
+	return nil, fmt.Errorf("不支持的运算符 %s", n.op)
+}
+
+// arithmetic 算术运算，null 参与运算结果为 null
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	// 字符串拼接（任意一侧为非数字字符串时）
+	if op == "+" {
+		_, lNum := toNumber(left)
+		_, rNum := toNumber(right)
+		if !lNum || !rNum || isString(left) && isString(right) {
+			return toString(left) + toString(right), nil
+		}
+	}
+
+	l, ok := toNumber(left)
+	if !ok {
+		return nil, fmt.Errorf("%v 不是数字", left)
+	}
+	r, ok := toNumber(right)
+	if !ok {
+		return nil, fmt.Errorf("%v 不是数字", right)
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("除数不能为0")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("除数不能为0")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// lookup 查找顶层变量
+func lookup(env map[string]interface{}, name string) interface{} {
+	if env == nil {
+		return nil
+	}
+	return normalize(env[name])
+}
+
+// member 访问对象字段，字段名精确匹配失败时忽略大小写匹配（业务表字段为大写）
+func member(object interface{}, name string) interface{} {
+	m, ok := object.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if value, ok := m[name]; ok {
+		return normalize(value)
+	}
+	for key, value := range m {
+		if strings.EqualFold(key, name) {
+			return normalize(value)
+		}
+	}
+	return nil
+}
+
+// indexValue 下标访问：对象按字段名，列表按序号，越界返回 null
+func indexValue(object, index interface{}) interface{} {
+	switch o := object.(type) {
+	case map[string]interface{}:
+		return member(o, toString(index))
+	case []interface{}:
+		num, ok := toNumber(index)
+		if !ok {
+			return nil
+		}
+		i := int(num)
+		if i < 0 || i >= len(o) {
+			return nil
+		}
+		return normalize(o[i])
+	}
+	return nil
+}
+
+// contains in 运算：列表包含元素或字符串包含子串
+func contains(container, item interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if equal(normalize(v), item) {
+				return true
+			}
+		}
+	case string:
+		if item == nil {
+			return false
+		}
+		return strings.Contains(c, toString(item))
+	}
+	return false
+}
+
+// equal 判断相等：数字按数值比较（数字字符串与数字可比较），null 只与 null 相等
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	if isNumber(left) || isNumber(right) {
+		l, lok := toNumber(left)
+		r, rok := toNumber(right)
+		if lok && rok {
+			return l == r
+		}
+		return false
+	}
+
+	switch l := left.(type) {
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	}
+
+	return false
+}
+
+// compare 大小比较，返回 -1/0/1
+func compare(left, right interface{}) (int, error) {
+	if isNumber(left) || isNumber(right) {
+		l, lok := toNumber(left)
+		r, rok := toNumber(right)
+		if !lok || !rok {
+			return 0, fmt.Errorf("%v 与 %v 类型不同，不能比较大小", left, right)
+		}
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if lok && rok {
+		return strings.Compare(l, r), nil
+	}
+
+	return 0, fmt.Errorf("%v 与 %v 不能比较大小", left, right)
+}
+
+// truthy 转换为布尔值
+func truthy(value interface{}) bool {
+	switch v := normalize(value).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// normalize 将外部传入的值统一为表达式内部类型
+// 数字统一为 float64，[]byte 和时间统一为字符串
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return value
+}
+
+// isNumber 判断是否为数字类型
+func isNumber(value interface{}) bool {
+	_, ok := normalize(value).(float64)
+	return ok
+}
+
+// isString 判断是否为字符串类型
+func isString(value interface{}) bool {
+	_, ok := normalize(value).(string)
+	return ok
+}
+
+// toNumber 转换为数字，字符串按十进制解析
+func toNumber(value interface{}) (float64, bool) {
+	switch v := normalize(value).(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// toString 转换为字符串，整数不带小数点
+func toString(value interface{}) string {
+	switch v := normalize(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
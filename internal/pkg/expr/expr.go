@@ -0,0 +1,92 @@
+// Package expr 提供沙箱化的表达式引擎（纯Go实现）
+//
+// 表达式只能读取传入的变量并调用内置函数，不能访问Go对象的方法，也不包含循环，
+// 因此求值时间与表达式长度成正比。
+//
+// 支持的语法：
+//   - 字面量：数字、'字符串' 或 "字符串"、true/false、null
+//   - 变量：name、record.AMOUNT、vars["key"]、list[0]
+//   - 比较：== != < <= > >=（单个 = 等同于 ==）、in（列表或字符串包含）
+//   - 逻辑：&& || !（也可写作 and or not）
+//   - 算术：+ - * / %（+ 用于字符串时为拼接）
+//   - 函数：见 functions
+//
+// 空值规则：未定义的变量为 null；null 参与算术运算结果为 null；
+// null 只与 null 相等；null 参与大小比较结果为 false。
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 表达式限制
+const (
+	maxLength = 4096 // 表达式最大长度
+	maxDepth  = 64   // 最大嵌套层级
+)
+
+// Program 编译后的表达式，可并发重复求值
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile 编译表达式（语法检查）
+func Compile(source string) (*Program, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("表达式不能为空")
+	}
+	if len(source) > maxLength {
+		return nil, fmt.Errorf("表达式长度不能超过%d", maxLength)
+	}
+
+	root, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("表达式语法错误: %v", err)
+	}
+
+	return &Program{source: source, root: root}, nil
+}
+
+// Source 返回表达式原文
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Eval 在给定变量环境中求值
+func (p *Program) Eval(env map[string]interface{}) (interface{}, error) {
+	value, err := eval(p.root, env)
+	if err != nil {
+		return nil, fmt.Errorf("表达式 %q 求值失败: %v", p.source, err)
+	}
+	return value, nil
+}
+
+// EvalBool 求值并转换为布尔值（null、false、0、空字符串为 false）
+func (p *Program) EvalBool(env map[string]interface{}) (bool, error) {
+	value, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(value), nil
+}
+
+// Eval 编译并求值表达式
+func Eval(source string, env map[string]interface{}) (interface{}, error) {
+	program, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return program.Eval(env)
+}
+
+// EvalBool 编译并求值表达式，结果转换为布尔值
+func EvalBool(source string, env map[string]interface{}) (bool, error) {
+	program, err := Compile(source)
+	if err != nil {
+		return false, err
+	}
+	return program.EvalBool(env)
+}
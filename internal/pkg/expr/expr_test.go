@@ -0,0 +1,114 @@
+package expr
+
+import (
+	"testing"
+	"time"
+)
+
+func testEnv() map[string]interface{} {
+	return map[string]interface{}{
+		"amount": 1500.0,
+		"days":   3,
+		"dept":   "sales",
+		"tags":   []interface{}{"urgent", "vip"},
+		"empty":  "",
+		"record": map[string]interface{}{
+			"AMOUNT":      []byte("2000.50"),
+			"STATUS":      "submitted",
+			"CREATE_TIME": time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local),
+			"REMARK":      nil,
+		},
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"数字比较", "amount > 1000", true},
+		{"整数变量", "days >= 3 && days < 5", true},
+		{"单等号", "dept = 'sales'", true},
+		{"关键字逻辑", "dept == 'hr' or not (amount < 100)", true},
+		{"算术", "amount * 2 - 1000 == 2000", true},
+		{"取模", "days % 2 == 1", true},
+		{"业务字段数字字符串", "record.AMOUNT > 2000", true},
+		{"业务字段忽略大小写", "record.status == 'submitted'", true},
+		{"时间按字符串比较", "record.CREATE_TIME >= '2026-01-01'", true},
+		{"下标访问", "record['STATUS'] != 'draft'", true},
+		{"列表包含", "'vip' in tags", true},
+		{"列表字面量", "dept in ['hr', 'sales']", true},
+		{"字符串函数", "startsWith(upper(dept), 'SA') && len(dept) == 5", true},
+		{"contains", "contains(dept, 'le')", true},
+		{"未定义变量为null", "missing == null", true},
+		{"null不参与大小比较", "missing > 0", false},
+		{"null算术结果为null", "isNull(missing + 1)", true},
+		{"空字段", "isNull(record.REMARK) && isEmpty(empty)", true},
+		{"coalesce", "coalesce(record.REMARK, 'x') == 'x'", true},
+		{"min/max", "max(1, amount, 20) == 1500 && min(days, 10) == 3", true},
+		{"round", "round(10 / 3, 2) == 3.33", true},
+		{"字符串拼接", "dept + '-' + days == 'sales-3'", true},
+		{"短路求值", "false && 1 / 0 > 0", false},
+		{"空字符串为假", "empty", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvalBool(tt.expr, testEnv())
+			if err != nil {
+				t.Fatalf("EvalBool(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalBool(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []string{
+		"",
+		"amount >",
+		"(amount > 1",
+		"'abc",
+		"amount > 1 1",
+		"exec('rm -rf /')",
+		"amount # 1",
+		"record.",
+	}
+
+	for _, src := range tests {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("Compile(%q) expected error", src)
+		}
+	}
+}
+
+func TestCompileDepthLimit(t *testing.T) {
+	src := ""
+	for i := 0; i < maxDepth+1; i++ {
+		src += "("
+	}
+	src += "1"
+	for i := 0; i < maxDepth+1; i++ {
+		src += ")"
+	}
+	if _, err := Compile(src); err == nil {
+		t.Error("Compile() expected depth error")
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	tests := []string{
+		"amount / 0 > 1",
+		"dept > 1",
+		"len(dept, 1) > 0",
+	}
+
+	for _, src := range tests {
+		if _, err := EvalBool(src, testEnv()); err == nil {
+			t.Errorf("EvalBool(%q) expected error", src)
+		}
+	}
+}
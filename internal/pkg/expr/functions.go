@@ -0,0 +1,191 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// function 内置函数，参数已求值
+type function func(args []interface{}) (interface{}, error)
+
+// functions 内置函数白名单（函数名不区分大小写）
+var functions = map[string]function{
+	// 字符串函数
+	"contains":   stringPredicate("contains", strings.Contains),
+	"startswith": stringPredicate("startsWith", strings.HasPrefix),
+	"endswith":   stringPredicate("endsWith", strings.HasSuffix),
+	"lower":      stringMapper("lower", strings.ToLower),
+	"upper":      stringMapper("upper", strings.ToUpper),
+	"trim":       stringMapper("trim", strings.TrimSpace),
+	"len":        fnLen,
+	"length":     fnLen,
+
+	// 空值函数
+	"isnull":   fnIsNull,
+	"isempty":  fnIsEmpty,
+	"coalesce": fnCoalesce,
+	"ifnull":   fnCoalesce,
+
+	// 数值函数
+	"abs":   fnAbs,
+	"round": fnRound,
+	"min":   numberReducer("min", math.Min),
+	"max":   numberReducer("max", math.Max),
+}
+
+// checkArgs 检查参数个数
+func checkArgs(name string, args []interface{}, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		if min == max {
+			return fmt.Errorf("函数 %s 需要%d个参数", name, min)
+		}
+		return fmt.Errorf("函数 %s 参数个数错误", name)
+	}
+	return nil
+}
+
+// stringPredicate 字符串判断函数，任一参数为 null 时返回 false
+func stringPredicate(name string, fn func(s, sub string) bool) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := checkArgs(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		if args[0] == nil || args[1] == nil {
+			return false, nil
+		}
+		return fn(toString(args[0]), toString(args[1])), nil
+	}
+}
+
+// stringMapper 字符串转换函数，null 返回 null
+func stringMapper(name string, fn func(s string) string) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := checkArgs(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(toString(args[0])), nil
+	}
+}
+
+// numberReducer 多参数数值函数，忽略 null 参数
+func numberReducer(name string, fn func(a, b float64) float64) function {
+	return func(args []interface{}) (interface{}, error) {
+		if err := checkArgs(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		var result interface{}
+		for _, arg := range args {
+			if arg == nil {
+				continue
+			}
+			num, ok := toNumber(arg)
+			if !ok {
+				return nil, fmt.Errorf("函数 %s 的参数 %v 不是数字", name, arg)
+			}
+			if result == nil {
+				result = num
+			} else {
+				result = fn(result.(float64), num)
+			}
+		}
+		return result, nil
+	}
+}
+
+// fnLen 字符串字符数或列表长度，null 为 0
+func fnLen(args []interface{}) (interface{}, error) {
+	if err := checkArgs("len", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	default:
+		return float64(utf8.RuneCountInString(toString(v))), nil
+	}
+}
+
+// fnIsNull 判断是否为 null
+func fnIsNull(args []interface{}) (interface{}, error) {
+	if err := checkArgs("isNull", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return args[0] == nil, nil
+}
+
+// fnIsEmpty 判断是否为 null、空字符串或空列表
+func fnIsEmpty(args []interface{}) (interface{}, error) {
+	if err := checkArgs("isEmpty", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return true, nil
+	case string:
+		return strings.TrimSpace(v) == "", nil
+	case []interface{}:
+		return len(v) == 0, nil
+	case map[string]interface{}:
+		return len(v) == 0, nil
+	}
+	return false, nil
+}
+
+// fnCoalesce 返回第一个非 null 参数
+func fnCoalesce(args []interface{}) (interface{}, error) {
+	if err := checkArgs("coalesce", args, 1, -1); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
+
+// fnAbs 绝对值
+func fnAbs(args []interface{}) (interface{}, error) {
+	if err := checkArgs("abs", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	num, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("函数 abs 的参数 %v 不是数字", args[0])
+	}
+	return math.Abs(num), nil
+}
+
+// fnRound 四舍五入，可指定小数位数
+func fnRound(args []interface{}) (interface{}, error) {
+	if err := checkArgs("round", args, 1, 2); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	num, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("函数 round 的参数 %v 不是数字", args[0])
+	}
+	places := 0.0
+	if len(args) == 2 {
+		if places, ok = toNumber(args[1]); !ok || places < 0 || places > 10 {
+			return nil, fmt.Errorf("函数 round 的小数位数必须为0-10")
+		}
+	}
+	factor := math.Pow(10, math.Floor(places))
+	return math.Round(num*factor) / factor, nil
+}
@@ -0,0 +1,146 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOperator
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// 多字符运算符（需优先于单字符匹配）
+var multiCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+// 单字符运算符
+const singleCharOperators = "+-*/%<>!()[],."
+
+// 关键字运算符，统一转换为符号形式
+var keywordOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+	"in":  "in",
+}
+
+// tokenize 将表达式拆分为词法单元
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	i := 0
+
+	for i < len(runes) {
+		ch := runes[i]
+
+		if unicode.IsSpace(ch) {
+			i++
+			continue
+		}
+
+		start := i
+
+		// 数字
+		if unicode.IsDigit(ch) {
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: start})
+			continue
+		}
+
+		// 字符串（单引号或双引号，支持反斜杠转义）
+		if ch == '\'' || ch == '"' {
+			quote := ch
+			i++
+			var sb strings.Builder
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				if c == quote {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("位置 %d: 字符串未闭合", start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+			continue
+		}
+
+		// 标识符和关键字
+		if ch == '_' || ch == '$' || unicode.IsLetter(ch) {
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			word := string(runes[start:i])
+			if op, ok := keywordOperators[strings.ToLower(word)]; ok {
+				tokens = append(tokens, token{kind: tokOperator, text: op, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: word, pos: start})
+			}
+			continue
+		}
+
+		// 运算符
+		matched := false
+		for _, op := range multiCharOperators {
+			if strings.HasPrefix(string(runes[i:]), op) {
+				tokens = append(tokens, token{kind: tokOperator, text: op, pos: start})
+				i += len([]rune(op))
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if strings.ContainsRune(singleCharOperators, ch) {
+			tokens = append(tokens, token{kind: tokOperator, text: string(ch), pos: start})
+			i++
+			continue
+		}
+		// 单个 = 视为 ==，兼容常见写法
+		if ch == '=' {
+			tokens = append(tokens, token{kind: tokOperator, text: "==", pos: start})
+			i++
+			continue
+		}
+
+		return nil, fmt.Errorf("位置 %d: 无法识别的字符 %q", start, ch)
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,284 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// node 语法树节点
+type node interface{}
+
+type (
+	literalNode struct{ value interface{} }
+	identNode   struct{ name string }
+	memberNode  struct {
+		object node
+		name   string
+	}
+	indexNode struct {
+		object node
+		index  node
+	}
+	unaryNode struct {
+		op      string
+		operand node
+	}
+	binaryNode struct {
+		op          string
+		left, right node
+	}
+	callNode struct {
+		name string
+		args []node
+	}
+	listNode struct{ items []node }
+)
+
+// 二元运算符优先级（数字越大优先级越高）
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// parser 递归下降解析器（二元运算使用优先级爬升）
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parse 解析表达式为语法树
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parseExpr(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("位置 %d: 多余的内容 %q", tok.pos, tok.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOperator(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokOperator && tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	tok := p.next()
+	if tok.kind != tokOperator || tok.text != text {
+		return fmt.Errorf("位置 %d: 期望 %q", tok.pos, text)
+	}
+	return nil
+}
+
+// enter 限制嵌套深度，防止恶意表达式耗尽栈空间
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return fmt.Errorf("表达式嵌套层级不能超过%d", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+// parseExpr 解析优先级不低于 minPrec 的二元表达式
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokOperator {
+			break
+		}
+		prec, ok := binaryPrecedence[tok.text]
+		if !ok || prec < minPrec {
+			break
+		}
+		p.next()
+
+		right, err := p.parseExpr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseUnary 解析一元表达式（! 和 -）
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") || p.isOperator("-") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix 解析成员访问和下标访问
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOperator("."):
+			p.next()
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, fmt.Errorf("位置 %d: '.' 后应为字段名", tok.pos)
+			}
+			n = &memberNode{object: n, name: tok.text}
+		case p.isOperator("["):
+			p.next()
+			index, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{object: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// parsePrimary 解析基本表达式
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("位置 %d: 无效的数字 %q", tok.pos, tok.text)
+		}
+		return &literalNode{value: value}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		}
+
+		// 函数调用
+		if p.isOperator("(") {
+			p.next()
+			name := strings.ToLower(tok.text)
+			if _, ok := functions[name]; !ok {
+				return nil, fmt.Errorf("位置 %d: 未知函数 %s", tok.pos, tok.text)
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{name: name, args: args}, nil
+		}
+		return &identNode{name: tok.text}, nil
+
+	case tokOperator:
+		switch tok.text {
+		case "(":
+			n, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	}
+
+	if tok.kind == tokEOF {
+		return nil, fmt.Errorf("位置 %d: 表达式不完整", tok.pos)
+	}
+	return nil, fmt.Errorf("位置 %d: 意外的 %q", tok.pos, tok.text)
+}
+
+// parseList 解析逗号分隔的表达式列表，直到 closing
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.isOperator(closing) {
+		p.next()
+		return items, nil
+	}
+
+	for {
+		item, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if p.isOperator(",") {
+			p.next()
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
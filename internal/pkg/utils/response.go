@@ -71,6 +71,7 @@ func Error(c *gin.Context, httpStatus int, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
 		resp.Code = appErr.Code
 		resp.Message = appErr.Message
+		resp.Data = appErr.Data
 	}
 
 	c.JSON(httpStatus, resp)
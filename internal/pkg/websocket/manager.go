@@ -44,6 +44,7 @@ const (
 	TypeMessageDeleted  MessageType = "MESSAGE_DELETED"   // 消息删除
 	TypeUnreadCount     MessageType = "UNREAD_COUNT"      // 未读消息数更新
 	TypeSystemNotify    MessageType = "SYSTEM_NOTIFY"     // 系统通知
+	TypeJobProgress     MessageType = "JOB_PROGRESS"      // 后台作业进度和结果
 	TypeHeartbeat       MessageType = "HEARTBEAT"         // 心跳
 	TypeHeartbeatReply  MessageType = "HEARTBEAT_REPLY"   // 心跳响应
 )
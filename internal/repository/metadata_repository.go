@@ -24,6 +24,9 @@ type MetadataRepository interface {
 	// 根据ID获取字段
 	GetColumnByID(id uint) (*entity.SysColumn, error)
 
+	// 获取引用该表的外键字段（其他表中 REF_TABLE_ID 指向该表的字段）
+	GetColumnsByRefTableID(tableID uint) ([]*entity.SysColumn, error)
+
 	// ========== 表关联关系 ==========
 	// 获取表的所有关联关系
 	GetTableRefsByTableID(tableID uint) ([]*entity.SysTableRef, error)
@@ -70,6 +70,14 @@ func (r *metadataRepository) GetColumnByID(id uint) (*entity.SysColumn, error) {
 	return &column, nil
 }
 
+func (r *metadataRepository) GetColumnsByRefTableID(tableID uint) ([]*entity.SysColumn, error) {
+	var columns []*entity.SysColumn
+	err := r.db.Where("REF_TABLE_ID = ? AND IS_ACTIVE = ?", tableID, "Y").
+		Order("SYS_TABLE_ID ASC, ORDERNO ASC").
+		Find(&columns).Error
+	return columns, err
+}
+
 // ========== 表关联关系 ==========
 
 func (r *metadataRepository) GetTableRefsByTableID(tableID uint) ([]*entity.SysTableRef, error) {
@@ -0,0 +1,156 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/plugins/core"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 审批字段（单据表提交后由工作流维护，表单中只读）
+const (
+	ColApprovalStatus = "APPROVAL_STATUS" // 审批状态
+	ColWfInstanceID   = "WF_INSTANCE_ID"  // 审批流程实例
+)
+
+// 审批状态
+const (
+	ApprovalPending    = "pending"    // 审批中
+	ApprovalApproved   = "approved"   // 已通过
+	ApprovalRejected   = "rejected"   // 已驳回
+	ApprovalTerminated = "terminated" // 已终止
+	ApprovalWithdrawn  = "withdrawn"  // 已撤回
+)
+
+// 审批结束时执行的 sys_table_cmd 钩子动作
+const (
+	CmdApproved = "P" // 审批通过
+	CmdReturned = "R" // 审批驳回、终止或撤回，单据退回未提交
+)
+
+// BeginApproval 单据进入审批：记录审批状态和流程实例
+// db 为调用方的事务（提交单据的事务）
+func (s *service) BeginApproval(ctx context.Context, db *gorm.DB, tableID, id, instanceID uint) error {
+	table, err := s.metadataService.GetTableByID(tableID)
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+
+	updates := approvalUpdates(columns, ApprovalPending, instanceID)
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := db.WithContext(ctx).Table(table.Name).Where("ID = ?", id).Updates(updates).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新审批状态失败", err)
+	}
+	return nil
+}
+
+// FinishApproval 审批流程结束时回写单据
+// 通过时单据保持已提交；驳回、终止、撤回时单据退回未提交，可修改后重新提交
+// 在同一事务中执行 begin钩子/before插件 + 更新单据 + end钩子/after插件
+// （钩子动作 P/R，插件钩子点 table.before/after.approve、table.before/after.reject）
+func (s *service) FinishApproval(ctx context.Context, db *gorm.DB, tableID, id, instanceID uint, result string) error {
+	table, err := s.metadataService.GetTableByID(tableID)
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+
+	cmd, action := CmdApproved, "approve"
+	if result != ApprovalApproved {
+		cmd, action = CmdReturned, "reject"
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record map[string]interface{}
+		if err := tx.Table(table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ID = ? AND IS_ACTIVE = ?", id, "Y").
+			Take(&record).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New(errors.ErrResourceNotFound, fmt.Sprintf("审批单据(ID=%d)不存在", id))
+			}
+			return errors.Wrap(errors.ErrDatabase, "查询审批单据失败", err)
+		}
+
+		pluginData := core.PluginData{
+			TableName: table.Name,
+			Action:    action,
+			RecordID:  id,
+			Data:      record,
+			Extra: map[string]interface{}{
+				"approvalStatus": result,
+				"wfInstanceId":   instanceID,
+			},
+		}
+
+		if err := s.executeHooksInTx(ctx, tx, table.ID, cmd, "begin", record); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
+		}
+		if err := s.executePlugins(ctx, tx, pluginData, "before"); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行before插件失败", err)
+		}
+
+		updates := approvalUpdates(columns, result, instanceID)
+		updates["UPDATE_TIME"] = time.Now()
+		if hasColumn(columns, ColVersion) {
+			version, _ := toInt64(record[ColVersion])
+			updates[ColVersion] = version + 1
+		}
+		if result != ApprovalApproved && isDocumentTable(table) {
+			updates[ColDocStatus] = DocStatusDraft
+			updates[ColSubmitBy] = nil
+			updates[ColSubmitTime] = nil
+		}
+		if err := tx.Table(table.Name).Where("ID = ?", id).Updates(updates).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "回写审批结果失败", err)
+		}
+
+		for k, v := range updates {
+			record[k] = v
+		}
+		if err := s.executeHooksInTx(ctx, tx, table.ID, cmd, "end", record); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行after钩子失败", err)
+		}
+		if err := s.executePlugins(ctx, tx, pluginData, "after"); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行after插件失败", err)
+		}
+
+		return nil
+	})
+}
+
+// approvalUpdates 构建审批字段更新（表中没有审批字段时跳过）
+func approvalUpdates(columns []*entity.SysColumn, status string, instanceID uint) map[string]interface{} {
+	updates := make(map[string]interface{})
+	if hasColumn(columns, ColApprovalStatus) {
+		updates[ColApprovalStatus] = status
+	}
+	if hasColumn(columns, ColWfInstanceID) {
+		updates[ColWfInstanceID] = instanceID
+	}
+	return updates
+}
+
+// hasColumn 检查表是否定义了字段
+func hasColumn(columns []*entity.SysColumn, dbName string) bool {
+	for _, col := range columns {
+		if col.DbName == dbName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package crud
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 字段赋值方式（SysColumn.SetValueType）
+const (
+	SetValuePK       = "pk"       // 主键，由ID生成器赋值
+	SetValueDocNo    = "docno"    // 单据编号，新增时未提交则按 SEQ 序号生成器生成
+	SetValueCreateBy = "createBy" // 创建人，新增时赋值，修改时不变
+	SetValueByPage   = "byPage"   // 界面输入
+	SetValueSelect   = "select"   // 下拉选项
+	SetValueFK       = "fk"       // 外键关联
+	SetValueSysdate  = "sysdate"  // 操作时间，新增和修改时赋值
+	SetValueOperator = "operator" // 操作用户，新增和修改时赋值
+	SetValuePassword = "password" // 密码，bcrypt 加密保存，查询时不返回
+	SetValueIgnore   = "ignore"   // 忽略，不写入
+)
+
+// passwordMask 变更历史中密码字段的显示值
+const passwordMask = "******"
+
+// applyDefaults 新增时为未提交的字段填充默认值（DEFAULT_VALUE，未设置时取数据字典的默认项）
+func (s *service) applyDefaults(columns []*entity.SysColumn, data map[string]interface{}) error {
+	for _, col := range columns {
+		if _, exists := data[col.DbName]; exists {
+			continue
+		}
+		if untrackedFields[col.DbName] || lifecycleFields[col.DbName] || autoSetValueTypes[col.SetValueType] || col.SetValueType == SetValuePassword {
+			continue
+		}
+
+		if col.DefaultValue != "" {
+			data[col.DbName] = col.DefaultValue
+			continue
+		}
+		if col.SysDictID == "" {
+			continue
+		}
+		items, err := s.dictItems(col.SysDictID)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.IsDefaultValue == "Y" {
+				data[col.DbName] = item.Value
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// fillServerValues 按赋值方式填充由服务端维护的字段（在字段校验之前）
+// 操作时间、操作用户新增和修改时都赋值；创建人只在新增时赋值；忽略的字段和空密码不写入
+func fillServerValues(columns []*entity.SysColumn, data map[string]interface{}, username string, userID uint, create bool, now time.Time) {
+	for _, col := range columns {
+		if untrackedFields[col.DbName] {
+			continue
+		}
+
+		switch col.SetValueType {
+		case SetValueSysdate:
+			data[col.DbName] = now
+		case SetValueOperator:
+			data[col.DbName] = operatorValue(col, username, userID)
+		case SetValueCreateBy:
+			if create {
+				data[col.DbName] = operatorValue(col, username, userID)
+			} else {
+				delete(data, col.DbName)
+			}
+		case SetValueIgnore:
+			delete(data, col.DbName)
+		case SetValuePassword:
+			// 修改时不提交或提交空值表示不修改密码
+			if value, exists := data[col.DbName]; exists && includeKeyString(value) == "" {
+				delete(data, col.DbName)
+			}
+		case SetValueDocNo:
+			// 未提交单据编号时在校验后生成
+			if value, exists := data[col.DbName]; create && exists && col.Seq != "" && strings.TrimSpace(includeKeyString(value)) == "" {
+				delete(data, col.DbName)
+			}
+		}
+	}
+}
+
+// finishAutoValues 字段校验通过后生成单据编号并加密密码
+func (s *service) finishAutoValues(columns []*entity.SysColumn, data map[string]interface{}, create bool) error {
+	for _, col := range columns {
+		switch col.SetValueType {
+		case SetValueDocNo:
+			if _, exists := data[col.DbName]; !create || exists || col.Seq == "" {
+				continue
+			}
+			docNo, err := s.seqService.NextValue(col.Seq)
+			if err != nil {
+				return errors.Wrap(errors.ErrInternal, "生成单据编号失败", err)
+			}
+			data[col.DbName] = docNo
+		case SetValuePassword:
+			value, exists := data[col.DbName]
+			if !exists || value == nil {
+				continue
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(includeKeyString(value)), bcrypt.DefaultCost)
+			if err != nil {
+				return errors.Wrap(errors.ErrInternal, "密码加密失败", err)
+			}
+			data[col.DbName] = string(hash)
+		}
+	}
+	return nil
+}
+
+// operatorValue 操作用户字段的值：整数字段保存用户ID，其余保存用户名
+func operatorValue(col *entity.SysColumn, username string, userID uint) interface{} {
+	switch strings.ToLower(col.ColType) {
+	case "int", "integer", "bigint":
+		return userID
+	}
+	return username
+}
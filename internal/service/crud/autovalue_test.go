@@ -0,0 +1,45 @@
+package crud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestFillServerValues(t *testing.T) {
+	columns := []*entity.SysColumn{
+		{DbName: "EDIT_TIME", ColType: "datetime", SetValueType: SetValueSysdate},
+		{DbName: "EDITOR", ColType: "varchar", SetValueType: SetValueOperator},
+		{DbName: "EDITOR_ID", ColType: "int", SetValueType: SetValueOperator},
+		{DbName: "OWNER", ColType: "varchar", SetValueType: SetValueCreateBy},
+		{DbName: "REMARK", ColType: "varchar", SetValueType: SetValueIgnore},
+		{DbName: "PASSWORD", ColType: "varchar", SetValueType: SetValuePassword},
+		{DbName: "DOCNO", ColType: "varchar", SetValueType: SetValueDocNo, Seq: "SO"},
+	}
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.Local)
+
+	data := map[string]interface{}{"OWNER": "other", "REMARK": "x", "PASSWORD": "", "DOCNO": " "}
+	fillServerValues(columns, data, "zhangsan", 5, true, now)
+	want := map[string]interface{}{"EDIT_TIME": now, "EDITOR": "zhangsan", "EDITOR_ID": uint(5), "OWNER": "zhangsan"}
+	if len(data) != len(want) {
+		t.Fatalf("fillServerValues(create) = %v, want %v", data, want)
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("fillServerValues(create)[%s] = %v, want %v", k, data[k], v)
+		}
+	}
+
+	data = map[string]interface{}{"OWNER": "other", "PASSWORD": "secret", "DOCNO": ""}
+	fillServerValues(columns, data, "lisi", 6, false, now)
+	if _, ok := data["OWNER"]; ok {
+		t.Errorf("createBy column should not be updated")
+	}
+	if data["PASSWORD"] != "secret" || data["EDITOR"] != "lisi" {
+		t.Errorf("fillServerValues(update) = %v", data)
+	}
+	if _, ok := data["DOCNO"]; !ok {
+		t.Errorf("docno should only be generated on create")
+	}
+}
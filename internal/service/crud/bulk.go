@@ -0,0 +1,640 @@
+package crud
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/mask"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"gorm.io/gorm"
+)
+
+// Exporter 按查询条件逐条读取要导出的记录（导入导出服务使用）
+type Exporter struct {
+	s       *service
+	ctx     context.Context
+	req     *QueryRequest
+	userID  uint
+	table   *entity.SysTable
+	columns []*entity.SysColumn
+	visible []*entity.SysColumn
+	refs    map[string]*refLookup // 外键字段的显示键（按字段名）
+}
+
+// exportBatchSize 导出时批量查询外键显示键的记录数
+const exportBatchSize = 500
+
+// NewExporter 检查导出权限并创建导出器
+func (s *service) NewExporter(ctx context.Context, req *QueryRequest, userID uint) (*Exporter, error) {
+	table, columns, err := s.bulkTable(ctx, req.TableName, userID, groups.PermExport, "无导出权限")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrderBy != "" && !hasColumn(columns, req.OrderBy) {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("排序字段 %s 不存在", req.OrderBy))
+	}
+
+	return &Exporter{
+		s:       s,
+		ctx:     ctx,
+		req:     req,
+		userID:  userID,
+		table:   table,
+		columns: columns,
+		visible: bulkColumns(columns, "export"),
+		refs:    make(map[string]*refLookup),
+	}, nil
+}
+
+// Table 导出的表
+func (e *Exporter) Table() *entity.SysTable {
+	return e.table
+}
+
+// Columns 导出的字段（MASK 导出可见，不含密码字段），按字段顺序
+func (e *Exporter) Columns() []*entity.SysColumn {
+	return e.visible
+}
+
+// Count 按数据权限和查询条件统计要导出的记录数
+func (e *Exporter) Count() (int64, error) {
+	query, err := e.query()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, errors.Wrap(errors.ErrDatabase, "查询总数失败", err)
+	}
+	return total, nil
+}
+
+// Each 按数据权限和查询条件（同 GetList）逐条读取记录，不分页
+// 外键字段转换为被引用表的显示键（与导入时填写的值一致），被引用表未设置显示键或引用的记录不存在时为ID
+func (e *Exporter) Each(fn func(row map[string]interface{}) error) error {
+	if len(e.visible) == 0 {
+		return errors.New(errors.ErrValidation, "没有可导出的字段")
+	}
+
+	query, err := e.query()
+	if err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(e.visible))
+	for _, col := range e.visible {
+		fields = append(fields, col.DbName)
+	}
+	query = query.Select(strings.Join(fields, ", "))
+
+	if e.req.OrderBy != "" {
+		order := "ASC"
+		if strings.ToUpper(e.req.Order) == "DESC" {
+			order = "DESC"
+		}
+		query = query.Order(fmt.Sprintf("%s %s", e.req.OrderBy, order))
+	} else {
+		query = query.Order("ID DESC")
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+	defer rows.Close()
+
+	// 按批读取，每批的外键显示键一次查询
+	batch := make([]map[string]interface{}, 0, exportBatchSize)
+	flush := func() error {
+		if err := e.resolveRefKeys(batch); err != nil {
+			return err
+		}
+		for _, row := range batch {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for rows.Next() {
+		row := make(map[string]interface{}, len(fields))
+		if err := e.s.db.ScanRows(rows, &row); err != nil {
+			return errors.Wrap(errors.ErrDatabase, "读取数据失败", err)
+		}
+		batch = append(batch, row)
+		if len(batch) >= exportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(errors.ErrDatabase, "读取数据失败", err)
+	}
+	return flush()
+}
+
+// resolveRefKeys 将一批记录的外键值转换为被引用表的显示键
+func (e *Exporter) resolveRefKeys(rows []map[string]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for _, col := range e.visible {
+		if col.RefTableID == nil {
+			continue
+		}
+		lookup, ok := e.refs[col.DbName]
+		if !ok {
+			var err error
+			if lookup, err = e.s.newRefLookup(col); err != nil {
+				return err
+			}
+			e.refs[col.DbName] = lookup
+		}
+		if lookup.column == nil {
+			continue
+		}
+
+		ids := make([]string, 0, len(rows))
+		seen := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			id := includeKeyString(row[col.DbName])
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		var refs []map[string]interface{}
+		if err := e.s.db.WithContext(e.ctx).Table(lookup.table.Name).
+			Select("ID, "+lookup.column.DbName).
+			Where("ID IN ?", ids).
+			Find(&refs).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+		}
+		keys := make(map[string]interface{}, len(refs))
+		for _, ref := range refs {
+			if key := includeKeyString(ref[lookup.column.DbName]); key != "" {
+				keys[includeKeyString(ref["ID"])] = key
+			}
+		}
+		for _, row := range rows {
+			if key, ok := keys[includeKeyString(row[col.DbName])]; ok {
+				row[col.DbName] = key
+			}
+		}
+	}
+	return nil
+}
+
+// query 按数据权限和查询条件构建查询
+func (e *Exporter) query() (*gorm.DB, error) {
+	dataFilter, err := e.s.groupsService.GetUserDataFilter(e.ctx, e.userID, e.table.ID)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+
+	query := e.s.db.WithContext(e.ctx).Table(e.table.Name)
+	if len(dataFilter) > 0 {
+		query, err = e.s.applyFilters(query, dataFilter, e.columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+	}
+	query = query.Where("IS_ACTIVE = ?", "Y")
+	if len(e.req.Filters) > 0 {
+		query, err = e.s.applyFilters(query, e.req.Filters, e.columns, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	DryRun bool `json:"dryRun"` // 试运行：逐行校验但不写入
+	Upsert bool `json:"upsert"` // 按输入键（IS_AK）匹配已有记录，匹配到时修改，否则新增
+}
+
+// 导入一行的处理方式
+const (
+	ImportCreated = "created" // 新增
+	ImportUpdated = "updated" // 修改已有记录
+)
+
+// Importer 逐条导入记录（导入导出服务使用）
+type Importer struct {
+	s       *service
+	ctx     context.Context
+	userID  uint
+	opts    ImportOptions
+	table   *entity.SysTable
+	columns []*entity.SysColumn
+	visible []*entity.SysColumn
+	keys    []*entity.SysColumn    // 输入键字段（按输入键更新时使用）
+	filter  map[string]interface{} // 用户的数据过滤条件（按输入键更新时使用）
+	refs    map[string]*refLookup  // 外键字段的显示键查找（按字段名）
+	planned map[string]bool        // 试运行时前面的行将新增的输入键值
+}
+
+// refCacheSize 每个外键字段缓存的显示键数，超出时淘汰最久未使用的，导入内存不随引用值的种类增长
+const refCacheSize = 10000
+
+// refLookup 外键字段按被引用表的显示键（DK_COLUMN_ID）查找记录ID
+type refLookup struct {
+	table  *entity.SysTable
+	column *entity.SysColumn // 显示键字段，未设置时为空（按ID填写）
+	ids    *refCache         // 已查到的显示键值 -> ID
+}
+
+// refCache 显示键值到记录ID的LRU缓存
+type refCache struct {
+	size  int
+	order *list.List               // 按使用时间排列，最近使用的在前
+	items map[string]*list.Element // 显示键值 -> order 中的元素
+}
+
+// refCacheEntry refCache 的缓存项
+type refCacheEntry struct {
+	key string
+	id  uint
+}
+
+// newRefCache 创建最多缓存 size 个显示键的缓存
+func newRefCache(size int) *refCache {
+	return &refCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get 查找显示键值对应的ID
+func (c *refCache) get(key string) (uint, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return 0, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*refCacheEntry).id, true
+}
+
+// put 缓存显示键值对应的ID，超出容量时淘汰最久未使用的
+func (c *refCache) put(key string, id uint) {
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*refCacheEntry).id = id
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&refCacheEntry{key: key, id: id})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*refCacheEntry).key)
+	}
+}
+
+// NewImporter 检查导入权限并创建导入器
+// 按输入键更新时还需要修改权限，且表必须设置了可导入的输入键字段
+func (s *service) NewImporter(ctx context.Context, tableName string, opts ImportOptions, userID uint) (*Importer, error) {
+	table, columns, err := s.bulkTable(ctx, tableName, userID, groups.PermImport, "无导入权限")
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*entity.SysColumn, 0, len(columns))
+	for _, col := range bulkColumns(columns, "import") {
+		// 系统字段和由服务端赋值的字段不导入（单据编号可以导入）
+		if untrackedFields[col.DbName] || lifecycleFields[col.DbName] {
+			continue
+		}
+		if autoSetValueTypes[col.SetValueType] && col.SetValueType != SetValueDocNo {
+			continue
+		}
+		visible = append(visible, col)
+	}
+
+	importer := &Importer{
+		s:       s,
+		ctx:     ctx,
+		userID:  userID,
+		opts:    opts,
+		table:   table,
+		columns: columns,
+		visible: visible,
+		refs:    make(map[string]*refLookup),
+		planned: make(map[string]bool),
+	}
+
+	if opts.Upsert {
+		hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, groups.PermUpdate)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+		}
+		if !hasPermission {
+			return nil, errors.New(errors.ErrPermissionDenied, "无修改权限，不能按输入键更新")
+		}
+
+		for _, col := range columns {
+			if col.IsAK != "Y" {
+				continue
+			}
+			if !hasColumn(visible, col.DbName) {
+				return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("输入键字段 %s 不可导入，不能按输入键更新", col.DbName))
+			}
+			importer.keys = append(importer.keys, col)
+		}
+		if len(importer.keys) == 0 {
+			return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("表 %s 没有设置输入键，不能按输入键更新", table.Name))
+		}
+
+		importer.filter, err = s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+		}
+	}
+
+	return importer, nil
+}
+
+// Table 导入的表
+func (i *Importer) Table() *entity.SysTable {
+	return i.table
+}
+
+// Columns 可导入的字段（MASK 导入可见，不含系统字段和服务端赋值的字段），按字段顺序
+func (i *Importer) Columns() []*entity.SysColumn {
+	return i.visible
+}
+
+// RefColumn 外键字段导入时填写的被引用表显示键字段，被引用表未设置显示键时返回 nil（填写ID）
+func (i *Importer) RefColumn(col *entity.SysColumn) (*entity.SysTable, *entity.SysColumn, error) {
+	lookup, err := i.refLookup(col)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lookup.table, lookup.column, nil
+}
+
+// Import 导入一条记录，返回处理方式（ImportCreated 或 ImportUpdated）
+// 外键字段按被引用表的显示键转换为ID；按输入键更新时匹配到已有记录则修改（只修改提交的字段），否则新增；
+// 新增和修改的默认值、自动赋值、字段校验和钩子同 Create、Update；试运行时只校验不写入
+// 字段校验失败时返回 ErrValidation，data.fields 为各字段的错误
+func (i *Importer) Import(data map[string]interface{}) (string, error) {
+	processedData := make(map[string]interface{}, len(data))
+	for _, col := range i.visible {
+		if value, exists := data[col.DbName]; exists {
+			processedData[col.DbName] = value
+		}
+	}
+	if err := i.resolveRefs(processedData); err != nil {
+		return "", err
+	}
+
+	var id uint
+	if i.opts.Upsert {
+		var err error
+		if id, err = i.match(processedData); err != nil {
+			return "", err
+		}
+	}
+
+	if id == 0 {
+		if i.opts.DryRun {
+			return ImportCreated, i.checkCreate(processedData)
+		}
+		hookData := make(map[string]interface{}, len(processedData))
+		for key, value := range processedData {
+			hookData[key] = value
+		}
+		_, err := i.s.insert(i.ctx, i.table, i.columns, processedData, hookData, i.userID)
+		return ImportCreated, err
+	}
+
+	if i.opts.DryRun {
+		return ImportUpdated, i.checkUpdate(id, processedData)
+	}
+	return ImportUpdated, i.s.Update(i.ctx, i.table.Name, id, processedData, i.userID)
+}
+
+// resolveRefs 外键字段的值按被引用表的显示键查找记录ID（被引用表未设置显示键时按ID填写）
+func (i *Importer) resolveRefs(data map[string]interface{}) error {
+	var fieldErrors []*FieldError
+	for _, col := range i.visible {
+		value, exists := data[col.DbName]
+		if !exists || col.RefTableID == nil {
+			continue
+		}
+		key := strings.TrimSpace(includeKeyString(value))
+		if key == "" {
+			continue
+		}
+
+		lookup, err := i.refLookup(col)
+		if err != nil {
+			return err
+		}
+		if lookup.column == nil {
+			continue
+		}
+
+		id, cached := lookup.ids.get(key)
+		if !cached {
+			var ids []uint
+			if err := i.s.db.WithContext(i.ctx).Table(lookup.table.Name).
+				Where(fmt.Sprintf("%s = ? AND IS_ACTIVE = ?", lookup.column.DbName), key, "Y").
+				Limit(2).
+				Pluck("ID", &ids).Error; err != nil {
+				return errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+			}
+			switch len(ids) {
+			case 0:
+				fieldErrors = append(fieldErrors, newFieldError(col, fmt.Sprintf("引用的记录 %s 不存在", key)))
+				continue
+			case 1:
+				id = ids[0]
+				lookup.ids.put(key, id)
+			default:
+				fieldErrors = append(fieldErrors, newFieldError(col, fmt.Sprintf("%s 匹配到多条引用记录", key)))
+				continue
+			}
+		}
+		data[col.DbName] = id
+	}
+	return fieldErrorsToError(fieldErrors)
+}
+
+// refLookup 获取外键字段的显示键查找（按字段缓存）
+func (i *Importer) refLookup(col *entity.SysColumn) (*refLookup, error) {
+	if lookup, ok := i.refs[col.DbName]; ok {
+		return lookup, nil
+	}
+
+	lookup, err := i.s.newRefLookup(col)
+	if err != nil {
+		return nil, err
+	}
+	i.refs[col.DbName] = lookup
+	return lookup, nil
+}
+
+// newRefLookup 查询外键字段引用的表及其显示键字段
+func (s *service) newRefLookup(col *entity.SysColumn) (*refLookup, error) {
+	refTable, err := s.metadataService.GetTableByID(*col.RefTableID)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("外键字段 %s 引用的表不存在", col.DbName), err)
+	}
+	lookup := &refLookup{table: refTable, ids: newRefCache(refCacheSize)}
+	if refTable.DkColumnID != nil {
+		dkColumn, err := s.metadataRepo.GetColumnByID(*refTable.DkColumnID)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("表 %s 的显示键字段不存在", refTable.Name), err)
+		}
+		lookup.column = dkColumn
+	}
+	return lookup, nil
+}
+
+// match 按输入键查找已有记录，未匹配时返回0；输入键字段必须填写
+// 匹配到数据权限范围外的记录时返回冲突，不修改也不按其内容校验
+func (i *Importer) match(data map[string]interface{}) (uint, error) {
+	var fieldErrors []*FieldError
+	query := i.s.db.WithContext(i.ctx).Table(i.table.Name).Where("IS_ACTIVE = ?", "Y")
+	for _, col := range i.keys {
+		value, exists := data[col.DbName]
+		if !exists || strings.TrimSpace(includeKeyString(value)) == "" {
+			fieldErrors = append(fieldErrors, newFieldError(col, "不能为空（按输入键更新）"))
+			continue
+		}
+		normalized, message := validateValue(col, value)
+		if message != "" {
+			fieldErrors = append(fieldErrors, newFieldError(col, message))
+			continue
+		}
+		query = query.Where(fmt.Sprintf("%s = ?", col.DbName), normalized)
+	}
+	if err := fieldErrorsToError(fieldErrors); err != nil {
+		return 0, err
+	}
+
+	var ids []uint
+	if err := query.Limit(2).Pluck("ID", &ids).Error; err != nil {
+		return 0, errors.Wrap(errors.ErrDatabase, "按输入键查询记录失败", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if len(ids) > 1 {
+		return 0, errors.New(errors.ErrValidation, "输入键匹配到多条记录")
+	}
+
+	if len(i.filter) > 0 {
+		scoped, err := i.s.applyFilters(i.s.db.WithContext(i.ctx).Table(i.table.Name).Where("ID = ?", ids[0]), i.filter, i.columns, false)
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+		var count int64
+		if err := scoped.Count(&count).Error; err != nil {
+			return 0, errors.Wrap(errors.ErrDatabase, "按输入键查询记录失败", err)
+		}
+		if count == 0 {
+			return 0, errors.New(errors.ErrResourceConflict, "输入键已被数据权限范围外的记录使用")
+		}
+	}
+	return ids[0], nil
+}
+
+// checkCreate 试运行新增：补全默认值后按字段定义校验，不生成ID和单据编号、不执行钩子
+// 同一文件中前面的行将新增的输入键值也视为已存在
+func (i *Importer) checkCreate(data map[string]interface{}) error {
+	if err := i.s.applyDefaults(i.columns, data); err != nil {
+		return err
+	}
+	if isDocumentTable(i.table) {
+		removeLifecycleFields(data)
+	}
+	if err := i.s.validateFields(i.s.db.WithContext(i.ctx), i.table, i.columns, data, 0); err != nil {
+		return err
+	}
+
+	var fieldErrors []*FieldError
+	var planned []string
+	for _, col := range i.columns {
+		value, exists := data[col.DbName]
+		if col.IsAK != "Y" || !exists || value == nil {
+			continue
+		}
+		key := col.DbName + "=" + includeKeyString(value)
+		if i.planned[key] {
+			fieldErrors = append(fieldErrors, newFieldError(col, fmt.Sprintf("%s与前面的行重复", includeKeyString(value))))
+			continue
+		}
+		planned = append(planned, key)
+	}
+	if err := fieldErrorsToError(fieldErrors); err != nil {
+		return err
+	}
+	for _, key := range planned {
+		i.planned[key] = true
+	}
+	return nil
+}
+
+// checkUpdate 试运行修改：检查单据状态并按字段定义校验提交的字段，不执行钩子
+func (i *Importer) checkUpdate(id uint, data map[string]interface{}) error {
+	processedData, err := i.s.processFieldsForUpdate(i.columns, data, i.userID)
+	if err != nil {
+		return err
+	}
+	if isDocumentTable(i.table) {
+		removeLifecycleFields(processedData)
+	}
+
+	tx := i.s.db.WithContext(i.ctx)
+	if err := i.s.checkDocEditable(tx, i.table, []uint{id}); err != nil {
+		return err
+	}
+	return i.s.validateFields(tx, i.table, i.columns, processedData, id)
+}
+
+// bulkTable 获取导入导出的表并检查权限
+func (s *service) bulkTable(ctx context.Context, tableName string, userID uint, perm int, deniedMsg string) (*entity.SysTable, []*entity.SysColumn, error) {
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, perm)
+	if err != nil {
+		return nil, nil, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return nil, nil, errors.New(errors.ErrPermissionDenied, deniedMsg)
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, columns, nil
+}
+
+// bulkColumns 导入或导出可见的字段（未配置 MASK 的字段可见，密码字段不可见）
+func bulkColumns(columns []*entity.SysColumn, operation string) []*entity.SysColumn {
+	visible := make([]*entity.SysColumn, 0, len(columns))
+	for _, col := range columns {
+		if col.SetValueType == SetValuePassword {
+			continue
+		}
+		if col.Mask != "" && !mask.ParseMask(col.Mask).IsVisible(operation) {
+			continue
+		}
+		visible = append(visible, col)
+	}
+	return visible
+}
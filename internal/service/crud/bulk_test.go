@@ -0,0 +1,123 @@
+package crud
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func TestRefCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newRefCache(2)
+	cache.put("A", 1)
+	cache.put("B", 2)
+	if id, ok := cache.get("A"); !ok || id != 1 {
+		t.Fatalf("get(A) = %d, %v", id, ok)
+	}
+
+	// B 最久未使用，被淘汰
+	cache.put("C", 3)
+	if _, ok := cache.get("B"); ok {
+		t.Error("B should be evicted")
+	}
+	if id, ok := cache.get("A"); !ok || id != 1 {
+		t.Errorf("get(A) = %d, %v", id, ok)
+	}
+	if id, ok := cache.get("C"); !ok || id != 3 {
+		t.Errorf("get(C) = %d, %v", id, ok)
+	}
+	if cache.order.Len() != 2 || len(cache.items) != 2 {
+		t.Errorf("cache size = %d/%d, want 2", cache.order.Len(), len(cache.items))
+	}
+}
+
+func TestExportRefKeysRoundTrip(t *testing.T) {
+	customer := &entity.SysTable{Name: "CUSTOMER"}
+	code := &entity.SysColumn{DbName: "CODE"}
+	refTableID := uint(2)
+	columns := []*entity.SysColumn{
+		{DbName: "ID"},
+		{DbName: "NAME"},
+		{DbName: "CUSTOMER_ID", RefTableID: &refTableID},
+	}
+
+	db, _ := newFakeDB(t, map[string]*fakeResult{
+		"FROM `SALES_ORDER`": {
+			columns: []string{"ID", "NAME", "CUSTOMER_ID"},
+			rows:    [][]driver.Value{{int64(1), "订单1", int64(123)}, {int64(2), "订单2", nil}},
+		},
+		"SELECT ID, CODE FROM `CUSTOMER`": {
+			columns: []string{"ID", "CODE"},
+			rows:    [][]driver.Value{{int64(123), "C001"}},
+		},
+		"FROM `CUSTOMER` WHERE CODE = ?": {
+			columns: []string{"ID"},
+			rows:    [][]driver.Value{{int64(123)}},
+		},
+	})
+	s := &service{db: db, groupsService: &fakeGroups{}}
+	lookup := func() map[string]*refLookup {
+		return map[string]*refLookup{"CUSTOMER_ID": {table: customer, column: code, ids: newRefCache(refCacheSize)}}
+	}
+
+	exporter := &Exporter{
+		s: s, ctx: context.Background(), req: &QueryRequest{TableName: "SALES_ORDER"},
+		table: &entity.SysTable{Name: "SALES_ORDER"}, columns: columns, visible: columns, refs: lookup(),
+	}
+	var exported []map[string]interface{}
+	if err := exporter.Each(func(row map[string]interface{}) error {
+		exported = append(exported, row)
+		return nil
+	}); err != nil {
+		t.Fatalf("Each() = %v", err)
+	}
+	if len(exported) != 2 || exported[0]["CUSTOMER_ID"] != "C001" || exported[1]["CUSTOMER_ID"] != nil {
+		t.Fatalf("exported = %v, want CUSTOMER_ID exported as display key", exported)
+	}
+
+	// 导出的显示键可以原样导入
+	importer := &Importer{s: s, ctx: context.Background(), visible: columns, refs: lookup()}
+	data := map[string]interface{}{"NAME": "订单1", "CUSTOMER_ID": exported[0]["CUSTOMER_ID"]}
+	if err := importer.resolveRefs(data); err != nil {
+		t.Fatalf("resolveRefs() = %v", err)
+	}
+	if data["CUSTOMER_ID"] != uint(123) {
+		t.Errorf("CUSTOMER_ID = %v, want 123", data["CUSTOMER_ID"])
+	}
+}
+
+func TestImportMatchOutsideDataScope(t *testing.T) {
+	table := &entity.SysTable{Name: "CUSTOMER"}
+	columns := []*entity.SysColumn{{DbName: "ID"}, {DbName: "CODE", IsAK: "Y"}, {DbName: "SYS_COMPANY_ID"}}
+	filter := map[string]interface{}{"SYS_COMPANY_ID": 1}
+	data := map[string]interface{}{"CODE": "C001"}
+
+	tests := []struct {
+		name    string
+		inScope int64
+		wantID  uint
+		wantErr int
+	}{
+		{"范围内的记录", 1, 7, 0},
+		{"范围外的记录", 0, 0, errors.ErrResourceConflict},
+	}
+	for _, tt := range tests {
+		db, fake := newFakeDB(t, map[string]*fakeResult{
+			"SELECT `ID` FROM `CUSTOMER`": {columns: []string{"ID"}, rows: [][]driver.Value{{int64(7)}}},
+			"count(*)":                    countResult(tt.inScope),
+		})
+		importer := &Importer{
+			s: &service{db: db}, ctx: context.Background(), table: table, columns: columns,
+			keys: columns[1:2], filter: filter,
+		}
+		id, err := importer.match(data)
+		if id != tt.wantID || (err == nil) != (tt.wantErr == 0) || (err != nil && errors.GetCode(err) != tt.wantErr) {
+			t.Errorf("%s: match() = %d, %v", tt.name, id, err)
+		}
+		if len(fake.executed("SYS_COMPANY_ID = ?")) != 1 {
+			t.Errorf("%s: data filter not applied: %v", tt.name, fake.queries)
+		}
+	}
+}
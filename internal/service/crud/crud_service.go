@@ -9,20 +9,26 @@ import (
 
 	"github.com/sky-xhsoft/sky-server/internal/model/entity"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/executor"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/mask"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
 	"github.com/sky-xhsoft/sky-server/internal/repository"
+	"github.com/sky-xhsoft/sky-server/internal/service/dict"
 	"github.com/sky-xhsoft/sky-server/internal/service/groups"
 	"github.com/sky-xhsoft/sky-server/internal/service/idgen"
 	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
+	"github.com/sky-xhsoft/sky-server/internal/service/sequence"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/plugins/core"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Service 通用CRUD服务接口
 type Service interface {
-	// 查询单条记录
-	GetOne(ctx context.Context, tableName string, id uint, userID uint) (map[string]interface{}, error)
+	// 查询单条记录（include 为需要嵌入的关联表/外键字段）
+	GetOne(ctx context.Context, tableName string, id uint, include []string, userID uint) (map[string]interface{}, error)
 
 	// 查询列表（支持分页、排序、过滤）
 	GetList(ctx context.Context, req *QueryRequest, userID uint) (*QueryResponse, error)
@@ -33,11 +39,50 @@ type Service interface {
 	// 更新记录
 	Update(ctx context.Context, tableName string, id uint, data map[string]interface{}, userID uint) error
 
-	// 删除记录（软删除）
+	// 删除记录（表 PROPS 中 softDelete 为 true 时进入回收站，否则物理删除；按外键 REF_ON_DELETE 处理子表）
 	Delete(ctx context.Context, tableName string, id uint, userID uint) error
 
 	// 批量删除
 	BatchDelete(ctx context.Context, tableName string, ids []uint, userID uint) error
+
+	// 查询回收站中的记录
+	ListDeleted(ctx context.Context, req *QueryRequest, userID uint) (*QueryResponse, error)
+
+	// 从回收站恢复记录
+	RestoreDeleted(ctx context.Context, tableName string, ids []uint, userID uint) error
+
+	// 从回收站彻底删除记录
+	PurgeDeleted(ctx context.Context, tableName string, ids []uint, userID uint) error
+
+	// 创建导出器（检查导出权限，按数据权限和查询条件读取记录）
+	NewExporter(ctx context.Context, req *QueryRequest, userID uint) (*Exporter, error)
+
+	// 创建导入器（检查导入权限，逐条新增或按输入键更新记录，支持试运行）
+	NewImporter(ctx context.Context, tableName string, opts ImportOptions, userID uint) (*Importer, error)
+
+	// 创建打印器（检查查询权限，读取打印可见的字段和一对多明细）
+	NewPrinter(ctx context.Context, tableName string, userID uint) (*Printer, error)
+
+	// 查询记录的字段变更历史
+	ListChanges(ctx context.Context, tableName string, id uint, userID uint) ([]*RecordChange, error)
+
+	// 恢复记录到某次变更之前的状态
+	RestoreRecord(ctx context.Context, tableName string, id, changeID uint, userID uint) error
+
+	// 提交单据（表MASK需包含S）
+	Submit(ctx context.Context, tableName string, id uint, userID uint) error
+
+	// 反提交单据（表MASK需包含U）
+	Unsubmit(ctx context.Context, tableName string, id uint, userID uint) error
+
+	// 作废单据（表MASK需包含V）
+	Void(ctx context.Context, tableName string, id uint, userID uint) error
+
+	// 单据进入审批（工作流在提交事务中调用）
+	BeginApproval(ctx context.Context, db *gorm.DB, tableID, id, instanceID uint) error
+
+	// 审批结束回写单据并执行钩子（工作流调用）
+	FinishApproval(ctx context.Context, db *gorm.DB, tableID, id, instanceID uint, result string) error
 }
 
 // QueryRequest 查询请求
@@ -47,8 +92,8 @@ type QueryRequest struct {
 	PageSize  int                    `json:"pageSize"` // 每页大小
 	OrderBy   string                 `json:"orderBy"`  // 排序字段
 	Order     string                 `json:"order"`    // 排序方向: asc, desc
-	Filters   map[string]interface{} `json:"filters"`  // 过滤条件
-	Include   []string               `json:"include"`  // 包含的关联表
+	Filters   map[string]interface{} `json:"filters"`  // 过滤条件（支持操作符和 $and/$or 条件组，见 filterBuilder）
+	Include   []string               `json:"include"`  // 包含的关联表（子表表名或外键字段名，结果放在 _includes 下）
 }
 
 // QueryResponse 查询响应
@@ -67,6 +112,9 @@ type service struct {
 	metadataRepo    repository.MetadataRepository
 	userRepo        repository.UserRepository
 	idgenService    idgen.Service
+	dictService     dict.Service
+	seqService      sequence.Service
+	pluginManager   *core.Manager
 }
 
 // NewService 创建通用CRUD服务
@@ -77,6 +125,9 @@ func NewService(
 	metadataRepo repository.MetadataRepository,
 	userRepo repository.UserRepository,
 	idgenService idgen.Service,
+	dictService dict.Service,
+	seqService sequence.Service,
+	pluginManager *core.Manager,
 ) Service {
 	return &service{
 		db:              db,
@@ -85,11 +136,14 @@ func NewService(
 		metadataRepo:    metadataRepo,
 		userRepo:        userRepo,
 		idgenService:    idgenService,
+		dictService:     dictService,
+		seqService:      seqService,
+		pluginManager:   pluginManager,
 	}
 }
 
 // GetOne 查询单条记录
-func (s *service) GetOne(ctx context.Context, tableName string, id uint, userID uint) (map[string]interface{}, error) {
+func (s *service) GetOne(ctx context.Context, tableName string, id uint, include []string, userID uint) (map[string]interface{}, error) {
 	// 获取表元数据
 	table, err := s.metadataService.GetTable(tableName)
 	if err != nil {
@@ -131,8 +185,11 @@ func (s *service) GetOne(ctx context.Context, tableName string, id uint, userID
 	query = query.Where("ID = ?", id)
 
 	// 添加数据过滤条件
-	if dataFilter != nil && len(dataFilter) > 0 {
-		query = s.applyFilters(query, dataFilter, columns)
+	if len(dataFilter) > 0 {
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
 	}
 
 	// 添加IS_ACTIVE条件
@@ -147,6 +204,11 @@ func (s *service) GetOne(ctx context.Context, tableName string, id uint, userID
 		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
 	}
 
+	// 加载关联数据
+	if err := s.loadIncludes(ctx, table, columns, []map[string]interface{}{result}, include, userID); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -200,16 +262,22 @@ func (s *service) GetList(ctx context.Context, req *QueryRequest, userID uint) (
 	query := s.db.Table(table.Name).Select(selectFields)
 
 	// 添加数据过滤条件
-	if dataFilter != nil && len(dataFilter) > 0 {
-		query = s.applyFilters(query, dataFilter, columns)
+	if len(dataFilter) > 0 {
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
 	}
 
 	// 添加IS_ACTIVE条件
 	query = query.Where("IS_ACTIVE = ?", "Y")
 
-	// 添加过滤条件
-	if req.Filters != nil && len(req.Filters) > 0 {
-		query = s.applyFilters(query, req.Filters, columns)
+	// 添加过滤条件（字段和操作数均按元数据校验）
+	if len(req.Filters) > 0 {
+		query, err = s.applyFilters(query, req.Filters, columns, true)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// 计算总数
@@ -239,6 +307,11 @@ func (s *service) GetList(ctx context.Context, req *QueryRequest, userID uint) (
 		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
 	}
 
+	// 加载关联数据（按关联表批量查询，避免N+1）
+	if err := s.loadIncludes(ctx, table, columns, results, req.Include, userID); err != nil {
+		return nil, err
+	}
+
 	return &QueryResponse{
 		Total:    total,
 		Page:     req.Page,
@@ -276,33 +349,73 @@ func (s *service) Create(ctx context.Context, tableName string, data map[string]
 		return nil, err
 	}
 
+	// 填充默认值和自动赋值字段后插入
+	recordID, err := s.insert(ctx, table, columns, processedData, data, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回创建的记录
+	if recordID == 0 {
+		return processedData, nil
+	}
+
+	return s.GetOne(ctx, tableName, recordID, nil, userID)
+}
+
+// insert 填充默认值、ID、审计字段和自动赋值字段后插入记录，返回新记录ID
+// processedData 为已按 MASK 处理的数据，data 为提交的原始数据（传给before钩子）
+func (s *service) insert(ctx context.Context, table *entity.SysTable, columns []*entity.SysColumn, processedData, data map[string]interface{}, userID uint) (uint, error) {
+	if err := s.applyDefaults(columns, processedData); err != nil {
+		return 0, err
+	}
+
 	// 生成新的ID（在事务外，避免长时间持有锁）
 	newID, err := s.idgenService.GetNextID(ctx, table.Name)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrInternal, "生成ID失败", err)
+		return 0, errors.Wrap(errors.ErrInternal, "生成ID失败", err)
 	}
 	processedData["ID"] = newID
 
 	// 添加审计字段
 	processedData["IS_ACTIVE"] = "Y"
 
+	// 单据表新建时为未提交状态，状态字段只能通过提交/反提交/作废修改
+	if isDocumentTable(table) {
+		removeLifecycleFields(processedData)
+		processedData[ColDocStatus] = DocStatusDraft
+	}
+
 	// 获取用户信息以填充审计字段
 	user, userErr := s.userRepo.GetUserByID(userID)
 	if userErr == nil && user != nil {
 		// 设置创建人和公司ID
 		processedData["CREATE_BY"] = user.Username
 		processedData["SYS_COMPANY_ID"] = user.SysCompanyID
-		fmt.Printf("[DEBUG] 设置审计字段: CREATE_BY=%s, SYS_COMPANY_ID=%d\n", user.Username, user.SysCompanyID)
 	} else {
-		fmt.Printf("[DEBUG] 获取用户信息失败: userID=%d, err=%v\n", userID, userErr)
+		logger.Debug("获取用户信息失败，未填充创建人", zap.String("table", table.Name), zap.Uint("userId", userID), zap.Error(userErr))
 	}
 	// 设置创建时间
 	processedData["CREATE_TIME"] = time.Now()
 
-	fmt.Printf("[DEBUG] 准备插入的数据: %+v\n", processedData)
+	// 按赋值方式填充操作时间、操作用户等字段
+	var username string
+	if userErr == nil && user != nil {
+		username = user.Username
+	}
+	fillServerValues(columns, processedData, username, userID, true, time.Now())
 
-	// 在事务中执行：before钩子 + 插入 + after钩子
+	// 在事务中执行：字段校验 + before钩子 + 插入 + after钩子
 	err = transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		// 按字段定义校验（输入键唯一性在事务中检查）
+		if err := s.validateFields(tx, table, columns, processedData, 0); err != nil {
+			return err
+		}
+		// 校验通过后生成单据编号、加密密码
+		if err := s.finishAutoValues(columns, processedData, true); err != nil {
+			return err
+		}
+
 		// 执行before钩子（在事务中）
 		if err := s.executeHooksInTx(ctx, tx, table.ID, "A", "begin", data); err != nil {
 			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
@@ -322,21 +435,14 @@ func (s *service) Create(ctx context.Context, tableName string, data map[string]
 	})
 
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// 获取创建记录的ID（已在前面生成）
-	recordID := newID
-
-	// 返回创建的记录
-	if recordID == 0 {
-		return processedData, nil
-	}
-
-	return s.GetOne(ctx, tableName, recordID, userID)
+	return newID, nil
 }
 
 // Update 更新记录
+// 提交的数据包含 VERSION（表定义了该字段时）或 UPDATE_TIME 时进行乐观锁校验，记录已被修改则返回 ErrVersionConflict
 func (s *service) Update(ctx context.Context, tableName string, id uint, data map[string]interface{}, userID uint) error {
 	// 获取表元数据
 	table, err := s.metadataService.GetTable(tableName)
@@ -362,21 +468,39 @@ func (s *service) Update(ctx context.Context, tableName string, id uint, data ma
 		return err
 	}
 
+	// 取出乐观锁校验值（客户端读取时的 VERSION 或 UPDATE_TIME）
+	check, err := takeVersionCheck(columns, data)
+	if err != nil {
+		return err
+	}
+
 	// 验证和处理字段（在事务外）
 	processedData, err := s.processFieldsForUpdate(columns, data, userID)
 	if err != nil {
 		return err
 	}
+	if isDocumentTable(table) {
+		removeLifecycleFields(processedData)
+	}
 
 	// 添加审计字段
 	// 获取用户信息以填充审计字段
+	var username string
 	user, err := s.userRepo.GetUserByID(userID)
 	if err == nil && user != nil {
 		// 设置更新人
 		processedData["UPDATE_BY"] = user.Username
+		username = user.Username
 	}
 	// 设置更新时间
 	processedData["UPDATE_TIME"] = time.Now()
+	// 按赋值方式填充操作时间、操作用户等字段
+	fillServerValues(columns, processedData, username, userID, false, time.Now())
+	// 版本号在事务中读取后加1
+	hasVersion := hasColumn(columns, ColVersion)
+	if hasVersion {
+		processedData[ColVersion] = nil
+	}
 
 	// 获取要更新的字段列表（支持零值更新）
 	updateFields := make([]string, 0, len(processedData))
@@ -386,6 +510,32 @@ func (s *service) Update(ctx context.Context, tableName string, id uint, data ma
 
 	// 在事务中执行：before钩子 + 更新 + after钩子
 	err = transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		// 已提交/已作废的单据不允许修改
+		if err := s.checkDocEditable(tx, table, []uint{id}); err != nil {
+			return err
+		}
+
+		// 锁定记录并校验乐观锁，记录已被他人修改时返回冲突
+		before, err := s.lockRecord(tx, table, id)
+		if err != nil {
+			return err
+		}
+		version, err := s.checkVersion(tx, table, columns, before, check, userID)
+		if err != nil {
+			return err
+		}
+		if hasVersion {
+			processedData[ColVersion] = version + 1
+		}
+
+		// 按字段定义校验提交的字段
+		if err := s.validateFields(tx, table, columns, processedData, id); err != nil {
+			return err
+		}
+		if err := s.finishAutoValues(columns, processedData, false); err != nil {
+			return err
+		}
+
 		// 执行before钩子（在事务中）
 		if err := s.executeHooksInTx(ctx, tx, table.ID, "M", "begin", data); err != nil {
 			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
@@ -404,6 +554,11 @@ func (s *service) Update(ctx context.Context, tableName string, id uint, data ma
 			return errors.New(errors.ErrResourceNotFound, "记录不存在")
 		}
 
+		// 记录字段变更
+		if err := s.recordChange(tx, table, columns, id, ChangeUpdate, before, processedData, userID); err != nil {
+			return err
+		}
+
 		// 执行after钩子（在事务中）
 		processedData["ID"] = id
 		if err := s.executeHooksInTx(ctx, tx, table.ID, "M", "end", processedData); err != nil {
@@ -416,7 +571,7 @@ func (s *service) Update(ctx context.Context, tableName string, id uint, data ma
 	return err
 }
 
-// Delete 删除记录（物理删除）
+// Delete 删除记录（表启用软删除时进入回收站，否则物理删除）
 func (s *service) Delete(ctx context.Context, tableName string, id uint, userID uint) error {
 	// 获取表元数据
 	table, err := s.metadataService.GetTable(tableName)
@@ -433,36 +588,27 @@ func (s *service) Delete(ctx context.Context, tableName string, id uint, userID
 		return errors.New(errors.ErrPermissionDenied, "无删除权限")
 	}
 
-	// 在事务中执行：before钩子 + 删除 + after钩子
-	deleteData := map[string]interface{}{"ID": id}
-	err = transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
-		// 执行before钩子（在事务中）
-		if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "begin", deleteData); err != nil {
-			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
-		}
-
-		// 执行物理删除（在事务中）
-		result := tx.Table(table.Name).Where("ID = ?", id).Delete(nil)
-		if result.Error != nil {
-			return errors.Wrap(errors.ErrDatabase, "删除失败", result.Error)
-		}
-
-		if result.RowsAffected == 0 {
-			return errors.New(errors.ErrResourceNotFound, "记录不存在")
-		}
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
 
-		// 执行after钩子（在事务中）
-		if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "end", deleteData); err != nil {
-			return errors.Wrap(errors.ErrInternal, "执行after钩子失败", err)
+	// 在事务中执行：before钩子 + 删除 + 子表外键处理 + after钩子
+	username := s.username(userID)
+	err = transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		// 锁定记录，删除前的完整记录写入变更历史
+		before, err := s.lockRecord(tx, table, id)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		return s.deleteRecords(ctx, tx, table, columns, []map[string]interface{}{before}, username, userID, 0, 0)
 	})
 
 	return err
 }
 
-// BatchDelete 批量删除
+// BatchDelete 批量删除（不存在或已删除的记录忽略）
 func (s *service) BatchDelete(ctx context.Context, tableName string, ids []uint, userID uint) error {
 	// 获取表元数据
 	table, err := s.metadataService.GetTable(tableName)
@@ -479,31 +625,28 @@ func (s *service) BatchDelete(ctx context.Context, tableName string, ids []uint,
 		return errors.New(errors.ErrPermissionDenied, "无删除权限")
 	}
 
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+
 	// 在事务中执行批量删除
+	username := s.username(userID)
 	err = transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
-		// 对每个ID执行before钩子（在事务中）
-		for _, id := range ids {
-			deleteData := map[string]interface{}{"ID": id}
-			if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "begin", deleteData); err != nil {
-				return errors.Wrap(errors.ErrInternal, fmt.Sprintf("执行ID=%d的before钩子失败", id), err)
-			}
+		// 锁定记录，删除前的完整记录写入变更历史
+		var records []map[string]interface{}
+		if err := tx.Table(table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ID IN ? AND IS_ACTIVE = ?", ids, "Y").
+			Order("ID ASC").
+			Find(&records).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询失败", err)
 		}
-
-		// 执行批量物理删除（在事务中）
-		result := tx.Table(table.Name).Where("ID IN ?", ids).Delete(nil)
-		if result.Error != nil {
-			return errors.Wrap(errors.ErrDatabase, "批量删除失败", result.Error)
-		}
-
-		// 对每个ID执行after钩子（在事务中）
-		for _, id := range ids {
-			deleteData := map[string]interface{}{"ID": id}
-			if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "end", deleteData); err != nil {
-				return errors.Wrap(errors.ErrInternal, fmt.Sprintf("执行ID=%d的after钩子失败", id), err)
-			}
+		if len(records) == 0 {
+			return errors.New(errors.ErrResourceNotFound, "记录不存在")
 		}
 
-		return nil
+		return s.deleteRecords(ctx, tx, table, columns, records, username, userID, 0, 0)
 	})
 
 	return err
@@ -531,6 +674,11 @@ func (s *service) buildSelectFields(columns []*entity.SysColumn, userID uint, op
 		// TODO: 检查字段权限（基于SGRADE）- 需要集成groups权限服务
 		// 暂时允许所有字段访问
 
+		// 密码字段不返回
+		if col.SetValueType == SetValuePassword {
+			continue
+		}
+
 		// 主键字段和系统审计字段始终包含，不受MASK限制
 		isPrimaryKey := col.IsAK == "Y" || col.SetValueType == "pk"
 		isStandardField := standardFields[col.DbName]
@@ -617,54 +765,33 @@ func (s *service) processFieldsForUpdate(columns []*entity.SysColumn, data map[s
 	return processedData, nil
 }
 
-// applyDataFilter 应用数据过滤条件
-func (s *service) applyDataFilter(query *gorm.DB, filterJSON string, columns []*entity.SysColumn) *gorm.DB {
+// applyDataFilter 应用JSON格式的数据过滤条件（严格模式）
+func (s *service) applyDataFilter(query *gorm.DB, filterJSON string, columns []*entity.SysColumn) (*gorm.DB, error) {
 	if filterJSON == "" {
-		return query
+		return query, nil
 	}
 
+	// 无法解析的过滤条件报错，不忽略（忽略会扩大可见范围）
 	var filter map[string]interface{}
 	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
-		return query
+		return nil, errors.Wrap(errors.ErrInvalidParam, "过滤条件不是有效的JSON对象", err)
 	}
 
-	return s.applyFilters(query, filter, columns)
+	return s.applyFilters(query, filter, columns, false)
 }
 
 // applyFilters 应用过滤条件
-// 对于 text 类型字段使用 LIKE 模糊匹配，其他类型使用精确匹配
-func (s *service) applyFilters(query *gorm.DB, filters map[string]interface{}, columns []*entity.SysColumn) *gorm.DB {
-	// 构建字段类型映射，方便快速查找
-	columnTypeMap := make(map[string]string)
-	if columns != nil {
-		for _, col := range columns {
-			columnTypeMap[col.DbName] = col.DisplayType
-		}
+// 过滤语法见 filterBuilder；字段必须在 sys_column 中定义，checkQueryable 为 true 时还要求字段 IS_QUERY = 'Y'
+func (s *service) applyFilters(query *gorm.DB, filters map[string]interface{}, columns []*entity.SysColumn, checkQueryable bool) (*gorm.DB, error) {
+	where, args, err := newFilterBuilder(columns, checkQueryable).Build(filters)
+	if err != nil {
+		return nil, err
 	}
-
-	for field, value := range filters {
-		// 跳过 nil 值
-		if value == nil {
-			continue
-		}
-
-		// 获取字段的显示类型
-		displayType := columnTypeMap[field]
-
-		// 对于 text 类型字段（text, textarea），使用 LIKE 模糊查询
-		// 根据 DisplayType 字段定义：blank,button,hr,check,file,image,select,text,textarea,date,datetime,clob,xml,json
-		if displayType == "text" || displayType == "textarea" || displayType == "clob" {
-			// 转换为字符串并添加通配符
-			if strValue, ok := value.(string); ok && strValue != "" {
-				query = query.Where(fmt.Sprintf("%s LIKE ?", field), "%"+strValue+"%")
-			}
-		} else {
-			// 其他类型使用精确匹配（select, date, datetime, check 等）
-			query = query.Where(fmt.Sprintf("%s = ?", field), value)
-		}
+	if where == "" {
+		return query, nil
 	}
 
-	return query
+	return query.Where(where, args...), nil
 }
 
 // executeHooks 执行表命令钩子
@@ -0,0 +1,207 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/repository"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeResult 假数据库对一条查询返回的结果
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeDB 按 SQL 片段返回预设结果的假数据库，记录执行过的 SQL，用于不依赖 MySQL 的单元测试
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]*fakeResult // SQL 包含 key 时返回对应结果，未匹配的查询返回空结果
+	queries []string
+	args    [][]interface{} // 与 queries 对应的参数
+}
+
+// newFakeDB 创建使用假数据库的 gorm 连接
+func newFakeDB(t *testing.T, results map[string]*fakeResult) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{results: results}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(fake),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	return db, fake
+}
+
+// countResult COUNT 查询的结果
+func countResult(n int64) *fakeResult {
+	return &fakeResult{columns: []string{"count(*)"}, rows: [][]driver.Value{{n}}}
+}
+
+// executed 返回包含 fragment 的已执行 SQL
+func (f *fakeDB) executed(fragment string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []string
+	for _, query := range f.queries {
+		if strings.Contains(query, fragment) {
+			matched = append(matched, query)
+		}
+	}
+	return matched
+}
+
+// executedArgs 返回包含 fragment 的已执行 SQL 的参数
+func (f *fakeDB) executedArgs(fragment string) [][]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched [][]interface{}
+	for i, query := range f.queries {
+		if strings.Contains(query, fragment) {
+			matched = append(matched, f.args[i])
+		}
+	}
+	return matched
+}
+
+func (f *fakeDB) record(query string, named []driver.NamedValue) *fakeResult {
+	args := make([]interface{}, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, query)
+	f.args = append(f.args, args)
+	for fragment, result := range f.results {
+		if strings.Contains(query, fragment) {
+			return result
+		}
+	}
+	return &fakeResult{}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.record(query, args)
+	return &fakeRows{result: result}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	return fakeExecResult{}, nil
+}
+
+// fakeExecResult 写操作的结果：影响1行，新增记录的ID为1
+type fakeExecResult struct{}
+
+func (fakeExecResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeExecResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	result *fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+// fakeGroups 允许所有表权限、返回固定数据过滤条件的权限服务，其余方法未实现
+type fakeGroups struct {
+	groups.Service
+	filter map[string]interface{}
+}
+
+func (g *fakeGroups) CheckUserTablePermission(context.Context, uint, uint, int) (bool, error) {
+	return true, nil
+}
+
+func (g *fakeGroups) GetUserDataFilter(context.Context, uint, uint) (map[string]interface{}, error) {
+	return g.filter, nil
+}
+
+// fakeMetadata 返回固定表和字段定义的元数据服务，其余方法未实现
+type fakeMetadata struct {
+	metadata.Service
+	tables  map[string]*entity.SysTable  // 表名 -> 表
+	columns map[uint][]*entity.SysColumn // 表ID -> 字段
+}
+
+func (m *fakeMetadata) GetTable(tableName string) (*entity.SysTable, error) {
+	if table, ok := m.tables[tableName]; ok {
+		return table, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *fakeMetadata) GetColumns(tableID uint) ([]*entity.SysColumn, error) {
+	return m.columns[tableID], nil
+}
+
+// fakeMetadataRepo 按ID返回字段定义的元数据仓库，其余方法未实现
+type fakeMetadataRepo struct {
+	repository.MetadataRepository
+	columns map[uint]*entity.SysColumn // 字段ID -> 字段
+}
+
+func (r *fakeMetadataRepo) GetColumnByID(id uint) (*entity.SysColumn, error) {
+	return r.columns[id], nil
+}
+
+// GetTableCmdsByAction 没有配置钩子
+func (r *fakeMetadataRepo) GetTableCmdsByAction(uint, string, string) ([]*entity.SysTableCmd, error) {
+	return nil, nil
+}
+
+// fakeUserRepo 按ID返回用户的用户仓库，其余方法未实现
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uint]*entity.SysUser
+}
+
+func (r *fakeUserRepo) GetUserByID(id uint) (*entity.SysUser, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
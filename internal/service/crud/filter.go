@@ -0,0 +1,401 @@
+package crud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// 过滤操作符
+const (
+	OpEq         = "eq"         // 等于
+	OpNe         = "ne"         // 不等于
+	OpGt         = "gt"         // 大于
+	OpGte        = "gte"        // 大于等于
+	OpLt         = "lt"         // 小于
+	OpLte        = "lte"        // 小于等于
+	OpBetween    = "between"    // 区间（闭区间），value 为 [min, max]
+	OpIn         = "in"         // 在列表中，value 为数组
+	OpNotIn      = "notIn"      // 不在列表中，value 为数组
+	OpIsNull     = "isNull"     // 为空，value 为 false 时表示不为空
+	OpNotNull    = "notNull"    // 不为空
+	OpLike       = "like"       // 包含
+	OpStartsWith = "startsWith" // 以...开头
+	OpEndsWith   = "endsWith"   // 以...结尾
+)
+
+// 条件组关键字
+const (
+	FilterAnd = "$and" // 条件组：全部满足
+	FilterOr  = "$or"  // 条件组：任一满足
+)
+
+const (
+	maxFilterDepth = 5    // 条件组最大嵌套层数
+	maxInValues    = 1000 // IN 列表最大长度
+)
+
+// filterStandardFields 可直接过滤的系统字段（不一定在 sys_column 中定义）
+var filterStandardFields = map[string]bool{
+	"ID":             true,
+	"SYS_COMPANY_ID": true,
+	"CREATE_BY":      true,
+	"CREATE_TIME":    true,
+	"UPDATE_BY":      true,
+	"UPDATE_TIME":    true,
+	"IS_ACTIVE":      true,
+}
+
+// filterOperators 支持的操作符集合
+var filterOperators = map[string]bool{
+	OpEq: true, OpNe: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true,
+	OpBetween: true, OpIn: true, OpNotIn: true, OpIsNull: true, OpNotNull: true,
+	OpLike: true, OpStartsWith: true, OpEndsWith: true,
+}
+
+// filterBuilder 过滤条件编译器
+// 将 QueryRequest.Filters 编译为参数化的 WHERE 子句，字段名只允许来自 sys_column 白名单
+//
+// 支持的写法：
+//
+//	{"NAME": "abc"}                                    // 兼容旧写法：文本字段 LIKE，其他字段精确匹配
+//	{"STATUS": ["A", "B"]}                             // 数组等价于 in
+//	{"AMOUNT": {"op": "between", "value": [100, 200]}} // 显式操作符
+//	{"AMOUNT": {"gte": 100, "lt": 200}}                // 同一字段多个操作符（AND）
+//	{"$or": [{"NAME": {"op": "like", "value": "x"}}, {"CODE": {"op": "like", "value": "x"}}]}
+//
+// 数据权限和关联过滤条件（checkQueryable 为 false）按严格模式编译：空值、空条件组等无法生成条件的写法报错，
+// 不像用户查询条件那样忽略，避免配置错误时静默扩大可见范围
+type filterBuilder struct {
+	columns        map[string]*entity.SysColumn
+	checkQueryable bool // 是否校验 IS_QUERY（用户提交的过滤条件需要校验，数据权限过滤条件不校验）
+	strict         bool // 严格模式：条件无法生成时报错而不是忽略
+}
+
+// newFilterBuilder 创建过滤条件编译器（checkQueryable 为 false 时为严格模式）
+func newFilterBuilder(columns []*entity.SysColumn, checkQueryable bool) *filterBuilder {
+	columnMap := make(map[string]*entity.SysColumn, len(columns))
+	for _, col := range columns {
+		columnMap[col.DbName] = col
+	}
+	return &filterBuilder{
+		columns:        columnMap,
+		checkQueryable: checkQueryable,
+		strict:         !checkQueryable,
+	}
+}
+
+// Build 编译过滤条件，返回 WHERE 子句和参数；没有有效条件时返回空字符串（严格模式下非空的过滤条件必须生成条件）
+func (b *filterBuilder) Build(filters map[string]interface{}) (string, []interface{}, error) {
+	clause, args, err := b.buildGroup(filters, "AND", 0)
+	if err != nil {
+		return "", nil, err
+	}
+	if b.strict && clause == "" && len(filters) > 0 {
+		return "", nil, errors.New(errors.ErrInvalidParam, "过滤条件没有生成任何条件")
+	}
+	return clause, args, nil
+}
+
+// buildGroup 编译一组条件，组内条件使用 joiner 连接
+func (b *filterBuilder) buildGroup(filters map[string]interface{}, joiner string, depth int) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("过滤条件嵌套层数不能超过%d层", maxFilterDepth))
+	}
+
+	// 按键排序，保证生成的SQL稳定
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var clauses []string
+	var args []interface{}
+	for _, key := range keys {
+		value := filters[key]
+
+		var clause string
+		var clauseArgs []interface{}
+		var err error
+		switch key {
+		case FilterAnd, FilterOr:
+			clause, clauseArgs, err = b.buildLogical(key, value, depth)
+		default:
+			clause, clauseArgs, err = b.buildField(key, value)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if clause == "" {
+			continue
+		}
+		clauses = append(clauses, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return "", nil, nil
+	case 1:
+		return clauses[0], args, nil
+	default:
+		return "(" + strings.Join(clauses, " "+joiner+" ") + ")", args, nil
+	}
+}
+
+// buildLogical 编译 $and / $or 条件组
+func (b *filterBuilder) buildLogical(key string, value interface{}, depth int) (string, []interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 的值必须是条件数组", key))
+	}
+
+	joiner := "AND"
+	if key == FilterOr {
+		joiner = "OR"
+	}
+	if b.strict && len(items) == 0 {
+		return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 的条件数组不能为空", key))
+	}
+
+	var clauses []string
+	var args []interface{}
+	for _, item := range items {
+		group, ok := item.(map[string]interface{})
+		if !ok {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 的元素必须是条件对象", key))
+		}
+		if b.strict && len(group) == 0 {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 的条件对象不能为空", key))
+		}
+		clause, groupArgs, err := b.buildGroup(group, "AND", depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if clause == "" {
+			continue
+		}
+		clauses = append(clauses, clause)
+		args = append(args, groupArgs...)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return "", nil, nil
+	case 1:
+		return clauses[0], args, nil
+	default:
+		return "(" + strings.Join(clauses, " "+joiner+" ") + ")", args, nil
+	}
+}
+
+// buildField 编译单个字段的条件
+func (b *filterBuilder) buildField(field string, value interface{}) (string, []interface{}, error) {
+	col, err := b.resolveColumn(field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		// 兼容旧行为：nil 值忽略
+		if b.strict {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的过滤值为空", field))
+		}
+		return "", nil, nil
+	case []interface{}:
+		return b.buildCondition(field, OpIn, v)
+	case map[string]interface{}:
+		// 显式写法：{"op": "...", "value": ...}
+		if op, ok := v["op"]; ok {
+			opStr, ok := op.(string)
+			if !ok {
+				return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的操作符格式错误", field))
+			}
+			return b.buildCondition(field, opStr, v["value"])
+		}
+
+		// 简写：{"gte": 1, "lte": 5}
+		if b.strict && len(v) == 0 {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的过滤条件为空", field))
+		}
+		ops := make([]string, 0, len(v))
+		for op := range v {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+
+		var clauses []string
+		var args []interface{}
+		for _, op := range ops {
+			clause, opArgs, err := b.buildCondition(field, op, v[op])
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, clause)
+			args = append(args, opArgs...)
+		}
+		if len(clauses) == 0 {
+			return "", nil, nil
+		}
+		if len(clauses) == 1 {
+			return clauses[0], args, nil
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")", args, nil
+	default:
+		// 兼容旧行为：文本字段模糊匹配，其他字段精确匹配
+		if col != nil && isTextDisplayType(col.DisplayType) {
+			strValue, ok := v.(string)
+			if !ok || strValue == "" {
+				if b.strict {
+					return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的过滤值无效", field))
+				}
+				return "", nil, nil
+			}
+			return b.buildCondition(field, OpLike, strValue)
+		}
+		return b.buildCondition(field, OpEq, v)
+	}
+}
+
+// buildCondition 编译单个操作符条件
+func (b *filterBuilder) buildCondition(field, op string, value interface{}) (string, []interface{}, error) {
+	if !filterOperators[op] {
+		return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 不支持的操作符: %s", field, op))
+	}
+
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		if err := checkScalar(field, op, value); err != nil {
+			return "", nil, err
+		}
+		if value == nil {
+			// eq null / ne null 转换为 IS NULL / IS NOT NULL
+			switch op {
+			case OpEq:
+				return field + " IS NULL", nil, nil
+			case OpNe:
+				return field + " IS NOT NULL", nil, nil
+			default:
+				return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 %s 操作符不能使用空值", field, op))
+			}
+		}
+		return fmt.Sprintf("%s %s ?", field, comparisonSQL[op]), []interface{}{value}, nil
+
+	case OpBetween:
+		items, ok := value.([]interface{})
+		if !ok || len(items) != 2 {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 between 需要 [最小值, 最大值]", field))
+		}
+		for _, item := range items {
+			if item == nil {
+				return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 between 边界不能为空", field))
+			}
+			if err := checkScalar(field, op, item); err != nil {
+				return "", nil, err
+			}
+		}
+		return field + " BETWEEN ? AND ?", []interface{}{items[0], items[1]}, nil
+
+	case OpIn, OpNotIn:
+		items, ok := value.([]interface{})
+		if !ok {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 %s 需要数组", field, op))
+		}
+		if len(items) > maxInValues {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 %s 列表不能超过%d项", field, op, maxInValues))
+		}
+		for _, item := range items {
+			if err := checkScalar(field, op, item); err != nil {
+				return "", nil, err
+			}
+		}
+		if len(items) == 0 {
+			// 空列表：in 恒假，notIn 恒真
+			if op == OpIn {
+				return "1 = 0", nil, nil
+			}
+			return "", nil, nil
+		}
+		if op == OpIn {
+			return field + " IN ?", []interface{}{items}, nil
+		}
+		return field + " NOT IN ?", []interface{}{items}, nil
+
+	case OpIsNull:
+		if flag, ok := value.(bool); ok && !flag {
+			return field + " IS NOT NULL", nil, nil
+		}
+		return field + " IS NULL", nil, nil
+
+	case OpNotNull:
+		return field + " IS NOT NULL", nil, nil
+
+	default: // OpLike, OpStartsWith, OpEndsWith
+		strValue, ok := value.(string)
+		if !ok {
+			return "", nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 %s 需要字符串", field, op))
+		}
+		pattern := escapeLike(strValue)
+		switch op {
+		case OpStartsWith:
+			pattern = pattern + "%"
+		case OpEndsWith:
+			pattern = "%" + pattern
+		default:
+			pattern = "%" + pattern + "%"
+		}
+		return field + " LIKE ?", []interface{}{pattern}, nil
+	}
+}
+
+// resolveColumn 校验过滤字段，返回字段定义（系统字段返回 nil）
+func (b *filterBuilder) resolveColumn(field string) (*entity.SysColumn, error) {
+	col, exists := b.columns[field]
+	if !exists {
+		if filterStandardFields[field] {
+			return nil, nil
+		}
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("过滤字段不存在: %s", field))
+	}
+
+	if b.checkQueryable && col.IsQuery != "Y" && !filterStandardFields[field] {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段不允许作为查询条件: %s", field))
+	}
+
+	return col, nil
+}
+
+// comparisonSQL 比较操作符对应的SQL
+var comparisonSQL = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// checkScalar 校验操作数为标量（禁止嵌套对象或数组）
+func checkScalar(field, op string, value interface{}) error {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("字段 %s 的 %s 操作数必须是单个值", field, op))
+	}
+	return nil
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// isTextDisplayType 是否为文本类显示类型（旧写法下使用模糊匹配）
+func isTextDisplayType(displayType string) bool {
+	return displayType == "text" || displayType == "textarea" || displayType == "clob"
+}
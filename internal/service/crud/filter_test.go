@@ -0,0 +1,176 @@
+package crud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func testFilterColumns() []*entity.SysColumn {
+	return []*entity.SysColumn{
+		{DbName: "NAME", DisplayType: "text", IsQuery: "Y"},
+		{DbName: "CODE", DisplayType: "text", IsQuery: "Y"},
+		{DbName: "AMOUNT", DisplayType: "text", ColType: "decimal", IsQuery: "Y"},
+		{DbName: "STATUS", DisplayType: "select", IsQuery: "Y"},
+		{DbName: "SECRET", DisplayType: "text", IsQuery: "N"},
+	}
+}
+
+func TestFilterBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  map[string]interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "旧写法-文本字段模糊匹配",
+			filters:  map[string]interface{}{"NAME": "ab%c"},
+			wantSQL:  "NAME LIKE ?",
+			wantArgs: []interface{}{`%ab\%c%`},
+		},
+		{
+			name:     "旧写法-非文本字段精确匹配",
+			filters:  map[string]interface{}{"STATUS": "A"},
+			wantSQL:  "STATUS = ?",
+			wantArgs: []interface{}{"A"},
+		},
+		{
+			name:     "数组等价于in",
+			filters:  map[string]interface{}{"STATUS": []interface{}{"A", "B"}},
+			wantSQL:  "STATUS IN ?",
+			wantArgs: []interface{}{[]interface{}{"A", "B"}},
+		},
+		{
+			name:     "between",
+			filters:  map[string]interface{}{"AMOUNT": map[string]interface{}{"op": "between", "value": []interface{}{100.0, 200.0}}},
+			wantSQL:  "AMOUNT BETWEEN ? AND ?",
+			wantArgs: []interface{}{100.0, 200.0},
+		},
+		{
+			name:     "同一字段多个操作符",
+			filters:  map[string]interface{}{"AMOUNT": map[string]interface{}{"gte": 1.0, "lt": 5.0}},
+			wantSQL:  "(AMOUNT >= ? AND AMOUNT < ?)",
+			wantArgs: []interface{}{1.0, 5.0},
+		},
+		{
+			name:     "isNull",
+			filters:  map[string]interface{}{"CODE": map[string]interface{}{"op": "isNull"}},
+			wantSQL:  "CODE IS NULL",
+			wantArgs: nil,
+		},
+		{
+			name: "or条件组",
+			filters: map[string]interface{}{
+				"STATUS": "A",
+				"$or": []interface{}{
+					map[string]interface{}{"NAME": map[string]interface{}{"op": "startsWith", "value": "x"}},
+					map[string]interface{}{"CODE": map[string]interface{}{"op": "like", "value": "x"}},
+				},
+			},
+			wantSQL:  "((NAME LIKE ? OR CODE LIKE ?) AND STATUS = ?)",
+			wantArgs: []interface{}{"x%", "%x%", "A"},
+		},
+		{
+			name:     "系统字段",
+			filters:  map[string]interface{}{"CREATE_TIME": map[string]interface{}{"op": "gte", "value": "2026-01-01"}},
+			wantSQL:  "CREATE_TIME >= ?",
+			wantArgs: []interface{}{"2026-01-01"},
+		},
+		{
+			name:     "nil值忽略",
+			filters:  map[string]interface{}{"NAME": nil},
+			wantSQL:  "",
+			wantArgs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := newFilterBuilder(testFilterColumns(), true).Build(tt.filters)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("Build() sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestFilterBuilder_Reject(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+	}{
+		{"未定义字段", map[string]interface{}{"1=1; DROP TABLE x": "a"}},
+		{"不可查询字段", map[string]interface{}{"SECRET": "a"}},
+		{"未知操作符", map[string]interface{}{"AMOUNT": map[string]interface{}{"op": "regexp", "value": "a"}}},
+		{"between参数个数错误", map[string]interface{}{"AMOUNT": map[string]interface{}{"op": "between", "value": []interface{}{1.0}}}},
+		{"in非数组", map[string]interface{}{"STATUS": map[string]interface{}{"op": "in", "value": "A"}}},
+		{"嵌套对象操作数", map[string]interface{}{"STATUS": map[string]interface{}{"op": "eq", "value": map[string]interface{}{}}}},
+		{"or非数组", map[string]interface{}{"$or": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newFilterBuilder(testFilterColumns(), true).Build(tt.filters)
+			if err == nil {
+				t.Fatal("Build() expected error")
+			}
+			if errors.GetCode(err) != errors.ErrInvalidParam {
+				t.Errorf("Build() error code = %d, want %d", errors.GetCode(err), errors.ErrInvalidParam)
+			}
+		})
+	}
+}
+
+func TestFilterBuilder_DataFilterSkipsQueryableCheck(t *testing.T) {
+	sql, _, err := newFilterBuilder(testFilterColumns(), false).Build(map[string]interface{}{"SECRET": "a"})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if sql != "SECRET LIKE ?" {
+		t.Errorf("Build() sql = %q", sql)
+	}
+}
+
+func TestFilterBuilder_DataFilterStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+	}{
+		{"空值", map[string]interface{}{"STATUS": nil}},
+		{"空文本", map[string]interface{}{"NAME": ""}},
+		{"文本字段非字符串值", map[string]interface{}{"NAME": 1.0}},
+		{"空操作符对象", map[string]interface{}{"AMOUNT": map[string]interface{}{}}},
+		{"空or数组", map[string]interface{}{"$or": []interface{}{}}},
+		{"or中的空对象", map[string]interface{}{"$or": []interface{}{map[string]interface{}{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newFilterBuilder(testFilterColumns(), false).Build(tt.filters)
+			if err == nil {
+				t.Fatal("Build() expected error")
+			}
+			if errors.GetCode(err) != errors.ErrInvalidParam {
+				t.Errorf("Build() error code = %d, want %d", errors.GetCode(err), errors.ErrInvalidParam)
+			}
+
+			// 用户查询条件保持宽松：无法生成条件时忽略
+			sql, _, err := newFilterBuilder(testFilterColumns(), true).Build(tt.filters)
+			if err != nil {
+				t.Fatalf("Build() lenient error = %v", err)
+			}
+			if sql != "" {
+				t.Errorf("Build() lenient sql = %q, want empty", sql)
+			}
+		})
+	}
+}
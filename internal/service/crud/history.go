@@ -0,0 +1,511 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/mask"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 记录变更类型（SysRecordChange.Action）
+const (
+	ChangeUpdate  = "update"  // 修改
+	ChangeDelete  = "delete"  // 删除
+	ChangeRestore = "restore" // 恢复到历史版本，或从回收站恢复
+	ChangePurge   = "purge"   // 从回收站彻底删除
+
+	ChangeSubmit   = "submit"   // 提交单据
+	ChangeUnsubmit = "unsubmit" // 反提交单据
+	ChangeVoid     = "void"     // 作废单据
+)
+
+// untrackedFields 由服务端维护、不记入字段变更也不参与恢复的字段
+var untrackedFields = map[string]bool{
+	"ID":             true,
+	"SYS_COMPANY_ID": true,
+	"CREATE_BY":      true,
+	"CREATE_TIME":    true,
+	"UPDATE_BY":      true,
+	"UPDATE_TIME":    true,
+	"IS_ACTIVE":      true,
+	ColVersion:       true,
+}
+
+// FieldChange 字段变更
+type FieldChange struct {
+	Column      string      `json:"column"`
+	DisplayName string      `json:"displayName"`
+	Old         interface{} `json:"old"`
+	New         interface{} `json:"new"`
+}
+
+// RecordChange 记录变更历史条目
+type RecordChange struct {
+	ID       uint           `json:"id"`
+	Action   string         `json:"action"` // update, delete, restore, purge, submit, unsubmit, void
+	UserID   uint           `json:"userId"`
+	Username string         `json:"username"`
+	Time     time.Time      `json:"time"`
+	Changes  []*FieldChange `json:"changes"`
+}
+
+// ListChanges 查询记录的变更历史（按时间倒序，不含 MASK 不可见的字段）
+// 记录须在用户的数据权限范围内（含回收站中的记录）
+func (s *service) ListChanges(ctx context.Context, tableName string, id uint, userID uint) ([]*RecordChange, error) {
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, groups.PermRead)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return nil, errors.New(errors.ErrPermissionDenied, "无查询权限")
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRecordScope(ctx, s.db.WithContext(ctx), table, columns, id, userID); err != nil {
+		return nil, err
+	}
+
+	hidden := make(map[string]bool)
+	for _, col := range columns {
+		if col.Mask != "" && !mask.ParseMask(col.Mask).IsVisible("edit") {
+			hidden[col.DbName] = true
+		}
+	}
+
+	var rows []*entity.SysRecordChange
+	if err := s.db.WithContext(ctx).
+		Where("SYS_TABLE_ID = ? AND RECORD_ID = ? AND IS_ACTIVE = ?", table.ID, id, "Y").
+		Order("ID DESC").
+		Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询变更历史失败", err)
+	}
+
+	result := make([]*RecordChange, 0, len(rows))
+	for _, row := range rows {
+		var changes []*FieldChange
+		if row.Changes != "" {
+			if err := json.Unmarshal([]byte(row.Changes), &changes); err != nil {
+				return nil, errors.Wrap(errors.ErrInternal, "解析变更历史失败", err)
+			}
+		}
+		visible := make([]*FieldChange, 0, len(changes))
+		for _, change := range changes {
+			if !hidden[change.Column] {
+				visible = append(visible, change)
+			}
+		}
+		result = append(result, &RecordChange{
+			ID:       row.ID,
+			Action:   row.Action,
+			UserID:   row.UserID,
+			Username: row.CreateBy,
+			Time:     row.CreateTime,
+			Changes:  visible,
+		})
+	}
+
+	return result, nil
+}
+
+// RestoreRecord 将记录恢复到某次变更之前的状态
+// 记录仍存在时按修改处理：须在数据权限范围内，已提交的单据不能恢复，只恢复 MASK 可修改的字段，单据状态、审批字段和密码不恢复，
+// 恢复的值按字段定义校验；记录已删除时按新增处理重新插入（见 restoreDeleted）
+// 恢复本身也记入变更历史
+func (s *service) RestoreRecord(ctx context.Context, tableName string, id, changeID uint, userID uint) error {
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, groups.PermUpdate)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return errors.New(errors.ErrPermissionDenied, "无修改权限")
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+
+	var change entity.SysRecordChange
+	if err := s.db.WithContext(ctx).
+		Where("ID = ? AND SYS_TABLE_ID = ? AND RECORD_ID = ? AND IS_ACTIVE = ?", changeID, table.ID, id, "Y").
+		Take(&change).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrResourceNotFound, "变更历史不存在")
+		}
+		return errors.Wrap(errors.ErrDatabase, "查询变更历史失败", err)
+	}
+
+	// 大整数按原值恢复
+	var snapshot map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(change.Snapshot))
+	decoder.UseNumber()
+	if err := decoder.Decode(&snapshot); err != nil || len(snapshot) == 0 {
+		return errors.New(errors.ErrValidation, "变更历史没有可恢复的记录")
+	}
+
+	username := s.username(userID)
+
+	return transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		var current map[string]interface{}
+		err := tx.Table(table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ID = ?", id).
+			Take(&current).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return errors.Wrap(errors.ErrDatabase, "查询失败", err)
+		}
+
+		if err == gorm.ErrRecordNotFound {
+			return s.restoreDeleted(ctx, tx, table, columns, id, snapshot, username, userID)
+		}
+		if err := s.checkRecordScope(ctx, tx, table, columns, id, userID); err != nil {
+			return err
+		}
+		if includeKeyString(current["IS_ACTIVE"]) != "Y" {
+			return errors.New(errors.ErrResourceConflict, "记录在回收站中，请先从回收站恢复")
+		}
+
+		if err := s.checkDocEditable(tx, table, []uint{id}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		values := snapshotValues(columns, snapshot, "edit")
+		if isDocumentTable(table) {
+			removeLifecycleFields(values)
+		}
+		values["UPDATE_BY"] = username
+		values["UPDATE_TIME"] = now
+		fillServerValues(columns, values, username, userID, false, now)
+		if hasColumn(columns, ColVersion) {
+			version, _ := toInt64(current[ColVersion])
+			values[ColVersion] = version + 1
+		}
+
+		if err := s.validateFields(tx, table, columns, values, id); err != nil {
+			return err
+		}
+		if err := s.finishAutoValues(columns, values, false); err != nil {
+			return err
+		}
+
+		fields := make([]string, 0, len(values))
+		for field := range values {
+			fields = append(fields, field)
+		}
+
+		values["ID"] = id
+		if err := s.executeHooksInTx(ctx, tx, table.ID, "M", "begin", values); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
+		}
+		if err := tx.Table(table.Name).Where("ID = ?", id).Select(fields).Updates(values).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "恢复失败", err)
+		}
+		if err := s.recordChange(tx, table, columns, id, ChangeRestore, current, values, userID); err != nil {
+			return err
+		}
+		if err := s.executeHooksInTx(ctx, tx, table.ID, "M", "end", values); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行after钩子失败", err)
+		}
+
+		return nil
+	})
+}
+
+// restoreDeleted 按变更前的记录重新插入已删除的记录（需要创建权限）
+// 按新增处理：只恢复 MASK 新增可修改的字段，单据恢复为未提交状态，密码不恢复，恢复的值按字段定义校验；
+// 保留原ID、创建人和创建时间，插入后的记录须在用户的数据权限范围内
+func (s *service) restoreDeleted(ctx context.Context, tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, id uint, snapshot map[string]interface{}, username string, userID uint) error {
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, groups.PermCreate)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return errors.New(errors.ErrPermissionDenied, "无创建权限")
+	}
+
+	now := time.Now()
+	values := snapshotValues(columns, snapshot, "add")
+	if isDocumentTable(table) {
+		removeLifecycleFields(values)
+		values[ColDocStatus] = DocStatusDraft
+	}
+	fillServerValues(columns, values, username, userID, true, now)
+	for _, col := range columns {
+		if v, ok := snapshot[col.DbName]; ok && v != nil && col.SetValueType == SetValueCreateBy {
+			values[col.DbName] = v
+		}
+	}
+	for _, field := range []string{"SYS_COMPANY_ID", "CREATE_BY", "CREATE_TIME"} {
+		if v, ok := snapshot[field]; ok {
+			values[field] = v
+		}
+	}
+	values["ID"] = id
+	values["IS_ACTIVE"] = "Y"
+	values["UPDATE_BY"] = username
+	values["UPDATE_TIME"] = now
+	if hasColumn(columns, ColVersion) {
+		version, _ := toInt64(snapshot[ColVersion])
+		values[ColVersion] = version + 1
+	}
+
+	// 按外键删除动作检查引用的父记录
+	if err := s.checkParentRefs(tx, columns, values, values); err != nil {
+		return err
+	}
+	if err := s.validateFields(tx, table, columns, values, 0); err != nil {
+		return err
+	}
+	if err := s.finishAutoValues(columns, values, true); err != nil {
+		return err
+	}
+
+	if err := s.executeHooksInTx(ctx, tx, table.ID, "A", "begin", values); err != nil {
+		return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
+	}
+	if err := tx.Table(table.Name).Create(&values).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "恢复失败", err)
+	}
+	// 记录已删除时无法事先判断数据权限，插入后检查，不在范围内时回滚
+	if err := s.checkRecordScope(ctx, tx, table, columns, id, userID); err != nil {
+		return err
+	}
+	if err := s.recordChange(tx, table, columns, id, ChangeRestore, nil, values, userID); err != nil {
+		return err
+	}
+	if err := s.executeHooksInTx(ctx, tx, table.ID, "A", "end", values); err != nil {
+		return errors.Wrap(errors.ErrInternal, "执行after钩子失败", err)
+	}
+
+	return nil
+}
+
+// checkRecordScope 检查记录（含回收站中的记录）在用户的数据权限范围内，不在范围内时按记录不存在处理
+// 记录已物理删除时无法判断，有数据权限限制的用户同样按记录不存在处理
+func (s *service) checkRecordScope(ctx context.Context, db *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, id uint, userID uint) error {
+	dataFilter, err := s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+	if len(dataFilter) == 0 {
+		return nil
+	}
+
+	query, err := s.applyFilters(db.Table(table.Name).Where("ID = ?", id), dataFilter, columns, false)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+	if count == 0 {
+		return errors.New(errors.ErrResourceNotFound, "记录不存在")
+	}
+	return nil
+}
+
+// snapshotValues 从变更前的记录中取出可恢复的字段：按 MASK 可修改（operation 为 add 或 edit），
+// 不含服务端维护的字段和密码
+func snapshotValues(columns []*entity.SysColumn, snapshot map[string]interface{}, operation string) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, col := range columns {
+		if untrackedFields[col.DbName] || col.SetValueType == SetValuePassword {
+			continue
+		}
+		if col.Mask != "" && !mask.ParseMask(col.Mask).IsEditable(operation) {
+			continue
+		}
+		if v, ok := snapshot[col.DbName]; ok {
+			values[col.DbName] = v
+		}
+	}
+	return values
+}
+
+// lockRecord 在事务中锁定并读取当前记录
+func (s *service) lockRecord(tx *gorm.DB, table *entity.SysTable, id uint) (map[string]interface{}, error) {
+	var record map[string]interface{}
+	if err := tx.Table(table.Name).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ID = ? AND IS_ACTIVE = ?", id, "Y").
+		Take(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "记录不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+	return record, nil
+}
+
+// recordChange 记录字段变更和变更前的完整记录（after 为空表示删除；没有字段变化的修改不记录）
+func (s *service) recordChange(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, id uint, action string, before, after map[string]interface{}, userID uint) error {
+	_, err := s.logChange(tx, table, columns, id, action, before, after, userID, 0)
+	return err
+}
+
+// logChange 记录变更历史，返回历史ID（未记录时为0）
+// causeID 为引发本次变更的历史ID（级联删除、置空时为父记录的删除历史）
+func (s *service) logChange(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, id uint, action string, before, after map[string]interface{}, userID, causeID uint) (uint, error) {
+	changes := diffRecord(columns, before, after)
+	if len(changes) == 0 && action == ChangeUpdate {
+		return 0, nil
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrInternal, "序列化字段变更失败", err)
+	}
+	var snapshotJSON []byte
+	if before != nil {
+		if snapshotJSON, err = json.Marshal(snapshotRecord(columns, before)); err != nil {
+			return 0, errors.Wrap(errors.ErrInternal, "序列化变更前记录失败", err)
+		}
+	}
+
+	row := &entity.SysRecordChange{
+		SysTableID: table.ID,
+		RecordID:   id,
+		Action:     action,
+		UserID:     userID,
+		CauseID:    causeID,
+		Changes:    string(changesJSON),
+		Snapshot:   string(snapshotJSON),
+	}
+	row.IsActive = "Y"
+	if user, userErr := s.userRepo.GetUserByID(userID); userErr == nil && user != nil {
+		row.CreateBy = user.Username
+		row.SysCompanyID = user.SysCompanyID
+	}
+
+	if err := tx.Create(row).Error; err != nil {
+		return 0, errors.Wrap(errors.ErrDatabase, "记录变更历史失败", err)
+	}
+	return row.ID, nil
+}
+
+// snapshotRecord 写入变更历史的变更前记录（不含密码）
+func snapshotRecord(columns []*entity.SysColumn, before map[string]interface{}) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(before))
+	for k, v := range before {
+		snapshot[k] = normalizeValue(v)
+	}
+	for _, col := range columns {
+		if col.SetValueType == SetValuePassword {
+			delete(snapshot, col.DbName)
+		}
+	}
+	return snapshot
+}
+
+// diffRecord 按字段定义顺序比较变更前后的值
+// after 为空时（删除）列出变更前所有非空字段
+func diffRecord(columns []*entity.SysColumn, before, after map[string]interface{}) []*FieldChange {
+	changes := make([]*FieldChange, 0)
+	for _, col := range columns {
+		if untrackedFields[col.DbName] {
+			continue
+		}
+
+		old := normalizeValue(before[col.DbName])
+		var value interface{}
+		if after != nil {
+			v, ok := after[col.DbName]
+			if !ok {
+				continue
+			}
+			value = normalizeValue(v)
+		}
+		if sameValue(old, value) {
+			continue
+		}
+		if col.SetValueType == SetValuePassword {
+			old, value = maskPassword(old), maskPassword(value)
+		}
+
+		displayName := col.DisplayName
+		if displayName == "" {
+			displayName = col.DbName
+		}
+		changes = append(changes, &FieldChange{Column: col.DbName, DisplayName: displayName, Old: old, New: value})
+	}
+	return changes
+}
+
+// maskPassword 变更历史中不记录密码
+func maskPassword(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return passwordMask
+}
+
+// normalizeValue 统一数据库值的表示（[]byte 转字符串，时间按秒格式化）
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return nil
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	}
+	return value
+}
+
+// sameValue 比较两个字段值（数字按数值比较，时间按秒比较）
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	if sa == sb {
+		return true
+	}
+	if fa, err := strconv.ParseFloat(sa, 64); err == nil {
+		if fb, err := strconv.ParseFloat(sb, 64); err == nil {
+			return fa == fb
+		}
+	}
+	if ta, ok := toTime(sa); ok && !ta.IsZero() {
+		if tb, ok := toTime(sb); ok && !tb.IsZero() {
+			return ta.Unix() == tb.Unix()
+		}
+	}
+	return false
+}
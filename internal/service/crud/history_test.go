@@ -0,0 +1,86 @@
+package crud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestDiffRecord(t *testing.T) {
+	columns := []*entity.SysColumn{
+		{DbName: "ID"},
+		{DbName: "NAME", DisplayName: "名称"},
+		{DbName: "AMOUNT", DisplayName: "金额"},
+		{DbName: "DUE_DATE", DisplayName: "到期日"},
+		{DbName: "REMARK"},
+		{DbName: "UPDATE_TIME"},
+	}
+	due := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	before := map[string]interface{}{
+		"ID":          int64(7),
+		"NAME":        []byte("旧名称"),
+		"AMOUNT":      "12.50",
+		"DUE_DATE":    due,
+		"REMARK":      nil,
+		"UPDATE_TIME": due,
+	}
+
+	// 提交的值与原值相同（数字、时间格式不同）时不算变更
+	after := map[string]interface{}{
+		"NAME":        "新名称",
+		"AMOUNT":      12.5,
+		"DUE_DATE":    "2026-10-16 00:00:00",
+		"REMARK":      "备注",
+		"UPDATE_TIME": time.Now(),
+	}
+	changes := diffRecord(columns, before, after)
+	if len(changes) != 2 {
+		t.Fatalf("diffRecord() = %d changes, want 2: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.Column != "NAME" || c.DisplayName != "名称" || c.Old != "旧名称" || c.New != "新名称" {
+		t.Errorf("changes[0] = %+v", c)
+	}
+	if c := changes[1]; c.Column != "REMARK" || c.DisplayName != "REMARK" || c.Old != nil || c.New != "备注" {
+		t.Errorf("changes[1] = %+v", c)
+	}
+
+	// 删除时列出所有非空字段
+	changes = diffRecord(columns, before, nil)
+	if len(changes) != 3 {
+		t.Fatalf("diffRecord(delete) = %d changes, want 3: %+v", len(changes), changes)
+	}
+	if c := changes[2]; c.Column != "DUE_DATE" || c.Old != "2026-10-16 00:00:00" || c.New != nil {
+		t.Errorf("changes[2] = %+v", c)
+	}
+}
+
+func TestSnapshotExcludesPasswords(t *testing.T) {
+	columns := []*entity.SysColumn{
+		{DbName: "ID"},
+		{DbName: "NAME"},
+		{DbName: "STATUS", Mask: "1010100000"},
+		{DbName: "PASSWORD", SetValueType: SetValuePassword},
+	}
+	before := map[string]interface{}{
+		"ID":       int64(7),
+		"NAME":     []byte("张三"),
+		"STATUS":   "1",
+		"PASSWORD": "$2a$10$hash",
+	}
+
+	snapshot := snapshotRecord(columns, before)
+	if _, ok := snapshot["PASSWORD"]; ok {
+		t.Errorf("snapshot contains password: %+v", snapshot)
+	}
+	if snapshot["NAME"] != "张三" {
+		t.Errorf("snapshot NAME = %v", snapshot["NAME"])
+	}
+
+	// 恢复时只取 MASK 可修改的字段，服务端维护的字段和密码不恢复
+	snapshot["PASSWORD"] = "$2a$10$hash"
+	values := snapshotValues(columns, snapshot, "edit")
+	if len(values) != 1 || values["NAME"] != "张三" {
+		t.Errorf("snapshotValues(edit) = %+v", values)
+	}
+}
@@ -0,0 +1,280 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+)
+
+// IncludeKey 关联数据在记录中的键名
+// 关联数据统一放在该键下，避免与业务字段重名
+const IncludeKey = "_includes"
+
+// maxIncludes 单次查询允许的关联数量
+const maxIncludes = 10
+
+// loadIncludes 加载关联数据并嵌入到记录中
+// include 中的每一项可以是：
+//   - 子表表名：通过 sys_table_ref 解析（ASSOCTYPE=1 返回对象，n 返回数组）
+//   - 外键字段名：通过 sys_column.REF_TABLE_ID 解析，返回被引用的父记录
+//
+// 关联表同样执行表权限检查、数据权限过滤和 MASK 字段控制
+func (s *service) loadIncludes(ctx context.Context, table *entity.SysTable, columns []*entity.SysColumn, rows []map[string]interface{}, include []string, userID uint) error {
+	if len(include) == 0 || len(rows) == 0 {
+		return nil
+	}
+	if len(include) > maxIncludes {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("关联数量不能超过%d个", maxIncludes))
+	}
+
+	refs, err := s.metadataService.GetTableRefs(table.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		row[IncludeKey] = make(map[string]interface{}, len(include))
+	}
+
+	for _, name := range include {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		// 优先按子表表名匹配 sys_table_ref
+		ref, refTable, err := s.findTableRef(refs, name)
+		if err != nil {
+			return err
+		}
+		if ref != nil {
+			if err := s.includeChildren(ctx, ref, refTable, rows, name, userID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 其次按外键字段匹配
+		var fkColumn *entity.SysColumn
+		for _, col := range columns {
+			if col.DbName == name && col.RefTableID != nil {
+				fkColumn = col
+				break
+			}
+		}
+		if fkColumn != nil {
+			if err := s.includeParent(ctx, fkColumn, rows, name, userID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的关联: %s", name))
+	}
+
+	return nil
+}
+
+// findTableRef 根据子表表名查找关联关系
+func (s *service) findTableRef(refs []*entity.SysTableRef, name string) (*entity.SysTableRef, *entity.SysTable, error) {
+	for _, ref := range refs {
+		refTable, err := s.metadataService.GetTableByID(uint(ref.RefTableID))
+		if err != nil {
+			return nil, nil, err
+		}
+		if refTable.Name == name {
+			return ref, refTable, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// includeChildren 加载子表数据（sys_table_ref）
+func (s *service) includeChildren(ctx context.Context, ref *entity.SysTableRef, refTable *entity.SysTable, rows []map[string]interface{}, name string, userID uint) error {
+	// REF_COLUMN_ID 为子表中指向主表的外键字段
+	fkColumn, err := s.metadataRepo.GetColumnByID(uint(ref.RefColumnID))
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, fmt.Sprintf("关联 %s 的外键字段不存在", name), err)
+	}
+
+	parentIDs := collectIncludeKeys(rows, "ID")
+	children, keyVisible, err := s.queryRelatedRows(ctx, refTable, userID, fkColumn.DbName, parentIDs, ref.Filter)
+	if err != nil {
+		return err
+	}
+
+	grouped := make(map[string][]map[string]interface{})
+	for _, child := range children {
+		key := includeKeyString(child[fkColumn.DbName])
+		grouped[key] = append(grouped[key], child)
+		// 外键字段 MASK 不可见时只用于分组，不返回
+		if !keyVisible {
+			delete(child, fkColumn.DbName)
+		}
+	}
+
+	for _, row := range rows {
+		matched := grouped[includeKeyString(row["ID"])]
+		includes := row[IncludeKey].(map[string]interface{})
+		if ref.AssocType == "1" {
+			if len(matched) > 0 {
+				includes[name] = matched[0]
+			} else {
+				includes[name] = nil
+			}
+			continue
+		}
+		if matched == nil {
+			matched = []map[string]interface{}{}
+		}
+		includes[name] = matched
+	}
+
+	return nil
+}
+
+// includeParent 加载外键引用的父记录（sys_column.REF_TABLE_ID）
+func (s *service) includeParent(ctx context.Context, fkColumn *entity.SysColumn, rows []map[string]interface{}, name string, userID uint) error {
+	// 外键字段本身必须在主查询中可见（受 MASK 控制）
+	if _, visible := rows[0][fkColumn.DbName]; !visible {
+		return errors.New(errors.ErrPermissionDenied, fmt.Sprintf("关联字段不可见: %s", name))
+	}
+
+	refTable, err := s.metadataService.GetTableByID(*fkColumn.RefTableID)
+	if err != nil {
+		return err
+	}
+
+	fkValues := collectIncludeKeys(rows, fkColumn.DbName)
+	parents, _, err := s.queryRelatedRows(ctx, refTable, userID, "ID", fkValues, "")
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]map[string]interface{}, len(parents))
+	for _, parent := range parents {
+		byID[includeKeyString(parent["ID"])] = parent
+	}
+
+	for _, row := range rows {
+		includes := row[IncludeKey].(map[string]interface{})
+		if parent, ok := byID[includeKeyString(row[fkColumn.DbName])]; ok {
+			includes[name] = parent
+		} else {
+			includes[name] = nil
+		}
+	}
+
+	return nil
+}
+
+// queryRelatedRows 按键值批量查询关联表记录，并返回关联键是否为 MASK 可见字段
+// 与主查询使用相同的权限规则：表读权限、数据权限过滤、MASK 列表可见字段；
+// 关联键不可见时仍会查询用于回填，调用方回填后应从记录中删除
+func (s *service) queryRelatedRows(ctx context.Context, table *entity.SysTable, userID uint, keyColumn string, keys []interface{}, refFilter string) ([]map[string]interface{}, bool, error) {
+	if len(keys) == 0 {
+		return nil, true, nil
+	}
+
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, groups.PermRead)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return nil, false, errors.New(errors.ErrPermissionDenied, fmt.Sprintf("无关联表查询权限: %s", table.Name))
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	selectFields, err := s.buildSelectFields(columns, userID, "list")
+	if err != nil {
+		return nil, false, err
+	}
+	// 关联键用于回填，始终查询
+	keyVisible := containsField(selectFields, keyColumn)
+	if !keyVisible {
+		selectFields = selectFields + ", " + keyColumn
+	}
+
+	dataFilter, err := s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+
+	query := s.db.WithContext(ctx).Table(table.Name).Select(selectFields)
+	if len(dataFilter) > 0 {
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, false, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+	}
+	// sys_table_ref.FILTER 为 JSON 格式的附加过滤条件
+	query, err = s.applyDataFilter(query, refFilter, columns)
+	if err != nil {
+		return nil, false, errors.Wrap(errors.ErrInternal, "关联过滤条件无效", err)
+	}
+
+	var results []map[string]interface{}
+	if err := query.
+		Where(fmt.Sprintf("%s IN ?", keyColumn), keys).
+		Where("IS_ACTIVE = ?", "Y").
+		Order("ID ASC").
+		Find(&results).Error; err != nil {
+		return nil, false, errors.Wrap(errors.ErrDatabase, fmt.Sprintf("查询关联表 %s 失败", table.Name), err)
+	}
+
+	return results, keyVisible, nil
+}
+
+// collectIncludeKeys 收集记录中指定字段的去重非空值
+func collectIncludeKeys(rows []map[string]interface{}, field string) []interface{} {
+	seen := make(map[string]bool, len(rows))
+	keys := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		value := row[field]
+		key := includeKeyString(value)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// includeKeyString 将数据库返回的键值统一转换为字符串，用于匹配
+func includeKeyString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// containsField 检查逗号分隔的字段列表中是否包含指定字段
+func containsField(fields, field string) bool {
+	if fields == "*" {
+		return true
+	}
+	for _, f := range strings.Split(fields, ",") {
+		if strings.TrimSpace(f) == field {
+			return true
+		}
+	}
+	return false
+}
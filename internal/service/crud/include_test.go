@@ -0,0 +1,102 @@
+package crud
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestCollectIncludeKeys(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"PARENT_ID": int64(1)},
+		{"PARENT_ID": []byte("2")},
+		{"PARENT_ID": float64(1)},
+		{"PARENT_ID": nil},
+		{},
+	}
+	want := []interface{}{"1", "2"}
+	if got := collectIncludeKeys(rows, "PARENT_ID"); !reflect.DeepEqual(got, want) {
+		t.Errorf("collectIncludeKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestIncludeChildren(t *testing.T) {
+	const childTableID = 2
+	orderID := &entity.SysColumn{DbName: "ORDER_ID"}
+	orderID.ID = 21
+
+	tests := []struct {
+		name      string
+		assocType string
+		keyMask   string
+		wantKey   bool
+	}{
+		{"一对多", "n", "", true},
+		{"一对一", "1", "", true},
+		{"外键字段列表不可见", "n", "1111000000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := *orderID
+			key.Mask = tt.keyMask
+			db, _ := newFakeDB(t, map[string]*fakeResult{
+				"FROM `ORDER_ITEM`": {
+					columns: []string{"ID", "NAME", "ORDER_ID"},
+					rows: [][]driver.Value{
+						{int64(11), "明细1", int64(1)},
+						{int64(12), "明细2", int64(1)},
+						{int64(13), "明细3", int64(2)},
+					},
+				},
+			})
+			s := &service{
+				db:              db,
+				groupsService:   &fakeGroups{},
+				metadataService: &fakeMetadata{columns: map[uint][]*entity.SysColumn{childTableID: {{DbName: "ID"}, {DbName: "NAME"}, &key}}},
+				metadataRepo:    &fakeMetadataRepo{columns: map[uint]*entity.SysColumn{orderID.ID: &key}},
+			}
+
+			rows := []map[string]interface{}{{"ID": int64(1)}, {"ID": int64(2)}, {"ID": int64(3)}}
+			for _, row := range rows {
+				row[IncludeKey] = map[string]interface{}{}
+			}
+			ref := &entity.SysTableRef{RefTableID: childTableID, RefColumnID: int(orderID.ID), AssocType: tt.assocType}
+			refTable := &entity.SysTable{Name: "ORDER_ITEM"}
+			refTable.ID = childTableID
+			if err := s.includeChildren(context.Background(), ref, refTable, rows, "ORDER_ITEM", 1); err != nil {
+				t.Fatalf("includeChildren() = %v", err)
+			}
+
+			included := func(i int) interface{} { return rows[i][IncludeKey].(map[string]interface{})["ORDER_ITEM"] }
+			var children []map[string]interface{}
+			if tt.assocType == "1" {
+				first, _ := included(0).(map[string]interface{})
+				if first == nil || first["ID"] != int64(11) {
+					t.Fatalf("row 1 = %v, want first child", included(0))
+				}
+				if included(2) != nil {
+					t.Errorf("row 3 = %v, want nil", included(2))
+				}
+				children = []map[string]interface{}{first}
+			} else {
+				children = included(0).([]map[string]interface{})
+				if len(children) != 2 || len(included(1).([]map[string]interface{})) != 1 {
+					t.Fatalf("grouped children = %v / %v", included(0), included(1))
+				}
+				if empty := included(2).([]map[string]interface{}); len(empty) != 0 {
+					t.Errorf("row 3 = %v, want empty", empty)
+				}
+			}
+
+			for _, child := range children {
+				if _, ok := child["ORDER_ID"]; ok != tt.wantKey {
+					t.Errorf("child %v contains ORDER_ID = %v, want %v", child, ok, tt.wantKey)
+				}
+			}
+		})
+	}
+}
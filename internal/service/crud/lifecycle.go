@@ -0,0 +1,303 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"github.com/sky-xhsoft/sky-server/plugins/core"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 单据生命周期字段（表MASK包含 S 或 V 的单据表必须包含这些字段）
+const (
+	ColDocStatus  = "DOC_STATUS"  // 单据状态
+	ColSubmitBy   = "SUBMIT_BY"   // 提交人
+	ColSubmitTime = "SUBMIT_TIME" // 提交时间
+)
+
+// 单据状态
+const (
+	DocStatusDraft     = "draft"     // 未提交（字段为空时同样视为未提交）
+	DocStatusSubmitted = "submitted" // 已提交
+	DocStatusVoided    = "voided"    // 已作废
+)
+
+// lifecycleTransition 单据状态迁移定义
+type lifecycleTransition struct {
+	mask   string   // 表MASK中对应的字母，同时作为 sys_table_cmd 的 ACTION
+	action string   // 插件钩子动作名（table.before/after.<action>）
+	name   string   // 操作名称（用于错误提示）
+	perm   int      // 所需的表权限
+	from   []string // 允许的起始状态
+	to     string   // 目标状态
+	change string   // 记入变更历史的变更类型
+}
+
+var (
+	transitionSubmit = lifecycleTransition{
+		mask: "S", action: "submit", name: "提交", perm: groups.PermSubmit,
+		from: []string{DocStatusDraft}, to: DocStatusSubmitted, change: ChangeSubmit,
+	}
+	transitionUnsubmit = lifecycleTransition{
+		mask: "U", action: "unsubmit", name: "反提交", perm: groups.PermAudit,
+		from: []string{DocStatusSubmitted}, to: DocStatusDraft, change: ChangeUnsubmit,
+	}
+	transitionVoid = lifecycleTransition{
+		mask: "V", action: "void", name: "作废", perm: groups.PermAudit,
+		from: []string{DocStatusDraft}, to: DocStatusVoided, change: ChangeVoid,
+	}
+)
+
+// check 检查记录的当前状态是否允许迁移
+func (t lifecycleTransition) check(record map[string]interface{}) error {
+	status := docStatus(record)
+	if !containsStatus(t.from, status) {
+		return errors.New(errors.ErrResourceConflict, fmt.Sprintf("单据状态为%s，不能%s", docStatusName(status), t.name))
+	}
+	// 审批中的单据由审批流程控制，需撤回流程
+	if includeKeyString(record[ColApprovalStatus]) == ApprovalPending {
+		return errors.New(errors.ErrResourceConflict, fmt.Sprintf("单据审批中，不能%s，请先撤回审批流程", t.name))
+	}
+	return nil
+}
+
+// Submit 提交单据
+func (s *service) Submit(ctx context.Context, tableName string, id uint, userID uint) error {
+	return s.transition(ctx, tableName, id, userID, transitionSubmit)
+}
+
+// Unsubmit 反提交单据（已提交 -> 未提交）
+func (s *service) Unsubmit(ctx context.Context, tableName string, id uint, userID uint) error {
+	return s.transition(ctx, tableName, id, userID, transitionUnsubmit)
+}
+
+// Void 作废单据（仅未提交的单据可作废，已提交的单据需先反提交）
+func (s *service) Void(ctx context.Context, tableName string, id uint, userID uint) error {
+	return s.transition(ctx, tableName, id, userID, transitionVoid)
+}
+
+// transition 执行单据状态迁移
+// 在事务中依次执行：锁定记录 + 状态校验 + begin钩子/before插件 + 更新状态 + 记录变更历史 + end钩子/after插件
+func (s *service) transition(ctx context.Context, tableName string, id uint, userID uint, t lifecycleTransition) error {
+	// 获取表元数据
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+
+	// 表MASK必须声明该操作
+	if !strings.Contains(table.Mask, t.mask) {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("表 %s 不支持%s操作", table.Name, t.name))
+	}
+
+	// 检查权限
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, t.perm)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return errors.New(errors.ErrPermissionDenied, fmt.Sprintf("无%s权限", t.name))
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+
+	dataFilter, err := s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+
+	var username string
+	var companyID uint
+	if user, userErr := s.userRepo.GetUserByID(userID); userErr == nil && user != nil {
+		username = user.Username
+		companyID = user.SysCompanyID
+	}
+
+	return transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		// 锁定记录，避免并发迁移
+		query := tx.WithContext(ctx).Table(table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ID = ? AND IS_ACTIVE = ?", id, "Y")
+		if len(dataFilter) > 0 {
+			query, err = s.applyFilters(query, dataFilter, columns, false)
+			if err != nil {
+				return errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+			}
+		}
+
+		var record map[string]interface{}
+		if err := query.Take(&record).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New(errors.ErrResourceNotFound, "记录不存在")
+			}
+			return errors.Wrap(errors.ErrDatabase, "查询失败", err)
+		}
+
+		if err := t.check(record); err != nil {
+			return err
+		}
+
+		pluginData := core.PluginData{
+			TableName: table.Name,
+			Action:    t.action,
+			RecordID:  id,
+			Data:      record,
+			UserID:    userID,
+			CompanyID: companyID,
+		}
+
+		// 执行begin钩子和before插件
+		if err := s.executeHooksInTx(ctx, tx, table.ID, t.mask, "begin", record); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行before钩子失败", err)
+		}
+		if err := s.executePlugins(ctx, tx, pluginData, "before"); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行before插件失败", err)
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			ColDocStatus:  t.to,
+			"UPDATE_BY":   username,
+			"UPDATE_TIME": now,
+		}
+		if hasColumn(columns, ColVersion) {
+			version, _ := toInt64(record[ColVersion])
+			updates[ColVersion] = version + 1
+		}
+		switch t.to {
+		case DocStatusSubmitted:
+			updates[ColSubmitBy] = username
+			updates[ColSubmitTime] = now
+		case DocStatusDraft:
+			updates[ColSubmitBy] = nil
+			updates[ColSubmitTime] = nil
+			// 反提交后重新提交将重新审批
+			if hasColumn(columns, ColApprovalStatus) {
+				updates[ColApprovalStatus] = nil
+			}
+		}
+
+		if err := tx.Table(table.Name).Where("ID = ?", id).Updates(updates).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, t.name+"失败", err)
+		}
+		if err := s.recordChange(tx, table, columns, id, t.change, record, updates, userID); err != nil {
+			return err
+		}
+
+		// 执行end钩子和after插件（使用迁移后的记录）
+		for k, v := range updates {
+			record[k] = v
+		}
+		if err := s.executeHooksInTx(ctx, tx, table.ID, t.mask, "end", record); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行after钩子失败", err)
+		}
+		if err := s.executePlugins(ctx, tx, pluginData, "after"); err != nil {
+			return errors.Wrap(errors.ErrInternal, "执行after插件失败", err)
+		}
+
+		return nil
+	})
+}
+
+// checkDocEditable 检查单据是否允许修改/删除
+// 仅未提交的单据可以修改或删除；非单据表直接通过
+func (s *service) checkDocEditable(tx *gorm.DB, table *entity.SysTable, ids []uint) error {
+	if !isDocumentTable(table) || len(ids) == 0 {
+		return nil
+	}
+
+	var records []map[string]interface{}
+	if err := tx.Table(table.Name).
+		Select("ID, "+ColDocStatus).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ID IN ?", ids).
+		Find(&records).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询单据状态失败", err)
+	}
+
+	return checkDraft(records)
+}
+
+// checkDraft 检查记录均为未提交状态
+func checkDraft(records []map[string]interface{}) error {
+	for _, record := range records {
+		if status := docStatus(record); status != DocStatusDraft {
+			return errors.New(errors.ErrResourceConflict,
+				fmt.Sprintf("单据(ID=%s)状态为%s，不能修改或删除", includeKeyString(record["ID"]), docStatusName(status)))
+		}
+	}
+	return nil
+}
+
+// executePlugins 执行插件钩子点（table.timing.action）
+func (s *service) executePlugins(ctx context.Context, tx *gorm.DB, data core.PluginData, timing string) error {
+	if s.pluginManager == nil {
+		return nil
+	}
+	data.Timing = timing
+	return s.pluginManager.ExecuteWithDB(ctx, tx, data)
+}
+
+// isDocumentTable 判断是否为单据表（MASK 包含提交或作废）
+func isDocumentTable(table *entity.SysTable) bool {
+	return strings.ContainsAny(table.Mask, "SV")
+}
+
+// docStatus 获取记录的单据状态
+func docStatus(record map[string]interface{}) string {
+	status := includeKeyString(record[ColDocStatus])
+	if status == "" {
+		return DocStatusDraft
+	}
+	return status
+}
+
+// docStatusName 单据状态的显示名称
+func docStatusName(status string) string {
+	switch status {
+	case DocStatusDraft:
+		return "未提交"
+	case DocStatusSubmitted:
+		return "已提交"
+	case DocStatusVoided:
+		return "已作废"
+	default:
+		return status
+	}
+}
+
+// containsStatus 检查状态是否在列表中
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// lifecycleFields 单据状态和审批字段，由提交/反提交/作废和审批维护
+var lifecycleFields = map[string]bool{
+	ColDocStatus:      true,
+	ColSubmitBy:       true,
+	ColSubmitTime:     true,
+	ColApprovalStatus: true,
+	ColWfInstanceID:   true,
+}
+
+// removeLifecycleFields 移除客户端提交的单据状态和审批字段
+func removeLifecycleFields(data map[string]interface{}) {
+	for field := range lifecycleFields {
+		delete(data, field)
+	}
+}
@@ -0,0 +1,145 @@
+package crud
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+)
+
+func TestLifecycleTransitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		transition lifecycleTransition
+		record     map[string]interface{}
+		ok         bool
+	}{
+		{"提交未提交", transitionSubmit, map[string]interface{}{ColDocStatus: DocStatusDraft}, true},
+		{"提交历史数据(状态为空)", transitionSubmit, map[string]interface{}{ColDocStatus: nil}, true},
+		{"重复提交", transitionSubmit, map[string]interface{}{ColDocStatus: DocStatusSubmitted}, false},
+		{"提交已作废", transitionSubmit, map[string]interface{}{ColDocStatus: []byte(DocStatusVoided)}, false},
+		{"反提交已提交", transitionUnsubmit, map[string]interface{}{ColDocStatus: DocStatusSubmitted}, true},
+		{"反提交未提交", transitionUnsubmit, map[string]interface{}{ColDocStatus: DocStatusDraft}, false},
+		{"作废未提交", transitionVoid, map[string]interface{}{ColDocStatus: DocStatusDraft}, true},
+		{"作废已提交", transitionVoid, map[string]interface{}{ColDocStatus: DocStatusSubmitted}, false},
+		{"反提交审批中", transitionUnsubmit, map[string]interface{}{ColDocStatus: DocStatusSubmitted, ColApprovalStatus: ApprovalPending}, false},
+	}
+	for _, tt := range tests {
+		err := tt.transition.check(tt.record)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: check() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+		if err != nil && errors.GetCode(err) != errors.ErrResourceConflict {
+			t.Errorf("%s: code = %d, want ErrResourceConflict", tt.name, errors.GetCode(err))
+		}
+	}
+
+	if transitionSubmit.perm != groups.PermSubmit || transitionUnsubmit.perm != groups.PermAudit || transitionVoid.perm != groups.PermAudit {
+		t.Error("submit requires PermSubmit, unsubmit/void require PermAudit")
+	}
+}
+
+func TestCheckDraftBlocksUpdate(t *testing.T) {
+	if err := checkDraft([]map[string]interface{}{
+		{"ID": int64(1), ColDocStatus: DocStatusDraft},
+		{"ID": int64(2), ColDocStatus: nil},
+	}); err != nil {
+		t.Errorf("draft records should be editable: %v", err)
+	}
+
+	for _, status := range []string{DocStatusSubmitted, DocStatusVoided} {
+		err := checkDraft([]map[string]interface{}{
+			{"ID": int64(1), ColDocStatus: DocStatusDraft},
+			{"ID": int64(2), ColDocStatus: status},
+		})
+		if err == nil || errors.GetCode(err) != errors.ErrResourceConflict {
+			t.Errorf("%s record should block update, got %v", status, err)
+		}
+	}
+}
+
+func TestTransitionRecordsChange(t *testing.T) {
+	table := &entity.SysTable{Name: "orders", Mask: "AMDQSUV"}
+	table.ID = 100
+	columns := []*entity.SysColumn{
+		{DbName: "ID"}, {DbName: "NAME"}, {DbName: ColDocStatus, DisplayName: "单据状态"},
+		{DbName: ColSubmitBy}, {DbName: ColSubmitTime}, {DbName: "UPDATE_BY"}, {DbName: "UPDATE_TIME"},
+	}
+
+	tests := []struct {
+		name       string
+		transition lifecycleTransition
+		from       string
+		wantAction string
+	}{
+		{"提交", transitionSubmit, DocStatusDraft, ChangeSubmit},
+		{"反提交", transitionUnsubmit, DocStatusSubmitted, ChangeUnsubmit},
+		{"作废", transitionVoid, DocStatusDraft, ChangeVoid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, map[string]*fakeResult{
+				"FROM `orders`": {
+					columns: []string{"ID", "NAME", ColDocStatus, "IS_ACTIVE"},
+					rows:    [][]driver.Value{{int64(5), "订单", tt.from, "Y"}},
+				},
+			})
+			s := &service{
+				db:              db,
+				metadataService: &fakeMetadata{tables: map[string]*entity.SysTable{"orders": table}, columns: map[uint][]*entity.SysColumn{table.ID: columns}},
+				groupsService:   &fakeGroups{},
+				metadataRepo:    &fakeMetadataRepo{},
+				userRepo:        &fakeUserRepo{users: map[uint]*entity.SysUser{7: {Username: "alice"}}},
+			}
+
+			if err := s.transition(context.Background(), "orders", 5, 7, tt.transition); err != nil {
+				t.Fatalf("transition() error = %v", err)
+			}
+
+			// 变更历史在同一事务中、状态更新之后写入
+			updateIndex, changeIndex := -1, -1
+			for i, query := range fake.queries {
+				if strings.HasPrefix(query, "UPDATE `orders`") {
+					updateIndex = i
+				}
+				if strings.HasPrefix(query, "INSERT INTO `sys_record_change`") {
+					changeIndex = i
+				}
+			}
+			if updateIndex < 0 || changeIndex < updateIndex {
+				t.Fatalf("record change written at %d, status update at %d", changeIndex, updateIndex)
+			}
+
+			args := fake.executedArgs("INSERT INTO `sys_record_change`")[0]
+			var changes []*FieldChange
+			for _, arg := range args {
+				if str, ok := arg.(string); ok && strings.HasPrefix(str, "[") {
+					if err := json.Unmarshal([]byte(str), &changes); err != nil {
+						t.Fatalf("changes %q: %v", str, err)
+					}
+				}
+			}
+			if !containsValue(args, tt.wantAction) {
+				t.Errorf("record change args = %v, want action %s", args, tt.wantAction)
+			}
+			if len(changes) == 0 || changes[0].Column != ColDocStatus || changes[0].Old != tt.from || changes[0].New != tt.transition.to {
+				t.Errorf("changes = %+v, want %s %s -> %s first", changes, ColDocStatus, tt.from, tt.transition.to)
+			}
+		})
+	}
+}
+
+// containsValue 判断 SQL 参数中是否包含 value
+func containsValue(args []interface{}, value interface{}) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"gorm.io/gorm"
+)
+
+// Printer 读取要打印的记录和明细（打印服务使用）
+// 只读取 MASK 打印可见的字段，记录和明细按数据权限过滤
+type Printer struct {
+	s       *service
+	ctx     context.Context
+	userID  uint
+	table   *entity.SysTable
+	columns []*entity.SysColumn
+	visible []*entity.SysColumn
+}
+
+// PrintDetail 打印的明细表（sys_table_ref 中一对多关联的子表）
+type PrintDetail struct {
+	Ref      *entity.SysTableRef
+	Table    *entity.SysTable
+	Columns  []*entity.SysColumn // 打印可见的字段（不含指向主表的外键），按字段顺序
+	columns  []*entity.SysColumn
+	fkColumn string
+}
+
+// NewPrinter 检查查询权限并创建打印器
+func (s *service) NewPrinter(ctx context.Context, tableName string, userID uint) (*Printer, error) {
+	table, columns, err := s.bulkTable(ctx, tableName, userID, groups.PermRead, "无查询权限")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Printer{
+		s:       s,
+		ctx:     ctx,
+		userID:  userID,
+		table:   table,
+		columns: columns,
+		visible: bulkColumns(columns, "print"),
+	}, nil
+}
+
+// Table 打印的表
+func (p *Printer) Table() *entity.SysTable {
+	return p.table
+}
+
+// Columns 打印可见的字段（不含密码字段），按字段顺序
+func (p *Printer) Columns() []*entity.SysColumn {
+	return p.visible
+}
+
+// Records 按ID读取记录，按 ids 的顺序返回；记录不存在或无数据权限时返回错误
+func (p *Printer) Records(ids []uint) ([]map[string]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query, err := p.s.printQuery(p.ctx, p.table, p.columns, p.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if err := query.
+		Select(printFields(p.visible, "ID")).
+		Where("ID IN ?", ids).
+		Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+
+	byID := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		byID[includeKeyString(row["ID"])] = row
+	}
+	records := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		row, ok := byID[strconv.FormatUint(uint64(id), 10)]
+		if !ok {
+			return nil, errors.New(errors.ErrResourceNotFound, fmt.Sprintf("记录不存在: %d", id))
+		}
+		records = append(records, row)
+	}
+	return records, nil
+}
+
+// Details 主表全部可查询的明细表（sys_table_ref 中一对多的关联，按关联顺序），无查询权限的子表跳过
+func (p *Printer) Details() ([]*PrintDetail, error) {
+	refs, err := p.s.metadataService.GetTableRefs(p.table.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*PrintDetail, 0, len(refs))
+	for _, ref := range refs {
+		if ref.AssocType != "n" {
+			continue
+		}
+		refTable, err := p.s.metadataService.GetTableByID(uint(ref.RefTableID))
+		if err != nil {
+			return nil, err
+		}
+		detail, err := p.newDetail(ref, refTable)
+		if err != nil {
+			if errors.GetCode(err) == errors.ErrPermissionDenied {
+				continue
+			}
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// Detail 按子表表名查找明细表（须为 sys_table_ref 中的关联）
+func (p *Printer) Detail(tableName string) (*PrintDetail, error) {
+	refs, err := p.s.metadataService.GetTableRefs(p.table.ID)
+	if err != nil {
+		return nil, err
+	}
+	ref, refTable, err := p.s.findTableRef(refs, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 不是 %s 的明细表", tableName, p.table.Name))
+	}
+	return p.newDetail(ref, refTable)
+}
+
+// newDetail 检查子表的查询权限并读取打印可见的字段
+func (p *Printer) newDetail(ref *entity.SysTableRef, refTable *entity.SysTable) (*PrintDetail, error) {
+	hasPermission, err := p.s.groupsService.CheckUserTablePermission(p.ctx, p.userID, refTable.ID, groups.PermRead)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return nil, errors.New(errors.ErrPermissionDenied, fmt.Sprintf("无明细表查询权限: %s", refTable.Name))
+	}
+
+	// REF_COLUMN_ID 为子表中指向主表的外键字段
+	fkColumn, err := p.s.metadataRepo.GetColumnByID(uint(ref.RefColumnID))
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrResourceNotFound, fmt.Sprintf("明细表 %s 的外键字段不存在", refTable.Name), err)
+	}
+	columns, err := p.s.metadataService.GetColumns(refTable.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*entity.SysColumn, 0, len(columns))
+	for _, col := range bulkColumns(columns, "print") {
+		if col.DbName != fkColumn.DbName {
+			visible = append(visible, col)
+		}
+	}
+
+	return &PrintDetail{
+		Ref:      ref,
+		Table:    refTable,
+		Columns:  visible,
+		columns:  columns,
+		fkColumn: fkColumn.DbName,
+	}, nil
+}
+
+// DetailRows 读取记录的明细（按数据权限和关联过滤条件，按ID排序），按主表记录ID分组
+func (p *Printer) DetailRows(detail *PrintDetail, ids []uint) (map[uint][]map[string]interface{}, error) {
+	grouped := make(map[uint][]map[string]interface{}, len(ids))
+	if len(ids) == 0 {
+		return grouped, nil
+	}
+
+	query, err := p.s.printQuery(p.ctx, detail.Table, detail.columns, p.userID)
+	if err != nil {
+		return nil, err
+	}
+	// sys_table_ref.FILTER 为 JSON 格式的附加过滤条件
+	query, err = p.s.applyDataFilter(query, detail.Ref.Filter, detail.columns)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "关联过滤条件无效", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := query.
+		Select(printFields(detail.Columns, "ID", detail.fkColumn)).
+		Where(fmt.Sprintf("%s IN ?", detail.fkColumn), ids).
+		Order("ID ASC").
+		Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, fmt.Sprintf("查询明细表 %s 失败", detail.Table.Name), err)
+	}
+
+	for _, row := range rows {
+		parentID, err := strconv.ParseUint(includeKeyString(row[detail.fkColumn]), 10, 64)
+		if err != nil {
+			continue
+		}
+		grouped[uint(parentID)] = append(grouped[uint(parentID)], row)
+	}
+	return grouped, nil
+}
+
+// printQuery 按数据权限构建有效记录的查询
+func (s *service) printQuery(ctx context.Context, table *entity.SysTable, columns []*entity.SysColumn, userID uint) (*gorm.DB, error) {
+	dataFilter, err := s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+
+	query := s.db.WithContext(ctx).Table(table.Name)
+	if len(dataFilter) > 0 {
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+	}
+	return query.Where("IS_ACTIVE = ?", "Y"), nil
+}
+
+// printFields 查询的字段：打印可见的字段加上关联需要的键
+func printFields(columns []*entity.SysColumn, keys ...string) string {
+	fields := make([]string, 0, len(columns)+len(keys))
+	seen := make(map[string]bool, len(columns)+len(keys))
+	for _, col := range columns {
+		fields = append(fields, col.DbName)
+		seen[col.DbName] = true
+	}
+	for _, key := range keys {
+		if !seen[key] {
+			fields = append(fields, key)
+			seen[key] = true
+		}
+	}
+	return strings.Join(fields, ", ")
+}
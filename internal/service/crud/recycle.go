@@ -0,0 +1,657 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 外键删除动作（SysColumn.RefOnDelete）
+const (
+	RefNoAction = "noAction" // 无动作：父记录删除后子记录保留原外键
+	RefRestrict = "restrict" // 限制：存在有效子记录时父记录不能删除，父记录已删除时子记录不能恢复
+	RefCascade  = "cascade"  // 级联：删除父记录时一并删除子记录，从回收站恢复父记录时一并恢复
+	RefSetNull  = "setNull"  // 置空：删除父记录时子记录外键置空，从回收站恢复父记录时还原外键
+)
+
+// maxCascadeDepth 级联删除、恢复的最大层数
+const maxCascadeDepth = 10
+
+// TableProps 表扩展属性（SysTable.Props）
+type TableProps struct {
+	SoftDelete bool `json:"softDelete"` // 删除时只将 IS_ACTIVE 置为 N，记录进入回收站
+}
+
+// parseTableProps 解析表扩展属性，格式错误时按默认值处理
+func parseTableProps(table *entity.SysTable) TableProps {
+	var props TableProps
+	if strings.TrimSpace(table.Props) != "" {
+		_ = json.Unmarshal([]byte(table.Props), &props)
+	}
+	return props
+}
+
+// refAction 外键字段的删除动作，未配置或无法识别时为无动作
+func refAction(col *entity.SysColumn) string {
+	switch strings.ToLower(strings.NewReplacer("_", "", " ", "").Replace(col.RefOnDelete)) {
+	case "restrict":
+		return RefRestrict
+	case "cascade":
+		return RefCascade
+	case "setnull":
+		return RefSetNull
+	default:
+		return RefNoAction
+	}
+}
+
+// childRef 引用当前表的外键字段及其所在的表
+type childRef struct {
+	table   *entity.SysTable
+	columns []*entity.SysColumn
+	column  *entity.SysColumn
+	action  string
+}
+
+// childRefs 查询引用该表且配置了删除动作的外键字段
+func (s *service) childRefs(table *entity.SysTable) ([]*childRef, error) {
+	refColumns, err := s.metadataRepo.GetColumnsByRefTableID(table.ID)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询外键字段失败", err)
+	}
+
+	refs := make([]*childRef, 0, len(refColumns))
+	for _, col := range refColumns {
+		action := refAction(col)
+		if action == RefNoAction {
+			continue
+		}
+		child, err := s.metadataService.GetTableByID(col.SysTableID)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("外键字段 %s 所在的表不存在", col.FullName), err)
+		}
+		columns, err := s.metadataService.GetColumns(child.ID)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, &childRef{table: child, columns: columns, column: col, action: action})
+	}
+	return refs, nil
+}
+
+// recordIDs 取记录的ID
+func recordIDs(records []map[string]interface{}) []uint {
+	ids := make([]uint, 0, len(records))
+	for _, record := range records {
+		id, _ := toInt64(record["ID"])
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+// activeValues 修改记录有效状态时一并维护的字段
+func activeValues(columns []*entity.SysColumn, active, username string) map[string]interface{} {
+	values := map[string]interface{}{
+		"IS_ACTIVE":   active,
+		"UPDATE_BY":   username,
+		"UPDATE_TIME": time.Now(),
+	}
+	if hasColumn(columns, ColVersion) {
+		values[ColVersion] = gorm.Expr(ColVersion + " + 1")
+	}
+	return values
+}
+
+// deleteRecords 删除已锁定的有效记录并按外键删除动作处理子表
+// 表启用软删除时记录进入回收站，否则物理删除；causeID 为引发本次删除的父记录删除历史
+// 级联删除由表结构定义，不再检查子表的删除权限
+func (s *service) deleteRecords(ctx context.Context, tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, records []map[string]interface{}, username string, userID, causeID uint, depth int) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if depth > maxCascadeDepth {
+		return errors.New(errors.ErrValidation, "级联删除层数超过限制")
+	}
+
+	ids := recordIDs(records)
+
+	// 已提交/已作废的单据不允许删除
+	if err := s.checkDocEditable(tx, table, ids); err != nil {
+		return err
+	}
+
+	refs, err := s.childRefs(table)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.action != RefRestrict {
+			continue
+		}
+		query := tx.Table(ref.table.Name).
+			Where(fmt.Sprintf("%s IN ?", ref.column.DbName), ids).
+			Where("IS_ACTIVE = ?", "Y")
+		if ref.table.ID == table.ID {
+			// 自引用的表中一起删除的记录不算
+			query = query.Where("ID NOT IN ?", ids)
+		}
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+		}
+		if count > 0 {
+			return errors.New(errors.ErrResourceConflict,
+				fmt.Sprintf("存在引用该记录的%s数据(%d条)，不能删除", tableDisplayName(ref.table), count))
+		}
+	}
+
+	// 执行before钩子（在事务中）
+	for _, id := range ids {
+		if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "begin", map[string]interface{}{"ID": id}); err != nil {
+			return errors.Wrap(errors.ErrInternal, fmt.Sprintf("执行ID=%d的before钩子失败", id), err)
+		}
+	}
+
+	var result *gorm.DB
+	if parseTableProps(table).SoftDelete {
+		result = tx.Table(table.Name).
+			Where("ID IN ? AND IS_ACTIVE = ?", ids, "Y").
+			Updates(activeValues(columns, "N", username))
+	} else {
+		result = tx.Table(table.Name).Where("ID IN ?", ids).Delete(nil)
+	}
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "删除失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrResourceNotFound, "记录不存在")
+	}
+
+	changeIDs := make(map[string]uint, len(records))
+	for i, record := range records {
+		changeID, err := s.logChange(tx, table, columns, ids[i], ChangeDelete, record, nil, userID, causeID)
+		if err != nil {
+			return err
+		}
+		changeIDs[includeKeyString(record["ID"])] = changeID
+	}
+
+	for _, ref := range refs {
+		if ref.action == RefRestrict {
+			continue
+		}
+		var children []map[string]interface{}
+		if err := tx.Table(ref.table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(fmt.Sprintf("%s IN ?", ref.column.DbName), ids).
+			Where("IS_ACTIVE = ?", "Y").
+			Order("ID ASC").
+			Find(&children).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, fmt.Sprintf("查询子表 %s 失败", ref.table.Name), err)
+		}
+
+		// 按父记录分组，子记录的删除历史指向父记录的删除历史
+		grouped := make(map[string][]map[string]interface{})
+		keys := make([]string, 0)
+		for _, child := range children {
+			key := includeKeyString(child[ref.column.DbName])
+			if _, ok := grouped[key]; !ok {
+				keys = append(keys, key)
+			}
+			grouped[key] = append(grouped[key], child)
+		}
+
+		for _, key := range keys {
+			if ref.action == RefCascade {
+				if err := s.deleteRecords(ctx, tx, ref.table, ref.columns, grouped[key], username, userID, changeIDs[key], depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, child := range grouped[key] {
+				if err := s.setChildRef(tx, ref, child, nil, username, userID, changeIDs[key]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// 执行after钩子（在事务中）
+	for _, id := range ids {
+		if err := s.executeHooksInTx(ctx, tx, table.ID, "D", "end", map[string]interface{}{"ID": id}); err != nil {
+			return errors.Wrap(errors.ErrInternal, fmt.Sprintf("执行ID=%d的after钩子失败", id), err)
+		}
+	}
+
+	return nil
+}
+
+// setChildRef 修改子记录的外键（置空或还原）并记入变更历史
+func (s *service) setChildRef(tx *gorm.DB, ref *childRef, child map[string]interface{}, value interface{}, username string, userID, causeID uint) error {
+	id, _ := toInt64(child["ID"])
+	values := map[string]interface{}{
+		ref.column.DbName: value,
+		"UPDATE_BY":       username,
+		"UPDATE_TIME":     time.Now(),
+	}
+	if hasColumn(ref.columns, ColVersion) {
+		values[ColVersion] = gorm.Expr(ColVersion + " + 1")
+	}
+	if err := tx.Table(ref.table.Name).Where("ID = ?", id).Updates(values).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, fmt.Sprintf("修改子表 %s 外键失败", ref.table.Name), err)
+	}
+	_, err := s.logChange(tx, ref.table, ref.columns, uint(id), ChangeUpdate, child,
+		map[string]interface{}{ref.column.DbName: value}, userID, causeID)
+	return err
+}
+
+// ListDeleted 查询回收站中的记录（按删除时间倒序）
+func (s *service) ListDeleted(ctx context.Context, req *QueryRequest, userID uint) (*QueryResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	table, columns, dataFilter, err := s.recycleTable(ctx, req.TableName, userID, groups.PermRead, "无查询权限")
+	if err != nil {
+		return nil, err
+	}
+
+	selectFields, err := s.buildSelectFields(columns, userID, "list")
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.WithContext(ctx).Table(table.Name).Select(selectFields)
+	if len(dataFilter) > 0 {
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+	}
+	query = query.Where("IS_ACTIVE = ?", "N")
+	if len(req.Filters) > 0 {
+		query, err = s.applyFilters(query, req.Filters, columns, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询总数失败", err)
+	}
+
+	var results []map[string]interface{}
+	if err := query.
+		Order("UPDATE_TIME DESC, ID DESC").
+		Limit(req.PageSize).
+		Offset((req.Page - 1) * req.PageSize).
+		Find(&results).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+
+	return &QueryResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Data:     results,
+	}, nil
+}
+
+// RestoreDeleted 从回收站恢复记录
+// 外键为限制或级联删除时引用的父记录必须有效，为置空时父记录已删除则外键置空；
+// 级联删除的子记录一并恢复，删除时置空的子记录外键一并还原
+func (s *service) RestoreDeleted(ctx context.Context, tableName string, ids []uint, userID uint) error {
+	table, columns, dataFilter, err := s.recycleTable(ctx, tableName, userID, groups.PermDelete, "无删除权限")
+	if err != nil {
+		return err
+	}
+
+	username := s.username(userID)
+	return transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		records, err := s.lockDeleted(tx, table, columns, dataFilter, ids)
+		if err != nil {
+			return err
+		}
+		return s.restoreRecords(tx, table, columns, records, username, userID, 0, 0)
+	})
+}
+
+// restoreRecords 恢复已锁定的回收站记录，causeID 为引发本次恢复的父记录恢复历史
+func (s *service) restoreRecords(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, records []map[string]interface{}, username string, userID, causeID uint, depth int) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if depth > maxCascadeDepth {
+		return errors.New(errors.ErrValidation, "级联恢复层数超过限制")
+	}
+
+	refs, err := s.childRefs(table)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		id, _ := toInt64(record["ID"])
+
+		// 删除后可能新增了输入键相同的记录
+		if err := s.checkAlternateKeys(tx, table, columns, record, uint(id)); err != nil {
+			return err
+		}
+
+		values := activeValues(columns, "Y", username)
+		after := make(map[string]interface{})
+		if err := s.checkParentRefs(tx, columns, record, after); err != nil {
+			return err
+		}
+		for k, v := range after {
+			values[k] = v
+		}
+		if err := tx.Table(table.Name).Where("ID = ?", id).Updates(values).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "恢复失败", err)
+		}
+
+		// 最近一次删除历史，级联删除和置空的子记录指向它
+		var deleted entity.SysRecordChange
+		err := tx.Where("SYS_TABLE_ID = ? AND RECORD_ID = ? AND ACTION = ?", table.ID, id, ChangeDelete).
+			Order("ID DESC").
+			Take(&deleted).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return errors.Wrap(errors.ErrDatabase, "查询删除历史失败", err)
+		}
+
+		changeID, err := s.logChange(tx, table, columns, uint(id), ChangeRestore, record, after, userID, causeID)
+		if err != nil {
+			return err
+		}
+		if deleted.ID == 0 {
+			continue
+		}
+
+		for _, ref := range refs {
+			if err := s.restoreChildren(tx, ref, uint(id), deleted.ID, username, userID, changeID, depth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreChildren 恢复父记录被删除时一并处理的子记录
+func (s *service) restoreChildren(tx *gorm.DB, ref *childRef, parentID, deleteChangeID uint, username string, userID, changeID uint, depth int) error {
+	action := ChangeDelete
+	if ref.action == RefSetNull {
+		action = ChangeUpdate
+	}
+	affected := tx.Model(&entity.SysRecordChange{}).
+		Select("RECORD_ID").
+		Where("SYS_TABLE_ID = ? AND ACTION = ? AND CAUSE_ID = ?", ref.table.ID, action, deleteChangeID)
+
+	var children []map[string]interface{}
+	query := tx.Table(ref.table.Name).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ID IN (?)", affected).
+		Order("ID ASC")
+	switch ref.action {
+	case RefCascade:
+		query = query.Where(fmt.Sprintf("%s = ?", ref.column.DbName), parentID).Where("IS_ACTIVE = ?", "N")
+	case RefSetNull:
+		// 置空后又被修改过外键的子记录不再还原
+		query = query.Where(fmt.Sprintf("%s IS NULL", ref.column.DbName)).Where("IS_ACTIVE = ?", "Y")
+	default:
+		return nil
+	}
+	if err := query.Find(&children).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, fmt.Sprintf("查询子表 %s 失败", ref.table.Name), err)
+	}
+
+	if ref.action == RefCascade {
+		return s.restoreRecords(tx, ref.table, ref.columns, children, username, userID, changeID, depth+1)
+	}
+	for _, child := range children {
+		if err := s.setChildRef(tx, ref, child, parentID, username, userID, changeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAlternateKeys 恢复记录前检查输入键(AK)是否与有效记录重复
+func (s *service) checkAlternateKeys(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, record map[string]interface{}, id uint) error {
+	for _, col := range columns {
+		if col.IsAK != "Y" || includeKeyString(record[col.DbName]) == "" {
+			continue
+		}
+		exists, err := s.akExists(tx, table, col, record[col.DbName], id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New(errors.ErrResourceConflict,
+				fmt.Sprintf("%s为%s的有效记录已存在，不能恢复", newFieldError(col, "").DisplayName, includeKeyString(record[col.DbName])))
+		}
+	}
+	return nil
+}
+
+// checkParentRefs 恢复记录前检查外键引用的父记录
+// 限制或级联删除的外键要求父记录有效；置空的外键在父记录无效时写入 values 置空
+func (s *service) checkParentRefs(tx *gorm.DB, columns []*entity.SysColumn, record map[string]interface{}, values map[string]interface{}) error {
+	for _, col := range columns {
+		if col.RefTableID == nil {
+			continue
+		}
+		action := refAction(col)
+		key := includeKeyString(record[col.DbName])
+		if action == RefNoAction || key == "" {
+			continue
+		}
+
+		parent, err := s.metadataService.GetTableByID(*col.RefTableID)
+		if err != nil {
+			return errors.Wrap(errors.ErrInternal, fmt.Sprintf("外键字段 %s 引用的表不存在", col.DbName), err)
+		}
+		var count int64
+		if err := tx.Table(parent.Name).Where("ID = ? AND IS_ACTIVE = ?", key, "Y").Count(&count).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if action == RefSetNull {
+			values[col.DbName] = nil
+			continue
+		}
+		return errors.New(errors.ErrResourceConflict,
+			fmt.Sprintf("引用的%s记录(ID=%s)已删除，请先恢复", tableDisplayName(parent), key))
+	}
+	return nil
+}
+
+// PurgeDeleted 从回收站彻底删除记录
+// 存在有效的限制或级联删除子记录时不能删除；回收站中级联删除的子记录一并彻底删除，置空的子记录外键置空
+func (s *service) PurgeDeleted(ctx context.Context, tableName string, ids []uint, userID uint) error {
+	table, columns, dataFilter, err := s.recycleTable(ctx, tableName, userID, groups.PermDelete, "无删除权限")
+	if err != nil {
+		return err
+	}
+
+	username := s.username(userID)
+	return transaction.RunInTransaction(s.db, func(tx *gorm.DB) error {
+		records, err := s.lockDeleted(tx, table, columns, dataFilter, ids)
+		if err != nil {
+			return err
+		}
+		return s.purgeRecords(tx, table, columns, records, username, userID, 0)
+	})
+}
+
+// purgeRecords 物理删除已锁定的回收站记录
+func (s *service) purgeRecords(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, records []map[string]interface{}, username string, userID uint, depth int) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if depth > maxCascadeDepth {
+		return errors.New(errors.ErrValidation, "级联删除层数超过限制")
+	}
+
+	ids := recordIDs(records)
+	refs, err := s.childRefs(table)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		fkCondition := fmt.Sprintf("%s IN ?", ref.column.DbName)
+		if ref.action == RefRestrict || ref.action == RefCascade {
+			var count int64
+			if err := tx.Table(ref.table.Name).
+				Where(fkCondition, ids).
+				Where("IS_ACTIVE = ?", "Y").
+				Count(&count).Error; err != nil {
+				return errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+			}
+			if count > 0 {
+				return errors.New(errors.ErrResourceConflict,
+					fmt.Sprintf("存在引用该记录的有效%s数据(%d条)，不能彻底删除", tableDisplayName(ref.table), count))
+			}
+		}
+
+		if ref.action == RefRestrict {
+			continue
+		}
+
+		query := tx.Table(ref.table.Name).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(fkCondition, ids)
+		if ref.table.ID == table.ID {
+			query = query.Where("ID NOT IN ?", ids)
+		}
+		var children []map[string]interface{}
+		if err := query.Order("ID ASC").Find(&children).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, fmt.Sprintf("查询子表 %s 失败", ref.table.Name), err)
+		}
+
+		switch ref.action {
+		case RefCascade:
+			if err := s.purgeRecords(tx, ref.table, ref.columns, children, username, userID, depth+1); err != nil {
+				return err
+			}
+		case RefSetNull:
+			for _, child := range children {
+				if err := s.setChildRef(tx, ref, child, nil, username, userID, 0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := tx.Table(table.Name).Where("ID IN ?", ids).Delete(nil).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "彻底删除失败", err)
+	}
+	for i, record := range records {
+		if _, err := s.logChange(tx, table, columns, ids[i], ChangePurge, record, nil, userID, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleTable 获取启用了软删除的表并检查权限，同时返回用户的数据过滤条件
+func (s *service) recycleTable(ctx context.Context, tableName string, userID uint, perm int, deniedMsg string) (*entity.SysTable, []*entity.SysColumn, map[string]interface{}, error) {
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+	if !parseTableProps(table).SoftDelete {
+		return nil, nil, nil, errors.New(errors.ErrValidation, "该表未启用回收站")
+	}
+
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, perm)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return nil, nil, nil, errors.New(errors.ErrPermissionDenied, deniedMsg)
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dataFilter, err := s.groupsService.GetUserDataFilter(ctx, userID, table.ID)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(errors.ErrInternal, "获取数据过滤条件失败", err)
+	}
+	return table, columns, dataFilter, nil
+}
+
+// lockDeleted 锁定回收站中数据权限范围内的记录，任一记录不在回收站或不可见时返回不存在
+func (s *service) lockDeleted(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, dataFilter map[string]interface{}, ids []uint) ([]map[string]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, errors.New(errors.ErrValidation, "请选择记录")
+	}
+
+	query := tx.Table(table.Name).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ID IN ? AND IS_ACTIVE = ?", ids, "N")
+	if len(dataFilter) > 0 {
+		var err error
+		query, err = s.applyFilters(query, dataFilter, columns, false)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "数据过滤条件无效", err)
+		}
+	}
+
+	var records []map[string]interface{}
+	if err := query.Order("ID ASC").Find(&records).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询失败", err)
+	}
+
+	// 不区分记录不在回收站还是不在数据权限范围内，避免泄露其他范围的记录是否存在
+	found := make(map[uint]bool, len(records))
+	for _, id := range recordIDs(records) {
+		found[id] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, errors.New(errors.ErrResourceNotFound, "记录不存在")
+		}
+	}
+	return records, nil
+}
+
+// username 查询用户名，用于 UPDATE_BY
+func (s *service) username(userID uint) string {
+	if user, err := s.userRepo.GetUserByID(userID); err == nil && user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// tableDisplayName 表的显示名称（未设置时取表名）
+func tableDisplayName(table *entity.SysTable) string {
+	if table.DisplayName != "" {
+		return table.DisplayName
+	}
+	return table.Name
+}
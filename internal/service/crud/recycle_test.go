@@ -0,0 +1,101 @@
+package crud
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func TestParseTableProps(t *testing.T) {
+	tests := []struct {
+		props string
+		want  bool
+	}{
+		{"", false},
+		{`{"softDelete": true}`, true},
+		{`{"softDelete": false, "other": 1}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if got := parseTableProps(&entity.SysTable{Props: tt.props}).SoftDelete; got != tt.want {
+			t.Errorf("parseTableProps(%q).SoftDelete = %v, want %v", tt.props, got, tt.want)
+		}
+	}
+}
+
+func TestRefAction(t *testing.T) {
+	tests := map[string]string{
+		"":          RefNoAction,
+		"noAction":  RefNoAction,
+		"restrict":  RefRestrict,
+		"CASCADE":   RefCascade,
+		"setNull":   RefSetNull,
+		"set_null":  RefSetNull,
+		"SET NULL":  RefSetNull,
+		"something": RefNoAction,
+	}
+	for value, want := range tests {
+		if got := refAction(&entity.SysColumn{RefOnDelete: value}); got != want {
+			t.Errorf("refAction(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestCheckAlternateKeysOnRestore(t *testing.T) {
+	table := &entity.SysTable{Name: "CUSTOMER"}
+	columns := []*entity.SysColumn{
+		{DbName: "ID"},
+		{DbName: "CODE", DisplayName: "编码", IsAK: "Y"},
+		{DbName: "NAME"},
+	}
+	record := map[string]interface{}{"ID": int64(7), "CODE": []byte("A001"), "NAME": "客户A"}
+
+	// 删除 A 后新增了编码相同的 A'，恢复 A 时冲突
+	db, fake := newFakeDB(t, map[string]*fakeResult{"count(*)": countResult(1)})
+	err := (&service{}).checkAlternateKeys(db, table, columns, record, 7)
+	if err == nil || errors.GetCode(err) != errors.ErrResourceConflict {
+		t.Fatalf("checkAlternateKeys() = %v, want ErrResourceConflict", err)
+	}
+	if len(fake.executed("CODE = ?")) != 1 || len(fake.executed("IS_ACTIVE = ?")) != 1 {
+		t.Errorf("unexpected queries: %v", fake.queries)
+	}
+
+	db, _ = newFakeDB(t, map[string]*fakeResult{"count(*)": countResult(0)})
+	if err := (&service{}).checkAlternateKeys(db, table, columns, record, 7); err != nil {
+		t.Errorf("checkAlternateKeys() without conflict = %v", err)
+	}
+
+	// 输入键为空时不检查
+	db, fake = newFakeDB(t, nil)
+	if err := (&service{}).checkAlternateKeys(db, table, columns, map[string]interface{}{"ID": int64(7)}, 7); err != nil {
+		t.Errorf("checkAlternateKeys() with empty AK = %v", err)
+	}
+	if len(fake.queries) != 0 {
+		t.Errorf("empty AK should not be queried: %v", fake.queries)
+	}
+}
+
+func TestLockDeletedAppliesDataFilter(t *testing.T) {
+	table := &entity.SysTable{Name: "CUSTOMER"}
+	columns := []*entity.SysColumn{{DbName: "ID"}, {DbName: "SYS_COMPANY_ID"}}
+	dataFilter := map[string]interface{}{"SYS_COMPANY_ID": 1}
+
+	// 只有 ID=1 在数据权限范围内，恢复 1、2 时返回不存在
+	db, fake := newFakeDB(t, map[string]*fakeResult{
+		"FROM `CUSTOMER`": {columns: []string{"ID"}, rows: [][]driver.Value{{int64(1)}}},
+	})
+	_, err := (&service{}).lockDeleted(db, table, columns, dataFilter, []uint{1, 2})
+	if err == nil || errors.GetCode(err) != errors.ErrResourceNotFound {
+		t.Fatalf("lockDeleted() = %v, want ErrResourceNotFound", err)
+	}
+	if len(fake.executed("SYS_COMPANY_ID = ?")) != 1 {
+		t.Errorf("data filter not applied: %v", fake.queries)
+	}
+
+	records, err := (&service{}).lockDeleted(db, table, columns, dataFilter, []uint{1})
+	if err != nil || len(records) != 1 {
+		t.Errorf("lockDeleted() = %v, %v", records, err)
+	}
+}
@@ -0,0 +1,307 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/mask"
+	"gorm.io/gorm"
+)
+
+// FieldError 字段校验错误（随 ErrValidation 在 data.fields 中返回，表单按字段显示）
+type FieldError struct {
+	Column      string `json:"column"`
+	DisplayName string `json:"displayName"`
+	Message     string `json:"message"`
+}
+
+// autoSetValueTypes 由服务端赋值的赋值方式，新增时未提交也不算空值
+var autoSetValueTypes = map[string]bool{
+	SetValuePK:       true,
+	SetValueDocNo:    true,
+	SetValueCreateBy: true,
+	SetValueSysdate:  true,
+	SetValueOperator: true,
+	SetValueIgnore:   true,
+}
+
+// dateLayouts 日期字段支持的格式
+var dateLayouts = append([]string{"2006-01-02"}, updateTimeLayouts...)
+
+// passwordHashLength 密码字段保存的 bcrypt 哈希长度
+const passwordHashLength = 60
+
+// regexpCache 已编译的字段校验正则
+var regexpCache sync.Map
+
+// validateFields 按字段定义校验并规范化提交的数据（data 为已按 MASK 处理的数据，校验通过的值原地改写）
+// 新增时 id 为0，检查非空字段是否提交；修改时只校验提交的字段
+// 校验项：非空、长度/精度、类型、大写、正则、数据字典、外键引用的记录有效、输入键(AK)唯一
+func (s *service) validateFields(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, data map[string]interface{}, id uint) error {
+	var fieldErrors []*FieldError
+	addError := func(col *entity.SysColumn, message string) {
+		fieldErrors = append(fieldErrors, newFieldError(col, message))
+	}
+
+	for _, col := range columns {
+		if untrackedFields[col.DbName] || lifecycleFields[col.DbName] {
+			continue
+		}
+
+		value, exists := data[col.DbName]
+		if !exists {
+			if id == 0 && requiredOnCreate(col) {
+				addError(col, "不能为空")
+			}
+			continue
+		}
+
+		normalized, message := validateValue(col, value)
+		if message != "" {
+			addError(col, message)
+			continue
+		}
+		data[col.DbName] = normalized
+		if normalized == nil {
+			continue
+		}
+
+		if col.SysDictID != "" {
+			ok, err := s.isDictValue(col.SysDictID, includeKeyString(normalized))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				addError(col, "不是有效的字典值")
+				continue
+			}
+		}
+
+		if col.RefTableID != nil {
+			ok, err := s.refExists(tx, col, normalized)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				addError(col, "引用的记录不存在")
+				continue
+			}
+		}
+
+		if col.IsAK == "Y" {
+			exists, err := s.akExists(tx, table, col, normalized, id)
+			if err != nil {
+				return err
+			}
+			if exists {
+				addError(col, fmt.Sprintf("%s已存在", includeKeyString(normalized)))
+			}
+		}
+	}
+
+	return fieldErrorsToError(fieldErrors)
+}
+
+// newFieldError 创建字段校验错误（未设置显示名称时取字段名）
+func newFieldError(col *entity.SysColumn, message string) *FieldError {
+	displayName := col.DisplayName
+	if displayName == "" {
+		displayName = col.DbName
+	}
+	return &FieldError{Column: col.DbName, DisplayName: displayName, Message: message}
+}
+
+// fieldErrorsToError 将字段校验错误合并为 ErrValidation（没有错误时返回 nil）
+func fieldErrorsToError(fieldErrors []*FieldError) error {
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	message := fieldErrors[0].DisplayName + fieldErrors[0].Message
+	if len(fieldErrors) > 1 {
+		message = fmt.Sprintf("%s等%d个字段校验失败", message, len(fieldErrors))
+	}
+	return errors.New(errors.ErrValidation, message).
+		WithData(map[string]interface{}{"fields": fieldErrors})
+}
+
+// requiredOnCreate 新增时必须提交的字段：不可为空、界面可编辑、没有默认值且不由服务端赋值
+func requiredOnCreate(col *entity.SysColumn) bool {
+	if col.NullAble != "N" || col.DefaultValue != "" {
+		return false
+	}
+	// 未配置序号生成器的单据编号需要手工输入
+	if autoSetValueTypes[col.SetValueType] && (col.SetValueType != SetValueDocNo || col.Seq != "") {
+		return false
+	}
+	return col.Mask == "" || mask.ParseMask(col.Mask).IsEditable("add")
+}
+
+// validateValue 按字段定义校验单个值，返回规范化后的值（空值为 nil）和错误信息
+func validateValue(col *entity.SysColumn, value interface{}) (interface{}, string) {
+	// 服务端填充的时间
+	if _, ok := value.(time.Time); ok {
+		return value, ""
+	}
+
+	str := includeKeyString(value)
+	colType := strings.ToLower(col.ColType)
+	isText := colType == "" || colType == "varchar" || colType == "char" || strings.HasSuffix(colType, "text")
+
+	// 非文本字段的空字符串按空值处理，避免数据库类型转换错误
+	if value == nil || strings.TrimSpace(str) == "" {
+		if col.NullAble == "N" {
+			return nil, "不能为空"
+		}
+		if isText && value != nil {
+			return value, ""
+		}
+		return nil, ""
+	}
+
+	switch colType {
+	case "int", "integer", "bigint", "smallint", "tinyint", "datenumber":
+		if _, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64); err != nil {
+			return nil, "必须是整数"
+		}
+		return value, ""
+	case "decimal", "float", "double", "number":
+		return value, checkNumber(col, colType, strings.TrimSpace(str))
+	case "date", "datetime":
+		if !isDate(strings.TrimSpace(str)) {
+			return nil, "日期格式错误"
+		}
+		return value, ""
+	}
+
+	if col.IsUppercase == "Y" {
+		str = strings.ToUpper(str)
+		value = str
+	}
+	// 密码字段保存的是哈希值，长度与明文无关：不按字段长度校验明文，字段长度不足以保存哈希时报错
+	if col.SetValueType == SetValuePassword {
+		if col.ColLength > 0 && col.ColLength < passwordHashLength {
+			return nil, fmt.Sprintf("字段长度(%d)不足以保存密码，至少需要%d个字符", col.ColLength, passwordHashLength)
+		}
+	} else if col.ColLength > 0 && utf8.RuneCountInString(str) > col.ColLength {
+		return nil, fmt.Sprintf("长度不能超过%d个字符", col.ColLength)
+	}
+	if col.RegExpression != "" {
+		if re := compileRegexp(col.RegExpression); re != nil && !re.MatchString(str) {
+			if col.ErrMsg != "" {
+				return nil, col.ErrMsg
+			}
+			return nil, "格式不正确"
+		}
+	}
+	return value, ""
+}
+
+// checkNumber 校验数字及其整数位数、小数位数（COL_LENGTH 为总位数，COL_PRECISION 为小数位数）
+func checkNumber(col *entity.SysColumn, colType, str string) string {
+	if _, err := strconv.ParseFloat(str, 64); err != nil {
+		return "必须是数字"
+	}
+	if colType != "decimal" || col.ColLength <= 0 {
+		return ""
+	}
+
+	digits := strings.TrimLeft(str, "+-")
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	intDigits := len(strings.TrimLeft(intPart, "0"))
+	fracDigits := len(strings.TrimRight(fracPart, "0"))
+	if fracDigits > col.ColPrecision {
+		return fmt.Sprintf("小数位数不能超过%d位", col.ColPrecision)
+	}
+	if maxInt := col.ColLength - col.ColPrecision; intDigits > maxInt {
+		return fmt.Sprintf("整数部分不能超过%d位", maxInt)
+	}
+	return ""
+}
+
+// compileRegexp 编译字段校验正则，正则本身无效时不校验
+func compileRegexp(expr string) *regexp.Regexp {
+	if cached, ok := regexpCache.Load(expr); ok {
+		re, _ := cached.(*regexp.Regexp)
+		return re
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		re = nil
+	}
+	regexpCache.Store(expr, re)
+	return re
+}
+
+// isDictValue 判断值是否为数据字典的明细值
+func (s *service) isDictValue(dictRef, value string) (bool, error) {
+	items, err := s.dictItems(dictRef)
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		if item.Value == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dictItems 查询数据字典明细（SYS_DICT_ID 为字典ID或字典名称）
+func (s *service) dictItems(dictRef string) ([]*entity.SysDictItem, error) {
+	var items []*entity.SysDictItem
+	var err error
+	if dictID, parseErr := strconv.ParseUint(dictRef, 10, 64); parseErr == nil {
+		items, err = s.dictService.GetDictItems(uint(dictID))
+	} else {
+		items, err = s.dictService.GetDictItemsByName(dictRef)
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("查询数据字典 %s 失败", dictRef), err)
+	}
+	return items, nil
+}
+
+// refExists 判断外键引用的记录是否有效
+func (s *service) refExists(tx *gorm.DB, col *entity.SysColumn, value interface{}) (bool, error) {
+	refTable, err := s.metadataService.GetTableByID(*col.RefTableID)
+	if err != nil {
+		return false, errors.Wrap(errors.ErrInternal, fmt.Sprintf("外键字段 %s 引用的表不存在", col.DbName), err)
+	}
+
+	var count int64
+	if err := tx.Table(refTable.Name).Where("ID = ? AND IS_ACTIVE = ?", value, "Y").Count(&count).Error; err != nil {
+		return false, errors.Wrap(errors.ErrDatabase, "查询引用记录失败", err)
+	}
+	return count > 0, nil
+}
+
+// akExists 判断除 id 外是否存在输入键(AK)相同的有效记录
+func (s *service) akExists(tx *gorm.DB, table *entity.SysTable, col *entity.SysColumn, value interface{}, id uint) (bool, error) {
+	var count int64
+	if err := tx.Table(table.Name).
+		Where(fmt.Sprintf("%s = ?", col.DbName), value).
+		Where("ID <> ? AND IS_ACTIVE = ?", id, "Y").
+		Count(&count).Error; err != nil {
+		return false, errors.Wrap(errors.ErrDatabase, "查询输入键失败", err)
+	}
+	return count > 0, nil
+}
+
+// isDate 判断是否为有效的日期或日期时间
+func isDate(value string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return true
+		}
+	}
+	return false
+}
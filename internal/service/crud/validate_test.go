@@ -0,0 +1,45 @@
+package crud
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     *entity.SysColumn
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"required empty", &entity.SysColumn{ColType: "varchar", NullAble: "N"}, " ", nil, true},
+		{"optional empty text", &entity.SysColumn{ColType: "varchar"}, "", "", false},
+		{"optional empty int", &entity.SysColumn{ColType: "int"}, "", nil, false},
+		{"too long", &entity.SysColumn{ColType: "varchar", ColLength: 3}, "中文字符", nil, true},
+		{"max length", &entity.SysColumn{ColType: "varchar", ColLength: 4}, "中文字符", "中文字符", false},
+		{"password longer than hash", &entity.SysColumn{ColType: "varchar", ColLength: 60, SetValueType: SetValuePassword}, strings.Repeat("p", 72), strings.Repeat("p", 72), false},
+		{"password column too short for hash", &entity.SysColumn{ColType: "varchar", ColLength: 32, SetValueType: SetValuePassword}, "secret", nil, true},
+		{"uppercase", &entity.SysColumn{ColType: "varchar", IsUppercase: "Y"}, "ab1", "AB1", false},
+		{"regexp", &entity.SysColumn{ColType: "varchar", RegExpression: `^\d+$`, ErrMsg: "只能输入数字"}, "12a", nil, true},
+		{"int", &entity.SysColumn{ColType: "int"}, float64(12), float64(12), false},
+		{"not int", &entity.SysColumn{ColType: "int"}, "1.5", nil, true},
+		{"decimal scale", &entity.SysColumn{ColType: "decimal", ColLength: 5, ColPrecision: 2}, "1.234", nil, true},
+		{"decimal digits", &entity.SysColumn{ColType: "decimal", ColLength: 5, ColPrecision: 2}, float64(1234.5), nil, true},
+		{"decimal ok", &entity.SysColumn{ColType: "decimal", ColLength: 5, ColPrecision: 2}, "-123.40", "-123.40", false},
+		{"date", &entity.SysColumn{ColType: "date"}, "2026-10-16", "2026-10-16", false},
+		{"bad date", &entity.SysColumn{ColType: "datetime"}, "2026/10/16", nil, true},
+	}
+	for _, tt := range tests {
+		got, message := validateValue(tt.col, tt.value)
+		if (message != "") != tt.wantErr {
+			t.Errorf("%s: validateValue() message = %q, wantErr %v", tt.name, message, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%s: validateValue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
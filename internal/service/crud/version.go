@@ -0,0 +1,157 @@
+package crud
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 乐观锁字段
+const (
+	ColVersion    = "VERSION"     // 版本号（表定义了该字段时每次修改加1）
+	ColUpdateTime = "UPDATE_TIME" // 更新时间（未定义版本号的表用它校验，精确到秒）
+)
+
+// updateTimeLayouts 客户端提交的 UPDATE_TIME 支持的格式（不带时区的按服务器时区解析）
+var updateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// versionCheck 乐观锁校验条件：客户端读取记录时的 VERSION 或 UPDATE_TIME
+type versionCheck struct {
+	column   string
+	expected interface{}
+}
+
+// takeVersionCheck 从提交的数据中取出乐观锁校验值
+// 表定义了 VERSION 字段且提交了 VERSION 时按版本号校验，否则提交了 UPDATE_TIME 时按更新时间校验，都未提交时不校验
+func takeVersionCheck(columns []*entity.SysColumn, data map[string]interface{}) (*versionCheck, error) {
+	version, hasVersion := data[ColVersion]
+	updateTime, hasUpdateTime := data[ColUpdateTime]
+	// 版本号和更新时间由服务端维护，不能由客户端修改
+	delete(data, ColVersion)
+	delete(data, ColUpdateTime)
+
+	if hasVersion && hasColumn(columns, ColVersion) {
+		if _, ok := toInt64(version); !ok {
+			return nil, errors.New(errors.ErrValidation, "VERSION格式错误")
+		}
+		return &versionCheck{column: ColVersion, expected: version}, nil
+	}
+	if hasUpdateTime {
+		if _, ok := toTime(updateTime); !ok {
+			return nil, errors.New(errors.ErrValidation, "UPDATE_TIME格式错误")
+		}
+		return &versionCheck{column: ColUpdateTime, expected: updateTime}, nil
+	}
+	return nil, nil
+}
+
+// checkVersion 校验乐观锁，返回记录当前的版本号（表未定义 VERSION 时为0）
+// record 为事务中已锁定的当前记录；校验失败时返回 ErrVersionConflict，错误数据中包含记录当前的值
+func (s *service) checkVersion(tx *gorm.DB, table *entity.SysTable, columns []*entity.SysColumn, record map[string]interface{}, check *versionCheck, userID uint) (int64, error) {
+	if check != nil && !versionMatches(check, record[check.column]) {
+		selectFields, err := s.buildSelectFields(columns, userID, "edit")
+		if err != nil {
+			return 0, err
+		}
+		var current map[string]interface{}
+		if err := tx.Table(table.Name).Select(selectFields).Where("ID = ?", record["ID"]).Take(&current).Error; err != nil {
+			return 0, errors.Wrap(errors.ErrDatabase, "查询失败", err)
+		}
+		return 0, errors.New(errors.ErrVersionConflict, "记录已被他人修改，请刷新后重试").
+			WithData(map[string]interface{}{"current": current})
+	}
+
+	version, _ := toInt64(record[ColVersion])
+	return version, nil
+}
+
+// versionMatches 比较客户端提交的值与记录当前的值
+func versionMatches(check *versionCheck, current interface{}) bool {
+	if check.column == ColVersion {
+		expected, ok := toInt64(check.expected)
+		actual, _ := toInt64(current)
+		return ok && expected == actual
+	}
+
+	expected, ok := toTime(check.expected)
+	if !ok {
+		return false
+	}
+	actual, ok := toTime(current)
+	if !ok {
+		return false
+	}
+	if expected.IsZero() || actual.IsZero() {
+		return expected.IsZero() && actual.IsZero()
+	}
+	// 数据库按秒存储
+	return expected.Unix() == actual.Unix()
+}
+
+// toInt64 转换版本号
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case nil:
+		return 0, true
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case []byte:
+		return toInt64(string(v))
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// toTime 转换更新时间，空值返回零值
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}, true
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, true
+		}
+		return *v, true
+	case []byte:
+		return toTime(string(v))
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return time.Time{}, true
+		}
+		for _, layout := range updateTimeLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
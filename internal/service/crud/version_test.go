@@ -0,0 +1,69 @@
+package crud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestTakeVersionCheck(t *testing.T) {
+	withVersion := []*entity.SysColumn{{DbName: "NAME"}, {DbName: ColVersion}}
+	withoutVersion := []*entity.SysColumn{{DbName: "NAME"}}
+
+	data := map[string]interface{}{"NAME": "a", ColVersion: float64(3), ColUpdateTime: "2026-10-16 10:00:00"}
+	check, err := takeVersionCheck(withVersion, data)
+	if err != nil || check == nil || check.column != ColVersion {
+		t.Fatalf("takeVersionCheck() = %+v, %v, want VERSION check", check, err)
+	}
+	if _, ok := data[ColVersion]; ok {
+		t.Errorf("VERSION not removed from data")
+	}
+	if _, ok := data[ColUpdateTime]; ok {
+		t.Errorf("UPDATE_TIME not removed from data")
+	}
+
+	data = map[string]interface{}{"NAME": "a", ColVersion: float64(3), ColUpdateTime: "2026-10-16 10:00:00"}
+	check, err = takeVersionCheck(withoutVersion, data)
+	if err != nil || check == nil || check.column != ColUpdateTime {
+		t.Fatalf("takeVersionCheck() = %+v, %v, want UPDATE_TIME check", check, err)
+	}
+
+	check, err = takeVersionCheck(withoutVersion, map[string]interface{}{"NAME": "a"})
+	if err != nil || check != nil {
+		t.Errorf("takeVersionCheck() = %+v, %v, want no check", check, err)
+	}
+
+	if _, err := takeVersionCheck(withoutVersion, map[string]interface{}{ColUpdateTime: "yesterday"}); err == nil {
+		t.Errorf("takeVersionCheck() error = nil, want invalid UPDATE_TIME")
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	stored := time.Date(2026, 10, 16, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		check   versionCheck
+		current interface{}
+		want    bool
+	}{
+		{"same version", versionCheck{ColVersion, float64(3)}, int64(3), true},
+		{"stale version", versionCheck{ColVersion, float64(2)}, int64(3), false},
+		{"version as string", versionCheck{ColVersion, "3"}, []byte("3"), true},
+		{"same time", versionCheck{ColUpdateTime, "2026-10-16 10:00:00"}, stored, true},
+		{"same time RFC3339", versionCheck{ColUpdateTime, stored.Format(time.RFC3339)}, stored, true},
+		{"time with fraction", versionCheck{ColUpdateTime, stored.Add(300 * time.Millisecond).Format(time.RFC3339Nano)}, stored, true},
+		{"changed time", versionCheck{ColUpdateTime, "2026-10-16 09:59:59"}, stored, false},
+		{"never updated", versionCheck{ColUpdateTime, nil}, nil, true},
+		{"updated since read", versionCheck{ColUpdateTime, ""}, stored, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionMatches(&tt.check, tt.current); got != tt.want {
+				t.Errorf("versionMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
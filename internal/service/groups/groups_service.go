@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	"github.com/sky-xhsoft/sky-server/internal/model/entity"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
@@ -19,7 +20,9 @@ const (
 	PermDelete = 1 << 3 // 8 - 删除
 	PermExport = 1 << 4 // 16 - 导出
 	PermImport = 1 << 5 // 32 - 导入
-	PermAll    = 63     // 111111 - 所有权限
+	PermSubmit = 1 << 6 // 64 - 提交单据
+	PermAudit  = 1 << 7 // 128 - 审核（反提交、作废单据）
+	PermAll    = 255    // 11111111 - 所有权限
 )
 
 // Service 权限组服务接口
@@ -75,7 +78,7 @@ type ListDirectoriesRequest struct {
 // GroupPermission 权限组权限
 type GroupPermission struct {
 	DirectoryID uint   `json:"directoryId"`
-	Permission  int    `json:"permission"`  // 位运算权限值
+	Permission  int    `json:"permission"`  // 位运算权限值（0-255，见 PermRead 等权限位）
 	FilterObj   string `json:"filterObj"`   // JSON格式的过滤条件
 }
 
@@ -375,6 +378,13 @@ func (s *service) AssignPermissions(ctx context.Context, groupID uint, permissio
 		return err
 	}
 
+	// 权限值只能由已定义的权限位组成
+	for _, perm := range permissions {
+		if perm.Permission < PermNone || perm.Permission&^PermAll != 0 {
+			return errors.New(errors.ErrInvalidParam, fmt.Sprintf("目录 %d 的权限值无效: %d（有效范围 0-%d）", perm.DirectoryID, perm.Permission, PermAll))
+		}
+	}
+
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		// 先删除原有权限
 		if err := tx.Model(&entity.SysGroupPrem{}).
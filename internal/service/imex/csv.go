@@ -0,0 +1,166 @@
+package imex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// utf8BOM UTF-8 文件开头可能带有的字节序标记
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvWriter 逐行写出CSV，表头为字段显示名称
+type csvWriter struct {
+	w       *csv.Writer
+	encoder io.WriteCloser // 非 UTF-8 编码时的转码写入器
+	record  []string
+}
+
+// newCSVWriter 创建CSV写入器并写入表头
+func newCSVWriter(opts FileOptions, w io.Writer, columns []*entity.SysColumn) (*csvWriter, error) {
+	comma, err := opts.comma()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := opts.textEncoding()
+	if err != nil {
+		return nil, err
+	}
+
+	cw := &csvWriter{record: make([]string, len(columns))}
+	if enc != nil {
+		// 目标编码无法表示的字符替换为问号，不中断导出
+		cw.encoder = transform.NewWriter(w, encoding.ReplaceUnsupported(enc.NewEncoder()))
+		w = cw.encoder
+	}
+	cw.w = csv.NewWriter(w)
+	cw.w.Comma = comma
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = columnTitle(col)
+	}
+	if err := cw.w.Write(header); err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "写入表头失败", err)
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		cw.record[i] = textValue(value)
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写入数据失败", err)
+	}
+	return nil
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写出CSV文件失败", err)
+	}
+	if cw.encoder != nil {
+		if err := cw.encoder.Close(); err != nil {
+			return errors.Wrap(errors.ErrInternal, "写出CSV文件失败", err)
+		}
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	return nil
+}
+
+// csvReader 逐行读取CSV，第一行为表头
+type csvReader struct {
+	r       *csv.Reader
+	counter *countingReader
+	size    int64
+	header  []string
+}
+
+// newCSVReader 创建CSV读取器并读取表头
+func newCSVReader(opts FileOptions, counter *countingReader, size int64) (*csvReader, error) {
+	comma, err := opts.comma()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := opts.textEncoding()
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = counter
+	if enc != nil {
+		r = transform.NewReader(r, enc.NewDecoder())
+	} else {
+		// 跳过 UTF-8 字节序标记（Excel 另存为的 CSV 带有该标记）
+		br := bufio.NewReader(r)
+		if prefix, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+			_, _ = br.Discard(len(utf8BOM))
+		}
+		r = br
+	}
+
+	cr := &csvReader{r: csv.NewReader(r), counter: counter, size: size}
+	cr.r.Comma = comma
+	cr.r.FieldsPerRecord = -1
+	cr.r.ReuseRecord = true
+
+	header, err := cr.r.Read()
+	if err == io.EOF {
+		return nil, errors.New(errors.ErrInvalidParam, "CSV文件为空")
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidParam, "读取CSV表头失败", err)
+	}
+	cr.header = make([]string, len(header))
+	for i, title := range header {
+		cr.header[i] = strings.TrimSpace(title)
+	}
+	return cr, nil
+}
+
+func (cr *csvReader) Read() (int, map[string]string, error) {
+	values, err := cr.r.Read()
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	if err != nil {
+		// 格式错误只影响当前行，其余错误中止导入
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			return parseErr.StartLine, nil, errors.New(errors.ErrValidation, "CSV格式错误: "+parseErr.Err.Error())
+		}
+		return 0, nil, errors.Wrap(errors.ErrInvalidParam, "读取CSV行失败", err)
+	}
+	line, _ := cr.r.FieldPos(0)
+
+	record := make(map[string]string, len(values))
+	for i, value := range values {
+		if i < len(cr.header) && cr.header[i] != "" {
+			record[cr.header[i]] = value
+		}
+	}
+	return line, record, nil
+}
+
+func (cr *csvReader) Header() []string {
+	return cr.header
+}
+
+func (cr *csvReader) Progress() (int, int) {
+	return int(cr.counter.n), int(cr.size)
+}
+
+func (cr *csvReader) Close() error {
+	return nil
+}
@@ -0,0 +1,201 @@
+package imex
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 导入导出文件格式
+const (
+	FormatXlsx   = "xlsx"   // Excel（默认）
+	FormatCSV    = "csv"    // CSV，可设置分隔符和编码
+	FormatNDJSON = "ndjson" // JSON Lines，每行一个对象，键为字段名
+)
+
+// 导入导出文件的MIME类型
+const (
+	CSVContentType    = "text/csv"
+	NDJSONContentType = "application/x-ndjson"
+)
+
+// FileOptions 导入导出文件格式选项
+type FileOptions struct {
+	Format    string `json:"format"`    // 文件格式：xlsx（默认）、csv、ndjson
+	Delimiter string `json:"delimiter"` // CSV分隔符，默认逗号，\t 或 tab 表示制表符
+	Encoding  string `json:"encoding"`  // CSV编码：utf-8（默认）、gbk、gb18030
+}
+
+// normalize 检查并规范化格式选项，未指定格式时按文件名的扩展名判断
+func (o FileOptions) normalize(fileName string) (FileOptions, error) {
+	o.Format = strings.ToLower(strings.TrimSpace(o.Format))
+	if o.Format == "" {
+		o.Format = formatOf(fileName)
+	}
+	switch o.Format {
+	case FormatXlsx, FormatNDJSON:
+		return FileOptions{Format: o.Format}, nil
+	case FormatCSV:
+	case "jsonl":
+		return FileOptions{Format: FormatNDJSON}, nil
+	default:
+		return o, errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的文件格式: %s", o.Format))
+	}
+
+	if _, err := o.comma(); err != nil {
+		return o, err
+	}
+	if _, err := o.textEncoding(); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+// formatOf 按文件扩展名判断文件格式（无法判断时为Excel）
+func formatOf(fileName string) string {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return FormatXlsx
+}
+
+// comma CSV分隔符
+func (o FileOptions) comma() (rune, error) {
+	switch strings.ToLower(o.Delimiter) {
+	case "":
+		return ',', nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(o.Delimiter)
+	if size != len(o.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, errors.New(errors.ErrInvalidParam, fmt.Sprintf("无效的CSV分隔符: %s", o.Delimiter))
+	}
+	return r, nil
+}
+
+// textEncoding CSV编码，UTF-8 时返回 nil
+func (o FileOptions) textEncoding() (encoding.Encoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(o.Encoding, "-", "")) {
+	case "", "utf8":
+		return nil, nil
+	case "gbk", "cp936":
+		return simplifiedchinese.GBK, nil
+	case "gb18030":
+		return simplifiedchinese.GB18030, nil
+	}
+	return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的文件编码: %s", o.Encoding))
+}
+
+// contentType 文件的MIME类型
+func (o FileOptions) contentType() string {
+	switch o.Format {
+	case FormatCSV:
+		return CSVContentType
+	case FormatNDJSON:
+		return NDJSONContentType
+	}
+	return XlsxContentType
+}
+
+// extension 文件扩展名
+func (o FileOptions) extension() string {
+	return "." + o.Format
+}
+
+// rowWriter 导出文件的逐行写入
+type rowWriter interface {
+	// WriteRow 写入一行（按导出字段的顺序）
+	WriteRow(values []interface{}) error
+	// Flush 导出完成时写出剩余的内容
+	Flush() error
+	// Close 释放资源
+	Close() error
+}
+
+// newRowWriter 创建导出文件的写入器并写入表头
+func newRowWriter(opts FileOptions, w io.Writer, columns []*entity.SysColumn) (rowWriter, error) {
+	switch opts.Format {
+	case FormatCSV:
+		cw, err := newCSVWriter(opts, w, columns)
+		if err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	}
+	xw, err := newXlsxWriter(w, columns)
+	if err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+// rowReader 导入文件的逐行读取
+type rowReader interface {
+	// Read 读取下一个数据行，返回行号和各单元格的值（键为表头的字段显示名称或字段名），读完时返回 io.EOF
+	Read() (line int, record map[string]string, err error)
+	// Header 表头（没有表头的格式返回 nil）
+	Header() []string
+	// Progress 已读取的进度（已读取量/总量，总量未知时为0）
+	Progress() (done, total int)
+	// Close 释放资源
+	Close() error
+}
+
+// newRowReader 创建导入文件的读取器，size 为文件大小（未知时为0，只用于报告进度）
+func newRowReader(opts FileOptions, r io.Reader, size int64) (rowReader, error) {
+	counter := &countingReader{r: r}
+	switch opts.Format {
+	case FormatCSV:
+		cr, err := newCSVReader(opts, counter, size)
+		if err != nil {
+			return nil, err
+		}
+		return cr, nil
+	case FormatNDJSON:
+		return newNDJSONReader(counter, size), nil
+	}
+	book, err := openWorkbook(r)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// countingReader 统计已读取的字节数（文本格式按读取的字节数报告进度）
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// textValue 文本格式中单元格的值（空值为空字符串）
+func textValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,115 @@
+package imex
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func TestFileOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		opts     FileOptions
+		fileName string
+		format   string
+		wantErr  bool
+	}{
+		{FileOptions{}, "", FormatXlsx, false},
+		{FileOptions{}, "data.CSV", FormatCSV, false},
+		{FileOptions{}, "data.jsonl", FormatNDJSON, false},
+		{FileOptions{Format: "JSONL"}, "data.xlsx", FormatNDJSON, false},
+		{FileOptions{Format: "csv", Delimiter: "tab", Encoding: "GBK"}, "", FormatCSV, false},
+		{FileOptions{Format: "csv", Delimiter: ";;"}, "", "", true},
+		{FileOptions{Format: "csv", Encoding: "big5"}, "", "", true},
+		{FileOptions{Format: "xml"}, "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.normalize(tt.fileName)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalize(%+v, %q) error = %v, wantErr %v", tt.opts, tt.fileName, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.Format != tt.format {
+			t.Errorf("normalize(%+v, %q) format = %q, want %q", tt.opts, tt.fileName, got.Format, tt.format)
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	columns := []*entity.SysColumn{
+		{DbName: "CODE", DisplayName: "编码"},
+		{DbName: "NAME", DisplayName: "名称"},
+	}
+	opts := FileOptions{Format: FormatCSV, Delimiter: ";", Encoding: "gbk"}
+
+	var buf bytes.Buffer
+	rw, err := newRowWriter(opts, &buf, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]interface{}{{"A01", "中文;名称"}, {float64(2), nil}} {
+		if err := rw.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	size := int64(buf.Len())
+	reader, err := newRowReader(opts, &buf, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if header := reader.Header(); len(header) != 2 || header[1] != "名称" {
+		t.Fatalf("header = %v", header)
+	}
+	line, record, err := reader.Read()
+	if err != nil || line != 2 || record["编码"] != "A01" || record["名称"] != "中文;名称" {
+		t.Fatalf("row 1 = %d %v %v", line, record, err)
+	}
+	line, record, err = reader.Read()
+	if err != nil || line != 3 || record["编码"] != "2" || record["名称"] != "" {
+		t.Fatalf("row 2 = %d %v %v", line, record, err)
+	}
+	if _, _, err := reader.Read(); err != io.EOF {
+		t.Fatalf("want EOF, got %v", err)
+	}
+	if done, total := reader.Progress(); done != int(size) || total != int(size) {
+		t.Errorf("progress = %d/%d, want %d/%d", done, total, size, size)
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := "{\"CODE\":\"A01\",\"QTY\":10,\"ACTIVE\":true,\"MEMO\":null}\n\nnot json\n{\"CODE\":\"A02\"}\n"
+	reader, err := newRowReader(FileOptions{Format: FormatNDJSON}, bytes.NewBufferString(input), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	line, record, err := reader.Read()
+	if err != nil || line != 1 || record["CODE"] != "A01" || record["QTY"] != "10" || record["ACTIVE"] != "Y" {
+		t.Fatalf("line 1 = %d %v %v", line, record, err)
+	}
+	if _, ok := record["MEMO"]; ok {
+		t.Errorf("null value should be skipped")
+	}
+	// 格式错误的行返回校验错误，导入时记为失败行
+	line, _, err = reader.Read()
+	if line != 3 || errors.GetCode(err) != errors.ErrValidation {
+		t.Fatalf("line 3 = %d %v", line, err)
+	}
+	line, record, err = reader.Read()
+	if err != nil || line != 4 || record["CODE"] != "A02" {
+		t.Fatalf("line 4 = %d %v %v", line, record, err)
+	}
+	if _, _, err := reader.Read(); err != io.EOF {
+		t.Fatalf("want EOF, got %v", err)
+	}
+}
@@ -3,299 +3,497 @@ package imex
 import (
 	"context"
 	"fmt"
-	"mime/multipart"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sky-xhsoft/sky-server/internal/model/entity"
-	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/sky-xhsoft/sky-server/internal/service/dict"
+	"github.com/sky-xhsoft/sky-server/internal/service/job"
 	"github.com/xuri/excelize/v2"
-	"gorm.io/gorm"
 )
 
 // Service 导入导出服务接口
 type Service interface {
-	// ExportToExcel 导出数据到Excel
-	ExportToExcel(ctx context.Context, tableName string, filters map[string]interface{}, userID uint) (string, error)
+	// ExportToExcel 按查询条件导出数据到Excel（需要导出权限，按数据权限过滤，字典字段导出显示名称，外键字段导出显示键）
+	ExportToExcel(ctx context.Context, req *crud.QueryRequest, userID uint, w io.Writer) error
 
-	// ImportFromExcel 从Excel导入数据
-	ImportFromExcel(ctx context.Context, tableName string, file *multipart.FileHeader, userID uint) (*ImportResult, error)
+	// ImportFromExcel 从Excel导入数据（需要导入权限，逐行新增或按输入键更新，字典字段按显示名称、外键字段按显示键转换为值）
+	ImportFromExcel(ctx context.Context, tableName string, r io.Reader, opts crud.ImportOptions, userID uint) (*ImportResult, error)
 
-	// GenerateTemplate 生成Excel导入模板
-	GenerateTemplate(ctx context.Context, tableName string) (string, error)
+	// GenerateTemplate 生成Excel导入模板（需要导入权限）
+	GenerateTemplate(ctx context.Context, tableName string, userID uint, w io.Writer) error
+
+	// SubmitExport 提交导出作业（后台执行，结果文件为导出的Excel、CSV或NDJSON文件）
+	SubmitExport(ctx context.Context, req *crud.QueryRequest, file FileOptions, userID uint) (*job.JobView, error)
+
+	// SubmitImport 提交导入作业（后台执行，执行结果为导入结果，Excel文件有失败的行时结果文件为错误报告）
+	SubmitImport(ctx context.Context, tableName string, upload *Upload, opts crud.ImportOptions, userID uint) (*job.JobView, error)
+}
+
+// Upload 上传的导入文件
+type Upload struct {
+	Reader  io.Reader
+	Name    string      // 文件名（未指定格式时按扩展名判断）
+	Size    int64       // 文件大小（CSV、NDJSON按已读取的字节数报告进度）
+	Options FileOptions // 文件格式选项
 }
 
 // ImportResult 导入结果
 type ImportResult struct {
-	Total   int      `json:"total"`   // 总行数
-	Success int      `json:"success"` // 成功导入数
-	Failed  int      `json:"failed"`  // 失败数
-	Errors  []string `json:"errors"`  // 错误信息
+	DryRun          bool        `json:"dryRun"`          // 试运行（只校验不写入）
+	Upsert          bool        `json:"upsert"`          // 按输入键更新
+	Total           int         `json:"total"`           // 总行数
+	Success         int         `json:"success"`         // 成功数（试运行时为校验通过数）
+	Created         int         `json:"created"`         // 新增数（试运行时为将新增数）
+	Updated         int         `json:"updated"`         // 修改数（试运行时为将修改数）
+	Failed          int         `json:"failed"`          // 失败数
+	Errors          []string    `json:"errors"`          // 错误信息
+	Rows            []*RowError `json:"rows"`            // 失败行明细
+	ErrorsTruncated bool        `json:"errorsTruncated"` // 失败行超过 maxRowErrors 行，只保留前面的明细
 }
 
-// service 导入导出服务实现
-type service struct {
-	db              *gorm.DB
-	metadataService metadata.Service
-	exportDir       string // 导出文件目录
+// RowError 导入失败的行
+type RowError struct {
+	Row     int                `json:"row"`              // 文件中的行号
+	Message string             `json:"message"`          // 错误信息
+	Fields  []*crud.FieldError `json:"fields,omitempty"` // 字段校验错误
 }
 
-// NewService 创建导入导出服务
-func NewService(db *gorm.DB, metadataService metadata.Service, exportDir string) Service {
-	if exportDir == "" {
-		exportDir = "./exports"
-	}
-	return &service{
-		db:              db,
-		metadataService: metadataService,
-		exportDir:       exportDir,
+// maxRowErrors 导入结果中保留的失败行明细数，避免大文件的结果占用过多内存和存储
+const maxRowErrors = 100
+
+// addError 记录失败的行
+func (r *ImportResult) addError(rowErr *RowError) {
+	r.Failed++
+	if len(r.Rows) >= maxRowErrors {
+		r.ErrorsTruncated = true
+		return
 	}
+	r.Rows = append(r.Rows, rowErr)
+	r.Errors = append(r.Errors, fmt.Sprintf("第%d行: %s", rowErr.Row, rowErr.Message))
 }
 
-// ExportToExcel 导出数据到Excel
-func (s *service) ExportToExcel(ctx context.Context, tableName string, filters map[string]interface{}, userID uint) (string, error) {
-	// 获取表元数据
-	table, err := s.metadataService.GetTable(tableName)
-	if err != nil {
-		return "", errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
-	}
+// sheetName 导出和模板的工作表名称
+const sheetName = "Sheet1"
 
-	// 获取字段定义
-	columns, err := s.metadataService.GetColumns(table.ID)
-	if err != nil {
-		return "", err
-	}
+// XlsxContentType Excel文件的MIME类型
+const XlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 
-	// 创建Excel文件
-	f := excelize.NewFile()
-	defer f.Close()
+// progressFunc 报告进度（已处理数/总数），返回错误时中止（作业已取消）
+type progressFunc func(done, total int) error
 
-	sheetName := "Sheet1"
-	f.SetSheetName(f.GetSheetName(0), sheetName)
+// service 导入导出服务实现
+type service struct {
+	crudService crud.Service
+	dictService dict.Service
+	jobService  job.Service
+}
 
-	// 写入表头
-	for i, col := range columns {
-		cell := string(rune('A'+i)) + "1"
-		f.SetCellValue(sheetName, cell, col.Name)
+// NewService 创建导入导出服务，并注册导入、导出作业类型
+func NewService(crudService crud.Service, dictService dict.Service, jobService job.Service) Service {
+	s := &service{
+		crudService: crudService,
+		dictService: dictService,
+		jobService:  jobService,
 	}
+	jobService.Register(JobExport, s.runExport)
+	jobService.Register(JobImport, s.runImport)
+	return s
+}
 
-	// 查询数据
-	query := s.db.WithContext(ctx).Table(table.Name).Where("IS_ACTIVE = ?", "Y")
-
-	// 应用过滤条件
-	for key, value := range filters {
-		query = query.Where(fmt.Sprintf("%s = ?", key), value)
-	}
+// ExportToExcel 按查询条件导出数据到Excel
+// 查询条件和排序同列表查询，不分页；表头为字段显示名称
+func (s *service) ExportToExcel(ctx context.Context, req *crud.QueryRequest, userID uint, w io.Writer) error {
+	return s.export(ctx, req, FileOptions{Format: FormatXlsx}, userID, w, nil)
+}
 
-	var results []map[string]interface{}
-	if err := query.Find(&results).Error; err != nil {
-		return "", errors.Wrap(errors.ErrDatabase, "查询数据失败", err)
+// export 按查询条件逐行导出数据，progress 不为空时按已写入的行数报告进度
+// 记录从数据库游标逐条读取并写出，CSV、NDJSON 导出占用的内存与记录数无关
+func (s *service) export(ctx context.Context, req *crud.QueryRequest, file FileOptions, userID uint, w io.Writer, progress progressFunc) error {
+	exporter, err := s.crudService.NewExporter(ctx, req, userID)
+	if err != nil {
+		return err
 	}
+	columns := exporter.Columns()
 
-	// 写入数据
-	for rowIdx, row := range results {
-		for colIdx, col := range columns {
-			cell := string(rune('A'+colIdx)) + strconv.Itoa(rowIdx+2)
-			value := row[col.DbName]
-			f.SetCellValue(sheetName, cell, value)
+	total := 0
+	if progress != nil {
+		count, err := exporter.Count()
+		if err != nil {
+			return err
 		}
+		total = int(count)
 	}
 
-	// 生成文件名
-	filename := fmt.Sprintf("%s_%s.xlsx", tableName, time.Now().Format("20060102150405"))
-	filepath := fmt.Sprintf("%s/%s", s.exportDir, filename)
-
-	// 保存文件
-	if err := f.SaveAs(filepath); err != nil {
-		return "", errors.Wrap(errors.ErrInternal, "保存Excel文件失败", err)
-	}
-
-	return filepath, nil
-}
-
-// ImportFromExcel 从Excel导入数据
-func (s *service) ImportFromExcel(ctx context.Context, tableName string, fileHeader *multipart.FileHeader, userID uint) (*ImportResult, error) {
-	// 获取表元数据
-	table, err := s.metadataService.GetTable(tableName)
+	dicts, err := s.loadDicts(columns)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+		return err
 	}
 
-	// 获取字段定义
-	columns, err := s.metadataService.GetColumns(table.ID)
+	rw, err := newRowWriter(file, w, columns)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rw.Close()
 
-	// 打开上传的文件
-	file, err := fileHeader.Open()
+	done := 0
+	values := make([]interface{}, len(columns))
+	err = exporter.Each(func(row map[string]interface{}) error {
+		for i, col := range columns {
+			values[i] = exportValue(col, row[col.DbName], dicts[col.DbName])
+		}
+		if err := rw.WriteRow(values); err != nil {
+			return err
+		}
+		done++
+		if progress != nil {
+			return progress(done, total)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrInternal, "打开文件失败", err)
+		return err
 	}
-	defer file.Close()
+	return rw.Flush()
+}
 
-	// 读取Excel文件
-	f, err := excelize.OpenReader(file)
+// ImportFromExcel 从Excel导入数据
+// 第一个工作表的第一行为表头（字段显示名称或字段名）；每行单独处理，失败的行记录错误后继续
+func (s *service) ImportFromExcel(ctx context.Context, tableName string, r io.Reader, opts crud.ImportOptions, userID uint) (*ImportResult, error) {
+	book, err := openWorkbook(r)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrInternal, "解析Excel文件失败", err)
+		return nil, err
 	}
-	defer f.Close()
+	defer book.Close()
+
+	return s.importRows(ctx, book, tableName, opts, userID, nil, nil)
+}
 
-	// 获取第一个工作表
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
+// importRows 逐行导入，表头或 NDJSON 的键按字段显示名称或字段名匹配可导入的字段
+// progress 不为空时按读取进度报告进度；failed 不为空时每个失败的行都会回调（生成错误报告时收集）
+func (s *service) importRows(ctx context.Context, reader rowReader, tableName string, opts crud.ImportOptions, userID uint, progress progressFunc, failed func(*RowError)) (*ImportResult, error) {
+	importer, err := s.crudService.NewImporter(ctx, tableName, opts, userID)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrInternal, "读取Excel行失败", err)
+		return nil, err
 	}
+	columns := importer.Columns()
 
-	if len(rows) == 0 {
-		return nil, errors.New(errors.ErrInvalidParam, "Excel文件为空")
+	dicts, err := s.loadDicts(columns)
+	if err != nil {
+		return nil, err
 	}
 
-	// 第一行是表头，建立列名映射
-	header := rows[0]
-	colMap := make(map[int]*entity.SysColumn)
-	for i, headerName := range header {
-		for _, col := range columns {
-			if col.Name == headerName || col.DbName == headerName {
-				colMap[i] = col
+	if header := reader.Header(); header != nil {
+		matched := false
+		for _, title := range header {
+			if findColumn(columns, title) != nil {
+				matched = true
 				break
 			}
 		}
+		if !matched {
+			return nil, errors.New(errors.ErrInvalidParam, "表头中没有可导入的字段")
+		}
 	}
 
+	// 只有Excel读取的原始值中日期为序列号，文本格式中的数字按原样导入
+	_, serialDates := reader.(*workbook)
+
 	result := &ImportResult{
-		Total:   len(rows) - 1,
-		Success: 0,
-		Failed:  0,
-		Errors:  make([]string, 0),
+		DryRun: opts.DryRun,
+		Upsert: opts.Upsert,
+		Errors: make([]string, 0),
+		Rows:   make([]*RowError, 0),
+	}
+	addError := func(rowErr *RowError) {
+		result.addError(rowErr)
+		if failed != nil {
+			failed(rowErr)
+		}
 	}
 
-	// 逐行导入数据
-	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
-		row := rows[rowIdx]
-		data := make(map[string]interface{})
-
-		// 解析行数据
-		for colIdx, cellValue := range row {
-			col, exists := colMap[colIdx]
-			if !exists {
-				continue
+	for {
+		if progress != nil {
+			if err := progress(reader.Progress()); err != nil {
+				return nil, err
 			}
+		}
 
-			// 类型转换
-			var value interface{}
-			switch col.ColType {
-			case "int":
-				if cellValue != "" {
-					v, err := strconv.Atoi(cellValue)
-					if err != nil {
-						result.Errors = append(result.Errors, fmt.Sprintf("第%d行第%d列: 整数格式错误", rowIdx+1, colIdx+1))
-						continue
-					}
-					value = v
-				}
-			case "decimal":
-				if cellValue != "" {
-					v, err := strconv.ParseFloat(cellValue, 64)
-					if err != nil {
-						result.Errors = append(result.Errors, fmt.Sprintf("第%d行第%d列: 小数格式错误", rowIdx+1, colIdx+1))
-						continue
-					}
-					value = v
-				}
-			case "date", "datetime":
-				if cellValue != "" {
-					value = cellValue
-				}
-			default:
-				value = cellValue
+		line, record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 格式错误的行记为失败，其余读取错误中止导入
+			if errors.GetCode(err) != errors.ErrValidation {
+				return nil, err
 			}
-
-			data[col.DbName] = value
+			result.Total++
+			addError(&RowError{Row: line, Message: errorMessage(err)})
+			continue
 		}
 
-		// 添加审计字段
-		data["CREATE_BY"] = fmt.Sprintf("user_%d", userID)
-		data["UPDATE_BY"] = fmt.Sprintf("user_%d", userID)
-		data["IS_ACTIVE"] = "Y"
+		data := make(map[string]interface{}, len(record))
+		for title, cellValue := range record {
+			col := findColumn(columns, title)
+			if col == nil || strings.TrimSpace(cellValue) == "" {
+				continue
+			}
+			data[col.DbName] = importValue(col, cellValue, dicts[col.DbName], serialDates)
+		}
+		// 跳过空行
+		if len(data) == 0 {
+			continue
+		}
 
-		// 插入数据
-		if err := s.db.WithContext(ctx).Table(table.Name).Create(data).Error; err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, fmt.Sprintf("第%d行: 插入失败 - %s", rowIdx+1, err.Error()))
+		result.Total++
+		action, err := importer.Import(data)
+		if err != nil {
+			addError(&RowError{Row: line, Message: errorMessage(err), Fields: fieldErrors(err)})
+			continue
+		}
+		result.Success++
+		if action == crud.ImportUpdated {
+			result.Updated++
 		} else {
-			result.Success++
+			result.Created++
 		}
 	}
 
 	return result, nil
 }
 
+// findColumn 按字段显示名称或字段名（不区分大小写）查找字段
+func findColumn(columns []*entity.SysColumn, title string) *entity.SysColumn {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil
+	}
+	for _, col := range columns {
+		if title == col.DisplayName || strings.EqualFold(title, col.DbName) {
+			return col
+		}
+	}
+	return nil
+}
+
 // GenerateTemplate 生成Excel导入模板
-func (s *service) GenerateTemplate(ctx context.Context, tableName string) (string, error) {
-	// 获取表元数据
-	table, err := s.metadataService.GetTable(tableName)
+// 表头为可导入字段的显示名称，批注说明字段名、类型、是否必填和默认值，字典字段提供下拉选项
+func (s *service) GenerateTemplate(ctx context.Context, tableName string, userID uint, w io.Writer) error {
+	importer, err := s.crudService.NewImporter(ctx, tableName, crud.ImportOptions{}, userID)
 	if err != nil {
-		return "", errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+		return err
 	}
+	columns := importer.Columns()
 
-	// 获取字段定义
-	columns, err := s.metadataService.GetColumns(table.ID)
+	dicts, err := s.loadDicts(columns)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// 创建Excel文件
 	f := excelize.NewFile()
 	defer f.Close()
-
-	sheetName := "Sheet1"
 	f.SetSheetName(f.GetSheetName(0), sheetName)
 
-	// 写入表头
 	for i, col := range columns {
-		// 跳过系统字段
-		if strings.HasPrefix(col.DbName, "CREATE_") ||
-			strings.HasPrefix(col.DbName, "UPDATE_") ||
-			col.DbName == "IS_ACTIVE" ||
-			col.DbName == "ID" {
-			continue
+		cell, err := excelize.CoordinatesToCellName(i+1, 1)
+		if err != nil {
+			return errors.Wrap(errors.ErrInternal, "生成模板失败", err)
+		}
+		if err := f.SetCellValue(sheetName, cell, columnTitle(col)); err != nil {
+			return errors.Wrap(errors.ErrInternal, "生成模板失败", err)
 		}
 
-		cell := string(rune('A'+i)) + "1"
-		// 使用displayName作为表头，并添加注释
-		f.SetCellValue(sheetName, cell, col.Name)
-
-		// 添加备注说明字段类型
 		comment := fmt.Sprintf("字段: %s\n类型: %s\n", col.DbName, col.ColType)
-		if col.NullAble == "Y" {
+		if col.NullAble == "N" && col.DefaultValue == "" {
 			comment += "必填: 是\n"
 		}
 		if col.DefaultValue != "" {
 			comment += fmt.Sprintf("默认值: %s\n", col.DefaultValue)
 		}
-		err := f.AddComment(sheetName, excelize.Comment{
+		if col.IsAK == "Y" {
+			comment += "输入键: 是（按输入键更新时用于匹配已有记录）\n"
+		}
+		if col.RefTableID != nil {
+			refTable, refColumn, err := importer.RefColumn(col)
+			if err != nil {
+				return err
+			}
+			if refColumn != nil {
+				comment += fmt.Sprintf("填写: %s的%s\n", tableTitle(refTable), columnTitle(refColumn))
+			} else {
+				comment += fmt.Sprintf("填写: %s的ID\n", tableTitle(refTable))
+			}
+		}
+		// 批注和下拉选项只是提示，添加失败不影响模板使用
+		_ = f.AddComment(sheetName, excelize.Comment{
 			Cell:   cell,
 			Author: "System",
 			Text:   comment,
 		})
+
+		items := dicts[col.DbName]
+		if len(items) == 0 {
+			continue
+		}
+		labels := make([]string, 0, len(items))
+		for _, item := range items {
+			labels = append(labels, itemLabel(item))
+		}
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		dv := excelize.NewDataValidation(true)
+		dv.Sqref = fmt.Sprintf("%s2:%s%d", column, column, excelize.TotalRows)
+		if err := dv.SetDropList(labels); err == nil {
+			_ = f.AddDataValidation(sheetName, dv)
+		}
+	}
+
+	if err := f.Write(w); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写出模板文件失败", err)
+	}
+	return nil
+}
+
+// loadDicts 查询字典字段的字典明细（按字段名）
+func (s *service) loadDicts(columns []*entity.SysColumn) (map[string][]*entity.SysDictItem, error) {
+	dicts := make(map[string][]*entity.SysDictItem)
+	for _, col := range columns {
+		if col.SysDictID == "" {
+			continue
+		}
+		var items []*entity.SysDictItem
+		var err error
+		// SYS_DICT_ID 为字典ID或字典名称
+		if dictID, parseErr := strconv.ParseUint(col.SysDictID, 10, 64); parseErr == nil {
+			items, err = s.dictService.GetDictItems(uint(dictID))
+		} else {
+			items, err = s.dictService.GetDictItemsByName(col.SysDictID)
+		}
 		if err != nil {
-			// 添加注释失败不影响主流程，只记录错误
-			_ = err
+			return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("查询数据字典 %s 失败", col.SysDictID), err)
+		}
+		dicts[col.DbName] = items
+	}
+	return dicts, nil
+}
+
+// columnTitle 表头显示的字段名称（未设置显示名称时取字段名）
+func columnTitle(col *entity.SysColumn) string {
+	if col.DisplayName != "" {
+		return col.DisplayName
+	}
+	return col.DbName
+}
+
+// itemLabel 字典明细的显示名称（未设置时取值）
+func itemLabel(item *entity.SysDictItem) string {
+	if item.DisplayName != "" {
+		return item.DisplayName
+	}
+	return item.Value
+}
+
+// exportValue 转换导出的单元格值：字典值转为显示名称，日期格式化，数字按数值写入
+func exportValue(col *entity.SysColumn, value interface{}, items []*entity.SysDictItem) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return nil
+		}
+		if strings.ToLower(col.ColType) == "date" {
+			return t.Format("2006-01-02")
 		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
 	}
 
-	// 生成文件名
-	filename := fmt.Sprintf("%s_template.xlsx", tableName)
-	filepath := fmt.Sprintf("%s/%s", s.exportDir, filename)
+	if len(items) > 0 {
+		str := fmt.Sprint(value)
+		for _, item := range items {
+			if item.Value == str {
+				return itemLabel(item)
+			}
+		}
+		return str
+	}
 
-	// 保存文件
-	if err := f.SaveAs(filepath); err != nil {
-		return "", errors.Wrap(errors.ErrInternal, "保存模板文件失败", err)
+	if str, ok := value.(string); ok {
+		switch strings.ToLower(col.ColType) {
+		case "decimal", "float", "double", "number":
+			if f, err := strconv.ParseFloat(str, 64); err == nil {
+				return f
+			}
+		}
+	}
+	return value
+}
+
+// importValue 转换导入的单元格值：字典显示名称转为值（也接受字典值），serialDates 为 true 时Excel日期序列号转为日期
+func importValue(col *entity.SysColumn, cellValue string, items []*entity.SysDictItem, serialDates bool) interface{} {
+	cellValue = strings.TrimSpace(cellValue)
+
+	for _, item := range items {
+		if itemLabel(item) == cellValue {
+			return item.Value
+		}
 	}
 
-	return filepath, nil
+	if !serialDates {
+		return cellValue
+	}
+	switch strings.ToLower(col.ColType) {
+	case "date", "datetime":
+		serial, err := strconv.ParseFloat(cellValue, 64)
+		if err != nil {
+			return cellValue
+		}
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err != nil {
+			return cellValue
+		}
+		if strings.ToLower(col.ColType) == "date" {
+			return t.Format("2006-01-02")
+		}
+		// 秒以下的浮点误差四舍五入
+		return t.Round(time.Second).Format("2006-01-02 15:04:05")
+	}
+	return cellValue
+}
+
+// errorMessage 导入失败的原因，字段校验失败时列出各字段的错误
+func errorMessage(err error) string {
+	if fields := fieldErrors(err); len(fields) > 0 {
+		messages := make([]string, 0, len(fields))
+		for _, field := range fields {
+			messages = append(messages, field.DisplayName+field.Message)
+		}
+		return strings.Join(messages, "；")
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr.Message
+	}
+	return err.Error()
+}
+
+// fieldErrors 字段校验失败时的各字段错误（ErrValidation 的 data.fields）
+func fieldErrors(err error) []*crud.FieldError {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		return nil
+	}
+	if data, ok := appErr.Data.(map[string]interface{}); ok {
+		if fields, ok := data["fields"].([]*crud.FieldError); ok {
+			return fields
+		}
+	}
+	return nil
 }
@@ -0,0 +1,71 @@
+package imex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+)
+
+func TestDictValueMapping(t *testing.T) {
+	col := &entity.SysColumn{DbName: "STATUS", ColType: "varchar", SysDictID: "status"}
+	items := []*entity.SysDictItem{
+		{Value: "1", DisplayName: "启用"},
+		{Value: "0", DisplayName: "停用"},
+	}
+
+	if got := exportValue(col, []byte("1"), items); got != "启用" {
+		t.Errorf("exportValue(1) = %v, want 启用", got)
+	}
+	if got := importValue(col, "停用", items, true); got != "0" {
+		t.Errorf("importValue(停用) = %v, want 0", got)
+	}
+	// 直接填写字典值也可以导入
+	if got := importValue(col, " 1 ", items, false); got != "1" {
+		t.Errorf("importValue(1) = %v, want 1", got)
+	}
+}
+
+func TestDateValues(t *testing.T) {
+	date := &entity.SysColumn{DbName: "BILL_DATE", ColType: "date"}
+	datetime := &entity.SysColumn{DbName: "EDIT_TIME", ColType: "datetime"}
+
+	// 45946 为 2025-10-16
+	if got := importValue(date, "45946", nil, true); got != "2025-10-16" {
+		t.Errorf("importValue(date serial) = %v", got)
+	}
+	if got := importValue(datetime, "45946.5", nil, true); got != "2025-10-16 12:00:00" {
+		t.Errorf("importValue(datetime serial) = %v", got)
+	}
+	if got := importValue(date, "2026-10-16", nil, true); got != "2026-10-16" {
+		t.Errorf("importValue(date text) = %v", got)
+	}
+	// CSV、NDJSON 中的数字不是Excel序列号，按原样导入
+	if got := importValue(date, "20240101", nil, false); got != "20240101" {
+		t.Errorf("importValue(date number in text format) = %v", got)
+	}
+
+	when := time.Date(2026, 10, 16, 8, 30, 0, 0, time.Local)
+	if got := exportValue(date, when, nil); got != "2026-10-16" {
+		t.Errorf("exportValue(date) = %v", got)
+	}
+	if got := exportValue(datetime, when, nil); got != "2026-10-16 08:30:00" {
+		t.Errorf("exportValue(datetime) = %v", got)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := errors.New(errors.ErrValidation, "名称不能为空等2个字段校验失败").
+		WithData(map[string]interface{}{"fields": []*crud.FieldError{
+			{Column: "NAME", DisplayName: "名称", Message: "不能为空"},
+			{Column: "CODE", DisplayName: "编码", Message: "A01已存在"},
+		}})
+	if got := errorMessage(err); got != "名称不能为空；编码A01已存在" {
+		t.Errorf("errorMessage = %q", got)
+	}
+	if got := errorMessage(errors.New(errors.ErrPermissionDenied, "无导入权限")); got != "无导入权限" {
+		t.Errorf("errorMessage = %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package imex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/sky-xhsoft/sky-server/internal/service/job"
+)
+
+// 导入导出作业类型（SysJob.JobType）
+const (
+	JobExport = "export" // Excel导出
+	JobImport = "import" // Excel导入
+)
+
+// exportParams 导出作业参数
+type exportParams struct {
+	Request *crud.QueryRequest `json:"request"`
+	File    FileOptions        `json:"file"`
+}
+
+// importParams 导入作业参数
+type importParams struct {
+	TableName string             `json:"tableName"`
+	FileName  string             `json:"fileName"`
+	FileSize  int64              `json:"fileSize"`
+	File      FileOptions        `json:"file"`
+	Options   crud.ImportOptions `json:"options"`
+}
+
+// SubmitExport 提交导出作业，提交时检查导出权限、查询条件和文件格式
+func (s *service) SubmitExport(ctx context.Context, req *crud.QueryRequest, file FileOptions, userID uint) (*job.JobView, error) {
+	file, err := file.normalize("")
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := s.crudService.NewExporter(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobService.Submit(ctx, &job.SubmitRequest{
+		JobType: JobExport,
+		Title:   "导出" + tableTitle(exporter.Table()),
+		Params:  &exportParams{Request: req, File: file},
+		UserID:  userID,
+	})
+}
+
+// SubmitImport 提交导入作业，提交时检查导入权限（按输入键更新时还检查修改权限）和文件格式，并保存上传的文件
+func (s *service) SubmitImport(ctx context.Context, tableName string, upload *Upload, opts crud.ImportOptions, userID uint) (*job.JobView, error) {
+	file, err := upload.Options.normalize(upload.Name)
+	if err != nil {
+		return nil, err
+	}
+	importer, err := s.crudService.NewImporter(ctx, tableName, opts, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	title := "导入" + tableTitle(importer.Table())
+	if opts.DryRun {
+		title += "（试运行）"
+	}
+	return s.jobService.Submit(ctx, &job.SubmitRequest{
+		JobType: JobImport,
+		Title:   title,
+		Params: &importParams{
+			TableName: tableName,
+			FileName:  upload.Name,
+			FileSize:  upload.Size,
+			File:      file,
+			Options:   opts,
+		},
+		Input:     upload.Reader,
+		InputName: upload.Name,
+		UserID:    userID,
+	})
+}
+
+// runExport 执行导出作业，结果文件为导出的文件（边导出边写入存储）
+func (s *service) runExport(ctx context.Context, task *job.Task) error {
+	var params exportParams
+	if err := task.Bind(&params); err != nil {
+		return err
+	}
+	file, err := params.File.normalize("")
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s_%s%s", params.Request.TableName, time.Now().Format("20060102150405"), file.extension())
+	return task.SaveResult(fileName, file.contentType(), func(w io.Writer) error {
+		return s.export(ctx, params.Request, file, task.Job.UserID, w, task.Progress)
+	})
+}
+
+// runImport 执行导入作业，执行结果为导入结果（新增、修改、失败行数和失败行明细）
+// Excel文件有失败的行时结果文件为错误报告（上传的文件标出失败的行并增加错误信息列）；
+// CSV、NDJSON 逐行流式读取，失败行只在执行结果中记录
+func (s *service) runImport(ctx context.Context, task *job.Task) error {
+	var params importParams
+	if err := task.Bind(&params); err != nil {
+		return err
+	}
+	file, err := params.File.normalize(params.FileName)
+	if err != nil {
+		return err
+	}
+
+	input, err := task.OpenInput()
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	reader, err := newRowReader(file, input, params.FileSize)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Excel文件已整体读入内存，收集全部失败行用于生成错误报告
+	book, isBook := reader.(*workbook)
+	var failed func(*RowError)
+	var failedRows []*RowError
+	if isBook {
+		failed = func(rowErr *RowError) {
+			failedRows = append(failedRows, rowErr)
+		}
+	}
+
+	result, err := s.importRows(ctx, reader, params.TableName, params.Options, task.Job.UserID, task.Progress, failed)
+	if err != nil {
+		return err
+	}
+
+	if len(failedRows) > 0 {
+		if err := task.SaveResult(errorReportName(params.FileName), XlsxContentType, func(w io.Writer) error {
+			return book.writeErrorReport(failedRows, w)
+		}); err != nil {
+			return err
+		}
+	}
+	return task.SetResult(result)
+}
+
+// tableTitle 表的显示名称（未设置时取表名）
+func tableTitle(table *entity.SysTable) string {
+	if table.DisplayName != "" {
+		return table.DisplayName
+	}
+	return table.Name
+}
@@ -0,0 +1,147 @@
+package imex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// maxNDJSONLine NDJSON 单行的最大长度
+const maxNDJSONLine = 16 << 20
+
+// ndjsonWriter 逐行写出 JSON Lines，每行一个对象，键为字段名（按字段顺序）
+type ndjsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte // 各字段已编码的键
+	buf  bytes.Buffer
+}
+
+// newNDJSONWriter 创建 NDJSON 写入器
+func newNDJSONWriter(w io.Writer, columns []*entity.SysColumn) *ndjsonWriter {
+	nw := &ndjsonWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(columns))}
+	for i, col := range columns {
+		key, _ := json.Marshal(col.DbName)
+		nw.keys[i] = key
+	}
+	return nw
+}
+
+func (nw *ndjsonWriter) WriteRow(values []interface{}) error {
+	nw.buf.Reset()
+	nw.buf.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			nw.buf.WriteByte(',')
+		}
+		nw.buf.Write(nw.keys[i])
+		nw.buf.WriteByte(':')
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(errors.ErrInternal, "写入数据失败", err)
+		}
+		nw.buf.Write(data)
+	}
+	nw.buf.WriteString("}\n")
+	if _, err := nw.w.Write(nw.buf.Bytes()); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写入数据失败", err)
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	if err := nw.w.Flush(); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写出NDJSON文件失败", err)
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// ndjsonReader 逐行读取 JSON Lines，每行一个对象（键为字段名或字段显示名称），跳过空行
+// 不是有效JSON对象的行作为失败的行，其余行继续导入
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	counter *countingReader
+	size    int64
+	line    int
+}
+
+// newNDJSONReader 创建 NDJSON 读取器
+func newNDJSONReader(counter *countingReader, size int64) *ndjsonReader {
+	scanner := bufio.NewScanner(counter)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+	return &ndjsonReader{scanner: scanner, counter: counter, size: size}
+}
+
+func (nr *ndjsonReader) Read() (int, map[string]string, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		data := bytes.TrimSpace(nr.scanner.Bytes())
+		if nr.line == 1 {
+			data = bytes.TrimPrefix(data, utf8BOM)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			return nr.line, nil, errors.New(errors.ErrValidation, "不是有效的JSON对象")
+		}
+
+		record := make(map[string]string, len(object))
+		for key, value := range object {
+			if str, ok := jsonText(value); ok {
+				record[key] = str
+			}
+		}
+		return nr.line, record, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return nr.line + 1, nil, errors.Wrap(errors.ErrInvalidParam, "读取NDJSON行失败", err)
+	}
+	return 0, nil, io.EOF
+}
+
+func (nr *ndjsonReader) Header() []string {
+	return nil
+}
+
+func (nr *ndjsonReader) Progress() (int, int) {
+	return int(nr.counter.n), int(nr.size)
+}
+
+func (nr *ndjsonReader) Close() error {
+	return nil
+}
+
+// jsonText JSON 值转为导入的文本值，null 视为未填写，布尔值转为 Y/N
+func jsonText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "Y", true
+		}
+		return "N", true
+	}
+	// 对象和数组按 JSON 文本导入
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
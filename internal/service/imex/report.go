@@ -0,0 +1,128 @@
+package imex
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/xuri/excelize/v2"
+)
+
+// 错误报告的标记颜色
+const (
+	errorRowFill   = "FFC7CE" // 失败行
+	errorFieldFill = "FF8080" // 校验失败的单元格
+	errorFontColor = "9C0006" // 错误信息
+)
+
+// errorColumnTitle 错误报告中增加的错误信息列
+const errorColumnTitle = "错误信息"
+
+// writeErrorReport 生成错误报告：在上传的文件中标出失败的行和校验失败的单元格，并在最后增加错误信息列
+func (b *workbook) writeErrorReport(failed []*RowError, w io.Writer) error {
+	width := 0
+	for _, row := range b.rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	errorColumn := width + 1
+
+	// 表头（字段显示名称或字段名）对应的列，用于标出校验失败的单元格
+	header := b.Header()
+	fieldColumn := func(field *crud.FieldError) int {
+		for i, title := range header {
+			if title != "" && (title == field.DisplayName || strings.EqualFold(title, field.Column)) {
+				return i + 1
+			}
+		}
+		return 0
+	}
+
+	// 在单元格原有样式（数字格式、字体等）的基础上设置填充色，按原样式缓存
+	type styleKey struct {
+		styleID   int
+		fill      string
+		fontColor string
+	}
+	styles := make(map[styleKey]int)
+	highlight := func(cell, fill, fontColor string) error {
+		styleID, err := b.f.GetCellStyle(b.sheet, cell)
+		if err != nil {
+			return err
+		}
+		key := styleKey{styleID: styleID, fill: fill, fontColor: fontColor}
+		newID, ok := styles[key]
+		if !ok {
+			style, err := b.f.GetStyle(styleID)
+			if err != nil {
+				return err
+			}
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{fill}}
+			if fontColor != "" {
+				if style.Font == nil {
+					style.Font = &excelize.Font{}
+				}
+				style.Font.Color = fontColor
+			}
+			if newID, err = b.f.NewStyle(style); err != nil {
+				return err
+			}
+			styles[key] = newID
+		}
+		return b.f.SetCellStyle(b.sheet, cell, cell, newID)
+	}
+
+	// 错误信息列的表头沿用第一列表头的样式
+	titleCell, _ := excelize.CoordinatesToCellName(errorColumn, 1)
+	if err := b.f.SetCellValue(b.sheet, titleCell, errorColumnTitle); err != nil {
+		return errors.Wrap(errors.ErrInternal, "生成错误报告失败", err)
+	}
+	if styleID, err := b.f.GetCellStyle(b.sheet, "A1"); err == nil {
+		_ = b.f.SetCellStyle(b.sheet, titleCell, titleCell, styleID)
+	}
+
+	for _, rowErr := range failed {
+		failed := make(map[int]bool, len(rowErr.Fields))
+		for _, field := range rowErr.Fields {
+			if col := fieldColumn(field); col > 0 {
+				failed[col] = true
+			}
+		}
+
+		for col := 1; col <= width; col++ {
+			cell, _ := excelize.CoordinatesToCellName(col, rowErr.Row)
+			fill := errorRowFill
+			if failed[col] {
+				fill = errorFieldFill
+			}
+			if err := highlight(cell, fill, ""); err != nil {
+				return errors.Wrap(errors.ErrInternal, "生成错误报告失败", err)
+			}
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(errorColumn, rowErr.Row)
+		if err := b.f.SetCellValue(b.sheet, cell, rowErr.Message); err != nil {
+			return errors.Wrap(errors.ErrInternal, "生成错误报告失败", err)
+		}
+		if err := highlight(cell, errorRowFill, errorFontColor); err != nil {
+			return errors.Wrap(errors.ErrInternal, "生成错误报告失败", err)
+		}
+	}
+
+	if err := b.f.Write(w); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写出错误报告失败", err)
+	}
+	return nil
+}
+
+// errorReportName 错误报告的文件名（上传的文件名加后缀）
+func errorReportName(fileName string) string {
+	base := strings.TrimSuffix(path.Base(strings.ReplaceAll(fileName, "\\", "/")), path.Ext(fileName))
+	if base == "" || base == "." {
+		base = "import"
+	}
+	return base + "_错误报告.xlsx"
+}
@@ -0,0 +1,90 @@
+package imex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteErrorReport(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	for i, row := range [][]interface{}{
+		{"编码", "名称"},
+		{"A01", "正常"},
+		{"A01", ""},
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var upload bytes.Buffer
+	if err := f.Write(&upload); err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := openWorkbook(&upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer book.Close()
+	failed := []*RowError{{
+		Row:     3,
+		Message: "名称不能为空",
+		Fields:  []*crud.FieldError{{Column: "NAME", DisplayName: "名称", Message: "不能为空"}},
+	}}
+	var report bytes.Buffer
+	if err := book.writeErrorReport(failed, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := excelize.OpenReader(&report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	if got, _ := out.GetCellValue(sheet, "C1"); got != errorColumnTitle {
+		t.Errorf("C1 = %q, want %q", got, errorColumnTitle)
+	}
+	if got, _ := out.GetCellValue(sheet, "C3"); got != "名称不能为空" {
+		t.Errorf("C3 = %q", got)
+	}
+	if got, _ := out.GetCellValue(sheet, "C2"); got != "" {
+		t.Errorf("C2 = %q, want empty", got)
+	}
+
+	fill := func(cell string) string {
+		styleID, _ := out.GetCellStyle(sheet, cell)
+		style, err := out.GetStyle(styleID)
+		if err != nil || len(style.Fill.Color) == 0 {
+			return ""
+		}
+		return style.Fill.Color[0]
+	}
+	if got := fill("A3"); got != errorRowFill {
+		t.Errorf("A3 fill = %q, want %q", got, errorRowFill)
+	}
+	if got := fill("B3"); got != errorFieldFill {
+		t.Errorf("B3 fill = %q, want %q", got, errorFieldFill)
+	}
+	if got := fill("A2"); got != "" {
+		t.Errorf("A2 fill = %q, want none", got)
+	}
+}
+
+func TestErrorReportName(t *testing.T) {
+	cases := map[string]string{
+		"客户.xlsx":             "客户_错误报告.xlsx",
+		`C:\data\orders.xlsx`: "orders_错误报告.xlsx",
+		"":                    "import_错误报告.xlsx",
+	}
+	for name, want := range cases {
+		if got := errorReportName(name); got != want {
+			t.Errorf("errorReportName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
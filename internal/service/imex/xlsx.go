@@ -0,0 +1,129 @@
+package imex
+
+import (
+	"io"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxWriter 通过流式写入器逐行写出Excel，表头为字段显示名称
+type xlsxWriter struct {
+	w      io.Writer
+	f      *excelize.File
+	sw     *excelize.StreamWriter
+	rowNum int
+}
+
+// newXlsxWriter 创建Excel写入器并写入表头
+func newXlsxWriter(w io.Writer, columns []*entity.SysColumn) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	f.SetSheetName(f.GetSheetName(0), sheetName)
+
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(errors.ErrInternal, "创建Excel文件失败", err)
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = columnTitle(col)
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		f.Close()
+		return nil, errors.Wrap(errors.ErrInternal, "写入表头失败", err)
+	}
+	return &xlsxWriter{w: w, f: f, sw: sw, rowNum: 1}, nil
+}
+
+func (xw *xlsxWriter) WriteRow(values []interface{}) error {
+	xw.rowNum++
+	cell, err := excelize.CoordinatesToCellName(1, xw.rowNum)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "写入数据失败", err)
+	}
+	if err := xw.sw.SetRow(cell, values); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写入数据失败", err)
+	}
+	return nil
+}
+
+func (xw *xlsxWriter) Flush() error {
+	if err := xw.sw.Flush(); err != nil {
+		return errors.Wrap(errors.ErrInternal, "生成Excel文件失败", err)
+	}
+	if err := xw.f.Write(xw.w); err != nil {
+		return errors.Wrap(errors.ErrInternal, "写出Excel文件失败", err)
+	}
+	return nil
+}
+
+func (xw *xlsxWriter) Close() error {
+	return xw.f.Close()
+}
+
+// workbook 上传的导入文件（读取第一个工作表，第一行为表头）
+type workbook struct {
+	f      *excelize.File
+	sheet  string
+	rows   [][]string
+	header []string
+	next   int // 下一个要读取的行（从0开始）
+}
+
+// openWorkbook 打开上传的Excel文件，读取第一个工作表的原始值（日期为Excel序列号）
+func openWorkbook(r io.Reader) (*workbook, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidParam, "解析Excel文件失败", err)
+	}
+
+	sheet := f.GetSheetName(0)
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(errors.ErrInvalidParam, "读取Excel行失败", err)
+	}
+	if len(rows) == 0 {
+		f.Close()
+		return nil, errors.New(errors.ErrInvalidParam, "Excel文件为空")
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, title := range rows[0] {
+		header[i] = strings.TrimSpace(title)
+	}
+	return &workbook{f: f, sheet: sheet, rows: rows, header: header, next: 1}, nil
+}
+
+func (b *workbook) Read() (int, map[string]string, error) {
+	if b.next >= len(b.rows) {
+		return 0, nil, io.EOF
+	}
+	rowIdx := b.next
+	b.next++
+
+	record := make(map[string]string, len(b.rows[rowIdx]))
+	for i, value := range b.rows[rowIdx] {
+		if i < len(b.header) && b.header[i] != "" {
+			record[b.header[i]] = value
+		}
+	}
+	return rowIdx + 1, record, nil
+}
+
+func (b *workbook) Header() []string {
+	return b.header
+}
+
+func (b *workbook) Progress() (int, int) {
+	return b.next - 1, len(b.rows) - 1
+}
+
+// Close 关闭文件
+func (b *workbook) Close() error {
+	return b.f.Close()
+}
@@ -0,0 +1,595 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/storage"
+	ws "github.com/sky-xhsoft/sky-server/internal/pkg/websocket"
+	"github.com/sky-xhsoft/sky-server/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 作业状态（SysJob.Status）
+const (
+	JobQueued    = "queued"    // 排队中
+	JobRunning   = "running"   // 执行中
+	JobSucceeded = "succeeded" // 已完成
+	JobFailed    = "failed"    // 失败
+	JobCancelled = "cancelled" // 已取消
+)
+
+const (
+	jobLeaseGrace    = 30 * time.Second // 执行租约在超时之外的宽限时间
+	progressInterval = time.Second      // 进度更新和取消检查的最小间隔
+	maxErrorLength   = 2000             // 失败原因的最大长度
+)
+
+// errJobCancelled 作业已被取消
+var errJobCancelled = errors.New(errors.ErrResourceConflict, "作业已取消")
+
+// Config 后台作业配置
+type Config struct {
+	Workers int // 同时执行的作业数
+	Timeout int // 单个作业的执行超时（秒）
+}
+
+// withDefaults 补全未配置的参数
+func (c *Config) withDefaults() *Config {
+	cfg := Config{}
+	if c != nil {
+		cfg = *c
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3600
+	}
+	return &cfg
+}
+
+// Handler 作业类型的执行函数，返回错误时作业失败
+type Handler func(ctx context.Context, task *Task) error
+
+// Service 后台作业服务接口
+type Service interface {
+	// Register 注册作业类型的执行函数
+	Register(jobType string, handler Handler)
+
+	// Submit 提交作业（排队后由后台执行）
+	Submit(ctx context.Context, req *SubmitRequest) (*JobView, error)
+
+	// GetJob 查询自己提交的作业
+	GetJob(ctx context.Context, id, userID uint) (*JobView, error)
+
+	// ListJobs 查询自己提交的作业列表
+	ListJobs(ctx context.Context, req *ListRequest) ([]*JobView, int64, error)
+
+	// CancelJob 取消排队中或执行中的作业
+	CancelJob(ctx context.Context, id, userID uint) error
+
+	// OpenResult 打开已完成作业的结果文件
+	OpenResult(ctx context.Context, id, userID uint) (io.ReadCloser, *entity.SysJob, error)
+
+	// ProcessJobs 领取排队中的作业执行（由后台定时调用）
+	ProcessJobs(ctx context.Context) error
+}
+
+// SubmitRequest 提交作业请求
+type SubmitRequest struct {
+	JobType   string      // 作业类型
+	Title     string      // 作业说明
+	Params    interface{} // 作业参数，序列化为JSON保存
+	Input     io.Reader   // 输入文件（可选），提交时保存到存储中
+	InputName string      // 输入文件名
+	UserID    uint        // 提交人
+}
+
+// ListRequest 查询作业请求
+type ListRequest struct {
+	Status   string `json:"status"`
+	JobType  string `json:"jobType"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	UserID   uint   `json:"-"`
+}
+
+// JobView 作业信息（接口返回和WebSocket通知）
+type JobView struct {
+	*entity.SysJob
+	DownloadURL string `json:"downloadUrl,omitempty"` // 结果文件下载地址（作业完成且有结果文件时）
+}
+
+// service 后台作业服务实现
+type service struct {
+	db        *gorm.DB
+	storage   storage.Storage
+	wsManager *ws.Manager
+	userRepo  repository.UserRepository
+	cfg       *Config
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	slots    chan struct{}               // 执行槽位，限制同时执行的作业数
+	running  map[uint]context.CancelFunc // 本进程执行中的作业，取消时立即中止
+}
+
+// NewService 创建后台作业服务
+func NewService(db *gorm.DB, store storage.Storage, wsManager *ws.Manager, userRepo repository.UserRepository, cfg *Config) Service {
+	cfg = cfg.withDefaults()
+	return &service{
+		db:        db,
+		storage:   store,
+		wsManager: wsManager,
+		userRepo:  userRepo,
+		cfg:       cfg,
+		handlers:  make(map[string]Handler),
+		slots:     make(chan struct{}, cfg.Workers),
+		running:   make(map[uint]context.CancelFunc),
+	}
+}
+
+// Register 注册作业类型的执行函数
+func (s *service) Register(jobType string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+// handler 查询作业类型的执行函数
+func (s *service) handler(jobType string) Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.handlers[jobType]
+}
+
+// Submit 提交作业
+// 输入文件先保存到存储中再排队，执行进程可以不是接收请求的进程
+func (s *service) Submit(ctx context.Context, req *SubmitRequest) (*JobView, error) {
+	if s.handler(req.JobType) == nil {
+		return nil, errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的作业类型: %s", req.JobType))
+	}
+
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "序列化作业参数失败", err)
+	}
+
+	job := &entity.SysJob{
+		JobType: req.JobType,
+		Title:   req.Title,
+		UserID:  req.UserID,
+		Status:  JobQueued,
+		Params:  string(params),
+	}
+	job.IsActive = "Y"
+	if user, userErr := s.userRepo.GetUserByID(req.UserID); userErr == nil && user != nil {
+		job.CreateBy = user.Username
+		job.SysCompanyID = user.SysCompanyID
+	}
+
+	if req.Input == nil {
+		if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+			return nil, errors.Wrap(errors.ErrDatabase, "提交作业失败", err)
+		}
+	} else {
+		// 先保存输入文件，再创建作业，避免作业被领取时输入文件还不存在
+		job.InputFile = path.Join("inputs", fmt.Sprintf("%d", time.Now().UnixNano()), safeFileName(req.InputName))
+		if _, err := s.storage.Upload(ctx, job.InputFile, req.Input, ""); err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, "保存输入文件失败", err)
+		}
+		if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+			_ = s.storage.Delete(ctx, job.InputFile)
+			return nil, errors.Wrap(errors.ErrDatabase, "提交作业失败", err)
+		}
+	}
+
+	view := s.view(job)
+	s.notify(view)
+
+	// 有空闲槽位时立即执行，不等下次扫描
+	go func() {
+		if err := s.ProcessJobs(context.Background()); err != nil {
+			logger.Error("执行后台作业失败", zap.Error(err))
+		}
+	}()
+
+	return view, nil
+}
+
+// GetJob 查询自己提交的作业
+func (s *service) GetJob(ctx context.Context, id, userID uint) (*JobView, error) {
+	job, err := s.findJob(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(job), nil
+}
+
+// ListJobs 查询自己提交的作业列表（按提交时间倒序）
+func (s *service) ListJobs(ctx context.Context, req *ListRequest) ([]*JobView, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	query := s.db.WithContext(ctx).Model(&entity.SysJob{}).
+		Where("USER_ID = ? AND IS_ACTIVE = ?", req.UserID, "Y")
+	if req.Status != "" {
+		query = query.Where("STATUS = ?", req.Status)
+	}
+	if req.JobType != "" {
+		query = query.Where("JOB_TYPE = ?", req.JobType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(errors.ErrDatabase, "查询作业总数失败", err)
+	}
+
+	var jobs []*entity.SysJob
+	if err := query.Order("ID DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrap(errors.ErrDatabase, "查询作业列表失败", err)
+	}
+
+	views := make([]*JobView, 0, len(jobs))
+	for _, job := range jobs {
+		views = append(views, s.view(job))
+	}
+	return views, total, nil
+}
+
+// CancelJob 取消作业
+// 排队中的作业不再执行；执行中的作业在下一次进度更新时中止，已处理的部分（如已导入的行）不回滚
+func (s *service) CancelJob(ctx context.Context, id, userID uint) error {
+	job, err := s.findJob(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&entity.SysJob{}).
+		Where("ID = ? AND STATUS IN ?", job.ID, []string{JobQueued, JobRunning}).
+		Updates(map[string]interface{}{
+			"STATUS":       JobCancelled,
+			"LOCKED_UNTIL": nil,
+			"FINISH_TIME":  now,
+		})
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "取消作业失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrValidation, "只能取消排队中或执行中的作业")
+	}
+
+	// 本进程正在执行时立即中止；执行中的作业由执行进程结束时删除输入文件
+	s.mu.RLock()
+	cancel := s.running[job.ID]
+	s.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+	if job.Status == JobQueued {
+		s.discardInput(job)
+	}
+
+	job.Status = JobCancelled
+	job.FinishTime = &now
+	s.notify(s.view(job))
+	return nil
+}
+
+// OpenResult 打开已完成作业的结果文件
+func (s *service) OpenResult(ctx context.Context, id, userID uint) (io.ReadCloser, *entity.SysJob, error) {
+	job, err := s.findJob(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != JobSucceeded || job.ResultFile == "" {
+		return nil, nil, errors.New(errors.ErrResourceNotFound, "作业没有可下载的结果文件")
+	}
+
+	reader, err := s.storage.Download(ctx, job.ResultFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, job, nil
+}
+
+// findJob 查询自己提交的作业
+func (s *service) findJob(ctx context.Context, id, userID uint) (*entity.SysJob, error) {
+	var job entity.SysJob
+	if err := s.db.WithContext(ctx).
+		Where("ID = ? AND USER_ID = ? AND IS_ACTIVE = ?", id, userID, "Y").
+		Take(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "作业不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询作业失败", err)
+	}
+	return &job, nil
+}
+
+// ProcessJobs 领取排队中的作业执行
+// 执行中的作业租约过期（执行进程异常退出）时标记为失败，导入等作业重复执行可能产生重复数据，不自动重试
+func (s *service) ProcessJobs(ctx context.Context) error {
+	now := time.Now()
+
+	var expired []*entity.SysJob
+	if err := s.db.WithContext(ctx).
+		Where("STATUS = ? AND LOCKED_UNTIL <= ? AND IS_ACTIVE = ?", JobRunning, now, "Y").
+		Find(&expired).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询超时作业失败", err)
+	}
+	for _, job := range expired {
+		if err := s.finishJob(ctx, job, JobFailed, "执行超时或执行进程已退出"); err != nil {
+			logger.Error("标记超时作业失败", zap.Uint("jobId", job.ID), zap.Error(err))
+			continue
+		}
+		s.discardInput(job)
+	}
+
+	free := cap(s.slots) - len(s.slots)
+	if free <= 0 {
+		return nil
+	}
+
+	var jobs []*entity.SysJob
+	if err := s.db.WithContext(ctx).
+		Where("STATUS = ? AND IS_ACTIVE = ?", JobQueued, "Y").
+		Order("ID ASC").
+		Limit(free).
+		Find(&jobs).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询排队作业失败", err)
+	}
+
+	for _, job := range jobs {
+		// 槽位已被并发的扫描占满时留到下次
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			return nil
+		}
+
+		claimed, err := s.claimJob(ctx, job)
+		if err != nil || !claimed {
+			<-s.slots
+			if err != nil {
+				logger.Error("领取后台作业失败", zap.Uint("jobId", job.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		go func(job *entity.SysJob) {
+			defer func() { <-s.slots }()
+			s.runJob(job)
+		}(job)
+	}
+
+	return nil
+}
+
+// claimJob 以状态为条件领取作业，保证只被一个进程执行
+func (s *service) claimJob(ctx context.Context, job *entity.SysJob) (bool, error) {
+	now := time.Now()
+	lease := now.Add(time.Duration(s.cfg.Timeout)*time.Second + jobLeaseGrace)
+	result := s.db.WithContext(ctx).Model(&entity.SysJob{}).
+		Where("ID = ? AND STATUS = ?", job.ID, JobQueued).
+		Updates(map[string]interface{}{
+			"STATUS":       JobRunning,
+			"PROGRESS":     0,
+			"LOCKED_UNTIL": lease,
+			"START_TIME":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	job.Status = JobRunning
+	job.Progress = 0
+	job.LockedUntil = &lease
+	job.StartTime = &now
+	return true, nil
+}
+
+// runJob 执行已领取的作业并记录结果
+func (s *service) runJob(job *entity.SysJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.Timeout)*time.Second)
+	defer cancel()
+
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	// 作业结束（完成、失败或取消）后输入文件不再使用
+	defer s.discardInput(job)
+
+	s.notify(s.view(job))
+
+	task := &Task{ctx: ctx, s: s, Job: job}
+	err := s.execute(ctx, task)
+
+	switch {
+	case err == nil:
+		err = s.completeJob(job)
+	case err == errJobCancelled:
+		// 已由 CancelJob 更新状态
+		s.discardResult(job)
+		return
+	default:
+		message := err.Error()
+		if appErr, ok := err.(*errors.AppError); ok {
+			message = appErr.Message
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			message = fmt.Sprintf("执行超时（%d秒）", s.cfg.Timeout)
+		}
+		s.discardResult(job)
+		err = s.finishJob(context.Background(), job, JobFailed, message)
+	}
+	if err != nil {
+		logger.Error("更新后台作业结果失败", zap.Uint("jobId", job.ID), zap.Error(err))
+	}
+}
+
+// execute 调用作业类型的执行函数，执行函数 panic 时作业失败
+func (s *service) execute(ctx context.Context, task *Task) (err error) {
+	handler := s.handler(task.Job.JobType)
+	if handler == nil {
+		return errors.New(errors.ErrInternal, fmt.Sprintf("作业类型 %s 未注册", task.Job.JobType))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(errors.ErrInternal, fmt.Sprintf("作业执行异常: %v", r))
+		}
+	}()
+
+	if err := handler(ctx, task); err != nil {
+		// 取消后执行函数返回的是中止产生的错误
+		if ctx.Err() == context.Canceled {
+			return errJobCancelled
+		}
+		return err
+	}
+	if ctx.Err() == context.Canceled {
+		return errJobCancelled
+	}
+	return nil
+}
+
+// completeJob 记录作业完成
+func (s *service) completeJob(job *entity.SysJob) error {
+	now := time.Now()
+	result := s.db.Model(&entity.SysJob{}).
+		Where("ID = ? AND STATUS = ?", job.ID, JobRunning).
+		Updates(map[string]interface{}{
+			"STATUS":       JobSucceeded,
+			"PROGRESS":     100,
+			"RESULT_FILE":  job.ResultFile,
+			"RESULT_NAME":  job.ResultName,
+			"RESULT":       job.Result,
+			"ERROR":        "",
+			"LOCKED_UNTIL": nil,
+			"FINISH_TIME":  now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 执行期间被取消
+		s.discardResult(job)
+		return nil
+	}
+
+	job.Status = JobSucceeded
+	job.Progress = 100
+	job.FinishTime = &now
+	s.notify(s.view(job))
+	return nil
+}
+
+// finishJob 记录作业失败
+func (s *service) finishJob(ctx context.Context, job *entity.SysJob, status, message string) error {
+	if runes := []rune(message); len(runes) > maxErrorLength {
+		message = string(runes[:maxErrorLength])
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&entity.SysJob{}).
+		Where("ID = ? AND STATUS = ?", job.ID, JobRunning).
+		Updates(map[string]interface{}{
+			"STATUS":       status,
+			"ERROR":        message,
+			"LOCKED_UNTIL": nil,
+			"FINISH_TIME":  now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	job.Status = status
+	job.Error = message
+	job.FinishTime = &now
+	s.notify(s.view(job))
+	return nil
+}
+
+// discardResult 作业未完成时删除已保存的结果文件
+func (s *service) discardResult(job *entity.SysJob) {
+	if job.ResultFile == "" {
+		return
+	}
+	if err := s.storage.Delete(context.Background(), job.ResultFile); err != nil {
+		logger.Warn("删除作业结果文件失败", zap.Uint("jobId", job.ID), zap.Error(err))
+	}
+	job.ResultFile = ""
+	job.ResultName = ""
+}
+
+// discardInput 作业结束后删除上传的输入文件，作业记录保留文件路径
+func (s *service) discardInput(job *entity.SysJob) {
+	if job.InputFile == "" {
+		return
+	}
+	if err := s.storage.Delete(context.Background(), job.InputFile); err != nil {
+		logger.Warn("删除作业输入文件失败", zap.Uint("jobId", job.ID), zap.Error(err))
+	}
+}
+
+// view 作业信息（复制一份，WebSocket异步发送时不受后续修改影响），已完成且有结果文件时附带下载地址
+func (s *service) view(job *entity.SysJob) *JobView {
+	copied := *job
+	view := &JobView{SysJob: &copied}
+	if job.Status == JobSucceeded && job.ResultFile != "" {
+		view.DownloadURL = fmt.Sprintf("/api/v1/jobs/%d/download", job.ID)
+	}
+	return view
+}
+
+// notify 通过WebSocket推送作业状态给提交人
+func (s *service) notify(view *JobView) {
+	if s.wsManager == nil {
+		return
+	}
+	s.wsManager.SendToUser(view.UserID, ws.TypeJobProgress, view)
+}
+
+// safeFileName 存储路径中使用的文件名（去掉目录部分）
+func safeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || name == "" {
+		return "file"
+	}
+	return name
+}
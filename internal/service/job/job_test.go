@@ -0,0 +1,49 @@
+package job
+
+import "testing"
+
+func TestPercentOf(t *testing.T) {
+	cases := []struct {
+		done, total, want int
+	}{
+		{0, 100, 0},
+		{5, 0, 0},
+		{1, 3, 33},
+		{50, 100, 50},
+		// 完成前最多为99，100由作业完成时设置
+		{100, 100, 99},
+		{120, 100, 99},
+	}
+	for _, c := range cases {
+		if got := percentOf(c.done, c.total); got != c.want {
+			t.Errorf("percentOf(%d, %d) = %d, want %d", c.done, c.total, got, c.want)
+		}
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	cases := map[string]string{
+		"users.xlsx":           "users.xlsx",
+		"../../etc/passwd":     "passwd",
+		`C:\Users\a\data.xlsx`: "data.xlsx",
+		"..":                   "file",
+		"":                     "file",
+	}
+	for name, want := range cases {
+		if got := safeFileName(name); got != want {
+			t.Errorf("safeFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := (*Config)(nil).withDefaults()
+	if cfg.Workers != 2 || cfg.Timeout != 3600 {
+		t.Errorf("withDefaults() = %+v, want Workers=2 Timeout=3600", cfg)
+	}
+
+	cfg = (&Config{Workers: 4, Timeout: 60}).withDefaults()
+	if cfg.Workers != 4 || cfg.Timeout != 60 {
+		t.Errorf("withDefaults() = %+v, want configured values", cfg)
+	}
+}
@@ -0,0 +1,138 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// Task 执行中的作业，提供参数、输入文件、进度和结果的读写
+type Task struct {
+	Job *entity.SysJob
+
+	ctx      context.Context
+	s        *service
+	reported time.Time // 上次更新进度的时间
+}
+
+// Bind 解析作业参数
+func (t *Task) Bind(v interface{}) error {
+	if t.Job.Params == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(t.Job.Params), v); err != nil {
+		return errors.Wrap(errors.ErrInternal, "解析作业参数失败", err)
+	}
+	return nil
+}
+
+// OpenInput 打开提交作业时上传的输入文件
+func (t *Task) OpenInput() (io.ReadCloser, error) {
+	if t.Job.InputFile == "" {
+		return nil, errors.New(errors.ErrInvalidParam, "作业没有输入文件")
+	}
+	return t.s.storage.Download(t.ctx, t.Job.InputFile)
+}
+
+// Progress 更新进度（已处理数/总数），同时检查作业是否已被取消
+// 每秒最多更新一次；作业已取消或已超时时返回错误，执行函数应立即返回
+func (t *Task) Progress(done, total int) error {
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+	if time.Since(t.reported) < progressInterval {
+		return nil
+	}
+	t.reported = time.Now()
+
+	percent := percentOf(done, total)
+	result := t.s.db.WithContext(t.ctx).Model(&entity.SysJob{}).
+		Where("ID = ? AND STATUS = ?", t.Job.ID, JobRunning).
+		Update("PROGRESS", percent)
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新作业进度失败", result.Error)
+	}
+	// 进度未变化时影响行数也为0，需要再确认状态
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := t.s.db.WithContext(t.ctx).Model(&entity.SysJob{}).
+			Where("ID = ? AND STATUS = ?", t.Job.ID, JobRunning).
+			Count(&count).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询作业状态失败", err)
+		}
+		if count == 0 {
+			return errJobCancelled
+		}
+	}
+
+	if percent != t.Job.Progress {
+		t.Job.Progress = percent
+		t.s.notify(t.s.view(t.Job))
+	}
+	return nil
+}
+
+// SaveResult 生成并保存结果文件，作业完成后可通过下载地址下载
+func (t *Task) SaveResult(fileName, contentType string, write func(w io.Writer) error) error {
+	resultPath := path.Join("results", fmt.Sprintf("%d", t.Job.ID), safeFileName(fileName))
+
+	// 边生成边写入存储，不在内存中保留整个文件
+	pr, pw := io.Pipe()
+	var writeErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writeErr = write(pw)
+		pw.CloseWithError(writeErr)
+	}()
+
+	_, err := t.s.storage.Upload(t.ctx, resultPath, pr, contentType)
+	// 存储提前失败时让生成端的写入返回错误
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	if writeErr != nil || err != nil {
+		_ = t.s.storage.Delete(context.Background(), resultPath)
+	}
+	// 生成端的业务错误优先，其次是存储的错误
+	if _, ok := writeErr.(*errors.AppError); ok {
+		return writeErr
+	}
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "保存结果文件失败", err)
+	}
+	if writeErr != nil {
+		return errors.Wrap(errors.ErrInternal, "生成结果文件失败", writeErr)
+	}
+
+	t.Job.ResultFile = resultPath
+	t.Job.ResultName = fileName
+	return nil
+}
+
+// SetResult 记录执行结果（序列化为JSON）
+func (t *Task) SetResult(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "序列化作业结果失败", err)
+	}
+	t.Job.Result = string(data)
+	return nil
+}
+
+// percentOf 进度百分比，完成前最多为99
+func percentOf(done, total int) int {
+	if total <= 0 || done <= 0 {
+		return 0
+	}
+	percent := done * 100 / total
+	if percent > 99 {
+		percent = 99
+	}
+	return percent
+}
@@ -0,0 +1,102 @@
+package printing
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// htmlTemplate HTML打印页面：每条记录一页，@page 设置纸张和方向，浏览器打印时按纸张分页
+var htmlTemplate = template.Must(template.New("print").Funcs(template.FuncMap{
+	"orientation": func(o string) string {
+		if o == "L" {
+			return "landscape"
+		}
+		return "portrait"
+	},
+	"fieldRows": fieldRows,
+	"lines": func(s string) []string {
+		return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+@page { size: {{.PaperSize}} {{orientation .Orientation}}; margin: 15mm; }
+body { margin: 0; font-family: "Microsoft YaHei", "PingFang SC", "Noto Sans CJK SC", sans-serif; font-size: 10pt; color: #000; }
+.page { page-break-after: always; }
+.page:last-child { page-break-after: auto; }
+h1 { margin: 0 0 4mm; font-size: 16pt; text-align: center; }
+h2 { margin: 4mm 0 2mm; font-size: 11pt; }
+table { width: 100%; border-collapse: collapse; }
+.fields td { padding: 1mm 2mm 1mm 0; vertical-align: top; }
+.fields .label { color: #555; white-space: nowrap; }
+.detail th, .detail td { border: 1px solid #999; padding: 1mm 1.5mm; vertical-align: top; }
+.detail th { background: #eee; font-weight: normal; }
+.detail thead { display: table-header-group; }
+.detail tr { page-break-inside: avoid; }
+.num { text-align: right; }
+.footer { margin-top: 6mm; }
+.footer p { margin: 0 0 1mm; }
+</style>
+</head>
+<body>
+{{- $cols := .HeaderCols}}
+{{- range .Pages}}
+<div class="page">
+<h1>{{.Title}}</h1>
+{{- if .Fields}}
+<table class="fields">
+{{- range fieldRows .Fields $cols}}
+<tr>{{range .}}<td class="label">{{.Label}}：</td><td>{{.Value}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Tables}}
+<h2>{{.Title}}</h2>
+<table class="detail">
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- $numeric := .Numeric}}
+{{- range .Rows}}
+<tr>{{range $i, $cell := .}}<td{{if index $numeric $i}} class="num"{{end}}>{{$cell}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Footer}}
+<div class="footer">{{range lines .Footer}}<p>{{.}}</p>{{end}}</div>
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML 输出HTML打印页面（值均经过HTML转义）
+func renderHTML(doc *document, w io.Writer) error {
+	if err := htmlTemplate.Execute(w, doc); err != nil {
+		return errors.Wrap(errors.ErrInternal, "生成打印页面失败", err)
+	}
+	return nil
+}
+
+// fieldRows 表头字段按每行字段数分行
+func fieldRows(fields []*field, cols int) [][]*field {
+	if cols <= 0 {
+		cols = 1
+	}
+	rows := make([][]*field, 0, (len(fields)+cols-1)/cols)
+	for start := 0; start < len(fields); start += cols {
+		end := start + cols
+		if end > len(fields) {
+			end = len(fields)
+		}
+		rows = append(rows, fields[start:end])
+	}
+	return rows
+}
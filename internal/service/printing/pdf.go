@@ -0,0 +1,291 @@
+package printing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// PDF排版参数（单位毫米，字号单位磅）
+const (
+	pdfMargin      = 15.0 // 页边距
+	pdfCellPad     = 1.0  // 单元格内边距
+	pdfTitleSize   = 16.0 // 标题字号
+	pdfCaptionSize = 11.0 // 明细标题字号
+	pdfFontSize    = 10.0 // 表头字段、页脚文字字号
+	pdfTableSize   = 9.0  // 明细表字号
+	pdfLineHeight  = 5.0  // 表头字段、页脚文字行高
+	pdfTableLine   = 4.5  // 明细表行高
+	pdfMinColumn   = 10.0 // 明细表最小列宽
+)
+
+// pdfFontFamily 配置的中文字体在PDF中的名称
+const pdfFontFamily = "print"
+
+// errPDFFont 未配置PDF字体
+var errPDFFont = errors.New(errors.ErrInternal, "未配置PDF打印字体（print.fontPath 需指向支持中文的TrueType字体），请联系管理员或使用HTML格式打印")
+
+// pdfRenderer PDF排版：表头字段按网格排列，明细表按内容分配列宽并自动折行，换页时重复表头
+type pdfRenderer struct {
+	pdf    *fpdf.Fpdf
+	width  float64 // 版心宽度
+	bottom float64 // 版心底部的纵坐标
+}
+
+// renderPDF 输出PDF，每条记录从新的一页开始，页脚为页码
+// PDF使用配置的中文字体（print.fontPath），内置的西文字体无法显示中文，未配置时返回错误
+func (s *service) renderPDF(doc *document, w io.Writer) error {
+	font, err := s.loadFont()
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New(doc.Orientation, "mm", doc.PaperSize, "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetCellMargin(0)
+	pdf.SetTitle(doc.Title, true)
+	pdf.SetDrawColor(153, 153, 153)
+	pdf.SetFillColor(238, 238, 238)
+
+	r := &pdfRenderer{pdf: pdf}
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", font)
+	if err := pdf.Error(); err != nil {
+		return errors.Wrap(errors.ErrInternal, fmt.Sprintf("加载打印字体 %s 失败", s.cfg.FontPath), err)
+	}
+
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 4)
+		r.setFont(8)
+		pdf.CellFormat(0, 4, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	for _, p := range doc.Pages {
+		r.page(p, doc.HeaderCols)
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return errors.Wrap(errors.ErrInternal, "生成PDF失败", err)
+	}
+	return nil
+}
+
+// loadFont 读取配置的字体文件（只读取一次）
+func (s *service) loadFont() ([]byte, error) {
+	if s.cfg.FontPath == "" {
+		return nil, errPDFFont
+	}
+	s.fontOnce.Do(func() {
+		s.font, s.fontErr = os.ReadFile(s.cfg.FontPath)
+	})
+	if s.fontErr != nil {
+		return nil, errors.Wrap(errors.ErrInternal, "读取打印字体失败", s.fontErr)
+	}
+	return s.font, nil
+}
+
+// page 输出一条记录
+func (r *pdfRenderer) page(p *page, headerCols int) {
+	pdf := r.pdf
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	r.width = pageWidth - 2*pdfMargin
+	r.bottom = pageHeight - pdfMargin
+
+	r.setFont(pdfTitleSize)
+	pdf.CellFormat(r.width, 10, p.Title, "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	r.setFont(pdfFontSize)
+	for _, row := range fieldRows(p.Fields, headerCols) {
+		r.fieldRow(row, headerCols)
+	}
+
+	for _, t := range p.Tables {
+		r.table(t)
+	}
+
+	if p.Footer != "" {
+		r.setFont(pdfFontSize)
+		pdf.Ln(pdfLineHeight)
+		for _, line := range r.wrap(p.Footer, r.width) {
+			pdf.SetXY(pdfMargin, r.ensure(pdfLineHeight))
+			pdf.CellFormat(r.width, pdfLineHeight, line, "", 1, "L", false, 0, "")
+		}
+	}
+}
+
+// fieldRow 输出一行表头字段（“名称：值”，值过长时在单元格内折行）
+func (r *pdfRenderer) fieldRow(fields []*field, cols int) {
+	cellWidth := r.width / float64(cols)
+	lines := make([][]string, len(fields))
+	height := 0.0
+	for i, f := range fields {
+		lines[i] = r.wrap(f.Label+"："+f.Value, cellWidth-2*pdfCellPad)
+		if h := float64(len(lines[i])) * pdfLineHeight; h > height {
+			height = h
+		}
+	}
+
+	y := r.ensure(height)
+	for i := range fields {
+		r.drawLines(pdfMargin+float64(i)*cellWidth, y, cellWidth, lines[i], "L", pdfLineHeight)
+	}
+	r.pdf.SetXY(pdfMargin, y+height)
+}
+
+// table 输出明细表
+func (r *pdfRenderer) table(t *table) {
+	pdf := r.pdf
+	if len(t.Headers) == 0 {
+		return
+	}
+
+	// 明细标题至少和表头、第一行在同一页
+	r.setFont(pdfCaptionSize)
+	y := r.ensure(8 + 2*(pdfTableLine+2*pdfCellPad))
+	pdf.SetXY(pdfMargin, y+2)
+	pdf.CellFormat(r.width, 6, t.Title, "", 1, "L", false, 0, "")
+
+	r.setFont(pdfTableSize)
+	widths := r.columnWidths(t)
+	headerAligns := make([]string, len(t.Headers))
+	aligns := make([]string, len(t.Headers))
+	for i := range t.Headers {
+		headerAligns[i] = "C"
+		aligns[i] = "L"
+		if t.Numeric[i] {
+			aligns[i] = "R"
+		}
+	}
+
+	header := func() {
+		r.tableRow(t.Headers, widths, headerAligns, true, nil)
+	}
+	header()
+	for _, row := range t.Rows {
+		r.tableRow(row, widths, aligns, false, header)
+	}
+}
+
+// tableRow 输出明细表的一行，剩余高度不足时换页并调用 onBreak 重复表头
+func (r *pdfRenderer) tableRow(cells []string, widths []float64, aligns []string, fill bool, onBreak func()) {
+	pdf := r.pdf
+	lines := make([][]string, len(cells))
+	maxLines := 1
+	for i, cell := range cells {
+		lines[i] = r.wrap(cell, widths[i]-2*pdfCellPad)
+		if len(lines[i]) > maxLines {
+			maxLines = len(lines[i])
+		}
+	}
+	height := float64(maxLines)*pdfTableLine + 2*pdfCellPad
+
+	if pdf.GetY()+height > r.bottom {
+		pdf.AddPage()
+		if onBreak != nil {
+			onBreak()
+		}
+	}
+
+	style := "D"
+	if fill {
+		style = "FD"
+	}
+	x, y := pdfMargin, pdf.GetY()
+	for i := range cells {
+		pdf.Rect(x, y, widths[i], height, style)
+		r.drawLines(x, y+pdfCellPad, widths[i], lines[i], aligns[i], pdfTableLine)
+		x += widths[i]
+	}
+	pdf.SetXY(pdfMargin, y+height)
+}
+
+// columnWidths 按表头和内容的宽度分配列宽，铺满版心宽度
+func (r *pdfRenderer) columnWidths(t *table) []float64 {
+	widths := make([]float64, len(t.Headers))
+	total := 0.0
+	for i, header := range t.Headers {
+		w := r.textWidth(header)
+		for _, row := range t.Rows {
+			if cw := r.textWidth(row[i]); cw > w {
+				w = cw
+			}
+		}
+		w += 2 * pdfCellPad
+		if w < pdfMinColumn {
+			w = pdfMinColumn
+		}
+		// 单列最多占一半宽度，过长的内容折行
+		if len(t.Headers) > 1 && w > r.width/2 {
+			w = r.width / 2
+		}
+		widths[i] = w
+		total += w
+	}
+
+	scale := r.width / total
+	for i := range widths {
+		widths[i] *= scale
+	}
+	return widths
+}
+
+// drawLines 在单元格内逐行输出文字
+func (r *pdfRenderer) drawLines(x, y, width float64, lines []string, align string, lineHeight float64) {
+	for i, line := range lines {
+		r.pdf.SetXY(x+pdfCellPad, y+float64(i)*lineHeight)
+		r.pdf.CellFormat(width-2*pdfCellPad, lineHeight, line, "", 0, align, false, 0, "")
+	}
+}
+
+// ensure 当前页剩余高度不足时换页，返回开始输出的纵坐标
+func (r *pdfRenderer) ensure(height float64) float64 {
+	if r.pdf.GetY()+height > r.bottom {
+		r.pdf.AddPage()
+	}
+	return r.pdf.GetY()
+}
+
+// wrap 按宽度折行：逐字符累计宽度，西文优先在空格处折行，保留文字中的换行
+func (r *pdfRenderer) wrap(s string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		runes := []rune(paragraph)
+		start, lastSpace := 0, -1
+		lineWidth := 0.0
+		for i, ch := range runes {
+			chWidth := r.textWidth(string(ch))
+			if lineWidth+chWidth > width && i > start {
+				end := i
+				if lastSpace >= start {
+					end = lastSpace + 1
+				}
+				lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+				start, lastSpace = end, -1
+				lineWidth = r.textWidth(string(runes[start:i]))
+			}
+			if ch == ' ' {
+				lastSpace = i
+			}
+			lineWidth += chWidth
+		}
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+// setFont 设置字号
+func (r *pdfRenderer) setFont(size float64) {
+	r.pdf.SetFont(pdfFontFamily, "", size)
+}
+
+// textWidth 文字宽度
+func (r *pdfRenderer) textWidth(s string) float64 {
+	return r.pdf.GetStringWidth(s)
+}
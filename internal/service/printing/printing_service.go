@@ -0,0 +1,422 @@
+package printing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/sky-xhsoft/sky-server/internal/service/dict"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"github.com/sky-xhsoft/sky-server/internal/service/metadata"
+	"gorm.io/gorm"
+)
+
+// 打印输出格式
+const (
+	FormatHTML = "html" // HTML（默认），浏览器中预览和打印
+	FormatPDF  = "pdf"  // PDF
+)
+
+// maxPrintRecords 单次打印的最大记录数
+const maxPrintRecords = 100
+
+// maxHeaderCols 表头每行的最大字段数
+const maxHeaderCols = 6
+
+// paperSizes 支持的纸张（小写 -> 规范名称）
+var paperSizes = map[string]string{
+	"a4":     "A4",
+	"a5":     "A5",
+	"letter": "Letter",
+}
+
+// Config 打印配置
+type Config struct {
+	FontPath string // PDF使用的TrueType字体文件（需支持中文），未配置时不能输出PDF
+}
+
+// Service 打印服务接口
+type Service interface {
+	// ListTemplates 查询表的打印模板（需要表的查询权限）
+	ListTemplates(ctx context.Context, tableName string, userID uint) ([]*entity.SysPrintTemplate, error)
+
+	// GetTemplate 查询打印模板（需要所属表的查询权限）
+	GetTemplate(ctx context.Context, id, userID uint) (*entity.SysPrintTemplate, error)
+
+	// CreateTemplate 创建打印模板（需要所属表的修改权限）
+	CreateTemplate(ctx context.Context, tpl *entity.SysPrintTemplate, userID uint) error
+
+	// UpdateTemplate 修改打印模板（需要所属表的修改权限，所属表不可修改）
+	UpdateTemplate(ctx context.Context, tpl *entity.SysPrintTemplate, userID uint) error
+
+	// DeleteTemplate 删除打印模板（需要所属表的修改权限）
+	DeleteTemplate(ctx context.Context, id, userID uint) error
+
+	// Render 按模板打印记录（需要表的查询权限），输出HTML或PDF，每条记录从新的一页开始
+	Render(ctx context.Context, req *RenderRequest, userID uint, w io.Writer) error
+}
+
+// RenderRequest 打印请求
+type RenderRequest struct {
+	TableName  string `json:"-"`
+	IDs        []uint `json:"ids"`        // 记录ID（按顺序打印）
+	TemplateID uint   `json:"templateId"` // 打印模板，为0时使用表的默认模板（没有默认模板时打印全部打印可见字段和明细）
+	Format     string `json:"format"`     // 输出格式：html（默认）、pdf
+}
+
+// DetailConfig 模板中的明细表（SysPrintTemplate.Details 为其JSON数组）
+type DetailConfig struct {
+	Table   string   `json:"table"`   // 子表表名（sys_table_ref 中一对多的关联）
+	Title   string   `json:"title"`   // 明细标题（为空时为关联的显示名称）
+	Columns []string `json:"columns"` // 打印的字段名（为空时为全部打印可见字段）
+}
+
+// ContentType 输出格式的MIME类型
+func ContentType(format string) string {
+	if strings.EqualFold(format, FormatPDF) {
+		return "application/pdf"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// service 打印服务实现
+type service struct {
+	db              *gorm.DB
+	crudService     crud.Service
+	metadataService metadata.Service
+	dictService     dict.Service
+	groupsService   groups.Service
+	cfg             Config
+
+	fontOnce sync.Once
+	font     []byte
+	fontErr  error
+}
+
+// NewService 创建打印服务
+func NewService(db *gorm.DB, crudService crud.Service, metadataService metadata.Service, dictService dict.Service, groupsService groups.Service, cfg *Config) Service {
+	s := &service{
+		db:              db,
+		crudService:     crudService,
+		metadataService: metadataService,
+		dictService:     dictService,
+		groupsService:   groupsService,
+	}
+	if cfg != nil {
+		s.cfg = *cfg
+	}
+	return s
+}
+
+// ListTemplates 查询表的打印模板，按排序号排列
+func (s *service) ListTemplates(ctx context.Context, tableName string, userID uint) ([]*entity.SysPrintTemplate, error) {
+	table, err := s.metadataService.GetTable(tableName)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+	if err := s.checkPermission(ctx, userID, table, groups.PermRead, "无查询权限"); err != nil {
+		return nil, err
+	}
+
+	var templates []*entity.SysPrintTemplate
+	if err := s.db.WithContext(ctx).
+		Where("SYS_TABLE_ID = ? AND IS_ACTIVE = ?", table.ID, "Y").
+		Order("ORDERNO ASC, ID ASC").
+		Find(&templates).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询打印模板失败", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate 查询打印模板
+func (s *service) GetTemplate(ctx context.Context, id, userID uint) (*entity.SysPrintTemplate, error) {
+	tpl, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	table, err := s.metadataService.GetTableByID(tpl.SysTableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPermission(ctx, userID, table, groups.PermRead, "无查询权限"); err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// CreateTemplate 创建打印模板
+func (s *service) CreateTemplate(ctx context.Context, tpl *entity.SysPrintTemplate, userID uint) error {
+	table, err := s.metadataService.GetTableByID(tpl.SysTableID)
+	if err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "表不存在", err)
+	}
+	if err := s.checkPermission(ctx, userID, table, groups.PermUpdate, "无修改权限，不能维护打印模板"); err != nil {
+		return err
+	}
+	if err := s.validateTemplate(ctx, tpl, table); err != nil {
+		return err
+	}
+
+	tpl.ID = 0
+	tpl.IsActive = "Y"
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, tpl); err != nil {
+			return err
+		}
+		if err := tx.Create(tpl).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建打印模板失败", err)
+		}
+		return nil
+	})
+}
+
+// UpdateTemplate 修改打印模板
+func (s *service) UpdateTemplate(ctx context.Context, tpl *entity.SysPrintTemplate, userID uint) error {
+	existing, err := s.getTemplate(ctx, tpl.ID)
+	if err != nil {
+		return err
+	}
+	table, err := s.metadataService.GetTableByID(existing.SysTableID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPermission(ctx, userID, table, groups.PermUpdate, "无修改权限，不能维护打印模板"); err != nil {
+		return err
+	}
+
+	tpl.BaseModel = existing.BaseModel
+	tpl.SysTableID = existing.SysTableID
+	if err := s.validateTemplate(ctx, tpl, table); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefault(tx, tpl); err != nil {
+			return err
+		}
+		if err := tx.Save(tpl).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "更新打印模板失败", err)
+		}
+		return nil
+	})
+}
+
+// DeleteTemplate 删除打印模板
+func (s *service) DeleteTemplate(ctx context.Context, id, userID uint) error {
+	tpl, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	table, err := s.metadataService.GetTableByID(tpl.SysTableID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPermission(ctx, userID, table, groups.PermUpdate, "无修改权限，不能维护打印模板"); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&entity.SysPrintTemplate{}).
+		Where("ID = ?", id).
+		Update("IS_ACTIVE", "N").Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "删除打印模板失败", err)
+	}
+	return nil
+}
+
+// getTemplate 查询有效的打印模板
+func (s *service) getTemplate(ctx context.Context, id uint) (*entity.SysPrintTemplate, error) {
+	var tpl entity.SysPrintTemplate
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", id, "Y").First(&tpl).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "打印模板不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询打印模板失败", err)
+	}
+	return &tpl, nil
+}
+
+// checkPermission 检查用户对表的权限
+func (s *service) checkPermission(ctx context.Context, userID uint, table *entity.SysTable, perm int, deniedMsg string) error {
+	hasPermission, err := s.groupsService.CheckUserTablePermission(ctx, userID, table.ID, perm)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, "权限检查失败", err)
+	}
+	if !hasPermission {
+		return errors.New(errors.ErrPermissionDenied, deniedMsg)
+	}
+	return nil
+}
+
+// clearDefault 设为默认模板时取消同一表的其他默认模板
+func clearDefault(tx *gorm.DB, tpl *entity.SysPrintTemplate) error {
+	if tpl.IsDefault != "Y" {
+		return nil
+	}
+	if err := tx.Model(&entity.SysPrintTemplate{}).
+		Where("SYS_TABLE_ID = ? AND ID <> ? AND IS_DEFAULT = ?", tpl.SysTableID, tpl.ID, "Y").
+		Update("IS_DEFAULT", "N").Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新默认打印模板失败", err)
+	}
+	return nil
+}
+
+// validateTemplate 检查模板参数并补全默认值；表头字段须为表的字段，明细须为一对多关联的子表及其字段
+func (s *service) validateTemplate(ctx context.Context, tpl *entity.SysPrintTemplate, table *entity.SysTable) error {
+	tpl.Name = strings.TrimSpace(tpl.Name)
+	if tpl.Name == "" {
+		return errors.New(errors.ErrInvalidParam, "模板名称不能为空")
+	}
+	if err := normalizeTemplate(tpl); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&entity.SysPrintTemplate{}).
+		Where("SYS_TABLE_ID = ? AND NAME = ? AND ID <> ? AND IS_ACTIVE = ?", table.ID, tpl.Name, tpl.ID, "Y").
+		Count(&count).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询打印模板失败", err)
+	}
+	if count > 0 {
+		return errors.New(errors.ErrResourceConflict, fmt.Sprintf("打印模板 %s 已存在", tpl.Name))
+	}
+
+	columns, err := s.metadataService.GetColumns(table.ID)
+	if err != nil {
+		return err
+	}
+	for _, name := range splitNames(tpl.HeaderColumns) {
+		if findColumn(columns, name) == nil {
+			return errors.New(errors.ErrInvalidParam, fmt.Sprintf("表头字段不存在: %s", name))
+		}
+	}
+
+	details, err := parseDetails(tpl.Details)
+	if err != nil {
+		return err
+	}
+	if details == nil {
+		return nil
+	}
+	refs, err := s.metadataService.GetTableRefs(table.ID)
+	if err != nil {
+		return err
+	}
+	for _, detail := range details {
+		refTable, err := s.findDetailTable(refs, detail.Table)
+		if err != nil {
+			return err
+		}
+		if refTable == nil {
+			return errors.New(errors.ErrInvalidParam, fmt.Sprintf("%s 不是 %s 的明细表", detail.Table, table.Name))
+		}
+		refColumns, err := s.metadataService.GetColumns(refTable.ID)
+		if err != nil {
+			return err
+		}
+		for _, name := range detail.Columns {
+			if findColumn(refColumns, name) == nil {
+				return errors.New(errors.ErrInvalidParam, fmt.Sprintf("明细表 %s 的字段不存在: %s", detail.Table, name))
+			}
+		}
+	}
+	return nil
+}
+
+// findDetailTable 在一对多关联中按表名查找子表
+func (s *service) findDetailTable(refs []*entity.SysTableRef, tableName string) (*entity.SysTable, error) {
+	for _, ref := range refs {
+		if ref.AssocType != "n" {
+			continue
+		}
+		refTable, err := s.metadataService.GetTableByID(uint(ref.RefTableID))
+		if err != nil {
+			return nil, err
+		}
+		if refTable.Name == tableName {
+			return refTable, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeTemplate 检查纸张、方向等参数并补全默认值
+func normalizeTemplate(tpl *entity.SysPrintTemplate) error {
+	paper := strings.ToLower(strings.TrimSpace(tpl.PaperSize))
+	if paper == "" {
+		paper = "a4"
+	}
+	size, ok := paperSizes[paper]
+	if !ok {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的纸张: %s", tpl.PaperSize))
+	}
+	tpl.PaperSize = size
+
+	switch strings.ToUpper(strings.TrimSpace(tpl.Orientation)) {
+	case "", "P":
+		tpl.Orientation = "P"
+	case "L":
+		tpl.Orientation = "L"
+	default:
+		return errors.New(errors.ErrInvalidParam, "打印方向只能为 P（纵向）或 L（横向）")
+	}
+
+	if tpl.HeaderCols == 0 {
+		tpl.HeaderCols = 2
+	}
+	if tpl.HeaderCols < 1 || tpl.HeaderCols > maxHeaderCols {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("表头每行字段数应为1-%d", maxHeaderCols))
+	}
+
+	switch tpl.IsDefault {
+	case "":
+		tpl.IsDefault = "N"
+	case "Y", "N":
+	default:
+		return errors.New(errors.ErrInvalidParam, "是否默认模板只能为 Y 或 N")
+	}
+	return nil
+}
+
+// parseDetails 解析模板的明细表配置，为空时返回 nil（打印全部一对多子表）
+func parseDetails(details string) ([]*DetailConfig, error) {
+	if strings.TrimSpace(details) == "" {
+		return nil, nil
+	}
+	configs := make([]*DetailConfig, 0)
+	if err := json.Unmarshal([]byte(details), &configs); err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidParam, "明细表配置不是有效的JSON数组", err)
+	}
+	for _, config := range configs {
+		if config == nil || strings.TrimSpace(config.Table) == "" {
+			return nil, errors.New(errors.ErrInvalidParam, "明细表配置缺少子表表名")
+		}
+	}
+	return configs, nil
+}
+
+// splitNames 拆分逗号分隔的字段名
+func splitNames(names string) []string {
+	var result []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// findColumn 按字段名（不区分大小写）查找字段
+func findColumn(columns []*entity.SysColumn, name string) *entity.SysColumn {
+	for _, col := range columns {
+		if strings.EqualFold(col.DbName, name) {
+			return col
+		}
+	}
+	return nil
+}
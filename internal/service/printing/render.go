@@ -0,0 +1,349 @@
+package printing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"gorm.io/gorm"
+)
+
+// document 排版前的打印文档，HTML和PDF按同一结构输出
+type document struct {
+	Title       string
+	PaperSize   string // A4、A5、Letter
+	Orientation string // P:纵向, L:横向
+	HeaderCols  int    // 表头每行的字段数
+	Pages       []*page
+}
+
+// page 一条记录的打印内容（从新的一页开始，明细多时自动续页）
+type page struct {
+	Title  string
+	Fields []*field
+	Tables []*table
+	Footer string
+}
+
+// field 表头字段
+type field struct {
+	Label string
+	Value string
+}
+
+// table 明细表
+type table struct {
+	Title   string
+	Headers []string
+	Numeric []bool // 数值列（右对齐）
+	Rows    [][]string
+}
+
+// detailLayout 模板中的一个明细表及其打印的字段
+type detailLayout struct {
+	detail  *crud.PrintDetail
+	title   string
+	columns []*entity.SysColumn
+}
+
+// Render 按模板打印记录
+func (s *service) Render(ctx context.Context, req *RenderRequest, userID uint, w io.Writer) error {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = FormatHTML
+	}
+	if format != FormatHTML && format != FormatPDF {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("不支持的打印格式: %s", req.Format))
+	}
+	if format == FormatPDF && s.cfg.FontPath == "" {
+		return errPDFFont
+	}
+	if len(req.IDs) == 0 {
+		return errors.New(errors.ErrInvalidParam, "请选择要打印的记录")
+	}
+	if len(req.IDs) > maxPrintRecords {
+		return errors.New(errors.ErrInvalidParam, fmt.Sprintf("单次最多打印%d条记录", maxPrintRecords))
+	}
+
+	printer, err := s.crudService.NewPrinter(ctx, req.TableName, userID)
+	if err != nil {
+		return err
+	}
+	tpl, err := s.loadTemplate(ctx, printer.Table(), req.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	doc, err := s.buildDocument(printer, tpl, req.IDs)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatPDF {
+		return s.renderPDF(doc, w)
+	}
+	return renderHTML(doc, w)
+}
+
+// loadTemplate 查询表的打印模板；未指定时使用默认模板，没有默认模板时使用内置的默认布局
+func (s *service) loadTemplate(ctx context.Context, table *entity.SysTable, templateID uint) (*entity.SysPrintTemplate, error) {
+	var tpl entity.SysPrintTemplate
+	query := s.db.WithContext(ctx).Where("SYS_TABLE_ID = ? AND IS_ACTIVE = ?", table.ID, "Y")
+	if templateID > 0 {
+		query = query.Where("ID = ?", templateID)
+	} else {
+		query = query.Where("IS_DEFAULT = ?", "Y").Order("ORDERNO ASC, ID ASC")
+	}
+
+	if err := query.First(&tpl).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, errors.Wrap(errors.ErrDatabase, "查询打印模板失败", err)
+		}
+		if templateID > 0 {
+			return nil, errors.New(errors.ErrResourceNotFound, "打印模板不存在")
+		}
+		tpl = entity.SysPrintTemplate{}
+	}
+
+	if err := normalizeTemplate(&tpl); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// buildDocument 读取记录和明细，按模板排版为打印文档
+func (s *service) buildDocument(printer *crud.Printer, tpl *entity.SysPrintTemplate, ids []uint) (*document, error) {
+	headerColumns := pickColumns(printer.Columns(), splitNames(tpl.HeaderColumns))
+	details, err := s.detailLayouts(printer, tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := printer.Records(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := append([]*entity.SysColumn{}, headerColumns...)
+	detailRows := make([]map[uint][]map[string]interface{}, len(details))
+	for i, layout := range details {
+		columns = append(columns, layout.columns...)
+		if detailRows[i], err = printer.DetailRows(layout.detail, ids); err != nil {
+			return nil, err
+		}
+	}
+	dicts, err := s.loadDicts(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	title := tpl.Title
+	if title == "" {
+		title = tableTitle(printer.Table())
+	}
+	doc := &document{
+		Title:       title,
+		PaperSize:   tpl.PaperSize,
+		Orientation: tpl.Orientation,
+		HeaderCols:  tpl.HeaderCols,
+		Pages:       make([]*page, 0, len(records)),
+	}
+
+	for i, record := range records {
+		p := &page{
+			Title:  title,
+			Fields: make([]*field, 0, len(headerColumns)),
+			Footer: tpl.Footer,
+		}
+		for _, col := range headerColumns {
+			p.Fields = append(p.Fields, &field{
+				Label: columnTitle(col),
+				Value: displayValue(col, record[col.DbName], dicts[col]),
+			})
+		}
+
+		for j, layout := range details {
+			t := &table{
+				Title:   layout.title,
+				Headers: make([]string, len(layout.columns)),
+				Numeric: make([]bool, len(layout.columns)),
+			}
+			for k, col := range layout.columns {
+				t.Headers[k] = columnTitle(col)
+				t.Numeric[k] = isNumeric(col)
+			}
+			for _, row := range detailRows[j][ids[i]] {
+				cells := make([]string, len(layout.columns))
+				for k, col := range layout.columns {
+					cells[k] = displayValue(col, row[col.DbName], dicts[col])
+				}
+				t.Rows = append(t.Rows, cells)
+			}
+			p.Tables = append(p.Tables, t)
+		}
+
+		doc.Pages = append(doc.Pages, p)
+	}
+	return doc, nil
+}
+
+// detailLayouts 模板中的明细表：未配置时为全部可查询的一对多子表
+func (s *service) detailLayouts(printer *crud.Printer, tpl *entity.SysPrintTemplate) ([]*detailLayout, error) {
+	configs, err := parseDetails(tpl.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	if configs == nil {
+		details, err := printer.Details()
+		if err != nil {
+			return nil, err
+		}
+		layouts := make([]*detailLayout, 0, len(details))
+		for _, detail := range details {
+			layouts = append(layouts, &detailLayout{
+				detail:  detail,
+				title:   detailTitle(detail),
+				columns: detail.Columns,
+			})
+		}
+		return layouts, nil
+	}
+
+	layouts := make([]*detailLayout, 0, len(configs))
+	for _, config := range configs {
+		detail, err := printer.Detail(config.Table)
+		if err != nil {
+			return nil, err
+		}
+		title := config.Title
+		if title == "" {
+			title = detailTitle(detail)
+		}
+		layouts = append(layouts, &detailLayout{
+			detail:  detail,
+			title:   title,
+			columns: pickColumns(detail.Columns, config.Columns),
+		})
+	}
+	return layouts, nil
+}
+
+// pickColumns 按模板配置的字段名顺序选取打印可见的字段，未配置时为全部打印可见字段
+// 打印不可见的字段不输出（MASK 在打印时生效，模板中配置了也不打印）
+func pickColumns(visible []*entity.SysColumn, names []string) []*entity.SysColumn {
+	if len(names) == 0 {
+		return visible
+	}
+	picked := make([]*entity.SysColumn, 0, len(names))
+	for _, name := range names {
+		if col := findColumn(visible, name); col != nil {
+			picked = append(picked, col)
+		}
+	}
+	return picked
+}
+
+// loadDicts 加载字典字段的字典项（按字段）
+func (s *service) loadDicts(columns []*entity.SysColumn) (map[*entity.SysColumn][]*entity.SysDictItem, error) {
+	dicts := make(map[*entity.SysColumn][]*entity.SysDictItem)
+	for _, col := range columns {
+		if col.SysDictID == "" {
+			continue
+		}
+		var items []*entity.SysDictItem
+		var err error
+		// SYS_DICT_ID 为字典ID或字典名称
+		if dictID, parseErr := strconv.ParseUint(col.SysDictID, 10, 64); parseErr == nil {
+			items, err = s.dictService.GetDictItems(uint(dictID))
+		} else {
+			items, err = s.dictService.GetDictItemsByName(col.SysDictID)
+		}
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInternal, fmt.Sprintf("查询数据字典 %s 失败", col.SysDictID), err)
+		}
+		dicts[col] = items
+	}
+	return dicts, nil
+}
+
+// displayValue 打印的值：字典字段为显示名称，日期按字段类型格式化
+func displayValue(col *entity.SysColumn, value interface{}, items []*entity.SysDictItem) string {
+	if value == nil {
+		return ""
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		if strings.ToLower(col.ColType) == "date" {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		str = fmt.Sprint(v)
+	}
+
+	for _, item := range items {
+		if item.Value == str {
+			if item.DisplayName != "" {
+				return item.DisplayName
+			}
+			return item.Value
+		}
+	}
+	return str
+}
+
+// isNumeric 数值类型的字段
+func isNumeric(col *entity.SysColumn) bool {
+	if col.SysDictID != "" {
+		return false
+	}
+	switch strings.ToLower(col.ColType) {
+	case "int", "integer", "bigint", "smallint", "tinyint", "decimal", "float", "double", "number":
+		return true
+	}
+	return false
+}
+
+// tableTitle 表的显示名称
+func tableTitle(table *entity.SysTable) string {
+	if table.DisplayName != "" {
+		return table.DisplayName
+	}
+	return table.Name
+}
+
+// columnTitle 字段的显示名称
+func columnTitle(col *entity.SysColumn) string {
+	if col.DisplayName != "" {
+		return col.DisplayName
+	}
+	return col.DbName
+}
+
+// detailTitle 明细表的标题：关联的显示名称，未设置时为子表的显示名称
+func detailTitle(detail *crud.PrintDetail) string {
+	if detail.Ref.DisplayName != "" {
+		return detail.Ref.DisplayName
+	}
+	return tableTitle(detail.Table)
+}
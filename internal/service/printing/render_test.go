@@ -0,0 +1,135 @@
+package printing
+
+import (
+	"bytes"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestNormalizeTemplate(t *testing.T) {
+	tpl := &entity.SysPrintTemplate{PaperSize: "a5", Orientation: "l"}
+	if err := normalizeTemplate(tpl); err != nil {
+		t.Fatalf("normalizeTemplate: %v", err)
+	}
+	if tpl.PaperSize != "A5" || tpl.Orientation != "L" || tpl.HeaderCols != 2 || tpl.IsDefault != "N" {
+		t.Errorf("normalized = %s/%s/%d/%s", tpl.PaperSize, tpl.Orientation, tpl.HeaderCols, tpl.IsDefault)
+	}
+
+	for _, bad := range []*entity.SysPrintTemplate{
+		{PaperSize: "B5"},
+		{Orientation: "X"},
+		{HeaderCols: maxHeaderCols + 1},
+		{IsDefault: "y"},
+	} {
+		if err := normalizeTemplate(bad); err == nil {
+			t.Errorf("normalizeTemplate(%+v) should fail", bad)
+		}
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	if details, err := parseDetails(" "); err != nil || details != nil {
+		t.Errorf("empty details = %v, %v; want nil (all details)", details, err)
+	}
+	if details, err := parseDetails("[]"); err != nil || details == nil || len(details) != 0 {
+		t.Errorf("[] details = %v, %v; want no details", details, err)
+	}
+	details, err := parseDetails(`[{"table":"order_item","columns":["SKU","QTY"]}]`)
+	if err != nil || len(details) != 1 || details[0].Table != "order_item" || len(details[0].Columns) != 2 {
+		t.Errorf("details = %v, %v", details, err)
+	}
+	if _, err := parseDetails(`[{"title":"明细"}]`); err == nil {
+		t.Error("detail without table should fail")
+	}
+	if _, err := parseDetails(`{"table":"order_item"}`); err == nil {
+		t.Error("non-array details should fail")
+	}
+}
+
+func TestDisplayValue(t *testing.T) {
+	col := &entity.SysColumn{DbName: "STATUS", SysDictID: "order_status"}
+	items := []*entity.SysDictItem{{Value: "1", DisplayName: "已发货"}}
+	if got := displayValue(col, []byte("1"), items); got != "已发货" {
+		t.Errorf("dict value = %q", got)
+	}
+	if got := displayValue(col, "2", items); got != "2" {
+		t.Errorf("unknown dict value = %q", got)
+	}
+	if got := displayValue(&entity.SysColumn{}, 12.5, nil); got != "12.5" {
+		t.Errorf("float value = %q", got)
+	}
+}
+
+func testDocument() *document {
+	return &document{
+		Title:       "发货单",
+		PaperSize:   "A4",
+		Orientation: "P",
+		HeaderCols:  2,
+		Pages: []*page{{
+			Title: "发货单",
+			Fields: []*field{
+				{Label: "Doc No", Value: "SO-001"},
+				{Label: "Customer", Value: "<b>ACME</b>"},
+				{Label: "Note", Value: strings.Repeat("long text ", 40)},
+			},
+			Tables: []*table{{
+				Title:   "Items",
+				Headers: []string{"SKU", "QTY"},
+				Numeric: []bool{false, true},
+				Rows:    [][]string{{"A-1", "2"}, {"B-2", "10"}},
+			}},
+			Footer: "Signed:\nDate:",
+		}},
+	}
+}
+
+func TestFieldRows(t *testing.T) {
+	fields := testDocument().Pages[0].Fields
+	rows := fieldRows(fields, 2)
+	if len(rows) != 2 || len(rows[0]) != 2 || len(rows[1]) != 1 {
+		t.Errorf("fieldRows = %v", rows)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderHTML(testDocument(), &buf); err != nil {
+		t.Fatalf("renderHTML: %v", err)
+	}
+	html := buf.String()
+	if strings.Contains(html, "<b>ACME</b>") || !strings.Contains(html, "&lt;b&gt;ACME&lt;/b&gt;") {
+		t.Error("field values should be escaped")
+	}
+	if !strings.Contains(html, `<td class="num">10</td>`) {
+		t.Error("numeric cells should be right aligned")
+	}
+	if !strings.Contains(html, "size: A4 portrait") {
+		t.Error("page size missing")
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&service{}).renderPDF(testDocument(), &buf); err != errPDFFont {
+		t.Fatalf("renderPDF without font = %v, want errPDFFont", err)
+	}
+
+	// 使用 fpdf 自带的 UTF-8 字体
+	fontPath := filepath.Join(build.Default.GOPATH, "pkg", "mod", "github.com", "go-pdf", "fpdf@v0.9.0", "font", "DejaVuSansCondensed.ttf")
+	if _, err := os.Stat(fontPath); err != nil {
+		t.Skipf("test font not found: %v", err)
+	}
+	s := &service{cfg: Config{FontPath: fontPath}}
+	if err := s.renderPDF(testDocument(), &buf); err != nil {
+		t.Fatalf("renderPDF: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Error("output is not a PDF")
+	}
+}
@@ -0,0 +1,290 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/expr"
+	"github.com/sky-xhsoft/sky-server/internal/service/groups"
+	"gorm.io/gorm"
+)
+
+// 任务分配类型（WfNode.AssignType）
+const (
+	AssignTypeUser        = "user"        // 指定用户，ASSIGN_VALUE 为用户ID，多个用逗号分隔
+	AssignTypeStarter     = "starter"     // 流程发起人
+	AssignTypeRole        = "role"        // 权限组成员（同 group）
+	AssignTypeGroup       = "group"       // 权限组成员，ASSIGN_VALUE 为 sys_groups.ID，多个用逗号分隔
+	AssignTypeDirectory   = "directory"   // 安全目录成员，ASSIGN_VALUE 为 目录ID[:权限位]，默认要求读权限
+	AssignTypeDeptManager = "deptManager" // 发起人部门负责人，ASSIGN_VALUE 为向上层级（默认1，即本部门）
+	AssignTypeExpression  = "expression"  // 表达式，结果为用户ID、用户名或它们的列表
+)
+
+// maxDeptLevel 部门负责人向上查找的最大层级
+const maxDeptLevel = 10
+
+// resolveCandidates 解析任务候选人
+// 返回去重后按ID排序的用户列表；只有一个时直接分配，多个时创建候选组任务
+func (s *service) resolveCandidates(ctx context.Context, node *entity.WfNode, instance *entity.WfInstance) ([]uint, error) {
+	var candidates []uint
+	var err error
+
+	switch node.AssignType {
+	case AssignTypeUser:
+		candidates, err = parseIDList(node.AssignValue)
+	case AssignTypeStarter:
+		candidates = []uint{instance.StartUserID}
+	case AssignTypeRole, AssignTypeGroup:
+		candidates, err = s.getGroupMembers(ctx, node.AssignValue)
+	case AssignTypeDirectory:
+		candidates, err = s.getDirectoryMembers(ctx, node.AssignValue)
+	case AssignTypeDeptManager:
+		candidates, err = s.getDeptManager(ctx, instance.StartUserID, node.AssignValue)
+	case AssignTypeExpression:
+		candidates, err = s.evaluateAssignee(ctx, node.AssignValue, instance)
+	default:
+		return nil, errors.New(errors.ErrValidation, fmt.Sprintf("不支持的任务分配类型: %s", node.AssignType))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	candidates = uniqueIDs(candidates)
+	if len(candidates) == 0 {
+		return nil, errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 未找到任务处理人", node.Name))
+	}
+
+	return candidates, nil
+}
+
+// getGroupMembers 获取权限组成员
+func (s *service) getGroupMembers(ctx context.Context, value string) ([]uint, error) {
+	groupIDs, err := parseIDList(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupIDs) == 0 {
+		return nil, errors.New(errors.ErrValidation, "权限组分配必须配置权限组ID")
+	}
+
+	var userIDs []uint
+	if err := s.db.WithContext(ctx).
+		Table("sys_user_groups").
+		Joins("INNER JOIN sys_user ON sys_user.ID = sys_user_groups.SYS_USER_ID").
+		Where("sys_user_groups.SYS_DIRECTORY_ID IN ? AND sys_user_groups.IS_ACTIVE = ? AND sys_user.IS_ACTIVE = ?", groupIDs, "Y", "Y").
+		Distinct().
+		Pluck("sys_user_groups.SYS_USER_ID", &userIDs).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询权限组成员失败", err)
+	}
+
+	return userIDs, nil
+}
+
+// getDirectoryMembers 获取对安全目录拥有指定权限的用户
+// value 格式：目录ID 或 目录ID:权限位（如 12:128 表示需要审核权限）
+func (s *service) getDirectoryMembers(ctx context.Context, value string) ([]uint, error) {
+	dirPart, permPart, _ := strings.Cut(strings.TrimSpace(value), ":")
+	directoryID, err := strconv.ParseUint(strings.TrimSpace(dirPart), 10, 64)
+	if err != nil || directoryID == 0 {
+		return nil, errors.New(errors.ErrValidation, fmt.Sprintf("无效的安全目录配置: %s", value))
+	}
+
+	permission := groups.PermRead
+	if permPart != "" {
+		if permission, err = strconv.Atoi(strings.TrimSpace(permPart)); err != nil || permission <= 0 {
+			return nil, errors.New(errors.ErrValidation, fmt.Sprintf("无效的权限位配置: %s", value))
+		}
+	}
+
+	var userIDs []uint
+	if err := s.db.WithContext(ctx).
+		Table("sys_group_prem").
+		Joins("INNER JOIN sys_user_groups ON sys_group_prem.SYS_GROUPS_ID = sys_user_groups.SYS_DIRECTORY_ID").
+		Joins("INNER JOIN sys_user ON sys_user.ID = sys_user_groups.SYS_USER_ID").
+		Where("sys_group_prem.SYS_DIRECTORY_ID = ? AND sys_group_prem.PERMISSION & ? = ?", directoryID, permission, permission).
+		Where("sys_group_prem.IS_ACTIVE = ? AND sys_user_groups.IS_ACTIVE = ? AND sys_user.IS_ACTIVE = ?", "Y", "Y", "Y").
+		Distinct().
+		Pluck("sys_user_groups.SYS_USER_ID", &userIDs).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询安全目录成员失败", err)
+	}
+
+	return userIDs, nil
+}
+
+// getDeptManager 获取用户所在部门（或上级部门）的负责人
+// value 为向上层级：1 表示本部门负责人，2 表示上级部门负责人，以此类推
+// 部门未设置负责人或负责人为本人时继续向上查找
+func (s *service) getDeptManager(ctx context.Context, userID uint, value string) ([]uint, error) {
+	level := 1
+	if strings.TrimSpace(value) != "" {
+		var err error
+		if level, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || level < 1 || level > maxDeptLevel {
+			return nil, errors.New(errors.ErrValidation, fmt.Sprintf("部门层级必须为1-%d", maxDeptLevel))
+		}
+	}
+
+	var user entity.SysUser
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", userID, "Y").First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "流程发起人不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程发起人失败", err)
+	}
+	if user.SysDeptID == nil {
+		return nil, errors.New(errors.ErrValidation, "流程发起人未设置所属部门")
+	}
+
+	deptID := *user.SysDeptID
+	for depth := 1; depth <= maxDeptLevel; depth++ {
+		var dept entity.SysDept
+		if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", deptID, "Y").First(&dept).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.New(errors.ErrResourceNotFound, "部门不存在")
+			}
+			return nil, errors.Wrap(errors.ErrDatabase, "查询部门失败", err)
+		}
+
+		if depth >= level && dept.ManagerID != 0 && dept.ManagerID != userID {
+			return []uint{dept.ManagerID}, nil
+		}
+		if dept.ParentID == nil || *dept.ParentID == 0 {
+			break
+		}
+		deptID = *dept.ParentID
+	}
+
+	return nil, errors.New(errors.ErrValidation, "未找到部门负责人")
+}
+
+// evaluateAssignee 计算表达式分配的处理人
+// 表达式可引用流程变量和业务记录（record.字段名），结果可以是用户ID、用户名或它们的列表
+func (s *service) evaluateAssignee(ctx context.Context, expression string, instance *entity.WfInstance) ([]uint, error) {
+	var variables map[string]interface{}
+	if instance.Variables != "" {
+		json.Unmarshal([]byte(instance.Variables), &variables)
+	}
+
+	env, err := s.buildConditionEnv(ctx, instance, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := expr.Eval(expression, env)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrValidation, "处理人表达式计算失败", err)
+	}
+
+	var values []interface{}
+	switch v := result.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		values = v
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			values = append(values, part)
+		}
+	default:
+		values = []interface{}{v}
+	}
+
+	var userIDs []uint
+	var usernames []string
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case float64:
+			userIDs = append(userIDs, uint(v))
+		case string:
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+				userIDs = append(userIDs, uint(id))
+			} else {
+				usernames = append(usernames, v)
+			}
+		default:
+			return nil, errors.New(errors.ErrValidation, fmt.Sprintf("处理人表达式结果无效: %v", value))
+		}
+	}
+
+	if len(usernames) > 0 {
+		var ids []uint
+		if err := s.db.WithContext(ctx).Model(&entity.SysUser{}).
+			Where("USERNAME IN ? AND IS_ACTIVE = ?", usernames, "Y").
+			Pluck("ID", &ids).Error; err != nil {
+			return nil, errors.Wrap(errors.ErrDatabase, "查询处理人失败", err)
+		}
+		userIDs = append(userIDs, ids...)
+	}
+
+	return userIDs, nil
+}
+
+// validateAssignee 检查节点分配配置（发布时调用）
+func validateAssignee(node *entity.WfNode) error {
+	if node.NodeType != "user" {
+		return nil
+	}
+
+	switch node.AssignType {
+	case AssignTypeStarter, AssignTypeDeptManager, AssignTypeDirectory:
+		return nil
+	case AssignTypeUser, AssignTypeRole, AssignTypeGroup:
+		ids, err := parseIDList(node.AssignValue)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 未配置分配值", node.Name))
+		}
+		return nil
+	case AssignTypeExpression:
+		if _, err := expr.Compile(node.AssignValue); err != nil {
+			return errors.Wrap(errors.ErrValidation, fmt.Sprintf("节点 %s 的处理人表达式无效", node.Name), err)
+		}
+		return nil
+	default:
+		return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 的任务分配类型不支持: %s", node.Name, node.AssignType))
+	}
+}
+
+// parseIDList 解析逗号分隔的ID列表
+func parseIDList(value string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, errors.New(errors.ErrValidation, fmt.Sprintf("无效的ID: %s", part))
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// uniqueIDs 去重并排序，忽略0
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestParseIDList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []uint
+		wantErr bool
+	}{
+		{"空值", "", nil, false},
+		{"单个ID", "7", []uint{7}, false},
+		{"忽略空白和空项", " 1, 2,,3 ,", []uint{1, 2, 3}, false},
+		{"保留重复和顺序", "3,1,3", []uint{3, 1, 3}, false},
+		{"非数字", "1,abc", nil, true},
+		{"负数", "-1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIDList(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIDList(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIDList(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []uint
+		want []uint
+	}{
+		{"空列表", nil, []uint{}},
+		{"去重排序", []uint{3, 1, 3, 2}, []uint{1, 2, 3}},
+		{"忽略0", []uint{0, 5, 0}, []uint{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueIDs(%v) = %v, want %v", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAssignee(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *entity.WfNode
+		wantErr bool
+	}{
+		{"非用户任务不检查", &entity.WfNode{NodeType: NodeTypeGateway, AssignType: "unknown"}, false},
+		{"发起人", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeStarter}, false},
+		{"部门负责人无需分配值", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeDeptManager}, false},
+		{"指定用户", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeUser, AssignValue: "1,2"}, false},
+		{"指定用户未配置", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeUser, AssignValue: " , "}, true},
+		{"权限组ID无效", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeGroup, AssignValue: "admin"}, true},
+		{"表达式", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeExpression, AssignValue: "coalesce(approvers, 1)"}, false},
+		{"表达式语法错误", &entity.WfNode{NodeType: NodeTypeUser, AssignType: AssignTypeExpression, AssignValue: "amount >"}, true},
+		{"不支持的类型", &entity.WfNode{NodeType: NodeTypeUser, AssignType: "unknown"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAssignee(tt.node); (err != nil) != tt.wantErr {
+				t.Errorf("validateAssignee() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveCandidates(t *testing.T) {
+	// 未关联业务表的实例，表达式只引用流程变量，不访问数据库
+	instance := &entity.WfInstance{StartUserID: 9, Variables: `{"amount": 2000, "approvers": "5, 4"}`}
+
+	tests := []struct {
+		name       string
+		assignType string
+		value      string
+		want       []uint
+		wantErr    bool
+	}{
+		{"指定用户去重排序", AssignTypeUser, "2,1,2", []uint{1, 2}, false},
+		{"指定用户ID无效", AssignTypeUser, "1,x", nil, true},
+		{"指定用户为空", AssignTypeUser, "0", nil, true},
+		{"发起人", AssignTypeStarter, "", []uint{9}, false},
+		{"表达式返回列表", AssignTypeExpression, "[3, 1, 3]", []uint{1, 3}, false},
+		{"表达式返回逗号分隔的ID", AssignTypeExpression, "approvers", []uint{4, 5}, false},
+		{"表达式结果为空", AssignTypeExpression, "null", nil, true},
+		{"表达式结果类型无效", AssignTypeExpression, "amount > 1000", nil, true},
+		{"不支持的类型", "unknown", "", nil, true},
+	}
+	s := &service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &entity.WfNode{NodeType: NodeTypeUser, Name: "审批", AssignType: tt.assignType, AssignValue: tt.value}
+			got, err := s.resolveCandidates(context.Background(), node, instance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCandidates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"gorm.io/gorm"
+)
+
+// 任务操作（CompleteTaskRequest.Action）
+const (
+	ActionApprove       = "approve"       // 同意
+	ActionReject        = "reject"        // 拒绝，终止流程
+	ActionBack          = "back"          // 退回上一个用户节点，或 TargetNodeID 指定的已经过的节点
+	ActionBackToStarter = "backToStarter" // 退回发起人修改后重新提交
+	ActionWithdraw      = "withdraw"      // 发起人撤回（实例操作）
+)
+
+// 流程历史中的退回/撤回事件类型与操作同名
+const (
+	HistoryBack          = ActionBack
+	HistoryBackToStarter = ActionBackToStarter
+	HistoryWithdraw      = ActionWithdraw
+)
+
+// 任务操作写入的流程变量，可在流转条件中引用（如 _backCount > 2）
+const (
+	VarLastAction = "_lastAction" // 最近一次任务操作
+	VarBackCount  = "_backCount"  // 累计退回次数
+	VarBackFrom   = "_backFrom"   // 最近一次退回的来源节点名称
+)
+
+// validateTaskAction 检查任务操作
+func validateTaskAction(action string) error {
+	switch action {
+	case ActionApprove, ActionReject, ActionBack, ActionBackToStarter:
+		return nil
+	default:
+		return errors.New(errors.ErrValidation, fmt.Sprintf("不支持的任务操作: %s", action))
+	}
+}
+
+// recordActionVariables 将本次任务操作写入流程变量
+func recordActionVariables(variables map[string]interface{}, action string, node *entity.WfNode) {
+	variables[VarLastAction] = action
+	if action != ActionBack && action != ActionBackToStarter {
+		return
+	}
+
+	count := 0.0
+	if v, ok := variables[VarBackCount].(float64); ok {
+		count = v
+	}
+	variables[VarBackCount] = count + 1
+	variables[VarBackFrom] = node.Name
+}
+
+// sendBack 退回：当前令牌结束，在目标节点重新生成待处理任务
+// 目标节点在并行分支内时只退回当前分支，目标在主干上时取消所有分支后从目标节点重新开始
+func (s *service) sendBack(ctx context.Context, instance *entity.WfInstance, task *entity.WfTask, token *entity.WfToken, currentNode *entity.WfNode, req *CompleteTaskRequest, variables map[string]interface{}) error {
+	var target entity.WfNode
+	var forkID uint
+
+	if req.Action == ActionBackToStarter {
+		if currentNode.NodeType == NodeTypeStart {
+			return errors.New(errors.ErrValidation, "任务已在发起人处")
+		}
+		if err := s.db.WithContext(ctx).
+			Where("WF_DEFINITION_ID = ? AND NODE_TYPE = ? AND IS_ACTIVE = ?", instance.WfDefinitionID, NodeTypeStart, "Y").
+			First(&target).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询开始节点失败", err)
+		}
+	} else {
+		targetToken, err := s.findBackTarget(ctx, instance, token, req.TargetNodeID)
+		if err != nil {
+			return err
+		}
+		if err := s.db.WithContext(ctx).First(&target, targetToken.WfNodeID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询退回节点失败", err)
+		}
+		forkID = targetToken.ForkID
+	}
+
+	if forkID != 0 && forkID != token.ForkID {
+		return errors.New(errors.ErrValidation, "不能退回到其他并行分支的节点")
+	}
+
+	if err := s.leaveToken(ctx, token); err != nil {
+		return err
+	}
+	if forkID == 0 {
+		// 退回主干：取消所有分支的待处理任务和令牌
+		if err := s.cancelExecution(ctx, instance.ID); err != nil {
+			return err
+		}
+	} else {
+		// 分支内退回：只取消当前令牌上其他会签任务
+		if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Where("WF_TOKEN_ID = ? AND STATUS = ?", token.ID, "pending").
+			Update("STATUS", "canceled").Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "取消会签任务失败", err)
+		}
+	}
+
+	if err := s.recordHistory(ctx, &entity.WfHistory{
+		WfInstanceID: instance.ID,
+		WfTaskID:     task.ID,
+		WfNodeID:     currentNode.ID,
+		EventType:    req.Action,
+		OperatorID:   req.UserID,
+		Action:       req.Action,
+		Comment:      req.Comment,
+	}, map[string]interface{}{
+		"fromNodeId":   currentNode.ID,
+		"fromNodeName": currentNode.Name,
+		"toNodeId":     target.ID,
+		"toNodeName":   target.Name,
+	}); err != nil {
+		return err
+	}
+
+	newToken, err := s.createToken(ctx, instance, &target, forkID, 0)
+	if err != nil {
+		return err
+	}
+
+	// 退回发起人：在开始节点为发起人创建修改任务，同意即重新提交
+	if target.NodeType == NodeTypeStart {
+		starterTask := newTask(instance, newToken, &target, instance.StartUserID)
+		if err := s.db.WithContext(ctx).Create(starterTask).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建发起人任务失败", err)
+		}
+		return nil
+	}
+
+	return s.executeNode(ctx, instance, newToken, &target, variables)
+}
+
+// findBackTarget 查找退回目标节点的最近一次访问
+// 未指定目标时取当前分支内上一个经过的用户节点
+func (s *service) findBackTarget(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, targetNodeID uint) (*entity.WfToken, error) {
+	query := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Select("wf_token.*").
+		Joins("INNER JOIN wf_node ON wf_node.ID = wf_token.WF_NODE_ID").
+		Where("wf_token.WF_INSTANCE_ID = ? AND wf_token.ID < ? AND wf_token.STATUS = ? AND wf_node.NODE_TYPE = ?",
+			instance.ID, token.ID, TokenCompleted, NodeTypeUser).
+		// 迁移版本后只退回到当前版本中的节点
+		Where("wf_node.WF_DEFINITION_ID = ?", instance.WfDefinitionID)
+
+	if targetNodeID != 0 {
+		query = query.Where("wf_token.WF_NODE_ID = ?", targetNodeID)
+	} else {
+		query = query.Where("wf_token.FORK_ID = ? AND wf_token.WF_NODE_ID <> ?", token.ForkID, token.WfNodeID)
+	}
+
+	var target entity.WfToken
+	if err := query.Order("wf_token.ID DESC").Take(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			if targetNodeID != 0 {
+				return nil, errors.New(errors.ErrValidation, "只能退回到流程已经过的用户节点")
+			}
+			return nil, errors.New(errors.ErrValidation, "没有可退回的节点，请退回发起人")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询退回节点失败", err)
+	}
+
+	return &target, nil
+}
+
+// WithdrawInstance 发起人撤回流程
+// 只有在还没有审批人同意过任何用户任务时才能撤回，撤回后流程结束
+func (s *service) WithdrawInstance(ctx context.Context, id, userID uint, comment string) error {
+	return s.inTransaction(ctx, func(txs *service) error {
+		instance, err := txs.lockInstance(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if instance.StartUserID != userID {
+			return errors.New(errors.ErrPermissionDenied, "只有发起人可以撤回流程")
+		}
+		if instance.Status != "running" && instance.Status != "suspended" {
+			return errors.New(errors.ErrValidation, "只能撤回运行中或挂起的流程")
+		}
+
+		var approved int64
+		if err := txs.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Joins("INNER JOIN wf_node ON wf_node.ID = wf_task.WF_NODE_ID").
+			Where("wf_task.WF_INSTANCE_ID = ? AND wf_task.STATUS = ? AND wf_task.ACTION = ? AND wf_node.NODE_TYPE = ?",
+				instance.ID, "completed", ActionApprove, NodeTypeUser).
+			Count(&approved).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询审批记录失败", err)
+		}
+		if approved > 0 {
+			return errors.New(errors.ErrResourceConflict, "已有审批人同意，不能撤回")
+		}
+
+		if err := txs.cancelExecution(ctx, instance.ID); err != nil {
+			return err
+		}
+
+		var variables map[string]interface{}
+		if instance.Variables != "" {
+			json.Unmarshal([]byte(instance.Variables), &variables)
+		}
+		if variables == nil {
+			variables = make(map[string]interface{})
+		}
+		variables[VarLastAction] = ActionWithdraw
+		variablesJSON, _ := json.Marshal(variables)
+
+		instance.Variables = string(variablesJSON)
+		instance.Status = "withdrawn"
+		instance.EndTime = time.Now()
+		if err := txs.db.WithContext(ctx).Save(instance).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "撤回流程失败", err)
+		}
+
+		if err := txs.recordHistory(ctx, &entity.WfHistory{
+			WfInstanceID: instance.ID,
+			WfNodeID:     instance.CurrentNodeID,
+			EventType:    HistoryWithdraw,
+			OperatorID:   userID,
+			Action:       ActionWithdraw,
+			Comment:      comment,
+		}, nil); err != nil {
+			return err
+		}
+
+		return txs.finishBusiness(ctx, instance, crud.ApprovalWithdrawn)
+	})
+}
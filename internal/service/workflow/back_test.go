@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func TestRecordActionVariables(t *testing.T) {
+	node := &entity.WfNode{Name: "部门审批"}
+
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		action    string
+		want      map[string]interface{}
+	}{
+		{
+			"同意只记录操作",
+			map[string]interface{}{"amount": float64(100)},
+			ActionApprove,
+			map[string]interface{}{"amount": float64(100), VarLastAction: ActionApprove},
+		},
+		{
+			"首次退回",
+			map[string]interface{}{},
+			ActionBack,
+			map[string]interface{}{VarLastAction: ActionBack, VarBackCount: float64(1), VarBackFrom: "部门审批"},
+		},
+		{
+			"累计退回次数",
+			map[string]interface{}{VarLastAction: ActionApprove, VarBackCount: float64(2), VarBackFrom: "财务审批"},
+			ActionBackToStarter,
+			map[string]interface{}{VarLastAction: ActionBackToStarter, VarBackCount: float64(3), VarBackFrom: "部门审批"},
+		},
+		{
+			"同意保留退回记录",
+			map[string]interface{}{VarLastAction: ActionBack, VarBackCount: float64(1), VarBackFrom: "财务审批"},
+			ActionApprove,
+			map[string]interface{}{VarLastAction: ActionApprove, VarBackCount: float64(1), VarBackFrom: "财务审批"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordActionVariables(tt.variables, tt.action, node)
+			if !reflect.DeepEqual(tt.variables, tt.want) {
+				t.Errorf("recordActionVariables() = %v, want %v", tt.variables, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBackTarget(t *testing.T) {
+	instance := &entity.WfInstance{WfDefinitionID: 2}
+	instance.ID = 1
+	token := &entity.WfToken{WfInstanceID: 1, WfNodeID: 8, ForkID: 4}
+	token.ID = 10
+
+	found := &fakeResult{
+		columns: []string{"ID", "WF_INSTANCE_ID", "WF_NODE_ID", "FORK_ID", "STATUS"},
+		rows:    [][]driver.Value{{int64(6), int64(1), int64(7), int64(4), TokenCompleted}},
+	}
+
+	tests := []struct {
+		name         string
+		targetNodeID uint
+		result       *fakeResult
+		wantWhere    string
+		wantArgs     []interface{} // 目标条件的参数
+		wantErr      string
+	}{
+		{"指定目标节点", 7, found, "wf_token.WF_NODE_ID = ?", []interface{}{int64(7)}, ""},
+		{"当前分支上一个用户节点", 0, found, "wf_token.FORK_ID = ? AND wf_token.WF_NODE_ID <> ?", []interface{}{int64(4), int64(8)}, ""},
+		{"指定目标未经过", 7, &fakeResult{}, "wf_token.WF_NODE_ID = ?", []interface{}{int64(7)}, "只能退回到流程已经过的用户节点"},
+		{"没有上一个用户节点", 0, &fakeResult{}, "wf_token.FORK_ID = ?", []interface{}{int64(4), int64(8)}, "没有可退回的节点，请退回发起人"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, map[string]*fakeResult{"FROM `wf_token`": tt.result})
+			s := &service{db: db}
+
+			target, err := s.findBackTarget(context.Background(), instance, token, tt.targetNodeID)
+			if tt.wantErr != "" {
+				if err == nil || errors.GetCode(err) != errors.ErrValidation || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("findBackTarget() error = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("findBackTarget() error = %v", err)
+				}
+				if target.ID != 6 || target.WfNodeID != 7 {
+					t.Errorf("findBackTarget() = token %d node %d, want token 6 node 7", target.ID, target.WfNodeID)
+				}
+			}
+
+			queries := fake.executed(tt.wantWhere)
+			if len(queries) != 1 {
+				t.Fatalf("executed %q = %d queries, want 1", tt.wantWhere, len(queries))
+			}
+			query := queries[0]
+			for _, fragment := range []string{"wf_token.ID < ?", "wf_node.NODE_TYPE = ?", "wf_node.WF_DEFINITION_ID = ?", "ORDER BY wf_token.ID DESC"} {
+				if !strings.Contains(query.sql, fragment) {
+					t.Errorf("query %q missing %q", query.sql, fragment)
+				}
+			}
+			// 参数顺序：实例、当前令牌、状态、节点类型、流程版本，之后为目标条件
+			wantArgs := append([]interface{}{int64(1), int64(10), TokenCompleted, NodeTypeUser, int64(2)}, tt.wantArgs...)
+			if len(query.args) < len(wantArgs) || !reflect.DeepEqual(query.args[:len(wantArgs)], wantArgs) {
+				t.Errorf("query args = %v, want prefix %v", query.args, wantArgs)
+			}
+		})
+	}
+}
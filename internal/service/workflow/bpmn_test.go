@@ -0,0 +1,202 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+const testBPMN = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"
+    xmlns:bpmndi="http://www.omg.org/spec/BPMN/20100524/DI"
+    xmlns:dc="http://www.omg.org/spec/DD/20100524/DC"
+    xmlns:sky="http://sky-xhsoft.com/schema/bpmn" id="Definitions_1">
+  <bpmn:process id="leave" name="请假" isExecutable="true" sky:sysTableId="12">
+    <bpmn:startEvent id="start" name="开始" />
+    <bpmn:exclusiveGateway id="check" default="f_small" />
+    <bpmn:parallelGateway id="split" />
+    <bpmn:userTask id="hr" name="人事审批" sky:assignType="user" sky:assignValue="3" />
+    <bpmn:userTask id="leaders" name="领导会签" sky:assignType="role" sky:assignValue="5">
+      <bpmn:multiInstanceLoopCharacteristics />
+    </bpmn:userTask>
+    <bpmn:parallelGateway id="merge" />
+    <bpmn:serviceTask id="notify" sky:actionId="8" />
+    <bpmn:endEvent id="end" />
+    <bpmn:sequenceFlow id="f1" sourceRef="start" targetRef="check" />
+    <bpmn:sequenceFlow id="f_big" sourceRef="check" targetRef="split">
+      <bpmn:conditionExpression xsi:type="bpmn:tFormalExpression" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">days &gt; 3</bpmn:conditionExpression>
+    </bpmn:sequenceFlow>
+    <bpmn:sequenceFlow id="f_small" sourceRef="check" targetRef="hr" />
+    <bpmn:sequenceFlow id="f2" sourceRef="split" targetRef="hr" />
+    <bpmn:sequenceFlow id="f3" sourceRef="split" targetRef="leaders" />
+    <bpmn:sequenceFlow id="f4" sourceRef="hr" targetRef="merge" />
+    <bpmn:sequenceFlow id="f5" sourceRef="leaders" targetRef="merge" />
+    <bpmn:sequenceFlow id="f6" sourceRef="merge" targetRef="notify" />
+    <bpmn:sequenceFlow id="f7" sourceRef="notify" targetRef="end" />
+  </bpmn:process>
+  <bpmndi:BPMNDiagram id="d1">
+    <bpmndi:BPMNPlane id="p1" bpmnElement="leave">
+      <bpmndi:BPMNShape id="start_di" bpmnElement="start">
+        <dc:Bounds x="152" y="102" width="36" height="36" />
+      </bpmndi:BPMNShape>
+    </bpmndi:BPMNPlane>
+  </bpmndi:BPMNDiagram>
+</bpmn:definitions>`
+
+func TestParseBPMN(t *testing.T) {
+	graph, err := parseBPMN([]byte(testBPMN))
+	if err != nil {
+		t.Fatalf("parseBPMN() error = %v", err)
+	}
+
+	if graph.Definition.Name != "leave" || graph.Definition.DisplayName != "请假" || graph.Definition.SysTableID != 12 {
+		t.Errorf("definition = %+v", graph.Definition)
+	}
+
+	nodes := make(map[string]*entity.WfNode)
+	for _, node := range graph.Nodes {
+		nodes[node.Name] = node
+	}
+	wantTypes := map[string]string{
+		"start": NodeTypeStart, "check": NodeTypeGateway, "split": NodeTypeFork, "hr": NodeTypeUser,
+		"leaders": NodeTypeUser, "merge": NodeTypeJoin, "notify": NodeTypeAuto, "end": NodeTypeEnd,
+	}
+	for name, want := range wantTypes {
+		if nodes[name] == nil || nodes[name].NodeType != want {
+			t.Errorf("node %s type = %v, want %s", name, nodes[name], want)
+		}
+	}
+	if nodes["hr"].AssignType != "user" || nodes["hr"].AssignValue != "3" {
+		t.Errorf("hr assignee = %s/%s", nodes["hr"].AssignType, nodes["hr"].AssignValue)
+	}
+	if !strings.Contains(nodes["leaders"].Config, "multiInstance") {
+		t.Errorf("leaders config = %q, want multiInstance", nodes["leaders"].Config)
+	}
+	if nodes["notify"].ActionID != 8 {
+		t.Errorf("notify actionId = %d, want 8", nodes["notify"].ActionID)
+	}
+	if nodes["start"].PosX != 152 || nodes["start"].PosY != 102 {
+		t.Errorf("start position = (%d, %d)", nodes["start"].PosX, nodes["start"].PosY)
+	}
+
+	if len(graph.Transitions) != 9 {
+		t.Fatalf("transitions = %d, want 9", len(graph.Transitions))
+	}
+	if got := graph.Transitions[1].Transition.Condition; got != "days > 3" {
+		t.Errorf("condition = %q, want %q", got, "days > 3")
+	}
+}
+
+func TestParseBPMNUnsupported(t *testing.T) {
+	data := strings.Replace(testBPMN, `<bpmn:endEvent id="end" />`,
+		`<bpmn:endEvent id="end" /><bpmn:scriptTask id="calc" name="计算" />
+		<bpmn:intermediateCatchEvent id="wait"><bpmn:timerEventDefinition /></bpmn:intermediateCatchEvent>
+		<bpmn:startEvent id="timer"><bpmn:timerEventDefinition /></bpmn:startEvent>`, 1)
+
+	_, err := parseBPMN([]byte(data))
+	if err == nil {
+		t.Fatal("parseBPMN() error = nil, want unsupported elements")
+	}
+	for _, want := range []string{"scriptTask id=calc", "intermediateCatchEvent id=wait", "timerEventDefinition（startEvent id=timer 内）"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err.Error(), want)
+		}
+	}
+}
+
+func TestBuildBPMNRoundTrip(t *testing.T) {
+	graph, err := parseBPMN([]byte(testBPMN))
+	if err != nil {
+		t.Fatalf("parseBPMN() error = %v", err)
+	}
+
+	// 模拟入库后的 ID
+	ids := make(map[string]uint)
+	for i, node := range graph.Nodes {
+		node.ID = uint(i + 1)
+		ids[node.Name] = node.ID
+	}
+	transitions := make([]*entity.WfTransition, 0, len(graph.Transitions))
+	for i, flow := range graph.Transitions {
+		flow.Transition.ID = uint(i + 1)
+		flow.Transition.FromNodeID = ids[flow.From]
+		flow.Transition.ToNodeID = ids[flow.To]
+		transitions = append(transitions, flow.Transition)
+	}
+	graph.Definition.ID = 1
+
+	data, err := buildBPMN(graph.Definition, graph.Nodes, transitions)
+	if err != nil {
+		t.Fatalf("buildBPMN() error = %v", err)
+	}
+
+	again, err := parseBPMN(data)
+	if err != nil {
+		t.Fatalf("parseBPMN(export) error = %v\n%s", err, data)
+	}
+	if again.Definition.Name != "leave" || again.Definition.SysTableID != 12 {
+		t.Errorf("definition = %+v", again.Definition)
+	}
+	if len(again.Nodes) != len(graph.Nodes) || len(again.Transitions) != len(graph.Transitions) {
+		t.Fatalf("nodes/transitions = %d/%d, want %d/%d", len(again.Nodes), len(again.Transitions), len(graph.Nodes), len(graph.Transitions))
+	}
+	for i, node := range again.Nodes {
+		orig := graph.Nodes[i]
+		if node.Name != orig.Name || node.NodeType != orig.NodeType || node.Config != orig.Config ||
+			node.AssignValue != orig.AssignValue || node.ActionID != orig.ActionID || node.PosX != orig.PosX {
+			t.Errorf("node %d = %+v, want %+v", i, node, orig)
+		}
+	}
+	for i, flow := range again.Transitions {
+		if flow.From != graph.Transitions[i].From || flow.Transition.Condition != graph.Transitions[i].Transition.Condition {
+			t.Errorf("transition %d = %s->%s %q", i, flow.From, flow.To, flow.Transition.Condition)
+		}
+	}
+}
+
+func TestParseBPMNConditions(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "条件表达式无效",
+			old:  "days &gt; 3",
+			new:  "days &gt;",
+			want: "f_big 的条件表达式无效",
+		},
+		{
+			name: "默认顺序流不存在",
+			old:  `default="f_small"`,
+			new:  `default="f2"`,
+			want: "默认顺序流 f2 不是该网关的出口",
+		},
+		{
+			name: "默认顺序流之外的无条件流转",
+			old:  `<bpmn:sequenceFlow id="f_small" sourceRef="check" targetRef="hr" />`,
+			new:  `<bpmn:sequenceFlow id="f_small" sourceRef="check" targetRef="hr" /><bpmn:sequenceFlow id="f_other" sourceRef="check" targetRef="end" />`,
+			want: "f_other 必须配置条件",
+		},
+		{
+			name: "默认顺序流带条件",
+			old:  `default="f_small"`,
+			new:  `default="f_big"`,
+			want: "默认顺序流 f_big 不能配置条件",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseBPMN([]byte(strings.Replace(testBPMN, tt.old, tt.new, 1)))
+			if err == nil {
+				t.Fatal("parseBPMN() error = nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
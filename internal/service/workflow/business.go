@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/plugins/core"
+	"gorm.io/gorm"
+)
+
+// ApprovalHookPoint 审批插件的钩子点：任意单据表提交后
+const ApprovalHookPoint = core.WildcardTable + ".after.submit"
+
+// ApprovalPlugin 单据提交后自动启动业务表关联的已发布流程
+// 与提交在同一事务中执行，流程启动失败时提交回滚
+type ApprovalPlugin struct {
+	workflowService Service
+}
+
+// NewApprovalPlugin 创建审批插件
+func NewApprovalPlugin(workflowService Service) *ApprovalPlugin {
+	return &ApprovalPlugin{workflowService: workflowService}
+}
+
+// Name 插件名称
+func (p *ApprovalPlugin) Name() string {
+	return "workflow_approval"
+}
+
+// Description 插件描述
+func (p *ApprovalPlugin) Description() string {
+	return "单据提交后自动启动关联的审批流程"
+}
+
+// Version 插件版本
+func (p *ApprovalPlugin) Version() string {
+	return "1.0.0"
+}
+
+// Execute 启动审批流程（业务表没有关联已发布的流程时直接通过）
+func (p *ApprovalPlugin) Execute(ctx context.Context, db *gorm.DB, data core.PluginData) error {
+	_, err := p.workflowService.StartForRecord(ctx, db, data.TableName, data.RecordID, data.UserID)
+	return err
+}
+
+// StartForRecord 为业务记录启动业务表关联的已发布流程，db 为调用方的事务
+// 业务表没有关联已发布的流程时返回 nil
+func (s *service) StartForRecord(ctx context.Context, db *gorm.DB, tableName string, recordID, userID uint) (*entity.WfInstance, error) {
+	txs := s.withDB(db)
+
+	var table entity.SysTable
+	if err := txs.db.WithContext(ctx).Where("NAME = ? AND IS_ACTIVE = ?", tableName, "Y").Take(&table).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "业务表不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询业务表失败", err)
+	}
+
+	var def entity.WfDefinition
+	if err := txs.db.WithContext(ctx).
+		Where("SYS_TABLE_ID = ? AND STATUS = ? AND IS_ACTIVE = ?", table.ID, DefinitionPublished, "Y").
+		Order("ID DESC").
+		Take(&def).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询业务表流程定义失败", err)
+	}
+
+	title := table.DisplayName
+	if title == "" {
+		title = table.Name
+	}
+
+	instance, err := txs.StartProcess(ctx, &StartProcessRequest{
+		DefinitionID: def.ID,
+		SysTableID:   int(table.ID),
+		BusinessID:   recordID,
+		StartUserID:  userID,
+		Title:        fmt.Sprintf("%s #%d", title, recordID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有用户任务的流程在启动时已经结束，审批结果已回写
+	if instance.Status == "running" && s.crudService != nil {
+		if err := s.crudService.BeginApproval(ctx, db, table.ID, recordID, instance.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+// finishBusiness 流程结束时回写关联的业务单据
+func (s *service) finishBusiness(ctx context.Context, instance *entity.WfInstance, result string) error {
+	if s.crudService == nil || instance.SysTableID == 0 || instance.BusinessID == 0 {
+		return nil
+	}
+	return s.crudService.FinishApproval(ctx, s.db, uint(instance.SysTableID), instance.BusinessID, instance.ID, result)
+}
+
+// withDB 返回使用指定数据库连接（通常为调用方事务）的服务副本
+func (s *service) withDB(db *gorm.DB) *service {
+	c := *s
+	c.db = db
+	return &c
+}
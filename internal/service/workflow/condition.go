@@ -0,0 +1,160 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/expr"
+	"gorm.io/gorm"
+)
+
+// ConditionRecordKey 条件表达式中业务记录的变量名，业务字段只能以 record.字段名 引用（如 record.AMOUNT > 1000）
+// 流程变量直接按名称引用；该名称保留给业务记录，流程变量不能命名为 record
+const ConditionRecordKey = "record"
+
+// validateVariables 检查传入的流程变量，不能使用业务记录保留的变量名
+func validateVariables(variables map[string]interface{}) error {
+	if _, ok := variables[ConditionRecordKey]; ok {
+		return errors.New(errors.ErrValidation, fmt.Sprintf("流程变量不能命名为 %s，该名称保留给条件表达式中的业务记录", ConditionRecordKey))
+	}
+	return nil
+}
+
+// selectTransition 选择后续流转
+// 按 ORDERNO 顺序计算带条件的流转，取第一个满足条件的；都不满足时走第一个无条件流转（默认分支）
+func (s *service) selectTransition(ctx context.Context, instance *entity.WfInstance, transitions []*entity.WfTransition, variables map[string]interface{}) (*entity.WfTransition, error) {
+	var env map[string]interface{}
+	var defaultTransition *entity.WfTransition
+
+	for _, t := range transitions {
+		if t.Condition == "" {
+			if defaultTransition == nil {
+				defaultTransition = t
+			}
+			continue
+		}
+
+		// 只有存在条件时才加载业务记录
+		if env == nil {
+			var err error
+			if env, err = s.buildConditionEnv(ctx, instance, variables); err != nil {
+				return nil, err
+			}
+		}
+
+		matched, err := s.evaluateCondition(t.Condition, env)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrValidation, fmt.Sprintf("流转 %s 的条件计算失败", transitionLabel(t)), err)
+		}
+		if matched {
+			return t, nil
+		}
+	}
+
+	if defaultTransition == nil {
+		return nil, errors.New(errors.ErrValidation, "没有符合条件的流转")
+	}
+	return defaultTransition, nil
+}
+
+// selectForkTransitions 选择并行分叉激活的流转：无条件流转和满足条件的流转全部激活
+func (s *service) selectForkTransitions(ctx context.Context, instance *entity.WfInstance, transitions []*entity.WfTransition, variables map[string]interface{}) ([]*entity.WfTransition, error) {
+	var env map[string]interface{}
+	var selected []*entity.WfTransition
+
+	for _, t := range transitions {
+		if t.Condition != "" && env == nil {
+			var err error
+			if env, err = s.buildConditionEnv(ctx, instance, variables); err != nil {
+				return nil, err
+			}
+		}
+
+		matched, err := s.evaluateCondition(t.Condition, env)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrValidation, fmt.Sprintf("流转 %s 的条件计算失败", transitionLabel(t)), err)
+		}
+		if matched {
+			selected = append(selected, t)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New(errors.ErrValidation, "并行分叉没有符合条件的流转")
+	}
+	return selected, nil
+}
+
+// evaluateCondition 评估流转条件
+func (s *service) evaluateCondition(condition string, env map[string]interface{}) (bool, error) {
+	// 如果没有条件,默认为true
+	if condition == "" {
+		return true, nil
+	}
+
+	return expr.EvalBool(condition, env)
+}
+
+// buildConditionEnv 构建条件表达式的变量环境：流程变量 + 业务记录
+func (s *service) buildConditionEnv(ctx context.Context, instance *entity.WfInstance, variables map[string]interface{}) (map[string]interface{}, error) {
+	env := make(map[string]interface{}, len(variables)+1)
+	for k, v := range variables {
+		env[k] = v
+	}
+
+	record, err := s.loadBusinessRecord(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	env[ConditionRecordKey] = record
+
+	return env, nil
+}
+
+// loadBusinessRecord 加载流程实例关联的业务记录，未关联业务表时返回 nil
+func (s *service) loadBusinessRecord(ctx context.Context, instance *entity.WfInstance) (map[string]interface{}, error) {
+	if instance.SysTableID == 0 || instance.BusinessID == 0 {
+		return nil, nil
+	}
+
+	var table entity.SysTable
+	if err := s.db.WithContext(ctx).Where("ID = ?", instance.SysTableID).First(&table).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "流程关联的业务表不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询业务表失败", err)
+	}
+
+	var record map[string]interface{}
+	if err := s.db.WithContext(ctx).Table(table.Name).Where("ID = ?", instance.BusinessID).Take(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "流程关联的业务记录不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询业务记录失败", err)
+	}
+
+	return record, nil
+}
+
+// validateConditions 检查流转条件表达式语法（发布时调用）
+func validateConditions(transitions []*entity.WfTransition) error {
+	for _, t := range transitions {
+		if t.Condition == "" {
+			continue
+		}
+		if _, err := expr.Compile(t.Condition); err != nil {
+			return errors.Wrap(errors.ErrValidation, fmt.Sprintf("流转 %s 的条件表达式无效", transitionLabel(t)), err)
+		}
+	}
+	return nil
+}
+
+// transitionLabel 流转的显示名称（用于错误提示）
+func transitionLabel(t *entity.WfTransition) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return fmt.Sprintf("#%d", t.ID)
+}
@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+func TestValidateVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		wantErr   bool
+	}{
+		{"没有变量", nil, false},
+		{"普通变量", map[string]interface{}{"amount": 100, "records": 2}, false},
+		{"使用业务记录保留名", map[string]interface{}{ConditionRecordKey: map[string]interface{}{"AMOUNT": 1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVariables(tt.variables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateVariables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if appErr, ok := err.(*errors.AppError); tt.wantErr && (!ok || appErr.Code != errors.ErrValidation) {
+				t.Errorf("validateVariables() error = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+func TestVariablesNamedRecordRejected(t *testing.T) {
+	// 在访问数据库之前拒绝
+	s := &service{}
+	variables := map[string]interface{}{ConditionRecordKey: 1}
+
+	if _, err := s.StartProcess(context.Background(), &StartProcessRequest{DefinitionID: 1, StartUserID: 1, Variables: variables}); err == nil {
+		t.Error("StartProcess() error = nil, want rejected variable name")
+	}
+	if err := s.CompleteTask(context.Background(), &CompleteTaskRequest{TaskID: 1, UserID: 1, Action: ActionApprove, Variables: variables}); err == nil {
+		t.Error("CompleteTask() error = nil, want rejected variable name")
+	}
+}
+
+func TestBuildConditionEnv(t *testing.T) {
+	s := &service{}
+	variables := map[string]interface{}{"amount": float64(2000)}
+
+	// 未关联业务表时 record 为空，业务字段只能通过 record.字段名 引用
+	env, err := s.buildConditionEnv(context.Background(), &entity.WfInstance{}, variables)
+	if err != nil {
+		t.Fatalf("buildConditionEnv() error = %v", err)
+	}
+	if env["amount"] != float64(2000) {
+		t.Errorf("env[amount] = %v, want 2000", env["amount"])
+	}
+	if record, ok := env[ConditionRecordKey]; !ok || record.(map[string]interface{}) != nil {
+		t.Errorf("env[%s] = %v, want empty record", ConditionRecordKey, record)
+	}
+
+	matched, err := s.evaluateCondition("amount > 1000", env)
+	if err != nil || !matched {
+		t.Errorf("evaluateCondition() = %v, %v, want true", matched, err)
+	}
+}
@@ -0,0 +1,270 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// HistoryDelegate 任务按委托规则分配给受托人
+const HistoryDelegate = "delegate"
+
+// maxDelegationDepth 委托链最大长度（A 委托 B、B 又委托 C 时继续查找）
+const maxDelegationDepth = 5
+
+// DelegationRequest 创建委托规则请求
+type DelegationRequest struct {
+	FromUserID      uint      `json:"fromUserId"` // 委托人（为空时为当前用户，管理员可为他人设置）
+	ToUserID        uint      `json:"toUserId" binding:"required"`
+	StartTime       time.Time `json:"startTime" binding:"required"`
+	EndTime         time.Time `json:"endTime" binding:"required"`
+	DefinitionNames []string  `json:"definitionNames"` // 限定的流程名称，为空时对所有流程生效
+	Reason          string    `json:"reason"`
+	UserID          uint      `json:"-"` // 操作人
+}
+
+// CreateDelegation 创建委托规则
+// 同一委托人生效时间重叠的规则不能覆盖相同的流程
+func (s *service) CreateDelegation(ctx context.Context, req *DelegationRequest) (*entity.WfDelegation, error) {
+	if req.FromUserID == 0 {
+		req.FromUserID = req.UserID
+	}
+	if req.FromUserID != req.UserID {
+		if err := s.requireAdmin(ctx, req.UserID, "只能设置自己的委托"); err != nil {
+			return nil, err
+		}
+	}
+	if req.ToUserID == req.FromUserID {
+		return nil, errors.New(errors.ErrValidation, "不能委托给自己")
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New(errors.ErrValidation, "失效时间必须晚于生效时间")
+	}
+	if !req.EndTime.After(time.Now()) {
+		return nil, errors.New(errors.ErrValidation, "失效时间已过")
+	}
+
+	names := make([]string, 0, len(req.DefinitionNames))
+	seen := make(map[string]bool, len(req.DefinitionNames))
+	for _, name := range req.DefinitionNames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if strings.Contains(name, ",") {
+			return nil, errors.New(errors.ErrValidation, "流程名称不能包含逗号: "+name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&entity.WfDefinition{}).
+			Where("NAME IN ? AND IS_ACTIVE = ?", names, "Y").
+			Distinct("NAME").Count(&count).Error; err != nil {
+			return nil, errors.Wrap(errors.ErrDatabase, "查询流程定义失败", err)
+		}
+		if int(count) != len(names) {
+			return nil, errors.New(errors.ErrValidation, "流程定义不存在")
+		}
+	}
+
+	var toUser entity.SysUser
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", req.ToUserID, "Y").Take(&toUser).Error; err != nil {
+		return nil, errors.New(errors.ErrValidation, "受托人不存在")
+	}
+
+	delegation := &entity.WfDelegation{
+		FromUserID:      req.FromUserID,
+		ToUserID:        req.ToUserID,
+		StartTime:       req.StartTime,
+		EndTime:         req.EndTime,
+		DefinitionNames: strings.Join(names, ","),
+		Reason:          req.Reason,
+	}
+	delegation.IsActive = "Y"
+
+	err := s.inTransaction(ctx, func(txs *service) error {
+		// 锁定委托人的规则，避免并发创建重叠的规则
+		var existing []*entity.WfDelegation
+		if err := txs.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("FROM_USER_ID = ? AND IS_ACTIVE = ?", req.FromUserID, "Y").
+			Where("START_TIME < ? AND END_TIME > ?", req.EndTime, req.StartTime).
+			Find(&existing).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询委托规则失败", err)
+		}
+		for _, other := range existing {
+			if scopesOverlap(other.DefinitionNames, delegation.DefinitionNames) {
+				return errors.New(errors.ErrResourceConflict, "与已有的委托规则时间重叠")
+			}
+		}
+
+		if err := txs.db.WithContext(ctx).Create(delegation).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建委托规则失败", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delegation, nil
+}
+
+// ListDelegations 查询与用户相关的委托规则（自己委托出去的和委托给自己的）
+func (s *service) ListDelegations(ctx context.Context, userID uint, includeExpired bool) ([]*entity.WfDelegation, error) {
+	query := s.db.WithContext(ctx).
+		Where("IS_ACTIVE = ?", "Y").
+		Where("FROM_USER_ID = ? OR TO_USER_ID = ?", userID, userID)
+	if !includeExpired {
+		query = query.Where("END_TIME > ?", time.Now())
+	}
+
+	var delegations []*entity.WfDelegation
+	if err := query.Order("START_TIME DESC").Find(&delegations).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询委托规则失败", err)
+	}
+
+	return delegations, nil
+}
+
+// CancelDelegation 取消委托规则，已分配给受托人的任务不收回
+func (s *service) CancelDelegation(ctx context.Context, id, userID uint) error {
+	var delegation entity.WfDelegation
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", id, "Y").Take(&delegation).Error; err != nil {
+		return errors.Wrap(errors.ErrResourceNotFound, "委托规则不存在", err)
+	}
+	if delegation.FromUserID != userID {
+		if err := s.requireAdmin(ctx, userID, "只能取消自己的委托"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Model(&entity.WfDelegation{}).
+		Where("ID = ?", id).
+		Update("IS_ACTIVE", "N").Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "取消委托规则失败", err)
+	}
+
+	return nil
+}
+
+// delegateFor 查找任务的实际处理人：处理人在委托期间时沿委托链找到受托人
+// 返回的 delegation 为委托人的第一条规则，未委托时为 nil
+func (s *service) delegateFor(ctx context.Context, userID uint, definitionName string, now time.Time) (uint, *entity.WfDelegation, error) {
+	var first *entity.WfDelegation
+	visited := map[uint]bool{userID: true}
+	current := userID
+
+	for i := 0; i < maxDelegationDepth; i++ {
+		var rules []*entity.WfDelegation
+		if err := s.db.WithContext(ctx).
+			Where("FROM_USER_ID = ? AND IS_ACTIVE = ?", current, "Y").
+			Where("START_TIME <= ? AND END_TIME > ?", now, now).
+			Order("ID ASC").
+			Find(&rules).Error; err != nil {
+			return 0, nil, errors.Wrap(errors.ErrDatabase, "查询委托规则失败", err)
+		}
+
+		var rule *entity.WfDelegation
+		for _, r := range rules {
+			if scopeMatches(r.DefinitionNames, definitionName) {
+				rule = r
+				break
+			}
+		}
+		// 委托链出现循环时停在循环前的用户
+		if rule == nil || visited[rule.ToUserID] {
+			break
+		}
+		if first == nil {
+			first = rule
+		}
+		visited[rule.ToUserID] = true
+		current = rule.ToUserID
+	}
+
+	return current, first, nil
+}
+
+// delegatedTask 处理人在委托期间时将任务分配给受托人，并记录原处理人
+func (s *service) delegatedTask(ctx context.Context, task *entity.WfTask, definitionName string, now time.Time) error {
+	if task.AssigneeID == 0 {
+		return nil
+	}
+	delegate, rule, err := s.delegateFor(ctx, task.AssigneeID, definitionName, now)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	task.OriginalAssigneeID = task.AssigneeID
+	task.AssigneeID = delegate
+	return nil
+}
+
+// delegateCandidates 候选人在委托期间时由受托人代替（去重，保持顺序），返回的候选人记录原候选人
+// 受托人同时代替多个候选人时记录第一个委托人
+func (s *service) delegateCandidates(ctx context.Context, candidates []uint, definitionName string, now time.Time) ([]*entity.WfTaskCandidate, error) {
+	result := make([]*entity.WfTaskCandidate, 0, len(candidates))
+	seen := make(map[uint]*entity.WfTaskCandidate, len(candidates))
+	for _, userID := range candidates {
+		delegate, rule, err := s.delegateFor(ctx, userID, definitionName, now)
+		if err != nil {
+			return nil, err
+		}
+
+		candidate, ok := seen[delegate]
+		if !ok {
+			candidate = &entity.WfTaskCandidate{UserID: delegate}
+			candidate.IsActive = "Y"
+			seen[delegate] = candidate
+			result = append(result, candidate)
+		}
+		if rule != nil && candidate.OriginalUserID == 0 {
+			candidate.OriginalUserID = userID
+		}
+	}
+	return result, nil
+}
+
+// recordDelegation 记录任务委托历史
+func (s *service) recordDelegation(ctx context.Context, task *entity.WfTask) error {
+	return s.recordHistory(ctx, &entity.WfHistory{
+		WfInstanceID: task.WfInstanceID,
+		WfNodeID:     task.WfNodeID,
+		WfTaskID:     task.ID,
+		EventType:    HistoryDelegate,
+		OperatorID:   task.OriginalAssigneeID,
+		TargetUserID: task.AssigneeID,
+	}, map[string]interface{}{"originalAssigneeId": task.OriginalAssigneeID, "assigneeId": task.AssigneeID})
+}
+
+// scopeMatches 判断委托规则是否适用于该流程
+func scopeMatches(names, definitionName string) bool {
+	if names == "" {
+		return true
+	}
+	for _, name := range strings.Split(names, ",") {
+		if name == definitionName {
+			return true
+		}
+	}
+	return false
+}
+
+// scopesOverlap 判断两条委托规则的流程范围是否有交集（为空表示所有流程）
+func scopesOverlap(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	for _, name := range strings.Split(b, ",") {
+		if scopeMatches(a, name) {
+			return true
+		}
+	}
+	return false
+}
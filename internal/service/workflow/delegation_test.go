@@ -0,0 +1,182 @@
+package workflow
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestScopeMatches(t *testing.T) {
+	tests := []struct {
+		name           string
+		names          string
+		definitionName string
+		want           bool
+	}{
+		{"未限定流程", "", "leave", true},
+		{"单个流程", "leave", "leave", true},
+		{"多个流程之一", "leave,expense", "expense", true},
+		{"不在范围内", "leave,expense", "purchase", false},
+		{"不按前缀匹配", "leave", "leave2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopeMatches(tt.names, tt.definitionName); got != tt.want {
+				t.Errorf("scopeMatches(%q, %q) = %v, want %v", tt.names, tt.definitionName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"都不限定", "", "", true},
+		{"一方不限定", "", "leave", true},
+		{"另一方不限定", "leave", "", true},
+		{"有相同流程", "leave,expense", "purchase,expense", true},
+		{"没有相同流程", "leave", "expense,purchase", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopesOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("scopesOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// delegationRules 按委托人返回生效的委托规则
+func delegationRules(rules map[int64][2]interface{}) func(query fakeQuery) *fakeResult {
+	return func(query fakeQuery) *fakeResult {
+		if !strings.Contains(query.sql, "FROM `wf_delegation`") {
+			return nil
+		}
+		result := &fakeResult{columns: []string{"ID", "FROM_USER_ID", "TO_USER_ID", "DEFINITION_NAMES"}}
+		from := query.args[0].(int64)
+		if rule, ok := rules[from]; ok {
+			result.rows = [][]driver.Value{{from * 10, from, rule[0], rule[1]}}
+		}
+		return result
+	}
+}
+
+func TestDelegateCandidates(t *testing.T) {
+	// 1、2 委托给 5（2 只委托请假流程），4 只委托报销流程给 9
+	rules := map[int64][2]interface{}{
+		1: {int64(5), ""},
+		2: {int64(5), "leave"},
+		4: {int64(9), "expense"},
+	}
+
+	tests := []struct {
+		name       string
+		candidates []uint
+		want       []entity.WfTaskCandidate
+	}{
+		{
+			"受托人代替委托人",
+			[]uint{1, 3, 4},
+			[]entity.WfTaskCandidate{{UserID: 5, OriginalUserID: 1}, {UserID: 3}, {UserID: 4}},
+		},
+		{
+			"多个委托人委托给同一受托人时记录第一个",
+			[]uint{1, 2, 3},
+			[]entity.WfTaskCandidate{{UserID: 5, OriginalUserID: 1}, {UserID: 3}},
+		},
+		{
+			"受托人本身也是候选人",
+			[]uint{2, 5},
+			[]entity.WfTaskCandidate{{UserID: 5, OriginalUserID: 2}},
+		},
+		{
+			"受托人在委托人之前",
+			[]uint{5, 1},
+			[]entity.WfTaskCandidate{{UserID: 5, OriginalUserID: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, nil)
+			fake.respond = delegationRules(rules)
+			s := &service{db: db}
+
+			got, err := s.delegateCandidates(context.Background(), tt.candidates, "leave", time.Now())
+			if err != nil {
+				t.Fatalf("delegateCandidates() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("delegateCandidates() = %d candidates, want %d", len(got), len(tt.want))
+			}
+			for i, candidate := range got {
+				if candidate.UserID != tt.want[i].UserID || candidate.OriginalUserID != tt.want[i].OriginalUserID || candidate.IsActive != "Y" {
+					t.Errorf("candidate[%d] = user %d original %d, want user %d original %d",
+						i, candidate.UserID, candidate.OriginalUserID, tt.want[i].UserID, tt.want[i].OriginalUserID)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimDelegatedCandidateTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		originalUser int64
+		wantHistory  bool
+	}{
+		{"受托人签收记录委托人", 1, true},
+		{"候选人本人签收", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, map[string]*fakeResult{
+				"FROM `wf_task` WHERE": {
+					columns: []string{"ID", "WF_INSTANCE_ID", "WF_NODE_ID", "ASSIGNEE_ID", "STATUS", "IS_ACTIVE"},
+					rows:    [][]driver.Value{{int64(3), int64(1), int64(2), int64(0), "pending", "Y"}},
+				},
+				"FROM `wf_task_candidate`": {
+					columns: []string{"ID", "WF_TASK_ID", "USER_ID", "ORIGINAL_USER_ID"},
+					rows:    [][]driver.Value{{int64(7), int64(3), int64(5), tt.originalUser}},
+				},
+			})
+			s := &service{db: db}
+
+			if err := s.ClaimTask(context.Background(), 3, 5); err != nil {
+				t.Fatalf("ClaimTask() error = %v", err)
+			}
+
+			updates := fake.executed("UPDATE `wf_task` SET")
+			if len(updates) != 1 || !strings.Contains(updates[0].sql, "`ORIGINAL_ASSIGNEE_ID`=?") {
+				t.Fatalf("claim update = %v, want ORIGINAL_ASSIGNEE_ID set", updates)
+			}
+			if !containsArg(updates[0].args, tt.originalUser) {
+				t.Errorf("claim update args = %v, want original assignee %d", updates[0].args, tt.originalUser)
+			}
+
+			histories := fake.executed("INSERT INTO `wf_history`")
+			if (len(histories) == 1) != tt.wantHistory {
+				t.Fatalf("delegation history = %d, want %v", len(histories), tt.wantHistory)
+			}
+			if tt.wantHistory && !containsArg(histories[0].args, HistoryDelegate) {
+				t.Errorf("history args = %v, want event %s", histories[0].args, HistoryDelegate)
+			}
+		})
+	}
+}
+
+// containsArg 判断 SQL 参数中是否包含 value
+func containsArg(args []interface{}, value interface{}) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
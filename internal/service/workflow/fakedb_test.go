@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeResult 假数据库对一条查询返回的结果
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeQuery 执行过的 SQL 及其参数
+type fakeQuery struct {
+	sql  string
+	args []interface{}
+}
+
+// fakeDB 按 SQL 片段返回预设结果的假数据库，记录执行过的 SQL，用于不依赖 MySQL 的单元测试
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]*fakeResult            // SQL 包含 key 时返回对应结果，未匹配的查询返回空结果
+	respond func(query fakeQuery) *fakeResult // 按 SQL 和参数返回结果，返回 nil 时再按 results 匹配
+	queries []fakeQuery
+}
+
+// newFakeDB 创建使用假数据库的 gorm 连接
+func newFakeDB(t *testing.T, results map[string]*fakeResult) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{results: results}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(fake),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	return db, fake
+}
+
+// executed 返回包含 fragment 的已执行 SQL
+func (f *fakeDB) executed(fragment string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []fakeQuery
+	for _, query := range f.queries {
+		if strings.Contains(query.sql, fragment) {
+			matched = append(matched, query)
+		}
+	}
+	return matched
+}
+
+func (f *fakeDB) record(query string, named []driver.NamedValue) *fakeResult {
+	args := make([]interface{}, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, fakeQuery{sql: query, args: args})
+	if f.respond != nil {
+		if result := f.respond(f.queries[len(f.queries)-1]); result != nil {
+			return result
+		}
+	}
+	for fragment, result := range f.results {
+		if strings.Contains(query, fragment) {
+			return result
+		}
+	}
+	return &fakeResult{}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{result: c.db.record(query, args)}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	return fakeExecResult{}, nil
+}
+
+// fakeExecResult 写操作的结果：影响1行，新增记录的ID为1
+type fakeExecResult struct{}
+
+func (fakeExecResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeExecResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	result *fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,73 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// 历史事件类型（WfHistory.EventType）
+const (
+	HistoryRemind    = "remind"    // 到期提醒
+	HistoryEscalate  = "escalate"  // 超时处理
+	HistoryVariables = "variables" // 流程变量变更
+	HistoryAutoTask  = "autoTask"  // 自动任务执行结果
+)
+
+// recordHistory 记录流程历史事件，detail 序列化为 JSON
+func (s *service) recordHistory(ctx context.Context, history *entity.WfHistory, detail map[string]interface{}) error {
+	if len(detail) > 0 {
+		bytes, _ := json.Marshal(detail)
+		history.Detail = string(bytes)
+	}
+	history.IsActive = "Y"
+
+	if err := s.db.WithContext(ctx).Create(history).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "记录流程历史失败", err)
+	}
+	return nil
+}
+
+// changedVariables 比较流程变量，返回变更项（变量名 -> {old, new}）
+func changedVariables(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for k, v := range after {
+		old, ok := before[k]
+		if ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+		changes[k] = map[string]interface{}{"old": old, "new": v}
+	}
+	for k, old := range before {
+		if _, ok := after[k]; !ok {
+			changes[k] = map[string]interface{}{"old": old, "new": nil}
+		}
+	}
+	return changes
+}
+
+// recordVariables 流程变量有变化时记录变更历史
+func (s *service) recordVariables(ctx context.Context, history *entity.WfHistory, before, after map[string]interface{}) error {
+	changes := changedVariables(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	history.EventType = HistoryVariables
+	return s.recordHistory(ctx, history, map[string]interface{}{"changes": changes})
+}
+
+// ListHistory 查询流程实例历史事件
+func (s *service) ListHistory(ctx context.Context, instanceID uint) ([]*entity.WfHistory, error) {
+	var histories []*entity.WfHistory
+	if err := s.db.WithContext(ctx).
+		Where("WF_INSTANCE_ID = ? AND IS_ACTIVE = ?", instanceID, "Y").
+		Order("ID ASC").
+		Find(&histories).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程历史失败", err)
+	}
+
+	return histories, nil
+}
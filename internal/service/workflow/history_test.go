@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedVariables(t *testing.T) {
+	before := map[string]interface{}{"amount": float64(100), "reason": "出差", "urgent": true}
+	after := map[string]interface{}{"amount": float64(200), "reason": "出差", "days": float64(3)}
+
+	want := map[string]interface{}{
+		"amount": map[string]interface{}{"old": float64(100), "new": float64(200)},
+		"days":   map[string]interface{}{"old": nil, "new": float64(3)},
+		"urgent": map[string]interface{}{"old": true, "new": nil},
+	}
+	if got := changedVariables(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("changedVariables() = %v, want %v", got, want)
+	}
+
+	if got := changedVariables(after, after); len(got) != 0 {
+		t.Errorf("changedVariables(same) = %v, want empty", got)
+	}
+}
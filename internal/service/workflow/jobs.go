@@ -0,0 +1,536 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/transaction"
+	"github.com/sky-xhsoft/sky-server/internal/service/action"
+	"go.uber.org/zap"
+)
+
+// 作业状态（WfJob.Status）
+const (
+	JobPending   = "pending"   // 等待执行（含等待重试）
+	JobRunning   = "running"   // 执行中
+	JobCompleted = "completed" // 已完成
+	JobFailed    = "failed"    // 重试次数用尽，等待管理员处理
+	JobCanceled  = "canceled"  // 流程终止或退回时取消
+)
+
+// HistoryJobRetry 管理员重试失败作业（WfHistory.EventType）
+const HistoryJobRetry = "jobRetry"
+
+const (
+	jobBatchSize  = 100              // 每次扫描领取的作业数
+	jobLeaseGrace = 30 * time.Second // 执行租约在超时之外的宽限时间
+	maxJobBackoff = 24 * time.Hour   // 重试间隔上限
+)
+
+var (
+	// errJobSkipped 作业已被取消或被其他执行进程重新领取
+	errJobSkipped = errors.New(errors.ErrResourceConflict, "作业已不在执行中")
+	// errJobPostponed 流程实例已挂起，作业在恢复后执行
+	errJobPostponed = errors.New(errors.ErrValidation, "流程实例不在运行状态")
+)
+
+// JobConfig 自动任务作业配置（节点未配置 autoTask 时的默认值）
+type JobConfig struct {
+	MaxAttempts int // 最大执行次数
+	Backoff     int // 首次重试间隔（秒），之后每次翻倍
+	Timeout     int // 单次执行超时（秒）
+	Workers     int // 同时执行的作业数
+}
+
+// AutoTaskConfig 自动任务节点配置
+// 例：{"autoTask": {"maxAttempts": 5, "backoff": 60, "timeout": 120}}
+type AutoTaskConfig struct {
+	MaxAttempts int `json:"maxAttempts"` // 最大执行次数，0 表示使用默认值
+	Backoff     int `json:"backoff"`     // 首次重试间隔（秒），0 表示使用默认值
+	Timeout     int `json:"timeout"`     // 单次执行超时（秒），0 表示使用默认值
+}
+
+// validate 检查自动任务配置
+func (c *AutoTaskConfig) validate() error {
+	if c.MaxAttempts < 0 || c.Backoff < 0 || c.Timeout < 0 {
+		return fmt.Errorf("执行次数、重试间隔和超时时间不能为负数")
+	}
+	return nil
+}
+
+// ListJobsRequest 查询作业请求
+type ListJobsRequest struct {
+	Status     string `json:"status"`
+	InstanceID uint   `json:"instanceId"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"pageSize"`
+	UserID     uint   `json:"-"`
+}
+
+// withDefaults 补全未配置的作业参数
+func (c *JobConfig) withDefaults() *JobConfig {
+	cfg := *c
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 30
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	return &cfg
+}
+
+// newJob 为停留在自动任务节点上的令牌创建作业，节点配置优先于默认值
+func (c *JobConfig) newJob(instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode, nodeCfg *AutoTaskConfig) *entity.WfJob {
+	job := &entity.WfJob{
+		WfInstanceID: instance.ID,
+		WfNodeID:     node.ID,
+		WfTokenID:    token.ID,
+		ActionID:     node.ActionID,
+		Status:       JobPending,
+		MaxAttempts:  c.MaxAttempts,
+		Backoff:      c.Backoff,
+		Timeout:      c.Timeout,
+		NextRunTime:  time.Now(),
+	}
+	job.IsActive = "Y"
+
+	if nodeCfg != nil {
+		if nodeCfg.MaxAttempts > 0 {
+			job.MaxAttempts = nodeCfg.MaxAttempts
+		}
+		if nodeCfg.Backoff > 0 {
+			job.Backoff = nodeCfg.Backoff
+		}
+		if nodeCfg.Timeout > 0 {
+			job.Timeout = nodeCfg.Timeout
+		}
+	}
+
+	return job
+}
+
+// retryDelay 第 attempts 次执行失败后的重试间隔：backoff * 2^(attempts-1)，不超过上限
+func retryDelay(backoff, attempts int) time.Duration {
+	delay := time.Duration(backoff) * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxJobBackoff {
+			return maxJobBackoff
+		}
+	}
+	return delay
+}
+
+// ProcessJobs 执行到期的自动任务作业（由后台定时调用）
+// 等待执行和租约过期（执行进程异常退出）的作业都会被领取，挂起流程的作业在恢复后执行
+func (s *service) ProcessJobs(ctx context.Context) error {
+	now := time.Now()
+
+	var jobs []*entity.WfJob
+	if err := s.db.WithContext(ctx).
+		Select("wf_job.*").
+		Joins("INNER JOIN wf_instance ON wf_instance.ID = wf_job.WF_INSTANCE_ID").
+		Where("wf_job.IS_ACTIVE = ? AND wf_instance.STATUS = ?", "Y", "running").
+		Where("(wf_job.STATUS = ? AND wf_job.NEXT_RUN_TIME <= ?) OR (wf_job.STATUS = ? AND wf_job.LOCKED_UNTIL <= ?)",
+			JobPending, now, JobRunning, now).
+		Order("wf_job.NEXT_RUN_TIME ASC").
+		Limit(jobBatchSize).
+		Find(&jobs).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询待执行作业失败", err)
+	}
+
+	sem := make(chan struct{}, s.jobConfig.Workers)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job *entity.WfJob) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := s.runJob(ctx, job, now); err != nil {
+				logger.Error("执行自动任务作业失败", zap.Uint("jobId", job.ID), zap.Error(err))
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// runJob 领取并执行一个作业
+// 失败时（存储过程动作连同节点流转一起回滚）按退避策略安排重试
+func (s *service) runJob(ctx context.Context, job *entity.WfJob, now time.Time) error {
+	// 以执行次数作为版本号领取作业，保证同一次执行只被一个进程领取
+	lease := now.Add(time.Duration(job.Timeout)*time.Second + jobLeaseGrace)
+	result := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("ID = ? AND ATTEMPTS = ? AND ((STATUS = ? AND NEXT_RUN_TIME <= ?) OR (STATUS = ? AND LOCKED_UNTIL <= ?))",
+			job.ID, job.Attempts, JobPending, now, JobRunning, now).
+		Updates(map[string]interface{}{
+			"STATUS":       JobRunning,
+			"ATTEMPTS":     job.Attempts + 1,
+			"LOCKED_UNTIL": lease,
+			"START_TIME":   now,
+		})
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "领取作业失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	job.Status = JobRunning
+	job.Attempts++
+	job.LockedUntil = &lease
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(job.Timeout)*time.Second)
+	defer cancel()
+
+	err := s.executeJob(runCtx, job)
+	switch {
+	case err == nil, err == errJobSkipped:
+		return nil
+	case err == errJobPostponed:
+		return s.releaseJob(ctx, job)
+	default:
+		if runCtx.Err() == context.DeadlineExceeded {
+			err = errors.New(errors.ErrActionExecute, fmt.Sprintf("执行超时（%d秒）", job.Timeout))
+		}
+		return s.failJob(ctx, job, err)
+	}
+}
+
+// jobRun 作业执行时的实例、令牌、节点和流程变量
+type jobRun struct {
+	instance  *entity.WfInstance
+	token     *entity.WfToken
+	node      *entity.WfNode
+	variables map[string]interface{}
+	inTx      bool // 存储过程动作，在锁定实例的事务中执行
+}
+
+// executeJob 执行作业
+// URL、脚本等非数据库动作在加锁前执行，外部调用期间不持有实例行锁，不阻塞同一实例的审批、撤回、终止；
+// 之后在事务中锁定实例，重新检查作业和令牌状态，记录结果并推进令牌。
+// 存储过程动作在该事务中执行，与节点流转一起提交或回滚
+// 加锁后发现作业已取消或流程已挂起时，已执行的非数据库动作不回滚（作业按至少执行一次处理）
+func (s *service) executeJob(ctx context.Context, job *entity.WfJob) error {
+	var instance entity.WfInstance
+	if err := s.db.WithContext(ctx).
+		Where("ID = ? AND IS_ACTIVE = ?", job.WfInstanceID, "Y").
+		Take(&instance).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询流程实例失败", err)
+	}
+	run, err := s.loadJob(ctx, job, &instance)
+	if err != nil {
+		return err
+	}
+
+	var actionResult *action.ActionResult
+	if run != nil && !run.inTx {
+		if actionResult, err = s.executeJobAction(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	return s.inTransaction(ctx, func(txs *service) error {
+		instance, err := txs.lockInstance(ctx, job.WfInstanceID)
+		if err != nil {
+			return err
+		}
+		// 执行动作期间流程可能已终止、退回（作业被取消）或挂起
+		locked, err := txs.loadJob(ctx, job, instance)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return txs.cancelJob(ctx, job)
+		}
+		if run == nil || locked.node.ID != run.node.ID || locked.inTx != run.inTx {
+			return errors.New(errors.ErrResourceConflict, "作业所在节点已变更，稍后重试")
+		}
+
+		if locked.inTx {
+			if actionResult, err = txs.executeJobAction(ctx, locked); err != nil {
+				return err
+			}
+		}
+		return txs.completeJob(ctx, job, locked, actionResult)
+	})
+}
+
+// loadJob 读取作业所在的令牌、节点和流程变量，检查作业仍在执行、流程在运行
+// 令牌已离开节点时返回 nil
+func (s *service) loadJob(ctx context.Context, job *entity.WfJob, instance *entity.WfInstance) (*jobRun, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("ID = ? AND STATUS = ? AND ATTEMPTS = ?", job.ID, JobRunning, job.Attempts).
+		Count(&count).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询作业失败", err)
+	}
+	if count == 0 {
+		return nil, errJobSkipped
+	}
+	if instance.Status != "running" {
+		return nil, errJobPostponed
+	}
+
+	var token entity.WfToken
+	if err := s.db.WithContext(ctx).First(&token, job.WfTokenID).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询作业令牌失败", err)
+	}
+	if token.Status != TokenActive {
+		return nil, nil
+	}
+
+	// 按令牌所在节点执行（实例迁移后为目标版本的节点）
+	var node entity.WfNode
+	if err := s.db.WithContext(ctx).First(&node, token.WfNodeID).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询自动任务节点失败", err)
+	}
+	if node.ActionID == 0 {
+		return nil, errors.New(errors.ErrValidation, "自动任务必须配置动作")
+	}
+	act, err := s.actionService.GetAction(ctx, node.ActionID)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrActionExecute, "查询自动任务动作失败", err)
+	}
+
+	var variables map[string]interface{}
+	if instance.Variables != "" {
+		json.Unmarshal([]byte(instance.Variables), &variables)
+	}
+	if variables == nil {
+		variables = make(map[string]interface{})
+	}
+
+	return &jobRun{
+		instance:  instance,
+		token:     &token,
+		node:      &node,
+		variables: variables,
+		inTx:      act.ActionType == "sp",
+	}, nil
+}
+
+// executeJobAction 执行作业的动作，存储过程在当前事务中执行
+func (s *service) executeJobAction(ctx context.Context, run *jobRun) (*action.ActionResult, error) {
+	if run.inTx {
+		ctx = transaction.WithTx(ctx, s.db)
+	}
+	actionResult, err := s.actionService.ExecuteAction(ctx, run.node.ActionID, run.variables, run.instance.StartUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !actionResult.Success {
+		return nil, errors.New(errors.ErrActionExecute, actionResult.Error)
+	}
+	return actionResult, nil
+}
+
+// cancelJob 令牌已离开节点（不应出现），作业不再执行
+func (s *service) cancelJob(ctx context.Context, job *entity.WfJob) error {
+	if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("ID = ?", job.ID).
+		Updates(map[string]interface{}{"STATUS": JobCanceled, "LOCKED_UNTIL": nil}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新作业失败", err)
+	}
+	return nil
+}
+
+// completeJob 记录作业结果并推进令牌（在锁定实例的事务中调用）
+func (s *service) completeJob(ctx context.Context, job *entity.WfJob, run *jobRun, actionResult *action.ActionResult) error {
+	resultJSON, _ := json.Marshal(actionResult)
+	if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("ID = ?", job.ID).
+		Updates(map[string]interface{}{
+			"STATUS":       JobCompleted,
+			"LOCKED_UNTIL": nil,
+			"FINISH_TIME":  time.Now(),
+			"LAST_ERROR":   "",
+			"RESULT":       string(resultJSON),
+		}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新作业失败", err)
+	}
+
+	if err := s.recordHistory(ctx, &entity.WfHistory{
+		WfInstanceID: run.instance.ID,
+		WfNodeID:     run.node.ID,
+		EventType:    HistoryAutoTask,
+	}, map[string]interface{}{
+		"jobId":      job.ID,
+		"attempt":    job.Attempts,
+		"actionId":   run.node.ActionID,
+		"success":    true,
+		"message":    actionResult.Message,
+		"data":       actionResult.Data,
+		"durationMs": actionResult.Duration.Milliseconds(),
+	}); err != nil {
+		return err
+	}
+
+	return s.moveToNext(ctx, run.instance, run.token, run.node, run.variables)
+}
+
+// failJob 记录执行失败：未达到最大次数时按退避间隔重新排队，否则标记为失败等待管理员处理
+func (s *service) failJob(ctx context.Context, job *entity.WfJob, cause error) error {
+	message := cause.Error()
+	if runes := []rune(message); len(runes) > 2000 {
+		message = string(runes[:2000])
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"LOCKED_UNTIL": nil,
+		"LAST_ERROR":   message,
+	}
+	detail := map[string]interface{}{
+		"jobId":    job.ID,
+		"attempt":  job.Attempts,
+		"actionId": job.ActionID,
+		"success":  false,
+		"error":    message,
+	}
+	if job.Attempts >= job.MaxAttempts {
+		updates["STATUS"] = JobFailed
+		updates["FINISH_TIME"] = now
+		detail["failed"] = true
+	} else {
+		next := now.Add(retryDelay(job.Backoff, job.Attempts))
+		updates["STATUS"] = JobPending
+		updates["NEXT_RUN_TIME"] = next
+		detail["nextRunTime"] = next
+	}
+
+	return s.inTransaction(ctx, func(txs *service) error {
+		result := txs.db.WithContext(ctx).Model(&entity.WfJob{}).
+			Where("ID = ? AND STATUS = ? AND ATTEMPTS = ?", job.ID, JobRunning, job.Attempts).
+			Updates(updates)
+		if result.Error != nil {
+			return errors.Wrap(errors.ErrDatabase, "更新作业失败", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if detail["failed"] == true {
+			logger.Warn("自动任务作业重试次数用尽", zap.Uint("jobId", job.ID), zap.Uint("instanceId", job.WfInstanceID), zap.String("error", message))
+		}
+
+		return txs.recordHistory(ctx, &entity.WfHistory{
+			WfInstanceID: job.WfInstanceID,
+			WfNodeID:     job.WfNodeID,
+			EventType:    HistoryAutoTask,
+			Comment:      message,
+		}, detail)
+	})
+}
+
+// releaseJob 流程挂起时归还作业，不计入执行次数
+func (s *service) releaseJob(ctx context.Context, job *entity.WfJob) error {
+	if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("ID = ? AND STATUS = ? AND ATTEMPTS = ?", job.ID, JobRunning, job.Attempts).
+		Updates(map[string]interface{}{
+			"STATUS":       JobPending,
+			"ATTEMPTS":     job.Attempts - 1,
+			"LOCKED_UNTIL": nil,
+		}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "归还作业失败", err)
+	}
+	return nil
+}
+
+// ListJobs 查询自动任务作业（管理员）
+func (s *service) ListJobs(ctx context.Context, req *ListJobsRequest) ([]*entity.WfJob, int64, error) {
+	if err := s.requireAdmin(ctx, req.UserID, "只有管理员可以查看自动任务作业"); err != nil {
+		return nil, 0, err
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	query := s.db.WithContext(ctx).Model(&entity.WfJob{}).Where("IS_ACTIVE = ?", "Y")
+	if req.Status != "" {
+		query = query.Where("STATUS = ?", req.Status)
+	}
+	if req.InstanceID != 0 {
+		query = query.Where("WF_INSTANCE_ID = ?", req.InstanceID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(errors.ErrDatabase, "查询作业总数失败", err)
+	}
+
+	var jobs []*entity.WfJob
+	if err := query.Order("ID DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrap(errors.ErrDatabase, "查询作业列表失败", err)
+	}
+
+	return jobs, total, nil
+}
+
+// RetryJob 重新执行失败的作业（管理员），执行次数清零
+func (s *service) RetryJob(ctx context.Context, id, userID uint) error {
+	if err := s.requireAdmin(ctx, userID, "只有管理员可以重试自动任务作业"); err != nil {
+		return err
+	}
+
+	return s.inTransaction(ctx, func(txs *service) error {
+		var job entity.WfJob
+		if err := txs.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", id, "Y").First(&job).Error; err != nil {
+			return errors.New(errors.ErrResourceNotFound, "作业不存在")
+		}
+
+		instance, err := txs.lockInstance(ctx, job.WfInstanceID)
+		if err != nil {
+			return err
+		}
+		if instance.Status != "running" {
+			return errors.New(errors.ErrValidation, "流程实例不在运行状态")
+		}
+
+		result := txs.db.WithContext(ctx).Model(&entity.WfJob{}).
+			Where("ID = ? AND STATUS = ?", id, JobFailed).
+			Updates(map[string]interface{}{
+				"STATUS":        JobPending,
+				"ATTEMPTS":      0,
+				"NEXT_RUN_TIME": time.Now(),
+			})
+		if result.Error != nil {
+			return errors.Wrap(errors.ErrDatabase, "重试作业失败", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(errors.ErrValidation, "只能重试失败的作业")
+		}
+
+		return txs.recordHistory(ctx, &entity.WfHistory{
+			WfInstanceID: job.WfInstanceID,
+			WfNodeID:     job.WfNodeID,
+			EventType:    HistoryJobRetry,
+			OperatorID:   userID,
+			Comment:      job.LastError,
+		}, map[string]interface{}{
+			"jobId":    job.ID,
+			"attempts": job.Attempts,
+		})
+	})
+}
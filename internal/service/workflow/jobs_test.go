@@ -0,0 +1,113 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  int
+		attempts int
+		want     time.Duration
+	}{
+		{"首次失败", 30, 1, 30 * time.Second},
+		{"第二次失败翻倍", 30, 2, time.Minute},
+		{"第四次失败", 30, 4, 4 * time.Minute},
+		{"达到上限", 3600, 6, maxJobBackoff},
+		{"远超上限", 3600, 10, maxJobBackoff},
+		{"超过上限不溢出", 30, 100, maxJobBackoff},
+		{"恰好等于上限", 3 * 3600, 4, maxJobBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.backoff, tt.attempts); got != tt.want {
+				t.Errorf("retryDelay(%d, %d) = %v, want %v", tt.backoff, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailJobAttempts(t *testing.T) {
+	// 次数用尽时记录告警日志
+	previous := logger.Logger
+	logger.Logger = zap.NewNop()
+	t.Cleanup(func() { logger.Logger = previous })
+
+	tests := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		wantStatus  string
+		wantDelay   time.Duration // 重新排队时的重试间隔
+	}{
+		{"首次失败重新排队", 1, 3, JobPending, 30 * time.Second},
+		{"再次失败间隔翻倍", 2, 3, JobPending, time.Minute},
+		{"次数用尽标记失败", 3, 3, JobFailed, 0},
+		{"超过最大次数标记失败", 4, 3, JobFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, nil)
+			s := &service{db: db}
+			job := &entity.WfJob{WfInstanceID: 1, WfNodeID: 2, Status: JobRunning, Attempts: tt.attempts, MaxAttempts: tt.maxAttempts, Backoff: 30}
+			job.ID = 9
+
+			before := time.Now()
+			if err := s.failJob(context.Background(), job, fmt.Errorf("动作执行失败")); err != nil {
+				t.Fatalf("failJob() error = %v", err)
+			}
+
+			updates := fake.executed("UPDATE `wf_job` SET")
+			if len(updates) != 1 {
+				t.Fatalf("job updates = %d, want 1", len(updates))
+			}
+			set := setValues(updates[0])
+			if set["STATUS"] != tt.wantStatus {
+				t.Errorf("STATUS = %v, want %s", set["STATUS"], tt.wantStatus)
+			}
+
+			if tt.wantStatus == JobFailed {
+				if _, ok := set["FINISH_TIME"]; !ok {
+					t.Error("failed job missing FINISH_TIME")
+				}
+				if _, ok := set["NEXT_RUN_TIME"]; ok {
+					t.Error("failed job should not be rescheduled")
+				}
+			} else {
+				next, ok := set["NEXT_RUN_TIME"].(time.Time)
+				if !ok {
+					t.Fatalf("NEXT_RUN_TIME = %v, want time", set["NEXT_RUN_TIME"])
+				}
+				if delay := next.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+					t.Errorf("retry delay = %v, want %v", delay, tt.wantDelay)
+				}
+			}
+
+			if histories := fake.executed("INSERT INTO `wf_history`"); len(histories) != 1 {
+				t.Errorf("history = %d, want 1", len(histories))
+			}
+		})
+	}
+}
+
+// setValues 按 UPDATE 语句 SET 子句的列顺序对应参数
+func setValues(query fakeQuery) map[string]interface{} {
+	clause := query.sql[strings.Index(query.sql, " SET ")+len(" SET "):]
+	clause = clause[:strings.Index(clause, " WHERE ")]
+
+	values := make(map[string]interface{})
+	for i, assignment := range strings.Split(clause, ",") {
+		column := strings.Trim(strings.TrimSuffix(assignment, "=?"), "`")
+		values[column] = query.args[i]
+	}
+	return values
+}
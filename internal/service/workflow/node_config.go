@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// 节点类型（WfNode.NodeType）
+const (
+	NodeTypeStart   = "start"   // 开始
+	NodeTypeEnd     = "end"     // 结束
+	NodeTypeUser    = "user"    // 用户任务
+	NodeTypeAuto    = "auto"    // 自动任务
+	NodeTypeGateway = "gateway" // 排他网关，取第一个满足条件的流转
+	NodeTypeFork    = "fork"    // 并行分叉，激活所有满足条件的流转
+	NodeTypeJoin    = "join"    // 并行汇聚，等待同一分叉激活的分支全部到达
+)
+
+// 会签完成规则（MultiInstanceConfig.Completion）
+const (
+	CompletionAll        = "all"        // 全部同意才通过，任一拒绝即驳回
+	CompletionAny        = "any"        // 任一同意即通过，全部拒绝才驳回
+	CompletionPercentage = "percentage" // 同意人数达到比例即通过
+)
+
+// 会签结果
+const (
+	outcomePending  = ""
+	outcomeApproved = "approve"
+	outcomeRejected = "reject"
+)
+
+// NodeConfig 节点配置（WfNode.Config 的 JSON 结构）
+type NodeConfig struct {
+	MultiInstance *MultiInstanceConfig `json:"multiInstance,omitempty"` // 会签配置，为空表示普通任务
+	SLA           *SLAConfig           `json:"sla,omitempty"`           // 处理时限配置，为空表示不限时
+	AutoTask      *AutoTaskConfig      `json:"autoTask,omitempty"`      // 自动任务重试配置，为空时使用全局默认值
+}
+
+// MultiInstanceConfig 会签配置：每个处理人各生成一个任务，按完成规则汇总结果
+// 例：{"multiInstance": {"completion": "percentage", "percentage": 60}}
+type MultiInstanceConfig struct {
+	Completion string `json:"completion"` // all:全部同意, any:任一同意, percentage:按比例
+	Percentage int    `json:"percentage"` // 通过所需的同意比例(1-100)，completion 为 percentage 时有效
+}
+
+// parseNodeConfig 解析节点配置，未配置时返回空配置
+func parseNodeConfig(node *entity.WfNode) (*NodeConfig, error) {
+	cfg := &NodeConfig{}
+	if strings.TrimSpace(node.Config) == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(node.Config), cfg); err != nil {
+		return nil, errors.Wrap(errors.ErrValidation, fmt.Sprintf("节点 %s 的配置格式错误", node.Name), err)
+	}
+	return cfg, nil
+}
+
+// validate 检查会签配置
+func (c *MultiInstanceConfig) validate() error {
+	switch c.Completion {
+	case "", CompletionAll, CompletionAny:
+		return nil
+	case CompletionPercentage:
+		if c.Percentage < 1 || c.Percentage > 100 {
+			return fmt.Errorf("会签通过比例必须为1-100")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的会签完成规则: %s", c.Completion)
+	}
+}
+
+// required 通过所需的同意人数
+func (c *MultiInstanceConfig) required(total int) int {
+	switch c.Completion {
+	case CompletionAny:
+		return 1
+	case CompletionPercentage:
+		// 向上取整，至少1人
+		n := (total*c.Percentage + 99) / 100
+		if n < 1 {
+			n = 1
+		}
+		return n
+	default:
+		return total
+	}
+}
+
+// outcome 根据任务处理情况计算会签结果
+// 同意人数达到要求时通过；剩余待处理任务全部同意也达不到要求时驳回；否则继续等待
+func (c *MultiInstanceConfig) outcome(approved, rejected, pending int) string {
+	required := c.required(approved + rejected + pending)
+	if approved >= required {
+		return outcomeApproved
+	}
+	if approved+pending < required {
+		return outcomeRejected
+	}
+	return outcomePending
+}
+
+// validateNodeConfig 检查节点配置（发布时调用）
+func validateNodeConfig(node *entity.WfNode) error {
+	cfg, err := parseNodeConfig(node)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MultiInstance != nil {
+		if node.NodeType != NodeTypeUser {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 不是用户任务，不能配置会签", node.Name))
+		}
+		if err := cfg.MultiInstance.validate(); err != nil {
+			return errors.Wrap(errors.ErrValidation, fmt.Sprintf("节点 %s 的会签配置无效", node.Name), err)
+		}
+	}
+
+	if cfg.SLA != nil {
+		if node.NodeType != NodeTypeUser {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 不是用户任务，不能配置处理时限", node.Name))
+		}
+		if err := cfg.SLA.validate(); err != nil {
+			return errors.Wrap(errors.ErrValidation, fmt.Sprintf("节点 %s 的处理时限配置无效", node.Name), err)
+		}
+	}
+
+	if cfg.AutoTask != nil {
+		if node.NodeType != NodeTypeAuto {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 不是自动任务，不能配置重试", node.Name))
+		}
+		if err := cfg.AutoTask.validate(); err != nil {
+			return errors.Wrap(errors.ErrValidation, fmt.Sprintf("节点 %s 的自动任务配置无效", node.Name), err)
+		}
+	}
+
+	return nil
+}
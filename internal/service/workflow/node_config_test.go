@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestMultiInstanceOutcome(t *testing.T) {
+	tests := []struct {
+		name                        string
+		cfg                         MultiInstanceConfig
+		approved, rejected, pending int
+		want                        string
+	}{
+		{"全部同意-等待", MultiInstanceConfig{Completion: CompletionAll}, 2, 0, 1, outcomePending},
+		{"全部同意-通过", MultiInstanceConfig{Completion: CompletionAll}, 3, 0, 0, outcomeApproved},
+		{"全部同意-一人拒绝", MultiInstanceConfig{Completion: CompletionAll}, 1, 1, 1, outcomeRejected},
+		{"默认规则为全部同意", MultiInstanceConfig{}, 1, 1, 0, outcomeRejected},
+		{"任一同意-通过", MultiInstanceConfig{Completion: CompletionAny}, 1, 0, 4, outcomeApproved},
+		{"任一同意-部分拒绝", MultiInstanceConfig{Completion: CompletionAny}, 0, 4, 1, outcomePending},
+		{"任一同意-全部拒绝", MultiInstanceConfig{Completion: CompletionAny}, 0, 5, 0, outcomeRejected},
+		{"比例-5人60%需3人", MultiInstanceConfig{Completion: CompletionPercentage, Percentage: 60}, 2, 1, 2, outcomePending},
+		{"比例-达到", MultiInstanceConfig{Completion: CompletionPercentage, Percentage: 60}, 3, 0, 2, outcomeApproved},
+		{"比例-无法达到", MultiInstanceConfig{Completion: CompletionPercentage, Percentage: 60}, 2, 3, 0, outcomeRejected},
+		{"比例-向上取整", MultiInstanceConfig{Completion: CompletionPercentage, Percentage: 50}, 1, 1, 1, outcomePending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.outcome(tt.approved, tt.rejected, tt.pending); got != tt.want {
+				t.Errorf("outcome(%d, %d, %d) = %q, want %q", tt.approved, tt.rejected, tt.pending, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountersignOutcome(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   MultiInstanceConfig
+		tasks [][]driver.Value // STATUS, ACTION
+		want  string
+	}{
+		{"同意和超时自动同意计入", MultiInstanceConfig{Completion: CompletionAll},
+			[][]driver.Value{{"completed", ActionApprove}, {"completed", EscalationApprove}}, outcomeApproved},
+		{"拒绝计入", MultiInstanceConfig{Completion: CompletionAll},
+			[][]driver.Value{{"completed", ActionApprove}, {"completed", ActionReject}}, outcomeRejected},
+		{"超时自动拒绝计入", MultiInstanceConfig{Completion: CompletionAll},
+			[][]driver.Value{{"completed", EscalationReject}, {"pending", ""}}, outcomeRejected},
+		{"退回不算同意", MultiInstanceConfig{Completion: CompletionAny},
+			[][]driver.Value{{"completed", ActionBack}, {"completed", ActionReject}}, outcomeRejected},
+		{"退回发起人和撤回不算同意", MultiInstanceConfig{Completion: CompletionAny},
+			[][]driver.Value{{"completed", ActionBackToStarter}, {"completed", ActionWithdraw}, {"completed", ActionReject}}, outcomeRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, map[string]*fakeResult{
+				"FROM `wf_task`": {columns: []string{"STATUS", "ACTION"}, rows: tt.tasks},
+			})
+			s := &service{db: db}
+			token := &entity.WfToken{}
+			token.ID = 8
+
+			got, err := s.countersignOutcome(context.Background(), token, &tt.cfg)
+			if err != nil {
+				t.Fatalf("countersignOutcome() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("countersignOutcome() = %q, want %q", got, tt.want)
+			}
+
+			queries := fake.executed("FROM `wf_task`")
+			if len(queries) != 1 || !containsArg(queries[0].args, int64(8)) {
+				t.Errorf("task query = %v, want filtered by token 8", queries)
+			}
+		})
+	}
+}
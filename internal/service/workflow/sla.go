@@ -0,0 +1,391 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
+	"github.com/sky-xhsoft/sky-server/internal/service/message"
+	"go.uber.org/zap"
+)
+
+// 超时处理方式（SLAConfig.Escalation）
+const (
+	EscalationNotify   = "notify"   // 只发送超时通知（默认）
+	EscalationApprove  = "approve"  // 自动同意
+	EscalationReject   = "reject"   // 自动拒绝
+	EscalationReassign = "reassign" // 转交给处理人的部门负责人
+)
+
+// deadlineBatchSize 每次扫描处理的任务数
+const deadlineBatchSize = 100
+
+const (
+	deadlineMaxAttempts = 3               // 提醒、超时处理的最大尝试次数，超过后不再处理
+	remindRetryDelay    = 5 * time.Minute // 提醒发送失败后的重试间隔，发送期间也按此推迟提醒时间
+)
+
+// SLAConfig 节点处理时限配置
+// 例：{"sla": {"timeoutMinutes": 1440, "remindMinutes": 120, "escalation": "reassign", "priority": 1}}
+type SLAConfig struct {
+	TimeoutMinutes int    `json:"timeoutMinutes"` // 处理时限（分钟），从任务创建开始计算
+	RemindMinutes  int    `json:"remindMinutes"`  // 到期前多少分钟提醒，0 表示不提醒
+	Escalation     string `json:"escalation"`     // 超时处理方式: notify, approve, reject, reassign
+	Priority       int    `json:"priority"`       // 任务优先级
+}
+
+// validate 检查时限配置
+func (c *SLAConfig) validate() error {
+	if c.TimeoutMinutes <= 0 {
+		return fmt.Errorf("处理时限必须大于0分钟")
+	}
+	if c.RemindMinutes < 0 || c.RemindMinutes >= c.TimeoutMinutes {
+		return fmt.Errorf("提醒时间必须小于处理时限")
+	}
+	switch c.Escalation {
+	case "", EscalationNotify, EscalationApprove, EscalationReject, EscalationReassign:
+		return nil
+	default:
+		return fmt.Errorf("不支持的超时处理方式: %s", c.Escalation)
+	}
+}
+
+// applySLA 按节点时限设置任务截止时间、提醒时间和优先级
+func applySLA(task *entity.WfTask, cfg *SLAConfig, now time.Time) {
+	if cfg == nil {
+		return
+	}
+
+	due := now.Add(time.Duration(cfg.TimeoutMinutes) * time.Minute)
+	task.DueTime = &due
+	if cfg.RemindMinutes > 0 {
+		remind := due.Add(-time.Duration(cfg.RemindMinutes) * time.Minute)
+		task.RemindTime = &remind
+	}
+	task.Priority = cfg.Priority
+}
+
+// ProcessDeadlines 处理到期提醒和超时任务（由定时任务调用）
+// 只处理运行中流程的任务，挂起的流程恢复后再处理；提醒和超时处理失败时重试，最多尝试 deadlineMaxAttempts 次
+func (s *service) ProcessDeadlines(ctx context.Context) error {
+	now := time.Now()
+
+	// 到期提醒
+	var remindTasks []*entity.WfTask
+	if err := s.db.WithContext(ctx).
+		Select("wf_task.*").
+		Joins("INNER JOIN wf_instance ON wf_instance.ID = wf_task.WF_INSTANCE_ID").
+		Where("wf_task.STATUS = ? AND wf_task.IS_ACTIVE = ? AND wf_task.REMIND_TIME <= ? AND wf_instance.STATUS = ?", "pending", "Y", now, "running").
+		Order("wf_task.REMIND_TIME ASC").
+		Limit(deadlineBatchSize).
+		Find(&remindTasks).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询待提醒任务失败", err)
+	}
+
+	for _, task := range remindTasks {
+		if err := s.processReminder(ctx, task, now); err != nil {
+			logger.Error("发送任务到期提醒失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		}
+	}
+
+	// 超时处理
+	var overdueTasks []*entity.WfTask
+	if err := s.db.WithContext(ctx).
+		Select("wf_task.*").
+		Joins("INNER JOIN wf_instance ON wf_instance.ID = wf_task.WF_INSTANCE_ID").
+		Where("wf_task.STATUS = ? AND wf_task.IS_ACTIVE = ? AND wf_task.DUE_TIME <= ? AND wf_task.ESCALATE_TIME IS NULL AND wf_instance.STATUS = ?", "pending", "Y", now, "running").
+		Order("wf_task.DUE_TIME ASC").
+		Limit(deadlineBatchSize).
+		Find(&overdueTasks).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询超时任务失败", err)
+	}
+
+	// 每个任务的超时处理在独立事务中执行
+	for _, task := range overdueTasks {
+		err := s.inTransaction(ctx, func(txs *service) error {
+			return txs.escalateTask(ctx, task.ID, now)
+		})
+		if err == nil {
+			continue
+		}
+		logger.Error("处理超时任务失败", zap.Uint("taskId", task.ID), zap.Int("attempt", task.EscalateAttempts+1), zap.Error(err))
+
+		// 处理失败已回滚，记录失败次数后下次扫描重试，达到最大次数时标记为已处理超时
+		updates := map[string]interface{}{"ESCALATE_ATTEMPTS": task.EscalateAttempts + 1}
+		if task.EscalateAttempts+1 >= deadlineMaxAttempts {
+			updates["ESCALATE_TIME"] = now
+		}
+		if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Where("ID = ? AND ESCALATE_TIME IS NULL AND ESCALATE_ATTEMPTS = ?", task.ID, task.EscalateAttempts).
+			Updates(updates).Error; err != nil {
+			logger.Error("记录超时处理失败次数失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// processReminder 领取并发送一个任务的到期提醒
+// 以发送次数作为版本号领取任务，发送期间推迟提醒时间，多个服务实例同时扫描时每次提醒只发送一次；
+// 发送成功后清空提醒时间，失败时在推迟后的提醒时间重试
+func (s *service) processReminder(ctx context.Context, task *entity.WfTask, now time.Time) error {
+	retry := now.Add(remindRetryDelay)
+	result := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("ID = ? AND REMIND_TIME IS NOT NULL AND REMIND_ATTEMPTS = ?", task.ID, task.RemindAttempts).
+		Updates(map[string]interface{}{
+			"REMIND_TIME":     retry,
+			"REMIND_ATTEMPTS": task.RemindAttempts + 1,
+		})
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "领取提醒任务失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	claimed := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("ID = ? AND REMIND_ATTEMPTS = ?", task.ID, task.RemindAttempts+1)
+
+	sent, err := s.remindTask(ctx, task)
+	switch {
+	case err != nil:
+		// 达到最大次数时放弃提醒
+		if task.RemindAttempts+1 >= deadlineMaxAttempts {
+			claimed.Update("REMIND_TIME", nil)
+		}
+		return err
+	case !sent:
+		// 流程已不在运行中，还原提醒时间，恢复后再提醒
+		return claimed.Updates(map[string]interface{}{
+			"REMIND_TIME":     task.RemindTime,
+			"REMIND_ATTEMPTS": task.RemindAttempts,
+		}).Error
+	default:
+		return claimed.Update("REMIND_TIME", nil).Error
+	}
+}
+
+// remindTask 向任务处理人发送到期提醒，流程不在运行中时不发送并返回 false
+func (s *service) remindTask(ctx context.Context, task *entity.WfTask) (bool, error) {
+	instance, err := s.GetInstance(ctx, task.WfInstanceID)
+	if err != nil {
+		return false, err
+	}
+	if instance.Status != "running" {
+		return false, nil
+	}
+
+	recipients, err := s.taskRecipients(ctx, task)
+	if err != nil {
+		return false, err
+	}
+
+	title := fmt.Sprintf("任务即将到期：%s", instance.Title)
+	content := fmt.Sprintf("流程「%s」的待办任务将于 %s 到期，请及时处理。", instance.Title, task.DueTime.Format("2006-01-02 15:04"))
+	if err := s.notify(ctx, recipients, task, title, content, "workflow_remind"); err != nil {
+		return false, err
+	}
+
+	for _, userID := range recipients {
+		if err := s.recordHistory(ctx, &entity.WfHistory{
+			WfInstanceID: task.WfInstanceID,
+			WfTaskID:     task.ID,
+			WfNodeID:     task.WfNodeID,
+			EventType:    HistoryRemind,
+			TargetUserID: userID,
+			Comment:      content,
+		}, nil); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// escalateTask 按节点配置处理超时任务，并记录历史
+func (s *service) escalateTask(ctx context.Context, taskID uint, now time.Time) error {
+	task, instance, err := s.lockTaskInstance(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	// 挂起的流程恢复后再处理
+	if instance.Status != "running" {
+		return nil
+	}
+
+	result := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("ID = ? AND STATUS = ? AND ESCALATE_TIME IS NULL", task.ID, "pending").
+		Update("ESCALATE_TIME", now)
+	if result.Error != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新超时任务失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	task.EscalateTime = &now
+
+	var node entity.WfNode
+	if err := s.db.WithContext(ctx).First(&node, task.WfNodeID).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询任务节点失败", err)
+	}
+	cfg, err := parseNodeConfig(&node)
+	if err != nil {
+		return err
+	}
+
+	escalation := EscalationNotify
+	if cfg.SLA != nil && cfg.SLA.Escalation != "" {
+		escalation = cfg.SLA.Escalation
+	}
+
+	history := &entity.WfHistory{
+		WfInstanceID: task.WfInstanceID,
+		WfTaskID:     task.ID,
+		WfNodeID:     task.WfNodeID,
+		EventType:    HistoryEscalate,
+		Action:       escalation,
+	}
+	detail := map[string]interface{}{
+		"assigneeId": task.AssigneeID,
+		"dueTime":    task.DueTime,
+	}
+
+	switch escalation {
+	case EscalationApprove, EscalationReject:
+		// 未签收的候选组任务没有处理人，不能以他人名义同意或拒绝，退化为超时通知候选人
+		if task.AssigneeID == 0 {
+			history.Action = EscalationNotify
+			detail["autoCompleteError"] = "任务未签收，无法自动处理"
+			break
+		}
+		history.Comment = "超时自动同意"
+		if escalation == EscalationReject {
+			history.Comment = "超时自动拒绝"
+		}
+		// 先记录历史，流转过程中可能结束流程
+		if err := s.recordHistory(ctx, history, detail); err != nil {
+			return err
+		}
+		return s.completeTask(ctx, task, instance, &CompleteTaskRequest{
+			TaskID:  task.ID,
+			UserID:  task.AssigneeID,
+			Action:  escalation,
+			Comment: history.Comment,
+		})
+
+	case EscalationReassign:
+		fromUserID := task.AssigneeID
+		if fromUserID == 0 {
+			fromUserID = instance.StartUserID
+		}
+		managers, err := s.getDeptManager(ctx, fromUserID, "")
+		if err != nil {
+			// 找不到负责人时退化为超时通知
+			history.Action = EscalationNotify
+			detail["reassignError"] = err.Error()
+			break
+		}
+
+		history.TargetUserID = managers[0]
+		history.Comment = "超时转交部门负责人"
+		if _, err := s.handOver(ctx, task, managers[0], history.Comment); err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, history, detail); err != nil {
+			return err
+		}
+		return s.notify(ctx, managers, task, fmt.Sprintf("超时任务转交：%s", instance.Title),
+			fmt.Sprintf("流程「%s」的待办任务已超时，已转交给您处理。", instance.Title), "workflow_escalate")
+	}
+
+	// 超时通知
+	recipients, err := s.taskRecipients(ctx, task)
+	if err != nil {
+		return err
+	}
+	if history.Comment == "" {
+		history.Comment = "任务已超时"
+	}
+	if err := s.recordHistory(ctx, history, detail); err != nil {
+		return err
+	}
+	return s.notify(ctx, recipients, task, fmt.Sprintf("任务已超时：%s", instance.Title),
+		fmt.Sprintf("流程「%s」的待办任务已于 %s 超时，请尽快处理。", instance.Title, task.DueTime.Format("2006-01-02 15:04")), "workflow_overdue")
+}
+
+// taskRecipients 任务通知对象：已分配的处理人，候选组任务为全部候选人
+func (s *service) taskRecipients(ctx context.Context, task *entity.WfTask) ([]uint, error) {
+	if task.AssigneeID != 0 {
+		return []uint{task.AssigneeID}, nil
+	}
+	return s.GetTaskCandidates(ctx, task.ID)
+}
+
+// notify 通过消息服务发送流程通知
+func (s *service) notify(ctx context.Context, userIDs []uint, task *entity.WfTask, title, content, category string) error {
+	if s.messageService == nil || len(userIDs) == 0 {
+		return nil
+	}
+
+	_, err := s.messageService.SendMessage(ctx, &message.SendMessageRequest{
+		Title:       title,
+		Content:     content,
+		MessageType: "workflow",
+		Priority:    task.Priority,
+		Category:    category,
+		TargetType:  "user",
+		TargetIDs:   userIDs,
+		LinkURL:     fmt.Sprintf("/workflow/tasks/%d", task.ID),
+		LinkType:    "internal",
+		Params: map[string]interface{}{
+			"taskId":     task.ID,
+			"instanceId": task.WfInstanceID,
+		},
+	}, nil)
+	return err
+}
+
+// handOver 将待处理任务移交给其他用户：原任务标记为已转交，为新处理人创建任务
+func (s *service) handOver(ctx context.Context, task *entity.WfTask, toUserID uint, comment string) (*entity.WfTask, error) {
+	result := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("ID = ? AND STATUS = ?", task.ID, "pending").
+		Updates(map[string]interface{}{
+			"STATUS":  "transferred",
+			"ACTION":  "transfer",
+			"COMMENT": comment,
+		})
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "转交任务失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New(errors.ErrResourceConflict, "任务已处理")
+	}
+	task.Status = "transferred"
+	task.Action = "transfer"
+	task.Comment = comment
+
+	// 创建新任务
+	newTask := &entity.WfTask{
+		WfInstanceID: task.WfInstanceID,
+		WfNodeID:     task.WfNodeID,
+		WfTokenID:    task.WfTokenID,
+		AssigneeID:   toUserID,
+		Status:       "pending",
+		Priority:     task.Priority,
+		Variables:    task.Variables,
+	}
+	newTask.IsActive = "Y"
+	// 未超时的任务保留原截止时间
+	if task.EscalateTime == nil {
+		newTask.DueTime = task.DueTime
+		newTask.RemindTime = task.RemindTime
+	}
+
+	if err := s.db.WithContext(ctx).Create(newTask).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "创建转交任务失败", err)
+	}
+
+	return newTask, nil
+}
@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestEscalateTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		escalation     string
+		assigneeID     int64
+		instanceStatus string
+		wantEscalated  bool   // 是否标记为已处理超时
+		wantAction     string // 超时历史的处理方式
+		wantCompleted  bool   // 是否自动完成任务
+	}{
+		{"默认超时通知", "", 5, "running", true, EscalationNotify, false},
+		{"已分配任务自动同意", EscalationApprove, 5, "running", true, EscalationApprove, true},
+		{"已分配任务自动拒绝", EscalationReject, 5, "running", true, EscalationReject, true},
+		{"未签收任务不自动同意", EscalationApprove, 0, "running", true, EscalationNotify, false},
+		{"未签收任务不自动拒绝", EscalationReject, 0, "running", true, EscalationNotify, false},
+		{"找不到负责人时退化为通知", EscalationReassign, 5, "running", true, EscalationNotify, false},
+		{"挂起的流程不处理", EscalationApprove, 5, "suspended", false, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ""
+			if tt.escalation != "" {
+				config = `{"sla": {"timeoutMinutes": 60, "escalation": "` + tt.escalation + `"}}`
+			}
+			due := time.Now().Add(-time.Hour)
+			db, fake := newFakeDB(t, map[string]*fakeResult{
+				"FROM `wf_task` WHERE": {
+					columns: []string{"ID", "WF_INSTANCE_ID", "WF_NODE_ID", "ASSIGNEE_ID", "STATUS", "DUE_TIME", "IS_ACTIVE"},
+					rows:    [][]driver.Value{{int64(3), int64(1), int64(2), tt.assigneeID, "pending", due, "Y"}},
+				},
+				"FROM `wf_instance` WHERE": {
+					columns: []string{"ID", "WF_DEFINITION_ID", "STATUS", "START_USER_ID", "TITLE", "IS_ACTIVE"},
+					rows:    [][]driver.Value{{int64(1), int64(9), tt.instanceStatus, int64(4), "请假", "Y"}},
+				},
+				"FROM `wf_node` WHERE": {
+					columns: []string{"ID", "WF_DEFINITION_ID", "NAME", "NODE_TYPE", "CONFIG"},
+					rows:    [][]driver.Value{{int64(2), int64(9), "审批", NodeTypeUser, config}},
+				},
+				"FROM `wf_task_candidate`": {
+					columns: []string{"USER_ID"},
+					rows:    [][]driver.Value{{int64(5)}, {int64(6)}},
+				},
+				"FROM `wf_transition`": {
+					columns: []string{"ID", "WF_DEFINITION_ID", "FROM_NODE_ID", "TO_NODE_ID"},
+					rows:    [][]driver.Value{{int64(1), int64(9), int64(2), int64(10)}},
+				},
+			})
+			// 审批节点之后是结束节点
+			fake.respond = func(query fakeQuery) *fakeResult {
+				if strings.Contains(query.sql, "FROM `wf_node` WHERE") && containsArg(query.args, int64(10)) {
+					return &fakeResult{
+						columns: []string{"ID", "WF_DEFINITION_ID", "NAME", "NODE_TYPE"},
+						rows:    [][]driver.Value{{int64(10), int64(9), "结束", NodeTypeEnd}},
+					}
+				}
+				return nil
+			}
+			s := &service{db: db}
+
+			err := s.inTransaction(context.Background(), func(txs *service) error {
+				return txs.escalateTask(context.Background(), 3, time.Now())
+			})
+			if err != nil {
+				t.Fatalf("escalateTask() error = %v", err)
+			}
+
+			escalated := fake.executed("SET `ESCALATE_TIME`=?")
+			if (len(escalated) == 1) != tt.wantEscalated {
+				t.Fatalf("escalate updates = %d, want %v", len(escalated), tt.wantEscalated)
+			}
+
+			histories := fake.executed("INSERT INTO `wf_history`")
+			if tt.wantAction == "" {
+				if len(histories) != 0 {
+					t.Errorf("histories = %v, want none", histories)
+				}
+				return
+			}
+			if len(histories) == 0 || !containsArg(histories[0].args, HistoryEscalate) || !containsArg(histories[0].args, tt.wantAction) {
+				t.Fatalf("histories = %v, want %s with action %s", histories, HistoryEscalate, tt.wantAction)
+			}
+
+			completed := false
+			for _, query := range fake.executed("UPDATE `wf_task` SET") {
+				if containsArg(query.args, "completed") {
+					completed = true
+					if !containsArg(query.args, tt.assigneeID) {
+						t.Errorf("completed task args = %v, want assignee %d", query.args, tt.assigneeID)
+					}
+				}
+			}
+			if completed != tt.wantCompleted {
+				t.Errorf("task completed = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestProcessDeadlinesEscalationFailure(t *testing.T) {
+	logger.Logger = zap.NewNop()
+
+	tests := []struct {
+		name          string
+		attempts      int64
+		wantGiveUp    bool
+		wantNextCount int
+	}{
+		{"首次失败下次重试", 0, false, 1},
+		{"达到最大次数后不再处理", deadlineMaxAttempts - 1, true, deadlineMaxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, nil)
+			fake.respond = func(query fakeQuery) *fakeResult {
+				// 超时扫描返回一个任务，加锁时读取任务失败（任务已删除），超时处理回滚
+				if strings.Contains(query.sql, "wf_task.DUE_TIME <=") {
+					return &fakeResult{
+						columns: []string{"ID", "WF_INSTANCE_ID", "WF_NODE_ID", "STATUS", "ESCALATE_ATTEMPTS", "IS_ACTIVE"},
+						rows:    [][]driver.Value{{int64(3), int64(1), int64(2), "pending", tt.attempts, "Y"}},
+					}
+				}
+				return nil
+			}
+			s := &service{db: db}
+
+			if err := s.ProcessDeadlines(context.Background()); err != nil {
+				t.Fatalf("ProcessDeadlines() error = %v", err)
+			}
+
+			scans := fake.executed("wf_task.DUE_TIME <=")
+			if len(scans) != 1 || !strings.Contains(scans[0].sql, "wf_instance.STATUS = ?") || !containsArg(scans[0].args, "running") {
+				t.Fatalf("overdue scan = %v, want only running instances", scans)
+			}
+
+			updates := fake.executed("`ESCALATE_ATTEMPTS`=?")
+			if len(updates) != 1 {
+				t.Fatalf("attempt updates = %v, want 1", updates)
+			}
+			values := setValues(updates[0])
+			if values["ESCALATE_ATTEMPTS"] != int64(tt.wantNextCount) {
+				t.Errorf("ESCALATE_ATTEMPTS = %v, want %d", values["ESCALATE_ATTEMPTS"], tt.wantNextCount)
+			}
+			if _, ok := values["ESCALATE_TIME"]; ok != tt.wantGiveUp {
+				t.Errorf("ESCALATE_TIME set = %v, want %v", ok, tt.wantGiveUp)
+			}
+			// 以失败次数作为版本号，多个实例同时扫描时只记录一次
+			if !strings.Contains(updates[0].sql, "ESCALATE_ATTEMPTS = ?") || !containsArg(updates[0].args, tt.attempts) {
+				t.Errorf("attempt update = %v, want guarded by current attempts %d", updates[0], tt.attempts)
+			}
+		})
+	}
+}
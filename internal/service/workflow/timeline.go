@@ -0,0 +1,339 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// 时间线条目类型（其余条目类型取 WfHistory.EventType）
+const (
+	TimelineEnter = "enter" // 进入节点
+	TimelineLeave = "leave" // 离开节点
+	TimelineTask  = "task"  // 用户任务
+)
+
+// 节点状态（流程图高亮）
+const (
+	NodeStateCompleted = "completed" // 已经过
+	NodeStateActive    = "active"    // 令牌停留中
+	NodeStateCanceled  = "canceled"  // 令牌被取消（流程终止，或退回主干时取消的并行分支）
+	NodeStateUntouched = "untouched" // 未经过
+)
+
+// TimelineEntry 流程时间线条目
+type TimelineEntry struct {
+	Time         time.Time       `json:"time"`
+	Type         string          `json:"type"` // enter, leave, task 或历史事件类型
+	NodeID       uint            `json:"nodeId"`
+	NodeName     string          `json:"nodeName"`
+	TokenID      uint            `json:"tokenId,omitempty"`
+	TransitionID uint            `json:"transitionId,omitempty"` // 进入节点经过的流转
+	TaskID       uint            `json:"taskId,omitempty"`
+	UserID       uint            `json:"userId,omitempty"`       // 任务处理人或事件操作人
+	TargetUserID uint            `json:"targetUserId,omitempty"` // 事件目标用户
+	Status       string          `json:"status,omitempty"`
+	Action       string          `json:"action,omitempty"`
+	Comment      string          `json:"comment,omitempty"`
+	EndTime      *time.Time      `json:"endTime,omitempty"`  // 任务完成或离开节点时间
+	Duration     int64           `json:"duration,omitempty"` // 耗时（秒）
+	Detail       json.RawMessage `json:"detail,omitempty"`   // 事件详情（变量变更、自动任务结果等）
+
+	order timelineOrder
+}
+
+// timelineOrder 时间相同（数据库按秒存储）时按令牌先后和条目类型排序
+type timelineOrder struct {
+	tokenID uint
+	rank    int
+}
+
+// InstanceTimeline 流程实例时间线
+type InstanceTimeline struct {
+	Instance *entity.WfInstance `json:"instance"`
+	Entries  []*TimelineEntry   `json:"entries"`
+}
+
+// GraphNode 带执行状态的流程节点
+type GraphNode struct {
+	entity.WfNode
+	State  string `json:"state"`  // completed, active, canceled, untouched
+	Visits int    `json:"visits"` // 进入次数
+}
+
+// GraphTransition 带执行状态的流程流转
+type GraphTransition struct {
+	entity.WfTransition
+	Taken bool `json:"taken"` // 是否经过
+}
+
+// InstanceGraph 流程实例图状态
+type InstanceGraph struct {
+	Instance    *entity.WfInstance   `json:"instance"`
+	Definition  *entity.WfDefinition `json:"definition"`
+	Nodes       []*GraphNode         `json:"nodes"`
+	Transitions []*GraphTransition   `json:"transitions"`
+}
+
+// GetTimeline 查询流程实例时间线：节点进出、任务处理、变量变更、自动任务结果及其他历史事件
+func (s *service) GetTimeline(ctx context.Context, instanceID uint) (*InstanceTimeline, error) {
+	instance, err := s.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.ListTokens(ctx, instanceID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*entity.WfTask
+	if err := s.db.WithContext(ctx).
+		Where("WF_INSTANCE_ID = ? AND IS_ACTIVE = ?", instanceID, "Y").
+		Order("ID ASC").
+		Find(&tasks).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程任务失败", err)
+	}
+
+	histories, err := s.ListHistory(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 迁移前的历史引用旧版本节点，按 ID 统一查询节点名称
+	nodeIDs := make([]uint, 0, len(tokens)+len(histories))
+	for _, token := range tokens {
+		nodeIDs = append(nodeIDs, token.WfNodeID)
+	}
+	for _, task := range tasks {
+		nodeIDs = append(nodeIDs, task.WfNodeID)
+	}
+	for _, history := range histories {
+		nodeIDs = append(nodeIDs, history.WfNodeID)
+	}
+	names, err := s.nodeNames(ctx, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*TimelineEntry, 0, len(tokens)*2+len(tasks)+len(histories))
+	taskTokens := make(map[uint]uint, len(tasks))
+
+	for _, token := range tokens {
+		entries = append(entries, &TimelineEntry{
+			Time:         token.EnterTime,
+			Type:         TimelineEnter,
+			NodeID:       token.WfNodeID,
+			NodeName:     names[token.WfNodeID],
+			TokenID:      token.ID,
+			TransitionID: token.WfTransitionID,
+			order:        timelineOrder{tokenID: token.ID, rank: 0},
+		})
+		if token.LeaveTime.IsZero() {
+			continue
+		}
+		leaveTime := token.LeaveTime
+		entries = append(entries, &TimelineEntry{
+			Time:     token.LeaveTime,
+			Type:     TimelineLeave,
+			NodeID:   token.WfNodeID,
+			NodeName: names[token.WfNodeID],
+			TokenID:  token.ID,
+			Status:   token.Status,
+			EndTime:  &leaveTime,
+			Duration: seconds(token.EnterTime, token.LeaveTime),
+			order:    timelineOrder{tokenID: token.ID, rank: 3},
+		})
+	}
+
+	for _, task := range tasks {
+		taskTokens[task.ID] = task.WfTokenID
+		entry := &TimelineEntry{
+			Time:     task.CreateTime,
+			Type:     TimelineTask,
+			NodeID:   task.WfNodeID,
+			NodeName: names[task.WfNodeID],
+			TokenID:  task.WfTokenID,
+			TaskID:   task.ID,
+			UserID:   task.AssigneeID,
+			Status:   task.Status,
+			Action:   task.Action,
+			Comment:  task.Comment,
+			order:    timelineOrder{tokenID: task.WfTokenID, rank: 1},
+		}
+		if !task.CompleteTime.IsZero() {
+			completeTime := task.CompleteTime
+			entry.EndTime = &completeTime
+			entry.Duration = seconds(task.CreateTime, task.CompleteTime)
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, history := range histories {
+		tokenID := taskTokens[history.WfTaskID]
+		if tokenID == 0 {
+			tokenID = tokenAt(tokens, history.WfNodeID, history.CreateTime)
+		}
+		entry := &TimelineEntry{
+			Time:         history.CreateTime,
+			Type:         history.EventType,
+			NodeID:       history.WfNodeID,
+			NodeName:     names[history.WfNodeID],
+			TokenID:      tokenID,
+			TaskID:       history.WfTaskID,
+			UserID:       history.OperatorID,
+			TargetUserID: history.TargetUserID,
+			Action:       history.Action,
+			Comment:      history.Comment,
+			order:        timelineOrder{tokenID: tokenID, rank: 2},
+		}
+		if history.Detail != "" && json.Valid([]byte(history.Detail)) {
+			entry.Detail = json.RawMessage(history.Detail)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.order.tokenID != b.order.tokenID {
+			return a.order.tokenID < b.order.tokenID
+		}
+		return a.order.rank < b.order.rank
+	})
+
+	return &InstanceTimeline{Instance: instance, Entries: entries}, nil
+}
+
+// GetInstanceGraph 查询流程实例图状态，标记已经过、停留中和未经过的节点及经过的流转
+func (s *service) GetInstanceGraph(ctx context.Context, instanceID uint) (*InstanceGraph, error) {
+	instance, err := s.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := s.GetDefinition(ctx, instance.WfDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.GetNodes(ctx, def.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := s.GetTransitions(ctx, def.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.ListTokens(ctx, instanceID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	visits := make(map[uint]int)
+	states := make(map[uint]string)
+	taken := make(map[uint]bool)
+	for _, token := range tokens {
+		visits[token.WfNodeID]++
+		if token.WfTransitionID != 0 {
+			taken[token.WfTransitionID] = true
+		}
+
+		// 同一节点多次进入时，停留中优先于终止，终止优先于已经过
+		var state string
+		switch token.Status {
+		case TokenActive, TokenWaiting:
+			state = NodeStateActive
+		case TokenCanceled:
+			state = NodeStateCanceled
+		default:
+			state = NodeStateCompleted
+		}
+		if stateRank(state) > stateRank(states[token.WfNodeID]) {
+			states[token.WfNodeID] = state
+		}
+	}
+
+	graph := &InstanceGraph{
+		Instance:    instance,
+		Definition:  def,
+		Nodes:       make([]*GraphNode, 0, len(nodes)),
+		Transitions: make([]*GraphTransition, 0, len(transitions)),
+	}
+	for _, node := range nodes {
+		state := states[node.ID]
+		if state == "" {
+			state = NodeStateUntouched
+		}
+		graph.Nodes = append(graph.Nodes, &GraphNode{WfNode: *node, State: state, Visits: visits[node.ID]})
+	}
+	for _, t := range transitions {
+		graph.Transitions = append(graph.Transitions, &GraphTransition{WfTransition: *t, Taken: taken[t.ID]})
+	}
+
+	return graph, nil
+}
+
+// nodeNames 查询节点显示名称（未设置时取节点名称）
+func (s *service) nodeNames(ctx context.Context, ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	var nodes []*entity.WfNode
+	if err := s.db.WithContext(ctx).Where("ID IN ?", ids).Find(&nodes).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程节点失败", err)
+	}
+	for _, node := range nodes {
+		names[node.ID] = node.DisplayName
+		if node.DisplayName == "" {
+			names[node.ID] = node.Name
+		}
+	}
+
+	return names, nil
+}
+
+// tokenAt 查找事件发生时所在节点最近进入的令牌（tokens 按 ID 升序）
+func tokenAt(tokens []*entity.WfToken, nodeID uint, at time.Time) uint {
+	var found uint
+	for _, token := range tokens {
+		if token.EnterTime.After(at) {
+			break
+		}
+		if token.WfNodeID == nodeID || nodeID == 0 {
+			found = token.ID
+		}
+	}
+	return found
+}
+
+// stateRank 节点状态优先级
+func stateRank(state string) int {
+	switch state {
+	case NodeStateActive:
+		return 3
+	case NodeStateCanceled:
+		return 2
+	case NodeStateCompleted:
+		return 1
+	}
+	return 0
+}
+
+// seconds 计算耗时（秒）
+func seconds(from, to time.Time) int64 {
+	if from.IsZero() || to.Before(from) {
+		return 0
+	}
+	return int64(to.Sub(from).Seconds())
+}
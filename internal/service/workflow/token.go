@@ -0,0 +1,388 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"gorm.io/gorm"
+)
+
+// 令牌状态（WfToken.Status）
+const (
+	TokenActive    = "active"    // 活动，停留在节点上
+	TokenWaiting   = "waiting"   // 在汇聚节点等待其他分支
+	TokenCompleted = "completed" // 已离开节点
+	TokenCanceled  = "canceled"  // 流程终止时取消
+)
+
+// createToken 创建进入节点的令牌，并记录为实例最近进入的节点
+// transitionID 为进入节点经过的流转，不经过流转（开始、退回）时为0
+func (s *service) createToken(ctx context.Context, instance *entity.WfInstance, node *entity.WfNode, forkID, transitionID uint) (*entity.WfToken, error) {
+	token := &entity.WfToken{
+		WfInstanceID:   instance.ID,
+		WfNodeID:       node.ID,
+		ForkID:         forkID,
+		WfTransitionID: transitionID,
+		Status:         TokenActive,
+		EnterTime:      time.Now(),
+	}
+	token.IsActive = "Y"
+
+	if err := s.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "创建流程令牌失败", err)
+	}
+
+	instance.CurrentNodeID = node.ID
+	if err := s.db.WithContext(ctx).Model(instance).Update("CURRENT_NODE_ID", node.ID).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "更新流程实例失败", err)
+	}
+
+	return token, nil
+}
+
+// leaveToken 令牌离开节点
+func (s *service) leaveToken(ctx context.Context, token *entity.WfToken) error {
+	token.Status = TokenCompleted
+	token.LeaveTime = time.Now()
+	if err := s.db.WithContext(ctx).Save(token).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新流程令牌失败", err)
+	}
+	return nil
+}
+
+// enterNode 经流转进入节点
+func (s *service) enterNode(ctx context.Context, instance *entity.WfInstance, transition *entity.WfTransition, node *entity.WfNode, forkID uint, variables map[string]interface{}) error {
+	token, err := s.createToken(ctx, instance, node, forkID, transition.ID)
+	if err != nil {
+		return err
+	}
+	return s.executeNode(ctx, instance, token, node, variables)
+}
+
+// executeNode 根据节点类型执行令牌所在节点
+func (s *service) executeNode(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode, variables map[string]interface{}) error {
+	switch node.NodeType {
+	case NodeTypeUser:
+		// 创建用户任务
+		return s.createUserTask(ctx, instance, token, node)
+	case NodeTypeAuto:
+		// 执行自动任务
+		return s.executeAutoTask(ctx, instance, token, node)
+	case NodeTypeJoin:
+		// 等待并行分支汇聚
+		return s.join(ctx, instance, token, node, variables)
+	case NodeTypeEnd:
+		// 分支结束，所有令牌结束后流程结束
+		if err := s.leaveToken(ctx, token); err != nil {
+			return err
+		}
+		return s.completeIfFinished(ctx, instance)
+	default:
+		// 继续流转
+		return s.moveToNext(ctx, instance, token, node, variables)
+	}
+}
+
+// fork 并行分叉：为每个满足条件的流转创建一个分支令牌
+// 先创建全部分支令牌再逐个执行，避免先到达结束节点的分支提前结束流程
+func (s *service) fork(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, transitions []*entity.WfTransition, variables map[string]interface{}) error {
+	selected, err := s.selectForkTransitions(ctx, instance, transitions, variables)
+	if err != nil {
+		return err
+	}
+
+	token.Branches = len(selected)
+	if err := s.leaveToken(ctx, token); err != nil {
+		return err
+	}
+
+	nodes := make([]*entity.WfNode, 0, len(selected))
+	tokens := make([]*entity.WfToken, 0, len(selected))
+	for _, t := range selected {
+		var node entity.WfNode
+		if err := s.db.WithContext(ctx).First(&node, t.ToNodeID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "查询下一个节点失败", err)
+		}
+		branch, err := s.createToken(ctx, instance, &node, token.ID, t.ID)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, &node)
+		tokens = append(tokens, branch)
+	}
+
+	for i, branch := range tokens {
+		if err := s.executeNode(ctx, instance, branch, nodes[i], variables); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// join 并行汇聚：同一分叉激活的分支全部到达后，由最后到达的令牌继续流转
+func (s *service) join(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode, variables map[string]interface{}) error {
+	// 不在并行分支中时直接通过
+	if token.ForkID == 0 {
+		return s.moveToNext(ctx, instance, token, node, variables)
+	}
+
+	var forkToken entity.WfToken
+	if err := s.db.WithContext(ctx).First(&forkToken, token.ForkID).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询分叉令牌失败", err)
+	}
+
+	token.Status = TokenWaiting
+	if err := s.db.WithContext(ctx).Save(token).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新流程令牌失败", err)
+	}
+
+	var arrived int64
+	if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Where("WF_INSTANCE_ID = ? AND WF_NODE_ID = ? AND FORK_ID = ? AND STATUS = ?", instance.ID, node.ID, forkToken.ID, TokenWaiting).
+		Count(&arrived).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询汇聚分支失败", err)
+	}
+	if int(arrived) < forkToken.Branches {
+		return nil
+	}
+
+	// 其他分支令牌结束，当前令牌回到分叉前的分支继续流转
+	if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Where("WF_INSTANCE_ID = ? AND WF_NODE_ID = ? AND FORK_ID = ? AND STATUS = ? AND ID <> ?", instance.ID, node.ID, forkToken.ID, TokenWaiting, token.ID).
+		Updates(map[string]interface{}{
+			"STATUS":     TokenCompleted,
+			"LEAVE_TIME": time.Now(),
+		}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新汇聚分支失败", err)
+	}
+
+	token.ForkID = forkToken.ForkID
+	token.Status = TokenActive
+	return s.moveToNext(ctx, instance, token, node, variables)
+}
+
+// completeIfFinished 没有活动令牌时结束流程
+func (s *service) completeIfFinished(ctx context.Context, instance *entity.WfInstance) error {
+	var remaining int64
+	if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS IN ?", instance.ID, []string{TokenActive, TokenWaiting}).
+		Count(&remaining).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询流程令牌失败", err)
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	instance.Status = "completed"
+	instance.EndTime = time.Now()
+	if err := s.db.WithContext(ctx).Save(instance).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新流程实例失败", err)
+	}
+	return s.finishBusiness(ctx, instance, crud.ApprovalApproved)
+}
+
+// cancelExecution 取消实例所有待处理任务、活动令牌和未完成的自动任务作业（流程终止或驳回时调用）
+func (s *service) cancelExecution(ctx context.Context, instanceID uint) error {
+	if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS = ?", instanceID, "pending").
+		Update("STATUS", "canceled").Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "取消待处理任务失败", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS IN ?", instanceID, []string{TokenActive, TokenWaiting}).
+		Updates(map[string]interface{}{
+			"STATUS":     TokenCanceled,
+			"LEAVE_TIME": time.Now(),
+		}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "取消流程令牌失败", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS IN ?", instanceID, []string{JobPending, JobRunning}).
+		Updates(map[string]interface{}{
+			"STATUS":       JobCanceled,
+			"LOCKED_UNTIL": nil,
+		}).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "取消自动任务作业失败", err)
+	}
+
+	return nil
+}
+
+// taskToken 获取任务所属令牌
+// 兼容令牌机制之前创建的任务：补建一个停留在任务节点上的主干令牌
+func (s *service) taskToken(ctx context.Context, instance *entity.WfInstance, task *entity.WfTask) (*entity.WfToken, error) {
+	if task.WfTokenID != 0 {
+		var token entity.WfToken
+		if err := s.db.WithContext(ctx).First(&token, task.WfTokenID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.New(errors.ErrResourceNotFound, "任务所属令牌不存在")
+			}
+			return nil, errors.Wrap(errors.ErrDatabase, "查询流程令牌失败", err)
+		}
+		return &token, nil
+	}
+
+	token := &entity.WfToken{
+		WfInstanceID: instance.ID,
+		WfNodeID:     task.WfNodeID,
+		Status:       TokenActive,
+		EnterTime:    task.CreateTime,
+	}
+	token.IsActive = "Y"
+	if err := s.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "创建流程令牌失败", err)
+	}
+	return token, nil
+}
+
+// ListTokens 查询流程实例的令牌（activeOnly 为 true 时只返回活动和等待汇聚的令牌）
+func (s *service) ListTokens(ctx context.Context, instanceID uint, activeOnly bool) ([]*entity.WfToken, error) {
+	query := s.db.WithContext(ctx).Where("WF_INSTANCE_ID = ? AND IS_ACTIVE = ?", instanceID, "Y")
+	if activeOnly {
+		query = query.Where("STATUS IN ?", []string{TokenActive, TokenWaiting})
+	}
+
+	var tokens []*entity.WfToken
+	if err := query.Order("ID ASC").Find(&tokens).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程令牌失败", err)
+	}
+
+	return tokens, nil
+}
+
+// validateGateways 检查并行网关（发布时调用）
+// 分叉、汇聚节点的流转数量，以及分叉的每条分支都汇聚到同一个汇聚节点：
+// 汇聚节点等待分叉激活的全部分支，分支先到达结束节点或绕过汇聚节点时流程会一直等待
+func validateGateways(nodes []*entity.WfNode, transitions []*entity.WfTransition) error {
+	outgoing := make(map[uint]int)
+	incoming := make(map[uint]int)
+	for _, t := range transitions {
+		outgoing[t.FromNodeID]++
+		incoming[t.ToNodeID]++
+	}
+
+	for _, node := range nodes {
+		switch node.NodeType {
+		case NodeTypeFork:
+			if outgoing[node.ID] < 2 {
+				return errors.New(errors.ErrValidation, fmt.Sprintf("并行分叉节点 %s 至少需要两个后续流转", node.Name))
+			}
+		case NodeTypeJoin:
+			if incoming[node.ID] < 2 {
+				return errors.New(errors.ErrValidation, fmt.Sprintf("并行汇聚节点 %s 至少需要两个进入流转", node.Name))
+			}
+		}
+	}
+
+	g := newGatewayGraph(nodes, transitions)
+	for _, node := range nodes {
+		if node.NodeType == NodeTypeFork {
+			if _, err := g.matchJoin(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// gatewayGraph 用于检查并行分支汇聚的流程图
+type gatewayGraph struct {
+	nodes     map[uint]*entity.WfNode
+	next      map[uint][]uint
+	joins     map[uint]*entity.WfNode // 分叉节点ID -> 汇聚节点
+	resolving map[uint]bool           // 正在检查的分叉节点，用于发现分支回到分叉节点
+}
+
+func newGatewayGraph(nodes []*entity.WfNode, transitions []*entity.WfTransition) *gatewayGraph {
+	g := &gatewayGraph{
+		nodes:     make(map[uint]*entity.WfNode, len(nodes)),
+		next:      make(map[uint][]uint),
+		joins:     make(map[uint]*entity.WfNode),
+		resolving: make(map[uint]bool),
+	}
+	for _, node := range nodes {
+		g.nodes[node.ID] = node
+	}
+	for _, t := range transitions {
+		g.next[t.FromNodeID] = append(g.next[t.FromNodeID], t.ToNodeID)
+	}
+	return g
+}
+
+// matchJoin 查找分叉节点的所有分支汇聚到的汇聚节点
+// 嵌套的分叉整体跳到其汇聚节点之后继续查找
+func (g *gatewayGraph) matchJoin(fork *entity.WfNode) (*entity.WfNode, error) {
+	if join, ok := g.joins[fork.ID]; ok {
+		return join, nil
+	}
+	if g.resolving[fork.ID] {
+		return nil, errors.New(errors.ErrValidation, fmt.Sprintf("并行分叉节点 %s 的分支不能回到分叉节点", fork.Name))
+	}
+	g.resolving[fork.ID] = true
+	defer delete(g.resolving, fork.ID)
+
+	var join *entity.WfNode
+	visited := make(map[uint]bool)
+	var walk func(nodeID uint) error
+	walk = func(nodeID uint) error {
+		if visited[nodeID] {
+			return nil
+		}
+		visited[nodeID] = true
+
+		node, ok := g.nodes[nodeID]
+		if !ok {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("并行分叉节点 %s 的分支指向不存在的节点", fork.Name))
+		}
+
+		switch node.NodeType {
+		case NodeTypeJoin:
+			if join != nil && join.ID != node.ID {
+				return errors.New(errors.ErrValidation,
+					fmt.Sprintf("并行分叉节点 %s 的分支必须汇聚到同一个汇聚节点（%s、%s）", fork.Name, join.Name, node.Name))
+			}
+			join = node
+			return nil
+		case NodeTypeEnd:
+			return errors.New(errors.ErrValidation,
+				fmt.Sprintf("并行分叉节点 %s 的分支未汇聚就到达结束节点 %s", fork.Name, node.Name))
+		case NodeTypeFork:
+			nested, err := g.matchJoin(node)
+			if err != nil {
+				return err
+			}
+			node = nested
+		}
+
+		if len(g.next[node.ID]) == 0 {
+			return errors.New(errors.ErrValidation,
+				fmt.Sprintf("并行分叉节点 %s 的分支在节点 %s 中断，未汇聚", fork.Name, node.Name))
+		}
+		for _, to := range g.next[node.ID] {
+			if err := walk(to); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, to := range g.next[fork.ID] {
+		if err := walk(to); err != nil {
+			return nil, err
+		}
+	}
+	if join == nil {
+		return nil, errors.New(errors.ErrValidation, fmt.Sprintf("并行分叉节点 %s 的分支没有汇聚节点", fork.Name))
+	}
+
+	g.joins[fork.ID] = join
+	return join, nil
+}
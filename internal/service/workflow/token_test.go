@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+)
+
+func TestValidateGatewaysConvergence(t *testing.T) {
+	node := func(id uint, nodeType string) *entity.WfNode {
+		n := &entity.WfNode{NodeType: nodeType, Name: nodeType}
+		n.ID = id
+		return n
+	}
+	flow := func(from, to uint) *entity.WfTransition {
+		return &entity.WfTransition{FromNodeID: from, ToNodeID: to}
+	}
+
+	// 1 开始 -> 2 分叉 -> 3/4 用户任务 -> 5 汇聚 -> 6 结束
+	baseNodes := []*entity.WfNode{
+		node(1, NodeTypeStart), node(2, NodeTypeFork), node(3, NodeTypeUser),
+		node(4, NodeTypeUser), node(5, NodeTypeJoin), node(6, NodeTypeEnd),
+	}
+	baseFlows := []*entity.WfTransition{flow(1, 2), flow(2, 3), flow(2, 4), flow(3, 5), flow(4, 5), flow(5, 6)}
+
+	tests := []struct {
+		name    string
+		nodes   []*entity.WfNode
+		flows   []*entity.WfTransition
+		wantErr bool
+	}{
+		{"汇聚到同一节点", baseNodes, baseFlows, false},
+		{
+			"分支内排他网关再汇聚",
+			append(append([]*entity.WfNode{}, baseNodes...), node(7, NodeTypeGateway), node(8, NodeTypeUser)),
+			[]*entity.WfTransition{flow(1, 2), flow(2, 7), flow(2, 4), flow(7, 3), flow(7, 8), flow(3, 5), flow(8, 5), flow(4, 5), flow(5, 6)},
+			false,
+		},
+		{
+			"嵌套分叉",
+			append(append([]*entity.WfNode{}, baseNodes...), node(7, NodeTypeFork), node(8, NodeTypeUser), node(9, NodeTypeJoin)),
+			[]*entity.WfTransition{flow(1, 2), flow(2, 7), flow(2, 4), flow(7, 3), flow(7, 8), flow(3, 9), flow(8, 9), flow(9, 5), flow(4, 5), flow(5, 6)},
+			false,
+		},
+		{
+			"分支直接到达结束节点",
+			baseNodes,
+			[]*entity.WfTransition{flow(1, 2), flow(2, 3), flow(2, 4), flow(3, 5), flow(4, 6), flow(5, 6)},
+			true,
+		},
+		{
+			"分支内排他网关绕过汇聚",
+			append(append([]*entity.WfNode{}, baseNodes...), node(7, NodeTypeGateway)),
+			[]*entity.WfTransition{flow(1, 2), flow(2, 7), flow(2, 4), flow(7, 3), flow(7, 6), flow(3, 5), flow(4, 5), flow(5, 6)},
+			true,
+		},
+		{
+			"分支汇聚到不同节点",
+			append(append([]*entity.WfNode{}, baseNodes...), node(7, NodeTypeJoin)),
+			[]*entity.WfTransition{flow(1, 2), flow(2, 3), flow(2, 4), flow(3, 5), flow(4, 7), flow(3, 7), flow(4, 5), flow(5, 6), flow(7, 6)},
+			true,
+		},
+		{
+			"分支回到分叉节点",
+			baseNodes,
+			[]*entity.WfTransition{flow(1, 2), flow(2, 3), flow(2, 4), flow(3, 2), flow(3, 5), flow(4, 5), flow(5, 6)},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGateways(tt.nodes, tt.flows)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateGateways() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inTransaction 在一个事务中执行流程步骤，fn 收到绑定该事务的服务副本
+// 服务已绑定调用方事务时（如单据提交时启动流程）使用保存点，随调用方一起提交或回滚
+func (s *service) inTransaction(ctx context.Context, fn func(txs *service) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(s.withDB(tx))
+	})
+}
+
+// lockInstance 锁定并读取流程实例（需在事务中调用）
+// 同一实例的任务处理、终止、撤回、超时处理等步骤持有实例行锁串行执行，
+// 加锁后读取的实例和任务状态才是可信的
+func (s *service) lockInstance(ctx context.Context, id uint) (*entity.WfInstance, error) {
+	var instance entity.WfInstance
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ID = ? AND IS_ACTIVE = ?", id, "Y").
+		Take(&instance).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "流程实例不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "锁定流程实例失败", err)
+	}
+
+	return &instance, nil
+}
+
+// lockTaskInstance 锁定任务所属的流程实例，并在加锁后重新读取任务
+func (s *service) lockTaskInstance(ctx context.Context, taskID uint) (*entity.WfTask, *entity.WfInstance, error) {
+	task, err := s.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instance, err := s.lockInstance(ctx, task.WfInstanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 等锁期间任务可能已被其他请求处理
+	task, err = s.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return task, instance, nil
+}
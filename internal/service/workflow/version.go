@@ -0,0 +1,344 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sky-xhsoft/sky-server/internal/model/entity"
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 流程定义状态（WfDefinition.Status）
+// 同名的流程定义为同一流程的不同版本：草稿可编辑，发布后不可修改，发布新版本时旧版本归档
+const (
+	DefinitionDraft     = "draft"     // 草稿
+	DefinitionPublished = "published" // 已发布，新流程使用该版本启动
+	DefinitionArchived  = "archived"  // 已归档，运行中的实例继续使用
+)
+
+// HistoryMigrate 流程实例迁移到其他版本
+const HistoryMigrate = "migrate"
+
+// MigrateInstancesRequest 迁移流程实例请求
+type MigrateInstancesRequest struct {
+	TargetDefinitionID uint          `json:"targetDefinitionId" binding:"required"` // 目标版本（需已发布）
+	InstanceIDs        []uint        `json:"instanceIds" binding:"required"`        // 要迁移的运行中实例
+	NodeMapping        map[uint]uint `json:"nodeMapping"`                           // 原版本节点ID -> 目标版本节点ID，未指定的节点按名称匹配
+	UserID             uint          `json:"-"`
+}
+
+// checkDraft 检查流程定义是否为草稿（已发布的版本不可修改）
+func (s *service) checkDraft(ctx context.Context, definitionID uint) error {
+	def, err := s.GetDefinition(ctx, definitionID)
+	if err != nil {
+		return err
+	}
+	if def.Status != DefinitionDraft {
+		return errors.New(errors.ErrValidation, fmt.Sprintf("流程定义 %s 版本%d 已发布，请创建新版本后修改", def.Name, def.Version))
+	}
+	return nil
+}
+
+// CreateVersion 以指定版本为基础创建新的草稿版本（复制节点和流转）
+// 同一流程同时只能有一个草稿版本
+func (s *service) CreateVersion(ctx context.Context, id uint) (*entity.WfDefinition, error) {
+	source, err := s.GetDefinition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var draft entity.WfDefinition
+	err = s.db.WithContext(ctx).
+		Where("NAME = ? AND STATUS = ? AND IS_ACTIVE = ?", source.Name, DefinitionDraft, "Y").
+		Take(&draft).Error
+	if err == nil {
+		return nil, errors.New(errors.ErrResourceConflict, fmt.Sprintf("流程 %s 已有草稿版本(ID=%d)", source.Name, draft.ID))
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询草稿版本失败", err)
+	}
+
+	var maxVersion int
+	if err := s.db.WithContext(ctx).Model(&entity.WfDefinition{}).
+		Where("NAME = ? AND IS_ACTIVE = ?", source.Name, "Y").
+		Select("COALESCE(MAX(VERSION), 0)").
+		Scan(&maxVersion).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程版本失败", err)
+	}
+
+	def := &entity.WfDefinition{
+		Name:        source.Name,
+		DisplayName: source.DisplayName,
+		Version:     maxVersion + 1,
+		Status:      DefinitionDraft,
+		SysTableID:  source.SysTableID,
+		Description: source.Description,
+		Config:      source.Config,
+	}
+	def.IsActive = "Y"
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(def).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建流程版本失败", err)
+		}
+
+		nodes, err := s.GetNodes(ctx, source.ID)
+		if err != nil {
+			return err
+		}
+		nodeIDs := make(map[uint]uint, len(nodes))
+		for _, node := range nodes {
+			copied := *node
+			copied.ID = 0
+			copied.WfDefinitionID = def.ID
+			if err := tx.Create(&copied).Error; err != nil {
+				return errors.Wrap(errors.ErrDatabase, "复制流程节点失败", err)
+			}
+			nodeIDs[node.ID] = copied.ID
+		}
+
+		transitions, err := s.GetTransitions(ctx, source.ID)
+		if err != nil {
+			return err
+		}
+		for _, t := range transitions {
+			copied := *t
+			copied.ID = 0
+			copied.WfDefinitionID = def.ID
+			copied.FromNodeID = nodeIDs[t.FromNodeID]
+			copied.ToNodeID = nodeIDs[t.ToNodeID]
+			if err := tx.Create(&copied).Error; err != nil {
+				return errors.Wrap(errors.ErrDatabase, "复制流程流转失败", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+// ListVersions 查询同一流程的所有版本（按版本号倒序）
+func (s *service) ListVersions(ctx context.Context, id uint) ([]*entity.WfDefinition, error) {
+	def, err := s.GetDefinition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*entity.WfDefinition
+	if err := s.db.WithContext(ctx).
+		Where("NAME = ? AND IS_ACTIVE = ?", def.Name, "Y").
+		Order("VERSION DESC").
+		Find(&versions).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程版本失败", err)
+	}
+
+	return versions, nil
+}
+
+// MigrateInstances 将运行中的流程实例迁移到同一流程的其他已发布版本（管理员操作）
+// 活动令牌和待处理任务所在节点必须都能映射到目标版本的同类型节点，全部实例在一个事务中迁移
+func (s *service) MigrateInstances(ctx context.Context, req *MigrateInstancesRequest) error {
+	if err := s.requireAdmin(ctx, req.UserID, "只有管理员可以迁移流程实例"); err != nil {
+		return err
+	}
+	if len(req.InstanceIDs) == 0 {
+		return errors.New(errors.ErrValidation, "请选择要迁移的流程实例")
+	}
+
+	target, err := s.GetDefinition(ctx, req.TargetDefinitionID)
+	if err != nil {
+		return err
+	}
+	if target.Status != DefinitionPublished {
+		return errors.New(errors.ErrValidation, "只能迁移到已发布的流程版本")
+	}
+
+	targetNodes, err := s.GetNodes(ctx, target.ID)
+	if err != nil {
+		return err
+	}
+	targetByID := make(map[uint]*entity.WfNode, len(targetNodes))
+	for _, node := range targetNodes {
+		targetByID[node.ID] = node
+	}
+
+	// 映射中的节点必须存在：原节点属于同一流程，目标节点属于目标版本
+	for fromID, toID := range req.NodeMapping {
+		var from entity.WfNode
+		if err := s.db.WithContext(ctx).
+			Select("wf_node.*").
+			Joins("INNER JOIN wf_definition ON wf_definition.ID = wf_node.WF_DEFINITION_ID").
+			Where("wf_node.ID = ? AND wf_definition.NAME = ?", fromID, target.Name).
+			Take(&from).Error; err != nil {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点映射中的原节点 %d 不存在", fromID))
+		}
+		to, ok := targetByID[toID]
+		if !ok {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点映射中的目标节点 %d 不在目标版本中", toID))
+		}
+		if from.NodeType != to.NodeType {
+			return errors.New(errors.ErrValidation, fmt.Sprintf("节点 %s 不能映射到不同类型的节点 %s", from.Name, to.Name))
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txs := s.withDB(tx)
+		for _, id := range req.InstanceIDs {
+			if err := txs.migrateInstance(ctx, id, target, targetNodes, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateInstance 迁移单个流程实例：令牌和任务改挂到目标版本的对应节点
+func (s *service) migrateInstance(ctx context.Context, id uint, target *entity.WfDefinition, targetNodes []*entity.WfNode, req *MigrateInstancesRequest) error {
+	instance, err := s.lockInstance(ctx, id)
+	if err != nil {
+		return err
+	}
+	if instance.Status != "running" && instance.Status != "suspended" {
+		return errors.New(errors.ErrValidation, fmt.Sprintf("流程实例 %d 不在运行中，不能迁移", id))
+	}
+	if instance.WfDefinitionID == target.ID {
+		return errors.New(errors.ErrValidation, fmt.Sprintf("流程实例 %d 已在目标版本", id))
+	}
+
+	source, err := s.GetDefinition(ctx, instance.WfDefinitionID)
+	if err != nil {
+		return err
+	}
+	if source.Name != target.Name {
+		return errors.New(errors.ErrValidation, fmt.Sprintf("流程实例 %d 只能迁移到同一流程的其他版本", id))
+	}
+
+	sourceNodes, err := s.GetNodes(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+	targetByName := make(map[string]*entity.WfNode, len(targetNodes))
+	for _, node := range targetNodes {
+		targetByName[node.Name] = node
+	}
+
+	// 计算原版本节点到目标版本节点的映射：优先使用指定映射，其次按节点名称和类型匹配
+	mapping := make(map[uint]uint)
+	names := make(map[uint]string)
+	for _, node := range sourceNodes {
+		names[node.ID] = node.Name
+		if toID, ok := req.NodeMapping[node.ID]; ok {
+			mapping[node.ID] = toID
+		} else if to, ok := targetByName[node.Name]; ok && to.NodeType == node.NodeType {
+			mapping[node.ID] = to.ID
+		}
+	}
+
+	// 活动令牌和待处理任务所在节点必须能映射
+	var inUse []uint
+	if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS IN ?", instance.ID, []string{TokenActive, TokenWaiting}).
+		Distinct().Pluck("WF_NODE_ID", &inUse).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询流程令牌失败", err)
+	}
+	var taskNodes []uint
+	if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+		Where("WF_INSTANCE_ID = ? AND STATUS = ?", instance.ID, "pending").
+		Distinct().Pluck("WF_NODE_ID", &taskNodes).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询待处理任务失败", err)
+	}
+	for _, nodeID := range append(inUse, taskNodes...) {
+		if _, ok := mapping[nodeID]; !ok {
+			return errors.New(errors.ErrValidation,
+				fmt.Sprintf("流程实例 %d 当前节点 %s 在目标版本中没有对应节点，请指定节点映射", id, names[nodeID]))
+		}
+	}
+
+	// 已经过的节点一并映射，退回时可以找到目标版本中的节点
+	for fromID, toID := range mapping {
+		if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+			Where("WF_INSTANCE_ID = ? AND WF_NODE_ID = ?", instance.ID, fromID).
+			Update("WF_NODE_ID", toID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "迁移流程令牌失败", err)
+		}
+		if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Where("WF_INSTANCE_ID = ? AND WF_NODE_ID = ?", instance.ID, fromID).
+			Update("WF_NODE_ID", toID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "迁移流程任务失败", err)
+		}
+		if err := s.db.WithContext(ctx).Model(&entity.WfJob{}).
+			Where("WF_INSTANCE_ID = ? AND WF_NODE_ID = ?", instance.ID, fromID).
+			Update("WF_NODE_ID", toID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "迁移自动任务作业失败", err)
+		}
+	}
+
+	// 经过的流转按两端节点映射到目标版本，找不到对应流转时清空
+	if err := s.migrateTokenTransitions(ctx, instance.ID, source.ID, target.ID, mapping); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"WF_DEFINITION_ID": target.ID}
+	if toID, ok := mapping[instance.CurrentNodeID]; ok {
+		updates["CURRENT_NODE_ID"] = toID
+	}
+	if err := s.db.WithContext(ctx).Model(instance).Updates(updates).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "迁移流程实例失败", err)
+	}
+
+	return s.recordHistory(ctx, &entity.WfHistory{
+		WfInstanceID: instance.ID,
+		WfNodeID:     instance.CurrentNodeID,
+		EventType:    HistoryMigrate,
+		OperatorID:   req.UserID,
+		Comment:      fmt.Sprintf("从版本%d迁移到版本%d", source.Version, target.Version),
+	}, map[string]interface{}{
+		"fromDefinitionId": source.ID,
+		"fromVersion":      source.Version,
+		"toDefinitionId":   target.ID,
+		"toVersion":        target.Version,
+		"nodeMapping":      mapping,
+	})
+}
+
+// migrateTokenTransitions 将令牌经过的流转映射到目标版本
+func (s *service) migrateTokenTransitions(ctx context.Context, instanceID, sourceID, targetID uint, mapping map[uint]uint) error {
+	sourceTransitions, err := s.GetTransitions(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	targetTransitions, err := s.GetTransitions(ctx, targetID)
+	if err != nil {
+		return err
+	}
+
+	byEnds := make(map[[2]uint]uint, len(targetTransitions))
+	for _, t := range targetTransitions {
+		byEnds[[2]uint{t.FromNodeID, t.ToNodeID}] = t.ID
+	}
+
+	for _, t := range sourceTransitions {
+		toID := byEnds[[2]uint{mapping[t.FromNodeID], mapping[t.ToNodeID]}]
+		if err := s.db.WithContext(ctx).Model(&entity.WfToken{}).
+			Where("WF_INSTANCE_ID = ? AND WF_TRANSITION_ID = ?", instanceID, t.ID).
+			Update("WF_TRANSITION_ID", toID).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "迁移流程令牌失败", err)
+		}
+	}
+
+	return nil
+}
+
+// requireAdmin 检查用户是否为管理员
+func (s *service) requireAdmin(ctx context.Context, userID uint, message string) error {
+	var user entity.SysUser
+	if err := s.db.WithContext(ctx).Where("ID = ?", userID).Take(&user).Error; err != nil || user.IsAdmin != "Y" {
+		return errors.New(errors.ErrPermissionDenied, message)
+	}
+	return nil
+}
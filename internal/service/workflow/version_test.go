@@ -0,0 +1,170 @@
+package workflow
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
+)
+
+// migrationFixture 版本迁移测试数据：流程「请假」的版本1（ID 1）迁移到已发布的版本2（ID 2）
+// 版本1节点：11 开始、12 审批、13 结束、14 复核(用户任务)；流转 101: 11->12、102: 12->13、103: 12->14
+// 版本2节点：21 开始、22 审批、23 结束、24 复核(自动任务)；流转 201: 21->22、202: 22->23
+// 实例5运行在版本1，当前节点为12，tokenNodes、taskNodes 为活动令牌和待处理任务所在节点
+func migrationFixture(t *testing.T, tokenNodes, taskNodes []int64) (*service, *fakeDB) {
+	t.Helper()
+	nodeColumns := []string{"ID", "WF_DEFINITION_ID", "NAME", "NODE_TYPE"}
+	nodes := map[int64][][]driver.Value{
+		1: {{int64(11), int64(1), "开始", NodeTypeStart}, {int64(12), int64(1), "审批", NodeTypeUser},
+			{int64(13), int64(1), "结束", NodeTypeEnd}, {int64(14), int64(1), "复核", NodeTypeUser}},
+		2: {{int64(21), int64(2), "开始", NodeTypeStart}, {int64(22), int64(2), "审批", NodeTypeUser},
+			{int64(23), int64(2), "结束", NodeTypeEnd}, {int64(24), int64(2), "复核", NodeTypeAuto}},
+	}
+	transitionColumns := []string{"ID", "WF_DEFINITION_ID", "FROM_NODE_ID", "TO_NODE_ID"}
+	transitions := map[int64][][]driver.Value{
+		1: {{int64(101), int64(1), int64(11), int64(12)}, {int64(102), int64(1), int64(12), int64(13)},
+			{int64(103), int64(1), int64(12), int64(14)}},
+		2: {{int64(201), int64(2), int64(21), int64(22)}, {int64(202), int64(2), int64(22), int64(23)}},
+	}
+	pluck := func(ids []int64) *fakeResult {
+		result := &fakeResult{columns: []string{"WF_NODE_ID"}}
+		for _, id := range ids {
+			result.rows = append(result.rows, []driver.Value{id})
+		}
+		return result
+	}
+
+	db, fake := newFakeDB(t, map[string]*fakeResult{
+		"FROM `sys_user`": {columns: []string{"ID", "IS_ADMIN"}, rows: [][]driver.Value{{int64(1), "Y"}}},
+		"FROM `wf_instance`": {
+			columns: []string{"ID", "WF_DEFINITION_ID", "STATUS", "CURRENT_NODE_ID", "IS_ACTIVE"},
+			rows:    [][]driver.Value{{int64(5), int64(1), "running", int64(12), "Y"}},
+		},
+	})
+	fake.respond = func(query fakeQuery) *fakeResult {
+		switch {
+		case strings.Contains(query.sql, "FROM `wf_definition`"):
+			columns := []string{"ID", "NAME", "VERSION", "STATUS"}
+			if containsArg(query.args, int64(2)) {
+				return &fakeResult{columns: columns, rows: [][]driver.Value{{int64(2), "请假", int64(2), DefinitionPublished}}}
+			}
+			return &fakeResult{columns: columns, rows: [][]driver.Value{{int64(1), "请假", int64(1), DefinitionPublished}}}
+		case strings.Contains(query.sql, "INNER JOIN wf_definition"):
+			// 节点映射中的原节点
+			for _, row := range nodes[1] {
+				if containsArg(query.args, row[0]) {
+					return &fakeResult{columns: nodeColumns, rows: [][]driver.Value{row}}
+				}
+			}
+			return &fakeResult{}
+		case strings.Contains(query.sql, "FROM `wf_node`"):
+			return &fakeResult{columns: nodeColumns, rows: nodes[query.args[0].(int64)]}
+		case strings.Contains(query.sql, "FROM `wf_transition`"):
+			return &fakeResult{columns: transitionColumns, rows: transitions[query.args[0].(int64)]}
+		case strings.Contains(query.sql, "SELECT DISTINCT `WF_NODE_ID` FROM `wf_token`"):
+			return pluck(tokenNodes)
+		case strings.Contains(query.sql, "SELECT DISTINCT `WF_NODE_ID` FROM `wf_task`"):
+			return pluck(taskNodes)
+		}
+		return nil
+	}
+	return &service{db: db}, fake
+}
+
+func TestMigrateInstancesRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokenNodes []int64
+		taskNodes  []int64
+		mapping    map[uint]uint
+		wantErr    string
+	}{
+		{"活动令牌所在节点无法映射", []int64{14}, nil, nil, "当前节点 复核 在目标版本中没有对应节点"},
+		{"待处理任务所在节点无法映射", []int64{12}, []int64{14}, nil, "当前节点 复核 在目标版本中没有对应节点"},
+		{"节点类型不一致", []int64{14}, []int64{14}, map[uint]uint{14: 24}, "不能映射到不同类型的节点"},
+		{"目标节点不在目标版本", []int64{14}, []int64{14}, map[uint]uint{14: 12}, "不在目标版本中"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := migrationFixture(t, tt.tokenNodes, tt.taskNodes)
+
+			err := s.MigrateInstances(context.Background(), &MigrateInstancesRequest{
+				TargetDefinitionID: 2,
+				InstanceIDs:        []uint{5},
+				NodeMapping:        tt.mapping,
+				UserID:             1,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("MigrateInstances() error = %v, want %q", err, tt.wantErr)
+			}
+			if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrValidation {
+				t.Errorf("MigrateInstances() error = %v, want validation error", err)
+			}
+			if updates := fake.executed("UPDATE "); len(updates) != 0 {
+				t.Errorf("updates = %v, want none", updates)
+			}
+		})
+	}
+}
+
+func TestMigrateInstancesRemaps(t *testing.T) {
+	s, fake := migrationFixture(t, []int64{12, 14}, []int64{12, 14})
+
+	// 复核在目标版本中是自动任务，按名称不能匹配，指定映射到审批
+	if err := s.MigrateInstances(context.Background(), &MigrateInstancesRequest{
+		TargetDefinitionID: 2,
+		InstanceIDs:        []uint{5},
+		NodeMapping:        map[uint]uint{14: 22},
+		UserID:             1,
+	}); err != nil {
+		t.Fatalf("MigrateInstances() error = %v", err)
+	}
+
+	wantNodes := map[int64]int64{11: 21, 12: 22, 13: 23, 14: 22}
+	for _, table := range []string{"wf_token", "wf_task", "wf_job"} {
+		got := remapped(fake, "UPDATE `"+table+"` SET `WF_NODE_ID`=?")
+		if len(got) != len(wantNodes) {
+			t.Errorf("%s node updates = %v, want %v", table, got, wantNodes)
+			continue
+		}
+		for from, to := range wantNodes {
+			if got[from] != to {
+				t.Errorf("%s node %d -> %d, want %d", table, from, got[from], to)
+			}
+		}
+	}
+
+	// 经过的流转按两端节点映射，12->14 在目标版本中对应 22->22，没有该流转时清空
+	wantTransitions := map[int64]int64{101: 201, 102: 202, 103: 0}
+	got := remapped(fake, "UPDATE `wf_token` SET `WF_TRANSITION_ID`=?")
+	for from, to := range wantTransitions {
+		if value, ok := got[from]; !ok || value != to {
+			t.Errorf("transition %d -> %v, want %d", from, value, to)
+		}
+	}
+
+	instances := fake.executed("UPDATE `wf_instance` SET")
+	if len(instances) != 1 {
+		t.Fatalf("instance updates = %v, want 1", instances)
+	}
+	values := setValues(instances[0])
+	if values["WF_DEFINITION_ID"] != int64(2) || values["CURRENT_NODE_ID"] != int64(22) {
+		t.Errorf("instance update = %v, want definition 2 and current node 22", values)
+	}
+
+	histories := fake.executed("INSERT INTO `wf_history`")
+	if len(histories) != 1 || !containsArg(histories[0].args, HistoryMigrate) {
+		t.Errorf("histories = %v, want one %s", histories, HistoryMigrate)
+	}
+}
+
+// remapped 按 UPDATE 的第一个设置值和最后一个条件参数，返回原值到新值的映射
+func remapped(fake *fakeDB, fragment string) map[int64]int64 {
+	result := make(map[int64]int64)
+	for _, query := range fake.executed(fragment) {
+		result[query.args[len(query.args)-1].(int64)] = query.args[0].(int64)
+	}
+	return result
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/sky-xhsoft/sky-server/internal/model/entity"
 	"github.com/sky-xhsoft/sky-server/internal/service/action"
+	"github.com/sky-xhsoft/sky-server/internal/service/crud"
+	"github.com/sky-xhsoft/sky-server/internal/service/message"
 	"github.com/sky-xhsoft/sky-server/internal/pkg/errors"
 	"gorm.io/gorm"
 )
@@ -20,6 +22,10 @@ type Service interface {
 	UpdateDefinition(ctx context.Context, def *entity.WfDefinition) error
 	PublishDefinition(ctx context.Context, id uint) error
 	ListDefinitions(ctx context.Context, status string, page, pageSize int) ([]*entity.WfDefinition, int64, error)
+	CreateVersion(ctx context.Context, id uint) (*entity.WfDefinition, error)
+	ListVersions(ctx context.Context, id uint) ([]*entity.WfDefinition, error)
+	ImportBPMN(ctx context.Context, data []byte) (*entity.WfDefinition, error)
+	ExportBPMN(ctx context.Context, id uint) (*entity.WfDefinition, []byte, error)
 
 	// 流程节点管理
 	CreateNode(ctx context.Context, node *entity.WfNode) error
@@ -37,15 +43,34 @@ type Service interface {
 	GetInstance(ctx context.Context, id uint) (*entity.WfInstance, error)
 	ListInstances(ctx context.Context, req *ListInstancesRequest) ([]*entity.WfInstance, int64, error)
 	TerminateInstance(ctx context.Context, id uint, userID uint) error
+	WithdrawInstance(ctx context.Context, id, userID uint, comment string) error
 	SuspendInstance(ctx context.Context, id uint) error
 	ResumeInstance(ctx context.Context, id uint) error
+	ListTokens(ctx context.Context, instanceID uint, activeOnly bool) ([]*entity.WfToken, error)
+	ListHistory(ctx context.Context, instanceID uint) ([]*entity.WfHistory, error)
+	ListJobs(ctx context.Context, req *ListJobsRequest) ([]*entity.WfJob, int64, error)
+	RetryJob(ctx context.Context, id, userID uint) error
+	GetTimeline(ctx context.Context, instanceID uint) (*InstanceTimeline, error)
+	GetInstanceGraph(ctx context.Context, instanceID uint) (*InstanceGraph, error)
+	MigrateInstances(ctx context.Context, req *MigrateInstancesRequest) error
+	StartForRecord(ctx context.Context, db *gorm.DB, tableName string, recordID, userID uint) (*entity.WfInstance, error)
 
 	// 任务管理
 	GetTask(ctx context.Context, id uint) (*entity.WfTask, error)
 	ListMyTasks(ctx context.Context, userID uint, status string, page, pageSize int) ([]*entity.WfTask, int64, error)
 	CompleteTask(ctx context.Context, req *CompleteTaskRequest) error
 	ClaimTask(ctx context.Context, taskID, userID uint) error
+	GetTaskCandidates(ctx context.Context, taskID uint) ([]uint, error)
 	TransferTask(ctx context.Context, taskID, fromUserID, toUserID uint, comment string) error
+
+	// 任务委托
+	CreateDelegation(ctx context.Context, req *DelegationRequest) (*entity.WfDelegation, error)
+	ListDelegations(ctx context.Context, userID uint, includeExpired bool) ([]*entity.WfDelegation, error)
+	CancelDelegation(ctx context.Context, id, userID uint) error
+
+	// 后台处理
+	ProcessDeadlines(ctx context.Context) error
+	ProcessJobs(ctx context.Context) error
 }
 
 // StartProcessRequest 启动流程请求
@@ -69,36 +94,52 @@ type ListInstancesRequest struct {
 
 // CompleteTaskRequest 完成任务请求
 type CompleteTaskRequest struct {
-	TaskID    uint                   `json:"taskId" binding:"required"`
-	UserID    uint                   `json:"userId" binding:"required"`
-	Action    string                 `json:"action" binding:"required"` // approve, reject
-	Comment   string                 `json:"comment"`
-	Variables map[string]interface{} `json:"variables"`
+	TaskID       uint                   `json:"taskId" binding:"required"`
+	UserID       uint                   `json:"userId" binding:"required"`
+	Action       string                 `json:"action" binding:"required"` // approve, reject, back, backToStarter
+	TargetNodeID uint                   `json:"targetNodeId"`              // 退回的目标节点（action 为 back 时可选，默认上一个用户节点）
+	Comment      string                 `json:"comment"`
+	Variables    map[string]interface{} `json:"variables"`
 }
 
 // service 工作流服务实现
 type service struct {
-	db            *gorm.DB
-	actionService action.Service
+	db             *gorm.DB
+	actionService  action.Service
+	messageService message.Service // 发送到期提醒和超时通知
+	crudService    crud.Service    // 审批结束时回写业务单据
+	jobConfig      *JobConfig      // 自动任务作业默认配置
 }
 
 // NewService 创建工作流服务
-func NewService(db *gorm.DB, actionService action.Service) Service {
+func NewService(db *gorm.DB, actionService action.Service, messageService message.Service, crudService crud.Service, jobConfig *JobConfig) Service {
+	if jobConfig == nil {
+		jobConfig = &JobConfig{}
+	}
 	return &service{
-		db:            db,
-		actionService: actionService,
+		db:             db,
+		actionService:  actionService,
+		messageService: messageService,
+		crudService:    crudService,
+		jobConfig:      jobConfig.withDefaults(),
 	}
 }
 
-// CreateDefinition 创建流程定义
+// CreateDefinition 创建流程定义（版本1的草稿，已有流程的新版本通过 CreateVersion 创建）
 func (s *service) CreateDefinition(ctx context.Context, def *entity.WfDefinition) error {
-	if def.Version == 0 {
-		def.Version = 1
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&entity.WfDefinition{}).
+		Where("NAME = ? AND IS_ACTIVE = ?", def.Name, "Y").
+		Count(&count).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "查询流程定义失败", err)
 	}
-	if def.Status == "" {
-		def.Status = "draft"
+	if count > 0 {
+		return errors.New(errors.ErrResourceExists, fmt.Sprintf("流程 %s 已存在，请创建新版本", def.Name))
 	}
 
+	def.Version = 1
+	def.Status = DefinitionDraft
+
 	if err := s.db.WithContext(ctx).Create(def).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "创建流程定义失败", err)
 	}
@@ -127,10 +168,15 @@ func (s *service) UpdateDefinition(ctx context.Context, def *entity.WfDefinition
 		return errors.Wrap(errors.ErrDatabase, "查询流程定义失败", err)
 	}
 
-	if existing.Status != "draft" {
+	if existing.Status != DefinitionDraft {
 		return errors.New(errors.ErrValidation, "只能更新草稿状态的流程定义")
 	}
 
+	// 名称标识同一流程的各个版本，版本号和状态由发布维护
+	def.Name = existing.Name
+	def.Version = existing.Version
+	def.Status = existing.Status
+
 	if err := s.db.WithContext(ctx).Save(def).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "更新流程定义失败", err)
 	}
@@ -146,7 +192,7 @@ func (s *service) PublishDefinition(ctx context.Context, id uint) error {
 		return err
 	}
 
-	if def.Status != "draft" {
+	if def.Status != DefinitionDraft {
 		return errors.New(errors.ErrValidation, "只能发布草稿状态的流程定义")
 	}
 
@@ -170,14 +216,42 @@ func (s *service) PublishDefinition(ctx context.Context, id uint) error {
 		return errors.New(errors.ErrValidation, "流程定义必须包含开始节点和结束节点")
 	}
 
-	// 更新状态为已发布
-	if err := s.db.WithContext(ctx).Model(&entity.WfDefinition{}).
-		Where("ID = ?", id).
-		Update("STATUS", "published").Error; err != nil {
-		return errors.Wrap(errors.ErrDatabase, "发布流程定义失败", err)
+	// 验证用户任务的分配配置和节点配置
+	for _, node := range nodes {
+		if err := validateAssignee(node); err != nil {
+			return err
+		}
+		if err := validateNodeConfig(node); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	// 验证流转条件表达式语法
+	transitions, err := s.GetTransitions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := validateConditions(transitions); err != nil {
+		return err
+	}
+	if err := validateGateways(nodes, transitions); err != nil {
+		return err
+	}
+
+	// 发布为不可修改的版本，同一流程之前发布的版本归档（运行中的实例继续使用原版本）
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.WfDefinition{}).
+			Where("NAME = ? AND ID <> ? AND STATUS = ?", def.Name, id, DefinitionPublished).
+			Update("STATUS", DefinitionArchived).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "归档旧版本失败", err)
+		}
+		if err := tx.Model(&entity.WfDefinition{}).
+			Where("ID = ?", id).
+			Update("STATUS", DefinitionPublished).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "发布流程定义失败", err)
+		}
+		return nil
+	})
 }
 
 // ListDefinitions 查询流程定义列表
@@ -210,6 +284,10 @@ func (s *service) ListDefinitions(ctx context.Context, status string, page, page
 
 // CreateNode 创建流程节点
 func (s *service) CreateNode(ctx context.Context, node *entity.WfNode) error {
+	if err := s.checkDraft(ctx, node.WfDefinitionID); err != nil {
+		return err
+	}
+
 	if err := s.db.WithContext(ctx).Create(node).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "创建流程节点失败", err)
 	}
@@ -217,6 +295,18 @@ func (s *service) CreateNode(ctx context.Context, node *entity.WfNode) error {
 	return nil
 }
 
+// getNode 获取流程节点
+func (s *service) getNode(ctx context.Context, id uint) (*entity.WfNode, error) {
+	var node entity.WfNode
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", id, "Y").First(&node).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrResourceNotFound, "流程节点不存在")
+		}
+		return nil, errors.Wrap(errors.ErrDatabase, "查询流程节点失败", err)
+	}
+	return &node, nil
+}
+
 // GetNodes 获取流程节点列表
 func (s *service) GetNodes(ctx context.Context, definitionID uint) ([]*entity.WfNode, error) {
 	var nodes []*entity.WfNode
@@ -231,6 +321,15 @@ func (s *service) GetNodes(ctx context.Context, definitionID uint) ([]*entity.Wf
 
 // UpdateNode 更新流程节点
 func (s *service) UpdateNode(ctx context.Context, node *entity.WfNode) error {
+	existing, err := s.getNode(ctx, node.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDraft(ctx, existing.WfDefinitionID); err != nil {
+		return err
+	}
+	node.WfDefinitionID = existing.WfDefinitionID
+
 	if err := s.db.WithContext(ctx).Save(node).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "更新流程节点失败", err)
 	}
@@ -240,6 +339,14 @@ func (s *service) UpdateNode(ctx context.Context, node *entity.WfNode) error {
 
 // DeleteNode 删除流程节点
 func (s *service) DeleteNode(ctx context.Context, id uint) error {
+	node, err := s.getNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDraft(ctx, node.WfDefinitionID); err != nil {
+		return err
+	}
+
 	if err := s.db.WithContext(ctx).Model(&entity.WfNode{}).
 		Where("ID = ?", id).
 		Update("IS_ACTIVE", "N").Error; err != nil {
@@ -251,6 +358,10 @@ func (s *service) DeleteNode(ctx context.Context, id uint) error {
 
 // CreateTransition 创建流程流转
 func (s *service) CreateTransition(ctx context.Context, transition *entity.WfTransition) error {
+	if err := s.checkDraft(ctx, transition.WfDefinitionID); err != nil {
+		return err
+	}
+
 	if err := s.db.WithContext(ctx).Create(transition).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "创建流程流转失败", err)
 	}
@@ -273,6 +384,17 @@ func (s *service) GetTransitions(ctx context.Context, definitionID uint) ([]*ent
 
 // DeleteTransition 删除流程流转
 func (s *service) DeleteTransition(ctx context.Context, id uint) error {
+	var transition entity.WfTransition
+	if err := s.db.WithContext(ctx).Where("ID = ? AND IS_ACTIVE = ?", id, "Y").First(&transition).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrResourceNotFound, "流程流转不存在")
+		}
+		return errors.Wrap(errors.ErrDatabase, "查询流程流转失败", err)
+	}
+	if err := s.checkDraft(ctx, transition.WfDefinitionID); err != nil {
+		return err
+	}
+
 	if err := s.db.WithContext(ctx).Model(&entity.WfTransition{}).
 		Where("ID = ?", id).
 		Update("IS_ACTIVE", "N").Error; err != nil {
@@ -284,13 +406,17 @@ func (s *service) DeleteTransition(ctx context.Context, id uint) error {
 
 // StartProcess 启动流程
 func (s *service) StartProcess(ctx context.Context, req *StartProcessRequest) (*entity.WfInstance, error) {
+	if err := validateVariables(req.Variables); err != nil {
+		return nil, err
+	}
+
 	// 获取流程定义
 	def, err := s.GetDefinition(ctx, req.DefinitionID)
 	if err != nil {
 		return nil, err
 	}
 
-	if def.Status != "published" {
+	if def.Status != DefinitionPublished {
 		return nil, errors.New(errors.ErrValidation, "只能启动已发布的流程定义")
 	}
 
@@ -329,20 +455,37 @@ func (s *service) StartProcess(ctx context.Context, req *StartProcessRequest) (*
 	}
 	instance.IsActive = "Y"
 
-	if err := s.db.WithContext(ctx).Create(instance).Error; err != nil {
-		return nil, errors.Wrap(errors.ErrDatabase, "创建流程实例失败", err)
-	}
+	// 创建实例和推进到第一个等待节点在同一事务中完成，自动任务失败时整体回滚
+	err = s.inTransaction(ctx, func(txs *service) error {
+		if err := txs.db.WithContext(ctx).Create(instance).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建流程实例失败", err)
+		}
+
+		if err := txs.recordVariables(ctx, &entity.WfHistory{
+			WfInstanceID: instance.ID,
+			WfNodeID:     startNode.ID,
+			OperatorID:   req.StartUserID,
+		}, nil, req.Variables); err != nil {
+			return err
+		}
 
-	// 移动到下一个节点
-	if err := s.moveToNext(ctx, instance, startNode, req.Variables); err != nil {
+		// 创建开始节点令牌并移动到下一个节点
+		token, err := txs.createToken(ctx, instance, startNode, 0, 0)
+		if err != nil {
+			return err
+		}
+		return txs.moveToNext(ctx, instance, token, startNode, req.Variables)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return instance, nil
 }
 
-// moveToNext 移动到下一个节点
-func (s *service) moveToNext(ctx context.Context, instance *entity.WfInstance, currentNode *entity.WfNode, variables map[string]interface{}) error {
+// moveToNext 令牌离开当前节点，移动到下一个节点
+// 并行分叉节点激活所有满足条件的流转，其他节点取第一个满足条件的流转
+func (s *service) moveToNext(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, currentNode *entity.WfNode, variables map[string]interface{}) error {
 	// 获取当前节点的所有流转
 	transitions, err := s.GetTransitions(ctx, instance.WfDefinitionID)
 	if err != nil {
@@ -358,26 +501,23 @@ func (s *service) moveToNext(ctx context.Context, instance *entity.WfInstance, c
 
 	if len(nextTransitions) == 0 {
 		// 没有后续流转,检查是否是结束节点
-		if currentNode.NodeType == "end" {
-			// 流程结束
-			instance.Status = "completed"
-			instance.EndTime = time.Now()
-			return s.db.WithContext(ctx).Save(instance).Error
+		if currentNode.NodeType == NodeTypeEnd {
+			if err := s.leaveToken(ctx, token); err != nil {
+				return err
+			}
+			return s.completeIfFinished(ctx, instance)
 		}
 		return errors.New(errors.ErrValidation, "流程定义错误：节点没有后续流转")
 	}
 
-	// 找到符合条件的第一个流转
-	var nextTransition *entity.WfTransition
-	for _, t := range nextTransitions {
-		if s.evaluateCondition(t.Condition, variables) {
-			nextTransition = t
-			break
-		}
+	if currentNode.NodeType == NodeTypeFork {
+		return s.fork(ctx, instance, token, nextTransitions, variables)
 	}
 
-	if nextTransition == nil {
-		return errors.New(errors.ErrValidation, "没有符合条件的流转")
+	// 找到符合条件的第一个流转（条件可引用流程变量和业务记录）
+	nextTransition, err := s.selectTransition(ctx, instance, nextTransitions, variables)
+	if err != nil {
+		return err
 	}
 
 	// 获取下一个节点
@@ -386,99 +526,135 @@ func (s *service) moveToNext(ctx context.Context, instance *entity.WfInstance, c
 		return errors.Wrap(errors.ErrDatabase, "查询下一个节点失败", err)
 	}
 
-	// 更新实例当前节点
-	instance.CurrentNodeID = nextNode.ID
-	if err := s.db.WithContext(ctx).Save(instance).Error; err != nil {
+	if err := s.leaveToken(ctx, token); err != nil {
 		return err
 	}
 
-	// 根据节点类型执行操作
-	switch nextNode.NodeType {
-	case "user":
-		// 创建用户任务
-		return s.createUserTask(ctx, instance, &nextNode)
-	case "auto":
-		// 执行自动任务
-		return s.executeAutoTask(ctx, instance, &nextNode, variables)
-	case "end":
-		// 流程结束
-		instance.Status = "completed"
-		instance.EndTime = time.Now()
-		return s.db.WithContext(ctx).Save(instance).Error
-	default:
-		// 继续流转
-		return s.moveToNext(ctx, instance, &nextNode, variables)
-	}
+	return s.enterNode(ctx, instance, nextTransition, &nextNode, token.ForkID, variables)
 }
 
-// evaluateCondition 评估流转条件
-func (s *service) evaluateCondition(condition string, variables map[string]interface{}) bool {
-	// 如果没有条件,默认为true
-	if condition == "" {
-		return true
+// createUserTask 创建用户任务
+// 会签节点为每个处理人各创建一个任务；普通节点只有一个候选人时直接分配，多个候选人时创建候选组任务，由候选人签收
+func (s *service) createUserTask(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode) error {
+	// 获取任务候选人
+	candidates, err := s.resolveCandidates(ctx, node, instance)
+	if err != nil {
+		return err
 	}
 
-	// TODO: 实现复杂的条件表达式评估
-	// 简单实现：支持 变量名==值 的格式
-	// 生产环境应使用表达式引擎如 govaluate
-	return true
-}
+	cfg, err := parseNodeConfig(node)
+	if err != nil {
+		return err
+	}
 
-// createUserTask 创建用户任务
-func (s *service) createUserTask(ctx context.Context, instance *entity.WfInstance, node *entity.WfNode) error {
-	// 获取任务执行人
-	assigneeID, err := s.getAssignee(ctx, node, instance)
+	def, err := s.GetDefinition(ctx, instance.WfDefinitionID)
 	if err != nil {
 		return err
 	}
 
-	task := &entity.WfTask{
-		WfInstanceID: instance.ID,
-		WfNodeID:     node.ID,
-		AssigneeID:   assigneeID,
-		Status:       "pending",
-		Variables:    instance.Variables,
+	now := time.Now()
+	if cfg.MultiInstance != nil {
+		// 会签任务逐个按委托规则分配，受托人代委托人投票
+		tasks := make([]*entity.WfTask, 0, len(candidates))
+		for _, userID := range candidates {
+			task := newTask(instance, token, node, userID)
+			applySLA(task, cfg.SLA, now)
+			if err := s.delegatedTask(ctx, task, def.Name, now); err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		if err := s.db.WithContext(ctx).Create(&tasks).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建会签任务失败", err)
+		}
+		for _, task := range tasks {
+			if task.OriginalAssigneeID == 0 {
+				continue
+			}
+			if err := s.recordDelegation(ctx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 候选组中在委托期间的候选人由受托人代替，候选人记录委托人
+	var rows []*entity.WfTaskCandidate
+	if len(candidates) > 1 {
+		rows, err = s.delegateCandidates(ctx, candidates, def.Name, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	task := newTask(instance, token, node, 0)
+	applySLA(task, cfg.SLA, now)
+	switch {
+	case len(candidates) == 1:
+		task.AssigneeID = candidates[0]
+		if err := s.delegatedTask(ctx, task, def.Name, now); err != nil {
+			return err
+		}
+	case len(rows) == 1:
+		// 代替后只剩一个候选人时直接分配给受托人，与直接分配一样记录委托人
+		task.AssigneeID = rows[0].UserID
+		task.OriginalAssigneeID = rows[0].OriginalUserID
+		rows = nil
 	}
-	task.IsActive = "Y"
 
 	if err := s.db.WithContext(ctx).Create(task).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "创建任务失败", err)
 	}
+	if task.OriginalAssigneeID != 0 {
+		if err := s.recordDelegation(ctx, task); err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 {
+		for _, candidate := range rows {
+			candidate.WfTaskID = task.ID
+		}
+		if err := s.db.WithContext(ctx).Create(&rows).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "创建任务候选人失败", err)
+		}
+	}
 
 	return nil
 }
 
-// getAssignee 获取任务执行人
-func (s *service) getAssignee(ctx context.Context, node *entity.WfNode, instance *entity.WfInstance) (uint, error) {
-	switch node.AssignType {
-	case "user":
-		// 直接指定用户ID
-		var assigneeID uint
-		fmt.Sscanf(node.AssignValue, "%d", &assigneeID)
-		return assigneeID, nil
-	case "starter":
-		// 流程发起人
-		return instance.StartUserID, nil
-	default:
-		// TODO: 支持更多分配类型: role, expression等
-		return 0, errors.New(errors.ErrValidation, "不支持的任务分配类型")
+// newTask 构建待处理任务
+func newTask(instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode, assigneeID uint) *entity.WfTask {
+	task := &entity.WfTask{
+		WfInstanceID: instance.ID,
+		WfNodeID:     node.ID,
+		WfTokenID:    token.ID,
+		AssigneeID:   assigneeID,
+		Status:       "pending",
+		Variables:    instance.Variables,
 	}
+	task.IsActive = "Y"
+	return task
 }
 
-// executeAutoTask 执行自动任务
-func (s *service) executeAutoTask(ctx context.Context, instance *entity.WfInstance, node *entity.WfNode, variables map[string]interface{}) error {
+// executeAutoTask 为自动任务创建作业，由后台异步执行动作
+// 令牌停留在节点上，作业完成后继续流转
+func (s *service) executeAutoTask(ctx context.Context, instance *entity.WfInstance, token *entity.WfToken, node *entity.WfNode) error {
 	if node.ActionID == 0 {
 		return errors.New(errors.ErrValidation, "自动任务必须配置动作")
 	}
 
-	// 执行关联的动作
-	_, err := s.actionService.ExecuteAction(ctx, node.ActionID, variables, instance.StartUserID)
+	cfg, err := parseNodeConfig(node)
 	if err != nil {
 		return err
 	}
 
-	// 继续流转到下一个节点
-	return s.moveToNext(ctx, instance, node, variables)
+	job := s.jobConfig.newJob(instance, token, node, cfg.AutoTask)
+	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "创建自动任务作业失败", err)
+	}
+
+	return nil
 }
 
 // GetInstance 获取流程实例
@@ -530,23 +706,29 @@ func (s *service) ListInstances(ctx context.Context, req *ListInstancesRequest)
 
 // TerminateInstance 终止流程实例
 func (s *service) TerminateInstance(ctx context.Context, id uint, userID uint) error {
-	instance, err := s.GetInstance(ctx, id)
-	if err != nil {
-		return err
-	}
+	return s.inTransaction(ctx, func(txs *service) error {
+		instance, err := txs.lockInstance(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if instance.Status != "running" && instance.Status != "suspended" {
-		return errors.New(errors.ErrValidation, "只能终止运行中或挂起的流程")
-	}
+		if instance.Status != "running" && instance.Status != "suspended" {
+			return errors.New(errors.ErrValidation, "只能终止运行中或挂起的流程")
+		}
 
-	instance.Status = "terminated"
-	instance.EndTime = time.Now()
+		if err := txs.cancelExecution(ctx, instance.ID); err != nil {
+			return err
+		}
 
-	if err := s.db.WithContext(ctx).Save(instance).Error; err != nil {
-		return errors.Wrap(errors.ErrDatabase, "终止流程失败", err)
-	}
+		instance.Status = "terminated"
+		instance.EndTime = time.Now()
 
-	return nil
+		if err := txs.db.WithContext(ctx).Save(instance).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "终止流程失败", err)
+		}
+
+		return txs.finishBusiness(ctx, instance, crud.ApprovalTerminated)
+	})
 }
 
 // SuspendInstance 挂起流程实例
@@ -593,8 +775,12 @@ func (s *service) ListMyTasks(ctx context.Context, userID uint, status string, p
 		pageSize = 20
 	}
 
+	// 包括分配给自己的任务、委托给他人处理的任务和自己（或自己的受托人）作为候选人、尚未被签收的任务
 	query := s.db.WithContext(ctx).Model(&entity.WfTask{}).
-		Where("ASSIGNEE_ID = ? AND IS_ACTIVE = ?", userID, "Y")
+		Where("IS_ACTIVE = ?", "Y").
+		Where("ASSIGNEE_ID = ? OR ORIGINAL_ASSIGNEE_ID = ? OR (ASSIGNEE_ID = 0 AND ID IN (?))", userID, userID,
+			s.db.Model(&entity.WfTaskCandidate{}).Select("WF_TASK_ID").
+				Where("(USER_ID = ? OR ORIGINAL_USER_ID = ?) AND IS_ACTIVE = ?", userID, userID, "Y"))
 
 	if status != "" {
 		query = query.Where("STATUS = ?", status)
@@ -616,31 +802,45 @@ func (s *service) ListMyTasks(ctx context.Context, userID uint, status string, p
 
 // CompleteTask 完成任务
 func (s *service) CompleteTask(ctx context.Context, req *CompleteTaskRequest) error {
-	// 获取任务
-	task, err := s.GetTask(ctx, req.TaskID)
-	if err != nil {
+	if err := validateTaskAction(req.Action); err != nil {
 		return err
 	}
-
-	// 验证任务执行人
-	if task.AssigneeID != req.UserID {
-		return errors.New(errors.ErrPermissionDenied, "只能完成分配给自己的任务")
+	if err := validateVariables(req.Variables); err != nil {
+		return err
 	}
 
-	if task.Status != "pending" {
-		return errors.New(errors.ErrValidation, "任务已处理")
-	}
+	// 锁定流程实例，同一实例的任务串行处理，避免两个处理人同时推进流程
+	return s.inTransaction(ctx, func(txs *service) error {
+		task, instance, err := txs.lockTaskInstance(ctx, req.TaskID)
+		if err != nil {
+			return err
+		}
 
-	// 获取流程实例
-	instance, err := s.GetInstance(ctx, task.WfInstanceID)
-	if err != nil {
-		return err
-	}
+		// 验证任务执行人（候选组任务需先签收）
+		if task.AssigneeID == 0 {
+			return errors.New(errors.ErrValidation, "请先签收任务")
+		}
+		if task.AssigneeID != req.UserID {
+			if task.OriginalAssigneeID == req.UserID {
+				return errors.New(errors.ErrPermissionDenied, "任务已委托给他人处理")
+			}
+			return errors.New(errors.ErrPermissionDenied, "只能完成分配给自己的任务")
+		}
 
-	if instance.Status != "running" {
-		return errors.New(errors.ErrValidation, "流程实例不在运行状态")
-	}
+		if task.Status != "pending" {
+			return errors.New(errors.ErrResourceConflict, "任务已处理")
+		}
+
+		if instance.Status != "running" {
+			return errors.New(errors.ErrValidation, "流程实例不在运行状态")
+		}
+
+		return txs.completeTask(ctx, task, instance, req)
+	})
+}
 
+// completeTask 完成任务并推进流程（处理人校验由调用方完成，超时自动处理也走这里）
+func (s *service) completeTask(ctx context.Context, task *entity.WfTask, instance *entity.WfInstance, req *CompleteTaskRequest) error {
 	// 更新任务状态
 	task.Status = "completed"
 	task.Action = req.Action
@@ -651,19 +851,22 @@ func (s *service) CompleteTask(ctx context.Context, req *CompleteTaskRequest) er
 		return errors.Wrap(errors.ErrDatabase, "更新任务失败", err)
 	}
 
-	// 如果是拒绝,终止流程
-	if req.Action == "reject" {
-		instance.Status = "terminated"
-		instance.EndTime = time.Now()
-		return s.db.WithContext(ctx).Save(instance).Error
-	}
-
 	// 获取当前节点
 	var currentNode entity.WfNode
 	if err := s.db.WithContext(ctx).First(&currentNode, task.WfNodeID).Error; err != nil {
 		return errors.Wrap(errors.ErrDatabase, "查询当前节点失败", err)
 	}
 
+	cfg, err := parseNodeConfig(&currentNode)
+	if err != nil {
+		return err
+	}
+
+	token, err := s.taskToken(ctx, instance, task)
+	if err != nil {
+		return err
+	}
+
 	// 合并变量
 	var instanceVars map[string]interface{}
 	if instance.Variables != "" {
@@ -672,79 +875,193 @@ func (s *service) CompleteTask(ctx context.Context, req *CompleteTaskRequest) er
 	if instanceVars == nil {
 		instanceVars = make(map[string]interface{})
 	}
+	before := make(map[string]interface{}, len(instanceVars))
+	for k, v := range instanceVars {
+		before[k] = v
+	}
 	for k, v := range req.Variables {
 		instanceVars[k] = v
 	}
+	recordActionVariables(instanceVars, req.Action, &currentNode)
 
 	// 更新实例变量
 	variablesJSON, _ := json.Marshal(instanceVars)
 	instance.Variables = string(variablesJSON)
-	s.db.WithContext(ctx).Save(instance)
+	if err := s.db.WithContext(ctx).Save(instance).Error; err != nil {
+		return errors.Wrap(errors.ErrDatabase, "更新流程变量失败", err)
+	}
+	if err := s.recordVariables(ctx, &entity.WfHistory{
+		WfInstanceID: instance.ID,
+		WfTaskID:     task.ID,
+		WfNodeID:     currentNode.ID,
+		OperatorID:   req.UserID,
+		Action:       req.Action,
+	}, before, instanceVars); err != nil {
+		return err
+	}
 
-	// 继续流转
-	return s.moveToNext(ctx, instance, &currentNode, instanceVars)
-}
+	// 退回不参与会签汇总，直接在目标节点重建任务
+	if req.Action == ActionBack || req.Action == ActionBackToStarter {
+		return s.sendBack(ctx, instance, task, token, &currentNode, req, instanceVars)
+	}
 
-// ClaimTask 签收任务
-func (s *service) ClaimTask(ctx context.Context, taskID, userID uint) error {
-	task, err := s.GetTask(ctx, taskID)
-	if err != nil {
-		return err
+	// 计算节点结果：会签节点按完成规则汇总，普通节点取本次操作
+	outcome := outcomeApproved
+	if req.Action == ActionReject {
+		outcome = outcomeRejected
+	}
+	if cfg.MultiInstance != nil {
+		if outcome, err = s.countersignOutcome(ctx, token, cfg.MultiInstance); err != nil {
+			return err
+		}
 	}
 
-	if task.AssigneeID != userID {
-		return errors.New(errors.ErrPermissionDenied, "只能签收分配给自己的任务")
+	switch outcome {
+	case outcomePending:
+		// 会签尚未结束，等待其他处理人
+		return nil
+	case outcomeRejected:
+		// 驳回，终止流程
+		if err := s.cancelExecution(ctx, instance.ID); err != nil {
+			return err
+		}
+		instance.Status = "terminated"
+		instance.EndTime = time.Now()
+		if err := s.db.WithContext(ctx).Save(instance).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "更新流程实例失败", err)
+		}
+		return s.finishBusiness(ctx, instance, crud.ApprovalRejected)
 	}
 
-	if task.Status != "pending" {
-		return errors.New(errors.ErrValidation, "任务已处理")
+	// 会签通过后取消其余未处理的任务
+	if cfg.MultiInstance != nil {
+		if err := s.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Where("WF_TOKEN_ID = ? AND STATUS = ?", token.ID, "pending").
+			Update("STATUS", "canceled").Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "取消会签任务失败", err)
+		}
 	}
 
-	task.ClaimTime = time.Now()
+	// 继续流转
+	return s.moveToNext(ctx, instance, token, &currentNode, instanceVars)
+}
 
-	if err := s.db.WithContext(ctx).Save(task).Error; err != nil {
-		return errors.Wrap(errors.ErrDatabase, "签收任务失败", err)
+// countersignOutcome 统计同一次节点访问的会签任务，计算会签结果
+// 只有同意和拒绝（含超时自动同意、拒绝）计入会签结果，退回、撤回等其他动作不计入
+func (s *service) countersignOutcome(ctx context.Context, token *entity.WfToken, cfg *MultiInstanceConfig) (string, error) {
+	var tasks []*entity.WfTask
+	if err := s.db.WithContext(ctx).
+		Where("WF_TOKEN_ID = ? AND STATUS IN ?", token.ID, []string{"pending", "completed"}).
+		Find(&tasks).Error; err != nil {
+		return "", errors.Wrap(errors.ErrDatabase, "查询会签任务失败", err)
+	}
+
+	approved, rejected, pending := 0, 0, 0
+	for _, t := range tasks {
+		switch {
+		case t.Status == "pending":
+			pending++
+		case t.Action == ActionApprove || t.Action == EscalationApprove:
+			approved++
+		case t.Action == ActionReject || t.Action == EscalationReject:
+			rejected++
+		}
 	}
 
-	return nil
+	return cfg.outcome(approved, rejected, pending), nil
 }
 
-// TransferTask 转交任务
-func (s *service) TransferTask(ctx context.Context, taskID, fromUserID, toUserID uint, comment string) error {
+// ClaimTask 签收任务
+// 候选组任务签收后锁定给签收人，其他候选人不再可见
+func (s *service) ClaimTask(ctx context.Context, taskID, userID uint) error {
 	task, err := s.GetTask(ctx, taskID)
 	if err != nil {
 		return err
 	}
 
-	if task.AssigneeID != fromUserID {
-		return errors.New(errors.ErrPermissionDenied, "只能转交自己的任务")
-	}
-
 	if task.Status != "pending" {
 		return errors.New(errors.ErrValidation, "任务已处理")
 	}
 
-	task.AssigneeID = toUserID
-	task.Status = "transferred"
-	task.Comment = comment
+	if task.AssigneeID != 0 {
+		if task.AssigneeID != userID {
+			return errors.New(errors.ErrPermissionDenied, "只能签收分配给自己的任务")
+		}
 
-	if err := s.db.WithContext(ctx).Save(task).Error; err != nil {
-		return errors.Wrap(errors.ErrDatabase, "转交任务失败", err)
+		task.ClaimTime = time.Now()
+		if err := s.db.WithContext(ctx).Save(task).Error; err != nil {
+			return errors.Wrap(errors.ErrDatabase, "签收任务失败", err)
+		}
+		return nil
 	}
 
-	// 创建新任务
-	newTask := &entity.WfTask{
-		WfInstanceID: task.WfInstanceID,
-		WfNodeID:     task.WfNodeID,
-		AssigneeID:   toUserID,
-		Status:       "pending",
-		Variables:    task.Variables,
-	}
-	newTask.IsActive = "Y"
+	// 候选组任务：校验候选人身份
+	var candidate entity.WfTaskCandidate
+	if err := s.db.WithContext(ctx).
+		Where("WF_TASK_ID = ? AND USER_ID = ? AND IS_ACTIVE = ?", taskID, userID, "Y").
+		Take(&candidate).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrPermissionDenied, "不是该任务的候选人")
+		}
+		return errors.Wrap(errors.ErrDatabase, "查询任务候选人失败", err)
+	}
+
+	// 受托人代委托人签收时，与直接分配一样记录委托人和委托历史
+	return s.inTransaction(ctx, func(txs *service) error {
+		// 条件更新保证只有一个候选人签收成功
+		result := txs.db.WithContext(ctx).Model(&entity.WfTask{}).
+			Where("ID = ? AND ASSIGNEE_ID = ? AND STATUS = ?", taskID, 0, "pending").
+			Updates(map[string]interface{}{
+				"ASSIGNEE_ID":          userID,
+				"ORIGINAL_ASSIGNEE_ID": candidate.OriginalUserID,
+				"CLAIM_TIME":           time.Now(),
+			})
+		if result.Error != nil {
+			return errors.Wrap(errors.ErrDatabase, "签收任务失败", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(errors.ErrResourceConflict, "任务已被他人签收")
+		}
+
+		if candidate.OriginalUserID == 0 {
+			return nil
+		}
+		task.AssigneeID = userID
+		task.OriginalAssigneeID = candidate.OriginalUserID
+		return txs.recordDelegation(ctx, task)
+	})
+}
 
-	if err := s.db.WithContext(ctx).Create(newTask).Error; err != nil {
-		return errors.Wrap(errors.ErrDatabase, "创建转交任务失败", err)
+// GetTaskCandidates 获取任务候选人
+func (s *service) GetTaskCandidates(ctx context.Context, taskID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := s.db.WithContext(ctx).Model(&entity.WfTaskCandidate{}).
+		Where("WF_TASK_ID = ? AND IS_ACTIVE = ?", taskID, "Y").
+		Order("USER_ID ASC").
+		Pluck("USER_ID", &userIDs).Error; err != nil {
+		return nil, errors.Wrap(errors.ErrDatabase, "查询任务候选人失败", err)
 	}
 
-	return nil
+	return userIDs, nil
+}
+
+// TransferTask 转交任务
+func (s *service) TransferTask(ctx context.Context, taskID, fromUserID, toUserID uint, comment string) error {
+	return s.inTransaction(ctx, func(txs *service) error {
+		task, _, err := txs.lockTaskInstance(ctx, taskID)
+		if err != nil {
+			return err
+		}
+
+		if task.AssigneeID != fromUserID {
+			return errors.New(errors.ErrPermissionDenied, "只能转交自己的任务")
+		}
+
+		if task.Status != "pending" {
+			return errors.New(errors.ErrResourceConflict, "任务已处理")
+		}
+
+		_, err = txs.handOver(ctx, task, toUserID, comment)
+		return err
+	})
 }
@@ -154,6 +154,11 @@ func (p *SysTableAfterCreatePlugin) Execute(ctx context.Context, db *gorm.DB, da
 	var pkColumnID uint
 	columns := p.getStandardColumns(tableID, tableName, data)
 
+	// 单据表（MASK 包含提交或作废）追加单据状态字段
+	if strings.ContainsAny(mask, "SV") {
+		columns = append(columns, p.getLifecycleColumns(tableID, tableName, data)...)
+	}
+
 	for _, column := range columns {
 		if err := db.WithContext(ctx).Table("sys_column").Create(&column).Error; err != nil {
 			return fmt.Errorf("创建标准字段失败 [%s]: %v", column["DB_NAME"], err)
@@ -366,3 +371,124 @@ func (p *SysTableAfterCreatePlugin) getStandardColumns(tableID uint, tableName s
 		},
 	}
 }
+
+// getLifecycleColumns 获取单据状态字段定义
+// 字段由提交/反提交/作废操作和审批流程维护，表单中只读
+func (p *SysTableAfterCreatePlugin) getLifecycleColumns(tableID uint, tableName string, data core.PluginData) []map[string]interface{} {
+	now := time.Now()
+	companyID := data.CompanyID
+	createBy := data.Data["CREATE_BY"]
+
+	return []map[string]interface{}{
+		// 1. DOC_STATUS 单据状态
+		{
+			"DISPLAY_NAME":   tableName + ".DOC_STATUS",
+			"DB_NAME":        "DOC_STATUS",
+			"FULL_NAME":      tableName + ".DOC_STATUS",
+			"DESCRIPTION":    "单据状态",
+			"COL_TYPE":       "varchar",
+			"COL_LENGTH":     20,
+			"SYS_TABLE_ID":   tableID,
+			"ORDERNO":        1005,
+			"NULL_ABLE":      "Y",
+			"MASK":           "0010100110",
+			"SET_VALUE_TYPE": "ignore", // 由单据操作赋值
+			"DEFAULT_VALUE":  "draft",
+			"MODIFI_ABLE":    "N",
+			"DISPLAY_TYPE":   "text",
+			"IS_QUERY":       "Y",
+			"IS_SHOW_TITLE":  "Y",
+			"IS_ACTIVE":      "Y",
+			"CREATE_BY":      createBy,
+			"CREATE_TIME":    now,
+			"SYS_COMPANY_ID": companyID,
+		},
+
+		// 2. SUBMIT_BY 提交人
+		{
+			"DISPLAY_NAME":   tableName + ".SUBMIT_BY",
+			"DB_NAME":        "SUBMIT_BY",
+			"FULL_NAME":      tableName + ".SUBMIT_BY",
+			"DESCRIPTION":    "提交人",
+			"COL_TYPE":       "varchar",
+			"COL_LENGTH":     80,
+			"SYS_TABLE_ID":   tableID,
+			"ORDERNO":        1006,
+			"NULL_ABLE":      "Y",
+			"MASK":           "0010100110",
+			"SET_VALUE_TYPE": "ignore",
+			"MODIFI_ABLE":    "N",
+			"DISPLAY_TYPE":   "text",
+			"IS_SHOW_TITLE":  "Y",
+			"IS_ACTIVE":      "Y",
+			"CREATE_BY":      createBy,
+			"CREATE_TIME":    now,
+			"SYS_COMPANY_ID": companyID,
+		},
+
+		// 3. SUBMIT_TIME 提交时间
+		{
+			"DISPLAY_NAME":   tableName + ".SUBMIT_TIME",
+			"DB_NAME":        "SUBMIT_TIME",
+			"FULL_NAME":      tableName + ".SUBMIT_TIME",
+			"DESCRIPTION":    "提交时间",
+			"COL_TYPE":       "datetime",
+			"SYS_TABLE_ID":   tableID,
+			"ORDERNO":        1007,
+			"NULL_ABLE":      "Y",
+			"MASK":           "0010100110",
+			"SET_VALUE_TYPE": "ignore",
+			"MODIFI_ABLE":    "N",
+			"DISPLAY_TYPE":   "datetime",
+			"IS_SHOW_TITLE":  "Y",
+			"IS_ACTIVE":      "Y",
+			"CREATE_BY":      createBy,
+			"CREATE_TIME":    now,
+			"SYS_COMPANY_ID": companyID,
+		},
+
+		// 4. APPROVAL_STATUS 审批状态（关联审批流程时由工作流维护）
+		{
+			"DISPLAY_NAME":   tableName + ".APPROVAL_STATUS",
+			"DB_NAME":        "APPROVAL_STATUS",
+			"FULL_NAME":      tableName + ".APPROVAL_STATUS",
+			"DESCRIPTION":    "审批状态",
+			"COL_TYPE":       "varchar",
+			"COL_LENGTH":     20,
+			"SYS_TABLE_ID":   tableID,
+			"ORDERNO":        1008,
+			"NULL_ABLE":      "Y",
+			"MASK":           "0010100110",
+			"SET_VALUE_TYPE": "ignore",
+			"MODIFI_ABLE":    "N",
+			"DISPLAY_TYPE":   "text",
+			"IS_QUERY":       "Y",
+			"IS_SHOW_TITLE":  "Y",
+			"IS_ACTIVE":      "Y",
+			"CREATE_BY":      createBy,
+			"CREATE_TIME":    now,
+			"SYS_COMPANY_ID": companyID,
+		},
+
+		// 5. WF_INSTANCE_ID 审批流程实例
+		{
+			"DISPLAY_NAME":   tableName + ".WF_INSTANCE_ID",
+			"DB_NAME":        "WF_INSTANCE_ID",
+			"FULL_NAME":      tableName + ".WF_INSTANCE_ID",
+			"DESCRIPTION":    "审批流程",
+			"COL_TYPE":       "int",
+			"SYS_TABLE_ID":   tableID,
+			"ORDERNO":        1009,
+			"NULL_ABLE":      "Y",
+			"MASK":           "0010100110",
+			"SET_VALUE_TYPE": "ignore",
+			"MODIFI_ABLE":    "N",
+			"DISPLAY_TYPE":   "text",
+			"IS_SHOW_TITLE":  "Y",
+			"IS_ACTIVE":      "Y",
+			"CREATE_BY":      createBy,
+			"CREATE_TIME":    now,
+			"SYS_COMPANY_ID": companyID,
+		},
+	}
+}
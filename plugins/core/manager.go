@@ -9,6 +9,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// WildcardTable 通配钩子点的表名
+const WildcardTable = "*"
+
 // Manager 插件管理器
 // 负责插件的注册、管理和执行
 type Manager struct {
@@ -28,6 +31,7 @@ func NewManager(db *gorm.DB) *Manager {
 // Register 注册插件到指定钩子点
 // hookPoint 格式：tableName.timing.action
 // 例如：sys_table.after.create, sys_user.before.update
+// tableName 为 * 时为通配钩子点，对所有表生效（如 *.after.submit），在表钩子点的插件之后执行
 func (m *Manager) Register(hookPoint string, plugin Plugin, metadata PluginMetadata) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -103,8 +107,12 @@ func (m *Manager) ExecuteWithDB(ctx context.Context, db *gorm.DB, data PluginDat
 	// 构建钩子点名称
 	hookPoint := fmt.Sprintf("%s.%s.%s", data.TableName, data.Timing, data.Action)
 
+	wildcard := fmt.Sprintf("%s.%s.%s", WildcardTable, data.Timing, data.Action)
+
 	m.mu.RLock()
-	plugins := m.plugins[hookPoint]
+	plugins := make([]*PluginInfo, 0, len(m.plugins[hookPoint])+len(m.plugins[wildcard]))
+	plugins = append(plugins, m.plugins[hookPoint]...)
+	plugins = append(plugins, m.plugins[wildcard]...)
 	m.mu.RUnlock()
 
 	if len(plugins) == 0 {
@@ -27,7 +27,7 @@ type PluginData struct {
 	// TableName 表名
 	TableName string `json:"tableName"`
 
-	// Action 操作类型: create, update, delete, query, submit, unsubmit
+	// Action 操作类型: create, update, delete, query, submit, unsubmit, void, approve, reject
 	Action string `json:"action"`
 
 	// Timing 执行时机: before, after
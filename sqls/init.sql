@@ -85,10 +85,10 @@ CREATE TABLE `sys_column`  (
   `SUBMETHOD` varchar(3) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '统计方法(sum:求和)',
   `FULL_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '字段全名',
   `MODIFI_ABLE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '允许界面修改',
-  `SET_VALUE_TYPE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '赋值方式(pk:pk,docno:单据编号,createBy:创建人,byPage:界面输入,select:下拉选项,fk:外键关联,sysdate:操作时间,operator:操作用户,ignore:忽略)',
+  `SET_VALUE_TYPE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '赋值方式(pk:pk,docno:单据编号,createBy:创建人,byPage:界面输入,select:下拉选项,fk:外键关联,sysdate:操作时间,operator:操作用户,password:密码,ignore:忽略)',
   `REF_TABLE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '关联表id',
   `REF_COLUMN_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '关联字段id',
-  `REF_ON_DELETE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '外键删除动作(noAction:无动作)',
+  `REF_ON_DELETE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '外键删除动作(noAction:无动作,restrict:限制,cascade:级联,setNull:置空)',
   `SEQ` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '单据编号生成器',
   `SYS_DICT_ID` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '数据字典',
   `DEFAULT_VALUE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '默认值',
@@ -185,6 +185,28 @@ CREATE TABLE `sys_dict_item`  (
 -- Records of sys_dict_item
 -- ----------------------------
 
+-- ----------------------------
+-- Table structure for sys_dept
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_dept`;
+CREATE TABLE `sys_dept`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '部门名称',
+  `PARENT_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '上级部门',
+  `MANAGER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '部门负责人',
+  `ORDERNO` int NULL DEFAULT NULL COMMENT '排序',
+  `DESCRIPTION` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_dept_parent`(`PARENT_ID` ASC) USING BTREE,
+  INDEX `idx_dept_manager`(`MANAGER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '部门' ROW_FORMAT = DYNAMIC;
+
 -- ----------------------------
 -- Table structure for sys_directory
 -- ----------------------------
@@ -223,7 +245,7 @@ CREATE TABLE `sys_group_prem`  (
   `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
   `SYS_GROUPS_ID` int NULL DEFAULT NULL COMMENT '权限组',
   `SYS_DIRECTORY_ID` int NULL DEFAULT NULL COMMENT '目录\r\n',
-  `PERMISSION` int NULL DEFAULT NULL COMMENT '权限(1:读;3:读,写;5:读,提交;……)',
+  `PERMISSION` int NULL DEFAULT NULL COMMENT '权限(位运算,1:读;2:创建;4:修改;8:删除;16:导出;32:导入;64:提交;128:审核(反提交,作废);255:全部)',
   `FILTER_OBJ` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '数据过滤({sql:\"\",display:\"\",other:\"\"})',
   PRIMARY KEY (`ID`) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '权限组明细' ROW_FORMAT = DYNAMIC;
@@ -254,6 +276,37 @@ CREATE TABLE `sys_groups`  (
 -- Records of sys_groups
 -- ----------------------------
 
+-- ----------------------------
+-- Table structure for sys_job
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_job`;
+CREATE TABLE `sys_job`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '提交时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `JOB_TYPE` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '作业类型(export:Excel导出,import:Excel导入)',
+                            `TITLE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '作业说明',
+                            `USER_ID` int UNSIGNED NOT NULL COMMENT '提交人',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(queued:排队中,running:执行中,succeeded:已完成,failed:失败,cancelled:已取消)',
+                            `PROGRESS` int NULL DEFAULT 0 COMMENT '进度百分比(0-100)',
+                            `PARAMS` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '作业参数(JSON)',
+                            `INPUT_FILE` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '输入文件在存储中的路径',
+                            `RESULT_FILE` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '结果文件在存储中的路径',
+                            `RESULT_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '结果文件下载名称',
+                            `RESULT` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '执行结果(JSON)',
+                            `ERROR` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '失败原因',
+                            `LOCKED_UNTIL` datetime NULL DEFAULT NULL COMMENT '执行租约到期时间',
+                            `START_TIME` datetime NULL DEFAULT NULL COMMENT '开始执行时间',
+                            `FINISH_TIME` datetime NULL DEFAULT NULL COMMENT '结束时间(完成、失败或取消)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_job_user`(`USER_ID` ASC) USING BTREE,
+                            INDEX `idx_job_status`(`STATUS` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '后台作业' ROW_FORMAT = DYNAMIC;
+
 -- ----------------------------
 -- Table structure for sys_model
 -- ----------------------------
@@ -324,6 +377,62 @@ CREATE TABLE `sys_param`  (
 -- Records of sys_param
 -- ----------------------------
 
+-- ----------------------------
+-- Table structure for sys_print_template
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_print_template`;
+CREATE TABLE `sys_print_template`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `SYS_TABLE_ID` int UNSIGNED NOT NULL COMMENT '所属表',
+  `NAME` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '模板名称(同一表内唯一)',
+  `TITLE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '打印标题(为空时为表的显示名称)',
+  `PAPER_SIZE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'A4' COMMENT '纸张(A4,A5,Letter)',
+  `ORIENTATION` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'P' COMMENT '打印方向(P:纵向,L:横向)',
+  `HEADER_COLUMNS` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '表头字段名,逗号分隔(为空时为全部打印可见字段)',
+  `HEADER_COLS` int NULL DEFAULT 2 COMMENT '表头每行的字段数',
+  `DETAILS` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '明细表(JSON数组,为空时为全部一对多子表)',
+  `FOOTER` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '页脚文字(如签收栏)',
+  `IS_DEFAULT` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'N' COMMENT '是否表的默认模板(Y/N)',
+  `ORDERNO` int NULL DEFAULT NULL COMMENT '排序',
+  `DESCRIPTION` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_print_template_table`(`SYS_TABLE_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '打印模板' ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Records of sys_print_template
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for sys_record_change
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_record_change`;
+CREATE TABLE `sys_record_change`  (
+                              `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                              `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                              `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作人',
+                              `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '操作时间',
+                              `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                              `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                              `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                              `SYS_TABLE_ID` int UNSIGNED NOT NULL COMMENT '表ID',
+                              `RECORD_ID` int UNSIGNED NOT NULL COMMENT '记录ID',
+                              `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '变更类型(update:修改,delete:删除,restore:恢复,purge:彻底删除,submit:提交,unsubmit:反提交,void:作废)',
+                              `USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '操作人ID',
+                              `CAUSE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '引发本次变更的历史ID(级联删除、置空时为父记录的删除历史)',
+                              `CHANGES` mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '字段变更(JSON数组:column,displayName,old,new)',
+                              `SNAPSHOT` mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '变更前的完整记录(JSON，不含密码)，恢复时使用',
+                              PRIMARY KEY (`ID`) USING BTREE,
+                              INDEX `idx_record_change`(`SYS_TABLE_ID` ASC, `RECORD_ID` ASC) USING BTREE,
+                              INDEX `idx_record_change_cause`(`CAUSE_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '业务记录变更历史' ROW_FORMAT = DYNAMIC;
+
 -- ----------------------------
 -- Table structure for sys_seq
 -- ----------------------------
@@ -407,7 +516,7 @@ CREATE TABLE `sys_table`  (
   `SYS_PARENT_TABLE_ID` int NULL DEFAULT NULL COMMENT '父表',
   `ROWCNT` int NULL DEFAULT NULL COMMENT '统计行数',
   `IS_BIG` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '是否海量',
-  `PROPS` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '扩展属性',
+  `PROPS` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '扩展属性(JSON，softDelete:true 时删除进入回收站)',
   `DESCRIPTION` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
   PRIMARY KEY (`ID`) USING BTREE,
   UNIQUE INDEX `IDX_SYSTABLE_NAME`(`NAME` ASC) USING BTREE
@@ -450,7 +559,7 @@ CREATE TABLE `sys_table_cmd`  (
   `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
   `SYS_TABLE_ID` int NULL DEFAULT NULL COMMENT '所属表单',
   `ACTION_TYPE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '按钮类型(1:系统按钮)',
-  `ACTION` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '按钮(A:新增,M:修改,D:删除,Q:查询,S:提交,U:反提交,V:作废,I:导入,E:导出)',
+  `ACTION` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '按钮(A:新增,M:修改,D:删除,Q:查询,S:提交,U:反提交,V:作废,I:导入,E:导出,P:审批通过,R:审批退回)',
   `ACTION_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '按钮名称',
   `EVENT` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '事件前后(begin:开始,end:结束)',
   `CONTENT` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '执行操作(存储过程/action动作)',
@@ -525,7 +634,9 @@ CREATE TABLE `sys_user`  (
   `LANGUAGE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '语言',
   `IS_ADMIN` char(2) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'Y' COMMENT '是否管理员',
   `SGRADE` int NULL DEFAULT NULL COMMENT '字段访问级别',
-  PRIMARY KEY (`ID`) USING BTREE
+  `SYS_DEPT_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属部门',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_user_dept`(`SYS_DEPT_ID` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '系统用户' ROW_FORMAT = DYNAMIC;
 
 -- ----------------------------
@@ -843,16 +954,17 @@ CREATE TABLE `wf_definition`  (
                                   `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
                                   `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
                                   `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
-                                  `NAME` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '流程名称',
+                                  `NAME` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '流程名称(同名定义为同一流程的不同版本)',
                                   `DISPLAY_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '显示名称',
                                   `VERSION` int NOT NULL DEFAULT 1 COMMENT '版本号',
-                                  `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'draft' COMMENT '状态(draft:草稿,published:已发布,archived:已归档)',
+                                  `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'draft' COMMENT '状态(draft:草稿,published:已发布,archived:已归档)，发布后不可修改',
                                   `SYS_TABLE_ID` int NULL DEFAULT NULL COMMENT '关联的业务表',
                                   `DESCRIPTION` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '描述',
                                   `CONFIG` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT 'JSON配置',
                                   PRIMARY KEY (`ID`) USING BTREE,
                                   INDEX `idx_wf_def_table`(`SYS_TABLE_ID` ASC) USING BTREE,
-                                  INDEX `idx_wf_def_status`(`STATUS` ASC) USING BTREE
+                                  INDEX `idx_wf_def_status`(`STATUS` ASC) USING BTREE,
+                                  UNIQUE INDEX `idx_wf_def_version`(`NAME` ASC, `VERSION` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流定义' ROW_FORMAT = DYNAMIC;
 
 -- ----------------------------
@@ -870,11 +982,11 @@ CREATE TABLE `wf_node`  (
                             `WF_DEFINITION_ID` int UNSIGNED NOT NULL COMMENT '所属流程定义',
                             `NAME` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '节点名称',
                             `DISPLAY_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '显示名称',
-                            `NODE_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '节点类型(start:开始,end:结束,user:用户任务,auto:自动任务,gateway:网关)',
-                            `ASSIGN_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '分配类型(user:指定用户,starter:发起人,role:角色,expression:表达式)',
+                            `NODE_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '节点类型(start:开始,end:结束,user:用户任务,auto:自动任务,gateway:排他网关,fork:并行分叉,join:并行汇聚)',
+                            `ASSIGN_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '分配类型(user:指定用户,starter:发起人,role/group:权限组,directory:安全目录,deptManager:部门负责人,expression:表达式)',
                             `ASSIGN_VALUE` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '分配值',
                             `ACTION_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '自动任务关联的动作ID',
-                            `CONFIG` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT 'JSON配置',
+                            `CONFIG` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT 'JSON配置(会签规则等)',
                             `POS_X` int NULL DEFAULT NULL COMMENT '节点X坐标',
                             `POS_Y` int NULL DEFAULT NULL COMMENT '节点Y坐标',
                             PRIMARY KEY (`ID`) USING BTREE,
@@ -921,7 +1033,7 @@ CREATE TABLE `wf_instance`  (
                                 `WF_DEFINITION_ID` int UNSIGNED NOT NULL COMMENT '流程定义ID',
                                 `SYS_TABLE_ID` int NULL DEFAULT NULL COMMENT '关联的业务表',
                                 `BUSINESS_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '业务数据ID',
-                                `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(running:运行中,completed:已完成,terminated:已终止,suspended:已挂起)',
+                                `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(running:运行中,completed:已完成,terminated:已终止,suspended:已挂起,withdrawn:已撤回)',
                                 `CURRENT_NODE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '当前节点ID',
                                 `START_USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '发起人',
                                 `START_TIME` datetime NULL DEFAULT NULL COMMENT '开始时间',
@@ -936,6 +1048,31 @@ CREATE TABLE `wf_instance`  (
                                 INDEX `idx_wf_inst_user`(`START_USER_ID` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流实例' ROW_FORMAT = DYNAMIC;
 
+-- ----------------------------
+-- Table structure for wf_token
+-- ----------------------------
+DROP TABLE IF EXISTS `wf_token`;
+CREATE TABLE `wf_token`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+                            `WF_NODE_ID` int UNSIGNED NOT NULL COMMENT '流程节点ID',
+                            `FORK_ID` int UNSIGNED NULL DEFAULT 0 COMMENT '所属分支的分叉令牌ID(主干为0)',
+                            `BRANCHES` int NULL DEFAULT 0 COMMENT '分叉节点激活的分支数',
+                            `WF_TRANSITION_ID` int UNSIGNED NULL DEFAULT 0 COMMENT '进入节点经过的流转ID(开始节点和退回为0)',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(active:活动,waiting:等待汇聚,completed:已完成,canceled:已取消)',
+                            `ENTER_TIME` datetime NULL DEFAULT NULL COMMENT '进入节点时间',
+                            `LEAVE_TIME` datetime NULL DEFAULT NULL COMMENT '离开节点时间',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_token_inst`(`WF_INSTANCE_ID` ASC, `STATUS` ASC) USING BTREE,
+                            INDEX `idx_wf_token_join`(`WF_NODE_ID` ASC, `FORK_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流令牌' ROW_FORMAT = DYNAMIC;
+
 -- ----------------------------
 -- Table structure for wf_task
 -- ----------------------------
@@ -950,22 +1087,135 @@ CREATE TABLE `wf_task`  (
                             `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
                             `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
                             `WF_NODE_ID` int UNSIGNED NOT NULL COMMENT '流程节点ID',
+                            `WF_TOKEN_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属令牌',
                             `ASSIGNEE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '任务执行人',
-                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(pending:待处理,completed:已完成,rejected:已拒绝,transferred:已转交)',
-                            `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作(approve:同意,reject:拒绝,transfer:转交)',
+                            `ORIGINAL_ASSIGNEE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '原处理人(按委托规则分配给受托人时记录委托人)',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(pending:待处理,completed:已完成,rejected:已拒绝,transferred:已转交,canceled:已取消)',
+                            `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作(approve:同意,reject:拒绝,back:退回,backToStarter:退回发起人,transfer:转交)',
                             `COMMENT` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '审批意见',
                             `CLAIM_TIME` datetime NULL DEFAULT NULL COMMENT '签收时间',
                             `COMPLETE_TIME` datetime NULL DEFAULT NULL COMMENT '完成时间',
                             `DUE_TIME` datetime NULL DEFAULT NULL COMMENT '截止时间',
+                            `REMIND_TIME` datetime NULL DEFAULT NULL COMMENT '计划提醒时间(提醒发送后清空)',
+                            `REMIND_ATTEMPTS` int NOT NULL DEFAULT 0 COMMENT '到期提醒发送次数(含失败)',
+                            `ESCALATE_TIME` datetime NULL DEFAULT NULL COMMENT '超时处理时间',
+                            `ESCALATE_ATTEMPTS` int NOT NULL DEFAULT 0 COMMENT '超时处理失败次数',
                             `PRIORITY` int NULL DEFAULT 0 COMMENT '优先级',
                             `VARIABLES` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '任务变量(JSON)',
                             PRIMARY KEY (`ID`) USING BTREE,
                             INDEX `idx_wf_task_inst`(`WF_INSTANCE_ID` ASC) USING BTREE,
                             INDEX `idx_wf_task_node`(`WF_NODE_ID` ASC) USING BTREE,
+                            INDEX `idx_wf_task_token`(`WF_TOKEN_ID` ASC) USING BTREE,
                             INDEX `idx_wf_task_assignee`(`ASSIGNEE_ID` ASC) USING BTREE,
-                            INDEX `idx_wf_task_status`(`STATUS` ASC) USING BTREE
+                            INDEX `idx_wf_task_original`(`ORIGINAL_ASSIGNEE_ID` ASC) USING BTREE,
+                            INDEX `idx_wf_task_status`(`STATUS` ASC) USING BTREE,
+                            INDEX `idx_wf_task_due`(`STATUS` ASC, `DUE_TIME` ASC) USING BTREE,
+                            INDEX `idx_wf_task_remind`(`STATUS` ASC, `REMIND_TIME` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流任务' ROW_FORMAT = DYNAMIC;
 
+-- ----------------------------
+-- Table structure for wf_history
+-- ----------------------------
+DROP TABLE IF EXISTS `wf_history`;
+CREATE TABLE `wf_history`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+                            `WF_TASK_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '任务ID',
+                            `WF_NODE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '流程节点ID',
+                            `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回,migrate:版本迁移,variables:变量变更,autoTask:自动任务,jobRetry:作业重试,delegate:委托)',
+                            `OPERATOR_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '操作人(系统自动处理为0)',
+                            `TARGET_USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '目标用户',
+                            `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '处理方式',
+                            `COMMENT` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '说明',
+                            `DETAIL` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '详细信息(JSON)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_history_inst`(`WF_INSTANCE_ID` ASC) USING BTREE,
+                            INDEX `idx_wf_history_task`(`WF_TASK_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流历史' ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for wf_job
+-- ----------------------------
+DROP TABLE IF EXISTS `wf_job`;
+CREATE TABLE `wf_job`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+                            `WF_NODE_ID` int UNSIGNED NOT NULL COMMENT '自动任务节点ID',
+                            `WF_TOKEN_ID` int UNSIGNED NOT NULL COMMENT '停留在节点上的令牌ID',
+                            `ACTION_ID` int UNSIGNED NOT NULL COMMENT '动作ID',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(pending:等待执行,running:执行中,completed:已完成,failed:失败,canceled:已取消)',
+                            `ATTEMPTS` int NULL DEFAULT 0 COMMENT '已执行次数',
+                            `MAX_ATTEMPTS` int NULL DEFAULT 3 COMMENT '最大执行次数',
+                            `BACKOFF` int NULL DEFAULT 30 COMMENT '首次重试间隔(秒)，之后每次翻倍',
+                            `TIMEOUT` int NULL DEFAULT 60 COMMENT '单次执行超时(秒)',
+                            `NEXT_RUN_TIME` datetime NULL DEFAULT NULL COMMENT '下次执行时间',
+                            `LOCKED_UNTIL` datetime NULL DEFAULT NULL COMMENT '执行租约到期时间',
+                            `START_TIME` datetime NULL DEFAULT NULL COMMENT '最近一次开始执行时间',
+                            `FINISH_TIME` datetime NULL DEFAULT NULL COMMENT '完成或最终失败时间',
+                            `LAST_ERROR` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '最近一次失败原因',
+                            `RESULT` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '执行结果(JSON)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_job_due`(`STATUS` ASC, `NEXT_RUN_TIME` ASC) USING BTREE,
+                            INDEX `idx_wf_job_inst`(`WF_INSTANCE_ID` ASC, `STATUS` ASC) USING BTREE,
+                            INDEX `idx_wf_job_token`(`WF_TOKEN_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流自动任务作业' ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for wf_delegation
+-- ----------------------------
+DROP TABLE IF EXISTS `wf_delegation`;
+CREATE TABLE `wf_delegation`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:已取消)',
+                            `FROM_USER_ID` int UNSIGNED NOT NULL COMMENT '委托人',
+                            `TO_USER_ID` int UNSIGNED NOT NULL COMMENT '受托人',
+                            `START_TIME` datetime NOT NULL COMMENT '生效时间',
+                            `END_TIME` datetime NOT NULL COMMENT '失效时间',
+                            `DEFINITION_NAMES` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '限定的流程名称(逗号分隔,对该流程所有版本生效),为空时对所有流程生效',
+                            `REASON` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '委托原因',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_delegation_from`(`FROM_USER_ID` ASC, `START_TIME` ASC) USING BTREE,
+                            INDEX `idx_wf_delegation_to`(`TO_USER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流任务委托规则' ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for wf_task_candidate
+-- ----------------------------
+DROP TABLE IF EXISTS `wf_task_candidate`;
+CREATE TABLE `wf_task_candidate`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `WF_TASK_ID` int UNSIGNED NOT NULL COMMENT '任务ID',
+                            `USER_ID` int UNSIGNED NOT NULL COMMENT '候选人',
+                            `ORIGINAL_USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '原候选人(按委托规则由受托人代替时记录委托人)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            UNIQUE INDEX `uk_wf_task_candidate`(`WF_TASK_ID` ASC, `USER_ID` ASC) USING BTREE,
+                            INDEX `idx_wf_candidate_user`(`USER_ID` ASC) USING BTREE,
+                            INDEX `idx_wf_candidate_original`(`ORIGINAL_USER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流任务候选人' ROW_FORMAT = DYNAMIC;
+
 
 SET FOREIGN_KEY_CHECKS = 1;
 
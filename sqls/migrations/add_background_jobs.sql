@@ -0,0 +1,71 @@
+-- ==========================================
+-- 后台作业迁移脚本
+-- ==========================================
+-- 用途：Excel导入、导出改为提交后台作业异步执行，避免大数据量时请求超时；
+--       作业进度和结果通过WebSocket推送给提交人，导出结果文件通过作业下载
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 后台作业表
+CREATE TABLE IF NOT EXISTS `sys_job`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '提交时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `JOB_TYPE` varchar(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '作业类型(export:Excel导出,import:Excel导入)',
+                            `TITLE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '作业说明',
+                            `USER_ID` int UNSIGNED NOT NULL COMMENT '提交人',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(queued:排队中,running:执行中,succeeded:已完成,failed:失败,cancelled:已取消)',
+                            `PROGRESS` int NULL DEFAULT 0 COMMENT '进度百分比(0-100)',
+                            `PARAMS` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '作业参数(JSON)',
+                            `INPUT_FILE` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '输入文件在存储中的路径',
+                            `RESULT_FILE` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '结果文件在存储中的路径',
+                            `RESULT_NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '结果文件下载名称',
+                            `RESULT` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '执行结果(JSON)',
+                            `ERROR` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '失败原因',
+                            `LOCKED_UNTIL` datetime NULL DEFAULT NULL COMMENT '执行租约到期时间',
+                            `START_TIME` datetime NULL DEFAULT NULL COMMENT '开始执行时间',
+                            `FINISH_TIME` datetime NULL DEFAULT NULL COMMENT '结束时间(完成、失败或取消)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_job_user`(`USER_ID` ASC) USING BTREE,
+                            INDEX `idx_job_status`(`STATUS` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '后台作业' ROW_FORMAT = DYNAMIC;
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+执行方式：
+- POST /api/v1/data/{tableName}/export 和 /import 提交时检查权限和参数，立即返回作业（status=queued）
+- 导入上传的文件和导出的结果文件保存在 uploads/jobs 目录下
+- 后台每隔 job.checkInterval 秒领取排队中的作业执行，同时最多执行 job.workers 个
+- 执行超过 job.timeout 秒的作业标记为失败；执行进程异常退出、租约到期的作业标记为失败，不自动重试（导入不可重复执行）
+- 取消执行中的作业时，作业在下次报告进度时停止；导入已提交的行不回滚
+
+作业状态：
+queued:排队中 -> running:执行中 -> succeeded:已完成 / failed:失败 / cancelled:已取消
+
+作业接口（只能查看自己提交的作业）：
+GET  /api/v1/jobs?status=running&jobType=export   查询作业列表
+GET  /api/v1/jobs/{id}                            查询作业（进度、结果、失败原因）
+POST /api/v1/jobs/{id}/cancel                     取消作业
+GET  /api/v1/jobs/{id}/download                   下载结果文件（导出的Excel）
+
+WebSocket通知（状态或进度变化时推送给提交人）：
+{"type": "JOB_PROGRESS", "data": {"id": 12, "jobType": "export", "status": "running", "progress": 45, ...}}
+作业完成后 data.downloadUrl 为结果文件下载地址，导入作业的 data.result 为导入结果
+
+配置（configs/config.yaml）：
+job:
+  checkInterval: 5
+  workers: 2
+  timeout: 3600
+
+查询失败的作业：
+SELECT ID, JOB_TYPE, TITLE, USER_ID, ERROR, FINISH_TIME
+FROM sys_job WHERE STATUS = 'failed' ORDER BY FINISH_TIME DESC;
+*/
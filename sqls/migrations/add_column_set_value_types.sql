@@ -0,0 +1,38 @@
+-- ==========================================
+-- 字段赋值方式迁移脚本
+-- ==========================================
+-- 用途：通用CRUD新增、修改时按 sys_column.SET_VALUE_TYPE 自动赋值，支持密码字段加密保存
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 更新赋值方式说明
+ALTER TABLE `sys_column`
+MODIFY COLUMN `SET_VALUE_TYPE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '赋值方式(pk:pk,docno:单据编号,createBy:创建人,byPage:界面输入,select:下拉选项,fk:外键关联,sysdate:操作时间,operator:操作用户,password:密码,ignore:忽略)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+赋值方式（sys_column.SET_VALUE_TYPE）：
+- pk       : 主键，由ID生成器赋值
+- docno    : 单据编号，新增时未提交（或提交空值）则按 SEQ 指定的序号生成器生成（sequence.NextValue）；
+             未配置 SEQ 时需手工输入
+- createBy : 创建人，新增时赋值，修改时忽略提交的值
+- sysdate  : 操作时间，新增和修改时赋值为当前时间
+- operator : 操作用户，新增和修改时赋值
+             （createBy、operator 字段类型为 int/bigint 时保存用户ID，其余保存用户名）
+- password : 密码，bcrypt 加密保存；查询、列表、关联、回收站都不返回；变更历史中显示为 ******；
+             修改时不提交或提交空值表示不修改
+- ignore   : 忽略，提交的值不写入
+- fk       : 外键关联，提交的值必须是 REF_TABLE_ID 指向的表中的有效记录（任何配置了 REF_TABLE_ID 的字段都会检查）
+
+默认值（新增时字段未提交）：
+- DEFAULT_VALUE 不为空时取 DEFAULT_VALUE
+- 否则字段关联了数据字典（SYS_DICT_ID 为字典ID或名称）时取字典中 IS_DEFAULT_VALUE = 'Y' 的明细值
+
+示例：
+UPDATE `sys_column` SET `SET_VALUE_TYPE` = 'docno', `SEQ` = 'SO' WHERE `FULL_NAME` = 'sale_order.DOCNO';
+UPDATE `sys_column` SET `SET_VALUE_TYPE` = 'password' WHERE `FULL_NAME` = 'crm_account.PASSWORD';
+-- 修改后刷新元数据缓存：POST /api/v1/metadata/refresh
+*/
@@ -0,0 +1,76 @@
+-- ==========================================
+-- 单据生命周期（提交/反提交/作废）迁移脚本
+-- ==========================================
+-- 用途：为 MASK 包含 S（提交）或 V（作废）的单据表添加单据状态字段
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 为业务单据表添加状态字段（将 {table_name} 替换为实际表名，每个单据表执行一次）
+ALTER TABLE `{table_name}`
+ADD COLUMN `DOC_STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'draft' COMMENT '单据状态(draft:未提交,submitted:已提交,voided:已作废)',
+ADD COLUMN `SUBMIT_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '提交人',
+ADD COLUMN `SUBMIT_TIME` datetime NULL DEFAULT NULL COMMENT '提交时间';
+
+CREATE INDEX `idx_doc_status` ON `{table_name}`(`DOC_STATUS` ASC) USING BTREE;
+
+-- 2. 为已有单据表补充 sys_column 元数据（新建的单据表由 sys_table_after_create 插件自动生成）
+INSERT INTO `sys_column` (`DISPLAY_NAME`, `DB_NAME`, `FULL_NAME`, `DESCRIPTION`, `COL_TYPE`, `COL_LENGTH`, `SYS_TABLE_ID`, `ORDERNO`, `NULL_ABLE`, `MASK`, `SET_VALUE_TYPE`, `DEFAULT_VALUE`, `MODIFI_ABLE`, `DISPLAY_TYPE`, `IS_QUERY`, `IS_SHOW_TITLE`, `IS_ACTIVE`, `CREATE_BY`, `CREATE_TIME`, `SYS_COMPANY_ID`)
+SELECT CONCAT(UPPER(t.NAME), '.DOC_STATUS'), 'DOC_STATUS', CONCAT(UPPER(t.NAME), '.DOC_STATUS'), '单据状态', 'varchar', 20, t.ID, 1005, 'Y', '0010100110', 'ignore', 'draft', 'N', 'text', 'Y', 'Y', 'Y', 'system', NOW(), t.SYS_COMPANY_ID
+FROM `sys_table` t
+WHERE (t.MASK LIKE '%S%' OR t.MASK LIKE '%V%')
+  AND NOT EXISTS (SELECT 1 FROM `sys_column` c WHERE c.SYS_TABLE_ID = t.ID AND c.DB_NAME = 'DOC_STATUS');
+
+INSERT INTO `sys_column` (`DISPLAY_NAME`, `DB_NAME`, `FULL_NAME`, `DESCRIPTION`, `COL_TYPE`, `COL_LENGTH`, `SYS_TABLE_ID`, `ORDERNO`, `NULL_ABLE`, `MASK`, `SET_VALUE_TYPE`, `MODIFI_ABLE`, `DISPLAY_TYPE`, `IS_SHOW_TITLE`, `IS_ACTIVE`, `CREATE_BY`, `CREATE_TIME`, `SYS_COMPANY_ID`)
+SELECT CONCAT(UPPER(t.NAME), '.SUBMIT_BY'), 'SUBMIT_BY', CONCAT(UPPER(t.NAME), '.SUBMIT_BY'), '提交人', 'varchar', 80, t.ID, 1006, 'Y', '0010100110', 'ignore', 'N', 'text', 'Y', 'Y', 'system', NOW(), t.SYS_COMPANY_ID
+FROM `sys_table` t
+WHERE (t.MASK LIKE '%S%' OR t.MASK LIKE '%V%')
+  AND NOT EXISTS (SELECT 1 FROM `sys_column` c WHERE c.SYS_TABLE_ID = t.ID AND c.DB_NAME = 'SUBMIT_BY');
+
+INSERT INTO `sys_column` (`DISPLAY_NAME`, `DB_NAME`, `FULL_NAME`, `DESCRIPTION`, `COL_TYPE`, `SYS_TABLE_ID`, `ORDERNO`, `NULL_ABLE`, `MASK`, `SET_VALUE_TYPE`, `MODIFI_ABLE`, `DISPLAY_TYPE`, `IS_SHOW_TITLE`, `IS_ACTIVE`, `CREATE_BY`, `CREATE_TIME`, `SYS_COMPANY_ID`)
+SELECT CONCAT(UPPER(t.NAME), '.SUBMIT_TIME'), 'SUBMIT_TIME', CONCAT(UPPER(t.NAME), '.SUBMIT_TIME'), '提交时间', 'datetime', t.ID, 1007, 'Y', '0010100110', 'ignore', 'N', 'datetime', 'Y', 'Y', 'system', NOW(), t.SYS_COMPANY_ID
+FROM `sys_table` t
+WHERE (t.MASK LIKE '%S%' OR t.MASK LIKE '%V%')
+  AND NOT EXISTS (SELECT 1 FROM `sys_column` c WHERE c.SYS_TABLE_ID = t.ID AND c.DB_NAME = 'SUBMIT_TIME');
+
+-- 3. 权限组新增提交(64)、审核(128)权限位，原有授权最大为63，升级后需补充授权：
+--    有修改权限(4)的授予提交，同时有修改和删除权限(4+8)的授予审核（反提交、作废）；可重复执行
+UPDATE `sys_group_prem` SET `PERMISSION` = `PERMISSION` | 64
+WHERE `PERMISSION` & 4 = 4;
+
+UPDATE `sys_group_prem` SET `PERMISSION` = `PERMISSION` | 128
+WHERE `PERMISSION` & 12 = 12;
+
+ALTER TABLE `sys_group_prem`
+MODIFY COLUMN `PERMISSION` int NULL DEFAULT NULL COMMENT '权限(位运算,1:读;2:创建;4:修改;8:删除;16:导出;32:导入;64:提交;128:审核(反提交,作废);255:全部)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+状态迁移：
+- 提交   (MASK 含 S，需提交权限 64)：draft     -> submitted，记录 SUBMIT_BY / SUBMIT_TIME
+- 反提交 (MASK 含 U，需审核权限 128)：submitted -> draft，清空 SUBMIT_BY / SUBMIT_TIME
+- 作废   (MASK 含 V，需审核权限 128)：draft     -> voided
+
+权限位（sys_group_prem.PERMISSION）：
+1:读 2:创建 4:修改 8:删除 16:导出 32:导入 64:提交 128:审核，全部为255；分配权限时超出范围的值被拒绝
+
+接口：
+POST /api/v1/data/{tableName}/{id}/submit
+POST /api/v1/data/{tableName}/{id}/unsubmit
+POST /api/v1/data/{tableName}/{id}/void
+
+约束：
+- 已提交、已作废的单据不能修改或删除
+- DOC_STATUS 为 NULL 的历史数据视为未提交
+- 状态迁移与字段修改一样记入变更历史（sys_record_change.ACTION 为 submit/unsubmit/void）
+
+钩子：
+- sys_table_cmd：ACTION 为 S/U/V，EVENT 为 begin/end，与状态更新在同一事务中执行
+- 插件钩子点：{table_name}.before.submit / {table_name}.after.submit（unsubmit、void 同理）
+
+示例（提交后推送通知的 Go 钩子）：
+INSERT INTO sys_table_cmd (SYS_TABLE_ID, ACTION, EVENT, CONTENT_TYPE, CONTENT, ORDERNO, IS_ACTIVE)
+VALUES (100, 'S', 'end', 'go', 'order_submit_notify', 10, 'Y');
+*/
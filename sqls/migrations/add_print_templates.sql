@@ -0,0 +1,79 @@
+-- ==========================================
+-- 打印模板迁移脚本
+-- ==========================================
+-- 用途：按打印模板将单据（如发货单、发票）输出为HTML或PDF；
+--       表头字段取自单据表，明细取自 sys_table_ref 中一对多关联的子表，只打印 MASK 打印可见（第9位）的字段
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 打印模板表
+CREATE TABLE IF NOT EXISTS `sys_print_template`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `SYS_TABLE_ID` int UNSIGNED NOT NULL COMMENT '所属表',
+  `NAME` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '模板名称(同一表内唯一)',
+  `TITLE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '打印标题(为空时为表的显示名称)',
+  `PAPER_SIZE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'A4' COMMENT '纸张(A4,A5,Letter)',
+  `ORIENTATION` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'P' COMMENT '打印方向(P:纵向,L:横向)',
+  `HEADER_COLUMNS` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '表头字段名,逗号分隔(为空时为全部打印可见字段)',
+  `HEADER_COLS` int NULL DEFAULT 2 COMMENT '表头每行的字段数',
+  `DETAILS` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '明细表(JSON数组,为空时为全部一对多子表)',
+  `FOOTER` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '页脚文字(如签收栏)',
+  `IS_DEFAULT` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT 'N' COMMENT '是否表的默认模板(Y/N)',
+  `ORDERNO` int NULL DEFAULT NULL COMMENT '排序',
+  `DESCRIPTION` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_print_template_table`(`SYS_TABLE_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '打印模板' ROW_FORMAT = DYNAMIC;
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+打印接口（需要表的查询权限，记录按数据权限过滤）：
+GET  /api/v1/data/{tableName}/{id}/print?format=pdf&templateId=3   打印单条记录
+POST /api/v1/data/{tableName}/print                               批量打印（每条记录从新的一页开始，单次最多100条）
+     {"ids": [101, 102], "templateId": 3, "format": "html"}
+- format：html（默认，浏览器中预览和打印）、pdf
+- 未指定 templateId 时使用表的默认模板（IS_DEFAULT='Y'）；没有模板时打印全部打印可见字段和全部一对多子表
+- 字典字段打印显示名称；字段 MASK 第9位为0时不打印（即使模板中配置了该字段）
+- 无查询权限的子表在默认布局中不打印，模板中明确配置的子表无权限时打印失败
+
+模板维护接口（需要表的修改权限）：
+GET    /api/v1/print-templates?tableName=sales_order   查询表的打印模板
+POST   /api/v1/print-templates                         创建模板
+GET    /api/v1/print-templates/{id}                    查询模板
+PUT    /api/v1/print-templates/{id}                    修改模板（所属表不可修改）
+DELETE /api/v1/print-templates/{id}                    删除模板
+
+模板示例（发货单）：
+{
+  "sysTableId": 120,
+  "name": "发货单",
+  "title": "销售发货单",
+  "paperSize": "A5",
+  "orientation": "L",
+  "headerColumns": "DOC_NO,CUSTOMER_NAME,SHIP_DATE,STATUS",
+  "headerCols": 2,
+  "details": "[{\"table\":\"sales_order_item\",\"title\":\"商品明细\",\"columns\":[\"SKU\",\"NAME\",\"QTY\",\"PRICE\",\"AMOUNT\"]}]",
+  "footer": "发货人：__________    收货人签字：__________",
+  "isDefault": "Y"
+}
+- details 为空时打印全部一对多子表，为 [] 时不打印明细
+- 同一表只有一个默认模板，设为默认时自动取消其他模板的默认
+
+PDF字体（configs/config.yaml）：
+print:
+  fontPath: /usr/share/fonts/NotoSansSC-Regular.ttf
+未配置字体时PDF打印返回错误；HTML使用浏览器字体，不受影响
+
+设置字段打印可见（MASK 第9位）：
+UPDATE sys_column SET MASK = CONCAT(SUBSTRING(MASK, 1, 8), '1', SUBSTRING(MASK, 10))
+WHERE SYS_TABLE_ID = 120 AND DB_NAME IN ('DOC_NO', 'CUSTOMER_NAME');
+*/
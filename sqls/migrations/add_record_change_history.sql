@@ -0,0 +1,58 @@
+-- ==========================================
+-- 业务记录变更历史迁移脚本
+-- ==========================================
+-- 用途：通用CRUD修改、删除记录时在同一事务中记录字段级变更，支持查询记录的变更历史并恢复到历史版本
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 变更历史表
+CREATE TABLE IF NOT EXISTS `sys_record_change`  (
+                              `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                              `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                              `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作人',
+                              `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '操作时间',
+                              `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                              `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                              `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                              `SYS_TABLE_ID` int UNSIGNED NOT NULL COMMENT '表ID',
+                              `RECORD_ID` int UNSIGNED NOT NULL COMMENT '记录ID',
+                              `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '变更类型(update:修改,delete:删除,restore:恢复)',
+                              `USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '操作人ID',
+                              `CHANGES` mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '字段变更(JSON数组:column,displayName,old,new)',
+                              `SNAPSHOT` mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '变更前的完整记录(JSON，不含密码)，恢复时使用',
+                              PRIMARY KEY (`ID`) USING BTREE,
+                              INDEX `idx_record_change`(`SYS_TABLE_ID` ASC, `RECORD_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '业务记录变更历史' ROW_FORMAT = DYNAMIC;
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+记录规则：
+- PUT /api/v1/data/{tableName}/{id}：事务中锁定并读取修改前的记录，逐字段比较，有变化时记录 update
+- DELETE /api/v1/data/{tableName}/{id} 和 batch-delete：每条记录记录一条 delete（列出删除前的非空字段）
+- 每条历史保存变更前的完整记录（SNAPSHOT），用于恢复；密码字段不保存
+- ID、创建人/时间、更新人/时间、IS_ACTIVE、VERSION 由服务端维护，不记入字段变更
+- 历史在业务数据的同一事务中写入，更新失败回滚时不会留下历史
+
+接口：
+GET  /api/v1/data/{tableName}/{id}/history                       查询变更历史（需查询权限，记录须在数据权限范围内，不含 MASK 不可见的字段）
+POST /api/v1/data/{tableName}/{id}/history/{changeId}/restore    恢复到该次变更之前的状态（需修改权限）
+
+返回示例：
+[
+  {
+    "id": 31, "action": "update", "userId": 5, "username": "zhangsan", "time": "2026-10-16T10:00:00+08:00",
+    "changes": [{"column": "AMOUNT", "displayName": "金额", "old": "12.50", "new": 20}]
+  }
+]
+
+恢复规则：
+- 记录须在用户的数据权限范围内；已物理删除的记录在重新插入后检查，不在范围内时回滚
+- 记录仍存在时按修改处理：已提交/已作废的单据不能恢复，只恢复 MASK 可修改的字段，单据状态、审批字段和密码不恢复，
+  恢复的值按字段定义校验；执行修改(M)钩子
+- 记录已删除时按新增处理重新插入（原ID、原创建人和创建时间），需要创建权限：只恢复 MASK 新增可修改的字段，
+  单据恢复为未提交状态，密码不恢复（密码必填的表无法恢复），恢复的值按字段定义校验；执行新增(A)钩子
+- 恢复本身记录为 restore，可以再次恢复
+*/
@@ -0,0 +1,53 @@
+-- ==========================================
+-- 软删除与回收站迁移脚本
+-- ==========================================
+-- 用途：表可配置删除时只将 IS_ACTIVE 置为 N（进入回收站），支持回收站查询、恢复和彻底删除；
+--       删除、恢复、彻底删除时按外键字段的 REF_ON_DELETE 处理子表
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 变更历史增加引发变更的历史ID（级联删除的子记录指向父记录的删除历史）
+ALTER TABLE `sys_record_change`
+ADD COLUMN `CAUSE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '引发本次变更的历史ID(级联删除、置空时为父记录的删除历史)' AFTER `USER_ID`,
+MODIFY COLUMN `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '变更类型(update:修改,delete:删除,restore:恢复,purge:彻底删除,submit:提交,unsubmit:反提交,void:作废)',
+ADD INDEX `idx_record_change_cause`(`CAUSE_ID` ASC) USING BTREE;
+
+-- 2. 更新外键删除动作说明
+ALTER TABLE `sys_column`
+MODIFY COLUMN `REF_ON_DELETE` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '外键删除动作(noAction:无动作,restrict:限制,cascade:级联,setNull:置空)';
+
+-- 3. 更新表扩展属性说明
+ALTER TABLE `sys_table`
+MODIFY COLUMN `PROPS` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '扩展属性(JSON，softDelete:true 时删除进入回收站)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+启用软删除（sys_table.PROPS）：
+UPDATE `sys_table` SET `PROPS` = '{"softDelete": true}' WHERE `NAME` = 'crm_customer';
+-- 修改后刷新元数据缓存：POST /api/v1/metadata/refresh
+
+删除规则：
+- 启用软删除的表删除时将 IS_ACTIVE 置为 N 并更新 UPDATE_BY/UPDATE_TIME（有 VERSION 字段时加1），记录进入回收站
+- 未启用的表仍为物理删除，可通过变更历史恢复（POST /api/v1/data/{tableName}/{id}/history/{changeId}/restore）
+- 唯一索引不会忽略回收站中的记录，需要重复使用唯一值的表请在索引中加入 IS_ACTIVE 或先彻底删除
+
+外键删除动作（sys_column.REF_ON_DELETE，子表中指向父表的外键字段）：
+- noAction : 无动作（默认），子记录保留原外键
+- restrict : 存在有效子记录时父记录不能删除；父记录已删除时子记录不能从回收站恢复
+- cascade  : 删除父记录时一并删除子记录（按子表自己的软删除设置）；从回收站恢复父记录时一并恢复当时级联删除的子记录；
+             父记录已删除时子记录不能单独恢复；彻底删除父记录时回收站中的子记录一并彻底删除
+- setNull  : 删除父记录时子记录外键置空；从回收站恢复父记录时还原当时置空且之后未修改过外键的子记录
+- 级联最多 10 层，级联删除不检查子表的删除权限，子表的删除(D)钩子照常执行
+- 子记录的删除、置空历史的 CAUSE_ID 指向父记录的删除历史
+
+接口（表需启用软删除）：
+POST /api/v1/data/{tableName}/recycle/query     查询回收站（需查询权限，按删除时间倒序）
+     {"page": 1, "pageSize": 20, "filters": {"NAME": {"op": "like", "value": "张"}}}
+POST /api/v1/data/{tableName}/recycle/restore   恢复（需删除权限）{"ids": [1, 2]}
+POST /api/v1/data/{tableName}/recycle/purge     彻底删除（需删除权限）{"ids": [1, 2]}
+
+恢复和彻底删除都记入变更历史（restore / purge）；回收站中的记录不能通过变更历史恢复，需先从回收站恢复
+*/
@@ -0,0 +1,76 @@
+-- ==========================================
+-- 工作流任务分配（权限组/安全目录/部门负责人/表达式）迁移脚本
+-- ==========================================
+-- 用途：新增部门表、用户所属部门字段和任务候选人表，支持候选组任务签收
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 部门表
+CREATE TABLE IF NOT EXISTS `sys_dept`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `NAME` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '部门名称',
+  `PARENT_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '上级部门',
+  `MANAGER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '部门负责人',
+  `ORDERNO` int NULL DEFAULT NULL COMMENT '排序',
+  `DESCRIPTION` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '备注',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_dept_parent`(`PARENT_ID` ASC) USING BTREE,
+  INDEX `idx_dept_manager`(`MANAGER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '部门' ROW_FORMAT = DYNAMIC;
+
+-- 2. 用户所属部门
+ALTER TABLE `sys_user`
+ADD COLUMN `SYS_DEPT_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属部门' AFTER `SGRADE`;
+
+CREATE INDEX `idx_user_dept` ON `sys_user`(`SYS_DEPT_ID` ASC) USING BTREE;
+
+-- 3. 任务候选人表
+CREATE TABLE IF NOT EXISTS `wf_task_candidate`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `WF_TASK_ID` int UNSIGNED NOT NULL COMMENT '任务ID',
+  `USER_ID` int UNSIGNED NOT NULL COMMENT '候选人',
+  PRIMARY KEY (`ID`) USING BTREE,
+  UNIQUE INDEX `uk_wf_task_candidate`(`WF_TASK_ID` ASC, `USER_ID` ASC) USING BTREE,
+  INDEX `idx_wf_candidate_user`(`USER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流任务候选人' ROW_FORMAT = DYNAMIC;
+
+-- 4. 更新节点分配类型说明
+ALTER TABLE `wf_node`
+MODIFY COLUMN `ASSIGN_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '分配类型(user:指定用户,starter:发起人,role/group:权限组,directory:安全目录,deptManager:部门负责人,expression:表达式)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+分配类型（wf_node.ASSIGN_TYPE / ASSIGN_VALUE）：
+- user        : 用户ID，多个用逗号分隔，如 '3,5,8'
+- starter     : 流程发起人，无需分配值
+- role / group: 权限组ID（sys_groups.ID），多个用逗号分隔
+- directory   : 安全目录ID，可附带权限位，如 '12' 或 '12:128'（需要审核权限）
+- deptManager : 发起人部门负责人，分配值为向上层级（默认 1 = 本部门，2 = 上级部门）
+- expression  : 表达式，可引用流程变量和业务记录，结果为用户ID、用户名或它们的列表，
+                如 "coalesce(approver, record.OWNER_ID)" 或 "['admin', 'finance']"
+
+候选组任务：
+- 只匹配到一个处理人时直接分配（ASSIGNEE_ID 为该用户）
+- 匹配到多个处理人时 ASSIGNEE_ID 为 0，候选人写入 wf_task_candidate
+- 候选人在"我的任务"中可见，调用 POST /api/v1/workflow/tasks/{id}/claim 签收后锁定给签收人
+- 并发签收时只有一人成功，其他人返回 409
+
+示例：
+INSERT INTO sys_dept (NAME, MANAGER_ID, IS_ACTIVE) VALUES ('销售部', 10, 'Y');
+UPDATE sys_user SET SYS_DEPT_ID = 1 WHERE ID IN (11, 12);
+*/
@@ -0,0 +1,39 @@
+-- ==========================================
+-- 工作流退回与撤回迁移脚本
+-- ==========================================
+-- 用途：更新字段说明，支持退回上一节点/指定节点、退回发起人和发起人撤回
+-- 日期：2026-10-16
+-- ==========================================
+
+ALTER TABLE `wf_instance`
+MODIFY COLUMN `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(running:运行中,completed:已完成,terminated:已终止,suspended:已挂起,withdrawn:已撤回)';
+
+ALTER TABLE `wf_task`
+MODIFY COLUMN `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '操作(approve:同意,reject:拒绝,back:退回,backToStarter:退回发起人,transfer:转交)';
+
+ALTER TABLE `wf_history`
+MODIFY COLUMN `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+任务操作（POST /api/v1/workflow/tasks/complete 的 action）：
+- approve       : 同意，继续流转
+- reject        : 拒绝，终止流程
+- back          : 退回上一个用户节点；可传 targetNodeId 退回到流程已经过的任一用户节点
+- backToStarter : 退回发起人，在开始节点为发起人生成修改任务，发起人以 approve 完成即重新提交
+
+退回规则：
+- 原任务保留（ACTION 为 back/backToStarter），目标节点按分配规则重新生成待处理任务
+- 退回到主干节点时取消所有并行分支；并行分支内只能退回到本分支或主干上的节点
+
+发起人撤回（POST /api/v1/workflow/instances/{id}/withdraw）：
+- 只有发起人可以撤回，且没有任何审批人同意过，撤回后实例状态为 withdrawn
+
+流程变量（可在流转条件中引用）：
+- _lastAction : 最近一次任务操作
+- _backCount  : 累计退回次数，如 "_backCount >= 3" 可流转到终止分支
+- _backFrom   : 最近一次退回的来源节点名称
+*/
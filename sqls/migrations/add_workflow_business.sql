@@ -0,0 +1,61 @@
+-- ==========================================
+-- 单据审批（工作流绑定业务表）迁移脚本
+-- ==========================================
+-- 用途：为单据表添加审批状态字段，单据提交后自动启动业务表关联的已发布流程
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 为业务单据表添加审批字段（将 {table_name} 替换为实际表名，每个单据表执行一次）
+ALTER TABLE `{table_name}`
+ADD COLUMN `APPROVAL_STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '审批状态(pending:审批中,approved:已通过,rejected:已驳回,terminated:已终止,withdrawn:已撤回)',
+ADD COLUMN `WF_INSTANCE_ID` int NULL DEFAULT NULL COMMENT '审批流程实例ID';
+
+CREATE INDEX `idx_approval_status` ON `{table_name}`(`APPROVAL_STATUS` ASC) USING BTREE;
+
+-- 2. 为已有单据表补充 sys_column 元数据（新建的单据表由 sys_table_after_create 插件自动生成）
+INSERT INTO `sys_column` (`DISPLAY_NAME`, `DB_NAME`, `FULL_NAME`, `DESCRIPTION`, `COL_TYPE`, `COL_LENGTH`, `SYS_TABLE_ID`, `ORDERNO`, `NULL_ABLE`, `MASK`, `SET_VALUE_TYPE`, `MODIFI_ABLE`, `DISPLAY_TYPE`, `IS_QUERY`, `IS_SHOW_TITLE`, `IS_ACTIVE`, `CREATE_BY`, `CREATE_TIME`, `SYS_COMPANY_ID`)
+SELECT CONCAT(UPPER(t.NAME), '.APPROVAL_STATUS'), 'APPROVAL_STATUS', CONCAT(UPPER(t.NAME), '.APPROVAL_STATUS'), '审批状态', 'varchar', 20, t.ID, 1008, 'Y', '0010100110', 'ignore', 'N', 'text', 'Y', 'Y', 'Y', 'system', NOW(), t.SYS_COMPANY_ID
+FROM `sys_table` t
+WHERE (t.MASK LIKE '%S%' OR t.MASK LIKE '%V%')
+  AND NOT EXISTS (SELECT 1 FROM `sys_column` c WHERE c.SYS_TABLE_ID = t.ID AND c.DB_NAME = 'APPROVAL_STATUS');
+
+INSERT INTO `sys_column` (`DISPLAY_NAME`, `DB_NAME`, `FULL_NAME`, `DESCRIPTION`, `COL_TYPE`, `SYS_TABLE_ID`, `ORDERNO`, `NULL_ABLE`, `MASK`, `SET_VALUE_TYPE`, `MODIFI_ABLE`, `DISPLAY_TYPE`, `IS_SHOW_TITLE`, `IS_ACTIVE`, `CREATE_BY`, `CREATE_TIME`, `SYS_COMPANY_ID`)
+SELECT CONCAT(UPPER(t.NAME), '.WF_INSTANCE_ID'), 'WF_INSTANCE_ID', CONCAT(UPPER(t.NAME), '.WF_INSTANCE_ID'), '审批流程', 'int', t.ID, 1009, 'Y', '0010100110', 'ignore', 'N', 'text', 'Y', 'Y', 'system', NOW(), t.SYS_COMPANY_ID
+FROM `sys_table` t
+WHERE (t.MASK LIKE '%S%' OR t.MASK LIKE '%V%')
+  AND NOT EXISTS (SELECT 1 FROM `sys_column` c WHERE c.SYS_TABLE_ID = t.ID AND c.DB_NAME = 'WF_INSTANCE_ID');
+
+-- 3. sys_table_cmd 增加审批回调动作
+ALTER TABLE `sys_table_cmd`
+MODIFY COLUMN `ACTION` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '按钮(A:新增,M:修改,D:删除,Q:查询,S:提交,U:反提交,V:作废,I:导入,E:导出,P:审批通过,R:审批退回)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+绑定方式：
+- wf_definition.SYS_TABLE_ID 指向单据表，流程发布后即生效（同一张表有多个已发布流程时取最新的一个）
+- 单据提交（POST /api/v1/data/{tableName}/{id}/submit）时，在提交事务中启动流程；
+  流程启动失败（如找不到处理人）时提交回滚
+- 没有关联已发布流程的单据表，提交行为不变
+
+审批状态：
+- 提交后        : DOC_STATUS=submitted, APPROVAL_STATUS=pending, WF_INSTANCE_ID=流程实例ID
+- 流程完成      : APPROVAL_STATUS=approved，单据保持已提交
+- 驳回/终止/撤回 : APPROVAL_STATUS=rejected/terminated/withdrawn，单据退回 draft，可修改后重新提交（启动新流程）
+
+锁定：
+- 审批中的单据已提交，不能修改、删除；也不能反提交，需由发起人撤回流程（POST /api/v1/workflow/instances/{id}/withdraw）
+- APPROVAL_STATUS、WF_INSTANCE_ID 不接受客户端写入
+
+回调钩子（与单据回写在同一事务中执行）：
+- sys_table_cmd：ACTION 为 P（审批通过）或 R（驳回/终止/撤回），EVENT 为 begin/end；
+  钩子参数中的 APPROVAL_STATUS 区分具体结果
+- 插件钩子点：{table_name}.before.approve / {table_name}.after.approve（reject 同理），
+  PluginData.Extra 含 approvalStatus、wfInstanceId
+
+示例（审批通过后过账的存储过程钩子）：
+INSERT INTO sys_table_cmd (SYS_TABLE_ID, ACTION, EVENT, CONTENT_TYPE, CONTENT, ORDERNO, IS_ACTIVE)
+VALUES (100, 'P', 'end', 'sp', 'sp_order_post', 10, 'Y');
+*/
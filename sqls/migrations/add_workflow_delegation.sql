@@ -0,0 +1,71 @@
+-- ==========================================
+-- 工作流任务委托迁移脚本
+-- ==========================================
+-- 用途：用户登记外出期间的委托规则，委托期间新创建的任务分配给受托人，任务上记录原处理人
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 委托规则表
+CREATE TABLE IF NOT EXISTS `wf_delegation`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:已取消)',
+                            `FROM_USER_ID` int UNSIGNED NOT NULL COMMENT '委托人',
+                            `TO_USER_ID` int UNSIGNED NOT NULL COMMENT '受托人',
+                            `START_TIME` datetime NOT NULL COMMENT '生效时间',
+                            `END_TIME` datetime NOT NULL COMMENT '失效时间',
+                            `DEFINITION_NAMES` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '限定的流程名称(逗号分隔,对该流程所有版本生效),为空时对所有流程生效',
+                            `REASON` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '委托原因',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_delegation_from`(`FROM_USER_ID` ASC, `START_TIME` ASC) USING BTREE,
+                            INDEX `idx_wf_delegation_to`(`TO_USER_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流任务委托规则' ROW_FORMAT = DYNAMIC;
+
+-- 2. wf_task 记录原处理人
+ALTER TABLE `wf_task`
+ADD COLUMN `ORIGINAL_ASSIGNEE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '原处理人(按委托规则分配给受托人时记录委托人)' AFTER `ASSIGNEE_ID`;
+
+CREATE INDEX `idx_wf_task_original` ON `wf_task`(`ORIGINAL_ASSIGNEE_ID` ASC) USING BTREE;
+
+-- 3. wf_task_candidate 记录被受托人代替的候选人
+ALTER TABLE `wf_task_candidate`
+ADD COLUMN `ORIGINAL_USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '原候选人(按委托规则由受托人代替时记录委托人)' AFTER `USER_ID`;
+
+CREATE INDEX `idx_wf_candidate_original` ON `wf_task_candidate`(`ORIGINAL_USER_ID` ASC) USING BTREE;
+
+-- 4. wf_history 增加委托事件
+ALTER TABLE `wf_history`
+MODIFY COLUMN `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回,migrate:版本迁移,variables:变量变更,autoTask:自动任务,jobRetry:作业重试,delegate:委托)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+委托规则：
+- 用户为自己登记委托（管理员可为他人登记），指定受托人、生效时间段，可限定流程名称（对该流程所有版本生效）
+- 同一委托人时间重叠的规则不能覆盖相同的流程
+- 只影响生效期间新创建的任务；已有任务不转移，取消规则也不收回已分配的任务
+- 受托人同样在委托期间时沿委托链继续查找（最多5层，出现循环时停在循环前的用户）
+- 直接分配和会签任务：任务分配给受托人，ORIGINAL_ASSIGNEE_ID 记录委托人，并记录 delegate 历史事件
+- 候选组任务：委托期间的候选人由受托人代替，ORIGINAL_USER_ID 记录委托人；受托人签收时任务的 ORIGINAL_ASSIGNEE_ID 记录委托人，并记录 delegate 历史事件
+- 委托人和受托人在"我的任务"中都能看到委托的任务，只有受托人可以处理
+
+接口：
+POST   /api/v1/workflow/delegations                    创建委托规则
+GET    /api/v1/workflow/delegations?includeExpired=true 查询自己委托出去的和委托给自己的规则
+DELETE /api/v1/workflow/delegations/{id}               取消委托规则
+
+创建请求示例：
+{
+  "toUserId": 8,
+  "startTime": "2026-10-20T00:00:00+08:00",
+  "endTime": "2026-10-27T00:00:00+08:00",
+  "definitionNames": ["leave", "expense"],
+  "reason": "年假"
+}
+*/
@@ -0,0 +1,78 @@
+-- ==========================================
+-- 工作流自动任务作业迁移脚本
+-- ==========================================
+-- 用途：自动任务节点的动作改为后台异步执行，失败按退避策略重试，
+--       重试次数用尽后标记为失败，由管理员查看和重试
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 自动任务作业表
+CREATE TABLE IF NOT EXISTS `wf_job`  (
+                            `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+                            `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+                            `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+                            `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+                            `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+                            `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+                            `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+                            `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+                            `WF_NODE_ID` int UNSIGNED NOT NULL COMMENT '自动任务节点ID',
+                            `WF_TOKEN_ID` int UNSIGNED NOT NULL COMMENT '停留在节点上的令牌ID',
+                            `ACTION_ID` int UNSIGNED NOT NULL COMMENT '动作ID',
+                            `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(pending:等待执行,running:执行中,completed:已完成,failed:失败,canceled:已取消)',
+                            `ATTEMPTS` int NULL DEFAULT 0 COMMENT '已执行次数',
+                            `MAX_ATTEMPTS` int NULL DEFAULT 3 COMMENT '最大执行次数',
+                            `BACKOFF` int NULL DEFAULT 30 COMMENT '首次重试间隔(秒)，之后每次翻倍',
+                            `TIMEOUT` int NULL DEFAULT 60 COMMENT '单次执行超时(秒)',
+                            `NEXT_RUN_TIME` datetime NULL DEFAULT NULL COMMENT '下次执行时间',
+                            `LOCKED_UNTIL` datetime NULL DEFAULT NULL COMMENT '执行租约到期时间',
+                            `START_TIME` datetime NULL DEFAULT NULL COMMENT '最近一次开始执行时间',
+                            `FINISH_TIME` datetime NULL DEFAULT NULL COMMENT '完成或最终失败时间',
+                            `LAST_ERROR` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '最近一次失败原因',
+                            `RESULT` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '执行结果(JSON)',
+                            PRIMARY KEY (`ID`) USING BTREE,
+                            INDEX `idx_wf_job_due`(`STATUS` ASC, `NEXT_RUN_TIME` ASC) USING BTREE,
+                            INDEX `idx_wf_job_inst`(`WF_INSTANCE_ID` ASC, `STATUS` ASC) USING BTREE,
+                            INDEX `idx_wf_job_token`(`WF_TOKEN_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流自动任务作业' ROW_FORMAT = DYNAMIC;
+
+-- 2. wf_history 增加作业重试事件
+ALTER TABLE `wf_history`
+MODIFY COLUMN `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回,migrate:版本迁移,variables:变量变更,autoTask:自动任务,jobRetry:作业重试)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+执行方式：
+- 流转到自动任务节点时创建作业，令牌停留在节点上，审批请求立即返回
+- 后台每隔 workflow.jobCheckInterval 秒领取到期作业执行：
+  URL、脚本动作在锁定流程实例之前执行，外部调用期间不阻塞同一实例的审批、撤回、终止，其外部副作用无法回滚；
+  之后锁定实例，重新检查作业和令牌状态，在同一事务中记录结果并流转；存储过程动作在该事务中执行，随事务回滚
+- 作业按至少执行一次处理：URL、脚本动作执行后流程被挂起或流转失败时，作业会再次执行
+- 执行失败后按 backoff * 2^(次数-1) 秒重新排队（最长24小时），达到最大次数后状态为 failed
+- 执行中的作业持有租约（超时时间 + 30秒），进程异常退出后租约到期可被重新领取
+- 挂起的流程不执行作业，恢复后继续；终止、撤回、退回主干时取消未完成的作业
+- 每次执行结果记入流程历史（autoTask 事件，detail 含 jobId、attempt、success、error、nextRunTime）
+
+节点配置（覆盖全局默认值）：
+{"autoTask": {"maxAttempts": 5, "backoff": 60, "timeout": 120}}
+
+全局默认值（configs/config.yaml）：
+workflow:
+  jobCheckInterval: 5
+  jobMaxAttempts: 3
+  jobBackoff: 30
+  jobTimeout: 60
+  jobWorkers: 4
+
+管理接口（管理员）：
+GET  /api/v1/workflow/jobs?status=failed&instanceId=101   查询作业
+POST /api/v1/workflow/jobs/{id}/retry                     重试失败的作业（执行次数清零）
+
+查询失败作业：
+SELECT j.ID, j.WF_INSTANCE_ID, i.TITLE, j.ATTEMPTS, j.LAST_ERROR, j.FINISH_TIME
+FROM wf_job j INNER JOIN wf_instance i ON i.ID = j.WF_INSTANCE_ID
+WHERE j.STATUS = 'failed' ORDER BY j.FINISH_TIME DESC;
+*/
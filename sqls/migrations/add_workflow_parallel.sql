@@ -0,0 +1,61 @@
+-- ==========================================
+-- 工作流并行网关与会签迁移脚本
+-- ==========================================
+-- 用途：新增流程令牌表，支持并行分叉/汇聚和会签（多实例）任务
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 流程令牌表（每进入一个节点生成一个令牌）
+CREATE TABLE IF NOT EXISTS `wf_token`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+  `WF_NODE_ID` int UNSIGNED NOT NULL COMMENT '流程节点ID',
+  `FORK_ID` int UNSIGNED NULL DEFAULT 0 COMMENT '所属分支的分叉令牌ID(主干为0)',
+  `BRANCHES` int NULL DEFAULT 0 COMMENT '分叉节点激活的分支数',
+  `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(active:活动,waiting:等待汇聚,completed:已完成,canceled:已取消)',
+  `ENTER_TIME` datetime NULL DEFAULT NULL COMMENT '进入节点时间',
+  `LEAVE_TIME` datetime NULL DEFAULT NULL COMMENT '离开节点时间',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_wf_token_inst`(`WF_INSTANCE_ID` ASC, `STATUS` ASC) USING BTREE,
+  INDEX `idx_wf_token_join`(`WF_NODE_ID` ASC, `FORK_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流令牌' ROW_FORMAT = DYNAMIC;
+
+-- 2. 任务所属令牌
+ALTER TABLE `wf_task`
+ADD COLUMN `WF_TOKEN_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属令牌' AFTER `WF_NODE_ID`,
+MODIFY COLUMN `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '状态(pending:待处理,completed:已完成,rejected:已拒绝,transferred:已转交,canceled:已取消)';
+
+CREATE INDEX `idx_wf_task_token` ON `wf_task`(`WF_TOKEN_ID` ASC) USING BTREE;
+
+-- 3. 更新节点类型说明
+ALTER TABLE `wf_node`
+MODIFY COLUMN `NODE_TYPE` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '节点类型(start:开始,end:结束,user:用户任务,auto:自动任务,gateway:排他网关,fork:并行分叉,join:并行汇聚)',
+MODIFY COLUMN `CONFIG` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT 'JSON配置(会签规则等)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+并行网关：
+- fork : 并行分叉，激活所有无条件流转和满足条件的流转，每个分支一个令牌
+- join : 并行汇聚，等待同一分叉激活的分支全部到达后继续流转
+- 发布时校验：fork 至少两个后续流转，join 至少两个进入流转
+- 查询实例当前所在节点：GET /api/v1/workflow/instances/{id}/tokens?active=true
+
+会签（多实例）用户节点，在 wf_node.CONFIG 中配置：
+  {"multiInstance": {"completion": "all"}}                       全部同意才通过，任一拒绝即驳回
+  {"multiInstance": {"completion": "any"}}                       任一同意即通过，全部拒绝才驳回
+  {"multiInstance": {"completion": "percentage", "percentage": 60}} 同意人数达到60%（向上取整）即通过
+- 分配规则解析出的每个处理人各生成一个任务（不使用候选组签收）
+- 结果确定后其余未处理的任务置为 canceled；驳回时终止流程
+
+历史数据：
+- 迁移前创建的任务 WF_TOKEN_ID 为空，完成时自动补建令牌，无需处理
+*/
@@ -0,0 +1,65 @@
+-- ==========================================
+-- 工作流任务时限、到期提醒和超时处理迁移脚本
+-- ==========================================
+-- 用途：任务增加提醒/超时处理时间，新增流程历史表记录提醒和超时处理事件
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 任务时限字段
+ALTER TABLE `wf_task`
+ADD COLUMN `REMIND_TIME` datetime NULL DEFAULT NULL COMMENT '计划提醒时间(提醒发送后清空)' AFTER `DUE_TIME`,
+ADD COLUMN `REMIND_ATTEMPTS` int NOT NULL DEFAULT 0 COMMENT '到期提醒发送次数(含失败)' AFTER `REMIND_TIME`,
+ADD COLUMN `ESCALATE_TIME` datetime NULL DEFAULT NULL COMMENT '超时处理时间' AFTER `REMIND_ATTEMPTS`,
+ADD COLUMN `ESCALATE_ATTEMPTS` int NOT NULL DEFAULT 0 COMMENT '超时处理失败次数' AFTER `ESCALATE_TIME`;
+
+CREATE INDEX `idx_wf_task_due` ON `wf_task`(`STATUS` ASC, `DUE_TIME` ASC) USING BTREE;
+CREATE INDEX `idx_wf_task_remind` ON `wf_task`(`STATUS` ASC, `REMIND_TIME` ASC) USING BTREE;
+
+-- 历史任务的截止时间未使用过，清理无效值
+UPDATE `wf_task` SET `DUE_TIME` = NULL WHERE `DUE_TIME` < '1970-01-02';
+
+-- 2. 流程历史表
+CREATE TABLE IF NOT EXISTS `wf_history`  (
+  `ID` int UNSIGNED NOT NULL AUTO_INCREMENT,
+  `SYS_COMPANY_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '所属公司',
+  `CREATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '创建人',
+  `CREATE_TIME` datetime NULL DEFAULT NULL COMMENT '创建时间',
+  `UPDATE_BY` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '更新人',
+  `UPDATE_TIME` datetime NULL DEFAULT NULL COMMENT '更新时间',
+  `IS_ACTIVE` char(1) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'Y' COMMENT '是否有效(Y:可用,N:不可用)',
+  `WF_INSTANCE_ID` int UNSIGNED NOT NULL COMMENT '流程实例ID',
+  `WF_TASK_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '任务ID',
+  `WF_NODE_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '流程节点ID',
+  `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理)',
+  `OPERATOR_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '操作人(系统自动处理为0)',
+  `TARGET_USER_ID` int UNSIGNED NULL DEFAULT NULL COMMENT '目标用户',
+  `ACTION` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '处理方式',
+  `COMMENT` varchar(2000) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '说明',
+  `DETAIL` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL COMMENT '详细信息(JSON)',
+  PRIMARY KEY (`ID`) USING BTREE,
+  INDEX `idx_wf_history_inst`(`WF_INSTANCE_ID` ASC) USING BTREE,
+  INDEX `idx_wf_history_task`(`WF_TASK_ID` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '工作流历史' ROW_FORMAT = DYNAMIC;
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+在用户任务节点的 wf_node.CONFIG 中配置处理时限：
+  {"sla": {"timeoutMinutes": 1440, "remindMinutes": 120, "escalation": "reassign", "priority": 1}}
+
+- timeoutMinutes : 处理时限（分钟），任务创建时计算 DUE_TIME
+- remindMinutes  : 到期前多少分钟发送提醒消息（0 不提醒）
+- escalation     : 超时处理方式
+    notify   : 只发送超时通知（默认）
+    approve  : 以处理人名义自动同意并继续流转（未签收的候选组任务退化为 notify）
+    reject   : 以处理人名义自动拒绝（未签收的候选组任务退化为 notify）
+    reassign : 转交给处理人的部门负责人（找不到负责人时退化为 notify）
+- priority       : 任务优先级，同时作为提醒消息的优先级
+
+定时检查间隔见 configs/config.yaml 的 workflow.deadlineCheckInterval（秒）。
+只处理运行中流程的任务，挂起的流程恢复后再提醒和超时处理。
+提醒发送失败时推迟后重试，超时处理失败时下次检查重试，均最多尝试 3 次。
+提醒和超时处理均记录在 wf_history，可通过 GET /api/v1/workflow/instances/{id}/history 查询。
+*/
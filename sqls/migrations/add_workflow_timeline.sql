@@ -0,0 +1,37 @@
+-- ==========================================
+-- 工作流实例时间线迁移脚本
+-- ==========================================
+-- 用途：令牌记录进入节点经过的流转，流程历史增加变量变更和自动任务结果事件，
+--       支持流程实例时间线和流程图高亮
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. wf_token 增加经过的流转
+ALTER TABLE `wf_token`
+ADD COLUMN `WF_TRANSITION_ID` int UNSIGNED NULL DEFAULT 0 COMMENT '进入节点经过的流转ID(开始节点和退回为0)' AFTER `BRANCHES`;
+
+-- 2. wf_history 增加变量变更和自动任务事件
+ALTER TABLE `wf_history`
+MODIFY COLUMN `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回,migrate:版本迁移,variables:变量变更,autoTask:自动任务)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+接口：
+GET /api/v1/workflow/instances/{id}/timeline   流程实例时间线
+GET /api/v1/workflow/instances/{id}/graph      流程图状态
+
+时间线条目（按时间排序，同一秒内按令牌先后排序）：
+- enter      令牌进入节点（transitionId 为经过的流转）
+- task       用户任务（处理人、操作、意见、完成时间、耗时）
+- variables  流程变量变更，detail: {"changes": {"amount": {"old": 100, "new": 200}}}
+- autoTask   自动任务执行结果，detail: {"actionId": 8, "success": true, "message": "", "error": "", "data": {}, "durationMs": 35}
+- leave      令牌离开节点（status 为 completed 或 canceled，duration 为停留秒数）
+- 其他       remind、escalate、back、backToStarter、withdraw、migrate 等历史事件
+
+流程图状态：
+- 节点 state: completed 已经过, active 停留中, canceled 被取消, untouched 未经过；visits 为进入次数
+- 流转 taken: 是否经过（迁移脚本执行前创建的令牌没有流转记录）
+*/
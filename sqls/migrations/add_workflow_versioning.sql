@@ -0,0 +1,61 @@
+-- ==========================================
+-- 工作流定义版本管理迁移脚本
+-- ==========================================
+-- 用途：同名流程定义作为同一流程的不同版本，发布后不可修改，运行中的实例固定在启动时的版本
+-- 日期：2026-10-16
+-- ==========================================
+
+-- 1. 同一流程只保留最新发布的版本，之前发布的版本归档（运行中的实例不受影响）
+UPDATE `wf_definition` d
+INNER JOIN (
+    SELECT `NAME`, MAX(`VERSION`) AS MAX_VERSION
+    FROM `wf_definition`
+    WHERE `STATUS` = 'published' AND `IS_ACTIVE` = 'Y'
+    GROUP BY `NAME`
+) latest ON latest.`NAME` = d.`NAME`
+SET d.`STATUS` = 'archived'
+WHERE d.`STATUS` = 'published' AND d.`VERSION` < latest.MAX_VERSION;
+
+-- 2. 版本号唯一（执行前请检查是否有同名同版本的定义：
+--    SELECT NAME, VERSION, COUNT(*) FROM wf_definition GROUP BY NAME, VERSION HAVING COUNT(*) > 1;）
+ALTER TABLE `wf_definition`
+MODIFY COLUMN `NAME` varchar(80) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '流程名称(同名定义为同一流程的不同版本)',
+MODIFY COLUMN `STATUS` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'draft' COMMENT '状态(draft:草稿,published:已发布,archived:已归档)，发布后不可修改';
+
+CREATE UNIQUE INDEX `idx_wf_def_version` ON `wf_definition`(`NAME` ASC, `VERSION` ASC) USING BTREE;
+
+-- 3. wf_history 增加迁移事件
+ALTER TABLE `wf_history`
+MODIFY COLUMN `EVENT_TYPE` varchar(30) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '事件类型(remind:到期提醒,escalate:超时处理,back:退回,backToStarter:退回发起人,withdraw:撤回,migrate:版本迁移)';
+
+-- ==========================================
+-- 使用说明
+-- ==========================================
+
+/*
+版本规则：
+- 新建流程定义为版本1的草稿；同名定义不能重复创建
+- 只有草稿可以修改定义、节点和流转；发布后该版本不可修改
+- 发布新版本时，同一流程之前发布的版本归档；新启动的流程使用最新发布的版本，
+  运行中的实例继续使用启动时的版本（任务、令牌引用的是该版本的节点）
+- 修改已发布的流程：以任一版本为基础创建新草稿（复制节点和流转），修改后发布
+
+接口：
+POST /api/v1/workflow/definitions/{id}/versions   以该版本为基础创建新草稿版本
+GET  /api/v1/workflow/definitions/{id}/versions   查询同一流程的所有版本
+POST /api/v1/workflow/instances/migrate           迁移运行中的实例到其他已发布版本（管理员）
+
+迁移请求示例：
+{
+  "targetDefinitionId": 12,
+  "instanceIds": [101, 102],
+  "nodeMapping": {"31": 58, "32": 60}
+}
+
+迁移规则：
+- 目标版本必须已发布，且与实例当前版本属于同一流程
+- nodeMapping 为 原版本节点ID -> 目标版本节点ID；映射中的节点必须存在且类型相同
+- 未指定映射的节点按名称匹配目标版本中的同类型节点
+- 活动令牌和待处理任务所在的节点必须都能映射，否则整批迁移失败
+- 迁移在一个事务中完成，每个实例记录一条 migrate 历史（detail 含版本和节点映射）
+*/